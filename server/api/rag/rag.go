@@ -11,6 +11,8 @@ type IRagV1 interface {
 	Chat(ctx context.Context, req *v1.ChatReq) (res *v1.ChatRes, err error)
 	Retriever(ctx context.Context, req *v1.RetrieverReq) (res *v1.RetrieverRes, err error)
 	Indexer(ctx context.Context, req *v1.IndexerReq) (res *v1.IndexerRes, err error)
+	IndexJob(ctx context.Context, req *v1.IndexJobReq) (res *v1.IndexJobRes, err error)
+	IndexJobStream(ctx context.Context, req *v1.IndexJobStreamReq) (res *v1.IndexJobStreamRes, err error)
 	ChatStream(ctx context.Context, req *v1.ChatStreamReq) (res *v1.ChatStreamRes, err error)
 	ChunksList(ctx context.Context, req *v1.ChunksListReq) (res *v1.ChunksListRes, err error)
 	ChunkDelete(ctx context.Context, req *v1.ChunkDeleteReq) (res *v1.ChunkDeleteRes, err error)
@@ -23,5 +25,12 @@ type IRagV1 interface {
 	KBDelete(ctx context.Context, req *v1.KBDeleteReq) (res *v1.KBDeleteRes, err error)
 	KBGetOne(ctx context.Context, req *v1.KBGetOneReq) (res *v1.KBGetOneRes, err error)
 	KBGetList(ctx context.Context, req *v1.KBGetListReq) (res *v1.KBGetListRes, err error)
+	KBReindex(ctx context.Context, req *v1.KBReindexReq) (res *v1.KBReindexRes, err error)
 	RetrieverDify(ctx context.Context, req *v1.RetrieverDifyReq) (res *v1.RetrieverDifyRes, err error)
+	ConversationList(ctx context.Context, req *v1.ConversationListReq) (res *v1.ConversationListRes, err error)
+	ConversationGet(ctx context.Context, req *v1.ConversationGetReq) (res *v1.ConversationGetRes, err error)
+	ConversationRename(ctx context.Context, req *v1.ConversationRenameReq) (res *v1.ConversationRenameRes, err error)
+	ConversationSearch(ctx context.Context, req *v1.ConversationSearchReq) (res *v1.ConversationSearchRes, err error)
+	ConversationExport(ctx context.Context, req *v1.ConversationExportReq) (res *v1.ConversationExportRes, err error)
+	ConversationDelete(ctx context.Context, req *v1.ConversationDeleteReq) (res *v1.ConversationDeleteRes, err error)
 }
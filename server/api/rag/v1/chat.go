@@ -3,6 +3,9 @@ package v1
 import (
 	"github.com/cloudwego/eino/schema"
 	"github.com/everfid-ever/ThinkForge/core/agent"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
+	"github.com/everfid-ever/ThinkForge/core/verifier"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
@@ -13,19 +16,28 @@ type ChatReq struct {
 	g.Meta `path:"/v1/chat" method:"post" tags:"rag"`
 
 	// ===== 基础参数 =====
-	ConvID        string `json:"conv_id"`                     // 会话 ID
-	Question      string `json:"question" v:"required"`       // 用户问题
-	KnowledgeName string `json:"knowledge_name" v:"required"` // 知识库名称
+	ConvID         string             `json:"conv_id"`                                             // 会话 ID
+	Question       string             `json:"question" v:"required"`                               // 用户问题
+	KnowledgeName  string             `json:"knowledge_name" v:"required-without:knowledge_bases"` // 知识库名称（与 knowledge_bases 至少填一个）
+	KnowledgeBases []*KnowledgeWeight `json:"knowledge_bases"`                                     // 跨知识库检索的知识库及权重
+	AutoScope      bool               `json:"auto_scope" d:"true"`                                 // 是否按问题中提到的知识库自动缩小或扩大检索范围
 
 	// ===== 检索参数 =====
-	TopK  int     `json:"top_k" d:"5"`   // 返回文档数量
-	Score float64 `json:"score" d:"0.2"` // 相关性阈值
+	TopK            int     `json:"top_k" d:"5"`                                                     // 返回文档数量
+	Score           float64 `json:"score" d:"0.2"`                                                   // 相关性阈值
+	RetrievalMode   string  `json:"retrieval_mode" v:"in:dense,hybrid"`                              // 检索模式：dense / hybrid（为空时使用配置）
+	Fusion          string  `json:"fusion" v:"in:rrf,weighted"`                                      // hybrid 模式下的融合方式：rrf / weighted
+	RewriteStrategy string  `json:"rewrite_strategy" v:"in:none,keyword,hyde,multi_query,step_back"` // 问题改写策略（为空时使用配置）
+
+	Filters []*MetadataFilter `json:"filters"` // 元数据过滤条件，全部满足
 
 	// ===== Agentic 参数（新增，可选） =====
 	EnableAgentic bool     `json:"enable_agentic" d:"true"` // 是否启用智能路由（默认开启）
 	UseRuleOnly   bool     `json:"use_rule_only" d:"true"`  // 仅使用规则分类（更快）
 	MaxIterations int      `json:"max_iterations" d:"5"`    // ReAct 最大推理轮数
+	Corrective    bool     `json:"corrective" d:"false"`    // 是否启用纠错检索（CRAG）：打分去掉不相关文档，不足时改写重检或网络搜索兜底
 	EnabledTools  []string `json:"enabled_tools,omitempty"` // 启用的工具（空=自动）
+	Verify        bool     `json:"verify" d:"false"`        // 是否校验回答，知识库与全局配置未开启校验时也校验并在得分低时返回警告
 
 	// ===== 调试参数 =====
 	ReturnIntent bool `json:"return_intent" d:"false"` // 是否返回意图信息
@@ -37,41 +49,74 @@ type ChatRes struct {
 	g.Meta `mime:"application/json"`
 
 	// ===== 核心返回 =====
-	Answer     string             `json:"answer"`     // 答案
-	References []*schema.Document `json:"references"` // 引用文档
+	Answer     string               `json:"answer"`     // 答案，带 [n] 形式的引用标记
+	References []*schema.Document   `json:"references"` // 引用文档，顺序与引用标记的编号一致
+	Citations  []*citation.Citation `json:"citations"`  // 答案中引用的 chunk 及引用它们的句子
 
 	// ===== 元信息 =====
-	Strategy      string `json:"strategy"`          // 使用的策略
-	ExecutionTime int64  `json:"execution_time_ms"` // 执行时间（毫秒）
+	Strategy      string           `json:"strategy"`          // 实际执行的策略，回退时为回退后的策略
+	ExecutionTime int64            `json:"execution_time_ms"` // 执行时间（毫秒）
+	CacheHit      bool             `json:"cache_hit"`         // 是否命中语义缓存
+	Rewrites      []*rewrite.Query `json:"rewrites"`          // 实际用于检索的改写问题
+
+	// 多轮对话中结合历史改写出的独立问题，意图识别与检索使用它，与原问题相同时为空
+	StandaloneQuestion string `json:"standalone_question,omitempty"`
 
 	// ===== 可选返回（调试用） =====
 	Intent         *agent.RAGIntent      `json:"intent,omitempty"`          // 意图分析
 	ReasoningSteps []agent.ReasoningStep `json:"reasoning_steps,omitempty"` // 推理步骤
+
+	// ===== 回答校验 =====
+	Groundedness *verifier.Report `json:"groundedness,omitempty"` // 回答校验结果：论断被参考资料支持的比例、不被支持的论断与警告
 }
 
 // ===== ChatStream 流式对话 =====
 
-// ChatStreamReq 流式对话请求
+// ChatStreamReq 流式对话请求，与 ChatReq 使用同一套策略路由
 type ChatStreamReq struct {
 	g.Meta `path:"/v1/chat/stream" method:"post" tags:"rag"`
 
 	// ===== 基础参数 =====
-	ConvID        string `json:"conv_id"`
-	Question      string `json:"question" v:"required"`
-	KnowledgeName string `json:"knowledge_name" v:"required"`
+	ConvID         string             `json:"conv_id"`
+	Question       string             `json:"question" v:"required"`
+	KnowledgeName  string             `json:"knowledge_name" v:"required-without:knowledge_bases"`
+	KnowledgeBases []*KnowledgeWeight `json:"knowledge_bases"`
+	AutoScope      bool               `json:"auto_scope" d:"true"`
 
 	// ===== 检索参数 =====
-	TopK  int     `json:"top_k" d:"5"`
-	Score float64 `json:"score" d:"0.2"`
+	TopK            int     `json:"top_k" d:"5"`
+	Score           float64 `json:"score" d:"0.2"`
+	RetrievalMode   string  `json:"retrieval_mode" v:"in:dense,hybrid"`
+	Fusion          string  `json:"fusion" v:"in:rrf,weighted"`
+	RewriteStrategy string  `json:"rewrite_strategy" v:"in:none,keyword,hyde,multi_query,step_back"`
+
+	Filters []*MetadataFilter `json:"filters"`
 
 	// ===== Agentic 参数 =====
-	EnableAgentic bool `json:"enable_agentic,omitempty"`
+	EnableAgentic bool     `json:"enable_agentic,omitempty"`
+	UseRuleOnly   bool     `json:"use_rule_only" d:"true"`
+	MaxIterations int      `json:"max_iterations" d:"5"`
+	Corrective    bool     `json:"corrective" d:"false"`
+	EnabledTools  []string `json:"enabled_tools,omitempty"`
+	Verify        bool     `json:"verify" d:"false"`
+
+	// ===== 调试参数（作用于 done 事件中的 ChatRes） =====
+	ReturnIntent bool `json:"return_intent" d:"false"`
+	ReturnSteps  bool `json:"return_steps" d:"false"`
 }
 
-// ChatStreamRes 流式对话响应
+// ChatStreamRes 流式对话响应，内容按 sse 包的协议直接写入，事件依次为：
+//   - meta        协议版本、流 ID 与 conv_id
+//   - reasoning   推理步骤（ReAct 的思考、动作与观察，多跳推理的子问题进度，纠错检索的打分）
+//   - references  参考文档，可能发送多次，以最后一次为准，编号与引用标记一致
+//   - token       最终答案的增量内容
+//   - citation    答案中的一个引用（sse 数据为 citation.Citation）
+//   - usage       本次请求调用模型的 token 用量
+//   - error       执行失败，之后紧接 done
+//   - done        结束，result 为完整的 ChatRes，其中的 groundedness 为回答的校验结果。
+//     答案已通过 token 实时发送，校验策略为 regenerate 时也不会重新生成，只在得分过低时给出警告
+//
+// 每个事件带有 "<stream_id>:<seq>" 形式的 id，断线后携带 Last-Event-ID 重新请求可从断点续传
 type ChatStreamRes struct {
 	g.Meta `mime:"text/event-stream"`
-
-	Stream     <-chan string      `json:"-"`          // 流式响应通道
-	References []*schema.Document `json:"references"` // 引用文档
 }
@@ -5,6 +5,12 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 )
 
+// knowledge_chunks.status 的取值
+const (
+	ChunkStatusDisabled = 0
+	ChunkStatusEnabled  = 1
+)
+
 type ChunksListReq struct {
 	g.Meta         `path:"/v1/chunks" method:"get" tags:"rag"`
 	KnowledgeDocId int64 `p:"knowledge_doc_id" dc:"knowledge_doc_id" v:"required"`
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"github.com/everfid-ever/ThinkForge/core/agent"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
+	"github.com/everfid-ever/ThinkForge/core/verifier"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 会话导出格式
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatMarkdown = "markdown"
+)
+
+type ConversationListReq struct {
+	g.Meta  `path:"/v1/conversations" method:"get" tags:"conversation" summary:"List conversations, pinned first then most recently updated"`
+	Keyword string `p:"keyword" dc:"filter by title"`
+	Page    int    `p:"page" dc:"page" v:"required|min:1" d:"1"`
+	Size    int    `p:"size" dc:"size" v:"required|min:1|max:100" d:"20"`
+}
+
+type ConversationListRes struct {
+	g.Meta `mime:"application/json"`
+	Data   []*Conversation `json:"data"`
+	Total  int             `json:"total"`
+	Page   int             `json:"page"`
+	Size   int             `json:"size"`
+}
+
+type ConversationGetReq struct {
+	g.Meta `path:"/v1/conversations/{conv_id}" method:"get" tags:"conversation" summary:"Get a conversation and its messages"`
+	ConvID string `p:"conv_id" dc:"conversation id" v:"required"`
+	Page   int    `p:"page" dc:"page of messages" v:"required|min:1" d:"1"`
+	Size   int    `p:"size" dc:"size of messages" v:"required|min:1|max:500" d:"100"`
+}
+
+type ConversationGetRes struct {
+	g.Meta `mime:"application/json"`
+	*Conversation
+	Messages []*Message `json:"messages"` // 按时间顺序
+}
+
+type ConversationRenameReq struct {
+	g.Meta `path:"/v1/conversations/{conv_id}" method:"put" tags:"conversation" summary:"Rename a conversation"`
+	ConvID string `p:"conv_id" dc:"conversation id" v:"required"`
+	Title  string `p:"title" dc:"new title" v:"required|length:1,255"`
+}
+
+type ConversationRenameRes struct {
+	g.Meta `mime:"application/json"`
+}
+
+type ConversationSearchReq struct {
+	g.Meta  `path:"/v1/conversations/search" method:"get" tags:"conversation" summary:"Search messages of all conversations"`
+	Keyword string `p:"keyword" dc:"keyword in message content" v:"required"`
+	Role    string `p:"role" dc:"only messages of the role" v:"in:user,assistant"`
+	Page    int    `p:"page" dc:"page" v:"required|min:1" d:"1"`
+	Size    int    `p:"size" dc:"size" v:"required|min:1|max:100" d:"20"`
+}
+
+type ConversationSearchRes struct {
+	g.Meta `mime:"application/json"`
+	Data   []*MessageHit `json:"data"` // 最新的消息在前
+	Total  int           `json:"total"`
+	Page   int           `json:"page"`
+	Size   int           `json:"size"`
+}
+
+type ConversationExportReq struct {
+	g.Meta `path:"/v1/conversations/{conv_id}/export" method:"get" tags:"conversation" summary:"Download a conversation with the audit info of its answers"`
+	ConvID string `p:"conv_id" dc:"conversation id" v:"required"`
+	Format string `p:"format" dc:"json or markdown" v:"in:json,markdown" d:"json"`
+}
+
+// ConversationExportRes 导出的文件直接写入响应
+type ConversationExportRes struct {
+	g.Meta `mime:"application/octet-stream"`
+}
+
+type ConversationDeleteReq struct {
+	g.Meta `path:"/v1/conversations/{conv_id}" method:"delete" tags:"conversation" summary:"Delete a conversation and all its messages"`
+	ConvID string `p:"conv_id" dc:"conversation id" v:"required"`
+}
+
+type ConversationDeleteRes struct {
+	g.Meta `mime:"application/json"`
+}
+
+// Conversation 会话
+type Conversation struct {
+	ConvID       string `json:"conv_id"`
+	Title        string `json:"title"` // 未重命名时为第一个问题
+	MessageCount int    `json:"message_count"`
+	IsPinned     bool   `json:"is_pinned"`
+	IsArchived   bool   `json:"is_archived"`
+	CreatedAt    int64  `json:"created_at"` // Unix 秒
+	UpdatedAt    int64  `json:"updated_at"` // 最后一条消息的时间，Unix 秒
+}
+
+// Message 会话中的一条消息
+type Message struct {
+	MsgID     string       `json:"msg_id"`
+	ConvID    string       `json:"conv_id"`
+	Role      string       `json:"role"` // user / assistant
+	Content   string       `json:"content"`
+	CreatedAt int64        `json:"created_at"`     // Unix 秒
+	Meta      *MessageMeta `json:"meta,omitempty"` // 助手消息生成时的审计信息
+}
+
+// MessageHit 搜索命中的消息
+type MessageHit struct {
+	*Message
+	Title string `json:"title"` // 所属会话的标题
+}
+
+// MessageMeta 助手消息生成时的审计信息，以 JSON 保存在 messages.metadata 中
+type MessageMeta struct {
+	Strategy     string              `json:"strategy"`               // 使用的策略
+	Intent       *agent.RAGIntent    `json:"intent,omitempty"`       // 意图识别结果
+	References   []*MessageReference `json:"references"`             // 参考文档，顺序与回答中的引用编号一致
+	Rewrites     []*rewrite.Query    `json:"rewrites,omitempty"`     // 实际用于检索的改写问题
+	Standalone   string              `json:"standalone,omitempty"`   // 结合对话历史改写出的独立问题
+	Groundedness *verifier.Report    `json:"groundedness,omitempty"` // 回答校验结果
+	CacheHit     bool                `json:"cache_hit,omitempty"`    // 回答来自语义缓存
+	Draft        bool                `json:"draft,omitempty"`        // 校验未通过、已被重新生成的回答替代的初稿
+}
+
+// MessageReference 回答所依据的一个参考文档，保存生成时的内容，chunk 之后被修改或删除也能核对
+type MessageReference struct {
+	*citation.Citation         // 编号与来源，Sentences 为回答中引用它的句子
+	Score              float64 `json:"score"`
+	Content            string  `json:"content"`
+}
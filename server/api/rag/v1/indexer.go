@@ -3,6 +3,7 @@ package v1
 import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
 type IndexerReq struct {
@@ -10,9 +11,103 @@ type IndexerReq struct {
 	File          *ghttp.UploadFile `p:"file" type:"file" dc:"If it's a local file, upload the file directly."`
 	URL           string            `p:"url" dc:"If it's a network file, just enter the URL."`
 	KnowledgeName string            `p:"knowledge_name" dc:"knowledge base name" v:"required"`
+	Async         bool              `p:"async" dc:"Return immediately after the job is queued, track it through /v1/indexer/jobs/{id}" d:"false"`
 }
 
 type IndexerRes struct {
+	g.Meta     `mime:"application/json"`
+	DocIDs     []string `json:"doc_ids"`
+	DocumentId int64    `json:"document_id"`
+	JobId      int64    `json:"job_id"`
+}
+
+type IndexJobReq struct {
+	g.Meta `path:"/v1/indexer/jobs/{id}" method:"get" tags:"rag" summary:"Get indexing job progress"`
+	Id     int64 `p:"id" dc:"job id" v:"required"`
+}
+
+type IndexJobRes struct {
 	g.Meta `mime:"application/json"`
-	DocIDs []string `json:"doc_ids"`
+	*IndexJob
+}
+
+type IndexJobStreamReq struct {
+	g.Meta `path:"/v1/indexer/jobs/{id}/stream" method:"get" tags:"rag" summary:"Stream indexing job progress"`
+	Id     int64 `p:"id" dc:"job id" v:"required"`
+}
+
+// IndexJobStreamRes 索引任务进度，按 sse 包的协议推送：meta 之后进度变化时发送 progress 事件（数据为 IndexJob），
+// 任务结束后发送 done 事件，result 为最终的 IndexJob
+type IndexJobStreamRes struct {
+	g.Meta `mime:"text/event-stream"`
+}
+
+// IndexJob 索引任务及其进度
+type IndexJob struct {
+	Id            int64             `json:"id"`
+	DocumentId    int64             `json:"document_id"`
+	KnowledgeName string            `json:"knowledge_name"`
+	Stage         string            `json:"stage"`
+	Status        JobStatus         `json:"status"`
+	Attempts      int               `json:"attempts"`
+	MaxAttempts   int               `json:"max_attempts"`
+	LastError     string            `json:"last_error"`
+	NextRunAt     *gtime.Time       `json:"next_run_at"`
+	Progress      *IndexJobProgress `json:"progress"`
+	Reindex       *ReindexResult    `json:"reindex,omitempty"` // 重建索引任务成功后的结果
+	CreatedAt     *gtime.Time       `json:"created_at"`
+	UpdatedAt     *gtime.Time       `json:"updated_at"`
+}
+
+// Finished 任务是否已经结束（成功或最终失败）
+func (j *IndexJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
 }
+
+// IndexJobProgress 索引流程各节点的执行进度
+type IndexJobProgress struct {
+	Stages   []*IndexStage `json:"stages"`
+	Chunks   int           `json:"chunks"`    // 切分合并后写入的 chunk 数
+	QATotal  int           `json:"qa_total"`  // 需要生成 QA 的 chunk 数
+	QADone   int           `json:"qa_done"`   // 已生成 QA 的 chunk 数
+	QAFailed int           `json:"qa_failed"` // QA 生成失败的 chunk 数
+}
+
+// IndexStage 单个节点的执行情况
+type IndexStage struct {
+	Name                      string      `json:"name"`
+	Status                    string      `json:"status"` // pending、running、done、failed
+	Input                     int         `json:"input"`  // 输入文档数
+	Output                    int         `json:"output"` // 输出文档数
+	EmbeddingBatches          int         `json:"embedding_batches,omitempty"`
+	EmbeddingBatchesDone      int         `json:"embedding_batches_done,omitempty"`
+	EmbeddingBatchesRemaining int         `json:"embedding_batches_remaining,omitempty"`
+	Error                     string      `json:"error,omitempty"`
+	StartedAt                 *gtime.Time `json:"started_at,omitempty"`
+	FinishedAt                *gtime.Time `json:"finished_at,omitempty"`
+}
+
+// 索引节点执行状态
+const (
+	StagePending = "pending"
+	StageRunning = "running"
+	StageDone    = "done"
+	StageFailed  = "failed"
+)
+
+// JobStatus marks indexing job status.
+type JobStatus int
+
+const (
+	JobPending   JobStatus = 0
+	JobRunning   JobStatus = 1
+	JobSucceeded JobStatus = 2
+	JobFailed    JobStatus = 3
+)
+
+// 索引任务所处阶段
+const (
+	JobStageIndex   = "index"   // 加载、切分并写入向量索引
+	JobStageQA      = "qa"      // 持久化 chunk、生成 QA 并写入 QA 向量
+	JobStageReindex = "reindex" // 重建整个知识库的索引并切换别名，任务不关联文档
+)
@@ -18,6 +18,28 @@ type KBCreateReq struct {
 	Name        string `v:"required|length:3,50" dc:"kb name"`
 	Description string `v:"required|length:3,200" dc:"kb description"`
 	Category    string `v:"length:3,50" dc:"kb category"`
+	// 以下为向量配置，未指定时使用全局 embedding 配置；创建后不可修改
+	EmbeddingModel string `v:"length:1,128" dc:"embedding model"`
+	EmbeddingDims  int    `v:"min:1" dc:"embedding dimensions, must match the model output"`
+	Similarity     string `v:"in:cosine,dot_product,l2_norm" dc:"vector similarity: cosine / dot_product / l2_norm"`
+	ChunkProfile
+	VerifyPolicy
+}
+
+// ChunkProfile 知识库的切分配置，未指定的项使用默认值
+type ChunkProfile struct {
+	Splitter     *string  `v:"in:auto,recursive,markdown,token,semantic" dc:"splitter: auto (by file type) / recursive / markdown / token / semantic"`
+	ChunkSize    *int     `v:"between:50,8000" dc:"max chunk length, in tokens for the token splitter, default 1000"`
+	ChunkOverlap *int     `v:"between:0,2000" dc:"overlap between chunks of the recursive and token splitters, default 100"`
+	Separators   []string `dc:"separators of the recursive splitter in priority order, default [\"\\n\", \".\", \"?\", \"!\"]"`
+	HeaderLevels *int     `v:"between:1,6" dc:"markdown header levels to split on, default 3"`
+	MergeLength  *int     `v:"between:50,8000" dc:"max length after merging adjacent markdown sections, default 512"`
+}
+
+// VerifyPolicy 知识库的回答校验配置，未指定时使用全局配置 verify.*
+type VerifyPolicy struct {
+	VerifyAction    *string  `v:"in:off,warn,regenerate" dc:"action when the answer groundedness is below the threshold: off (no verification) / warn / regenerate"`
+	VerifyThreshold *float64 `v:"between:0,1" dc:"groundedness threshold, the share of answer claims supported by the references, default 0.7"`
 }
 
 type KBCreateRes struct {
@@ -31,6 +53,8 @@ type KBUpdateReq struct {
 	Description *string `v:"length:3,200" dc:"kb description"`
 	Category    *string `v:"length:3,50" dc:"kb category"`
 	Status      *Status `v:"in:1,2" dc:"kb status"`
+	ChunkProfile
+	VerifyPolicy
 }
 type KBUpdateRes struct{}
 
@@ -58,3 +82,26 @@ type KBGetListReq struct {
 type KBGetListRes struct {
 	List []*entity.KnowledgeBase `json:"list" dc:"kb list"`
 }
+
+type KBReindexReq struct {
+	g.Meta `path:"/v1/kb/{id}/reindex" method:"post" tags:"kb" summary:"Queue a job that re-embeds all chunks of a kb into a new index and switches to it"`
+	Id     int64 `v:"required" dc:"kb id"`
+	// 以下为新的向量配置，未指定时沿用知识库当前的配置
+	EmbeddingModel string `v:"length:1,128" dc:"embedding model"`
+	EmbeddingDims  int    `v:"min:1" dc:"embedding dimensions, must match the model output"`
+	Similarity     string `v:"in:cosine,dot_product,l2_norm" dc:"vector similarity: cosine / dot_product / l2_norm"`
+	RegenerateQA   bool   `json:"regenerate_qa" dc:"regenerate qa content instead of reusing the existing one"`
+}
+
+type KBReindexRes struct {
+	JobId int64 `json:"job_id" dc:"reindex job id, track it through /v1/indexer/jobs/{id}"`
+}
+
+// ReindexResult 重建索引任务的结果
+type ReindexResult struct {
+	Alias    string   `json:"alias" dc:"alias used by the kb"`
+	Index    string   `json:"index" dc:"new versioned index"`
+	Previous []string `json:"previous" dc:"indexes used before the switch, kept for rollback"`
+	Chunks   int      `json:"chunks" dc:"number of re-embedded chunks"`
+	Deleted  int      `json:"deleted" dc:"number of chunks removed from the new index because they were deleted during the reindex"`
+}
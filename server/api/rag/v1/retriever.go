@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
@@ -14,25 +15,75 @@ type RetrieverReq struct {
 	// method: 指定请求方法为 POST
 	// tags: 用于接口文档的分组标签（如 Swagger 中显示为 "rag" 分组）
 
-	Question      string  `json:"question" v:"required"`       // 用户输入的问题内容（必填）
-	TopK          int     `json:"top_k"`                       // 需要返回的文档数量（默认为 5）
-	Score         float64 `json:"score"`                       // 文档相关性评分阈值（默认为 0.2）
-	KnowledgeName string  `json:"knowledge_name" v:"required"` // 目标知识库名称（必填）
+	Question        string             `json:"question" v:"required"`                                           // 用户输入的问题内容（必填）
+	TopK            int                `json:"top_k"`                                                           // 需要返回的文档数量（默认为 5）
+	Score           float64            `json:"score"`                                                           // 文档相关性评分阈值（默认为 0.2）
+	KnowledgeName   string             `json:"knowledge_name" v:"required-without:knowledge_bases"`             // 目标知识库名称（与 knowledge_bases 至少填一个）
+	KnowledgeBases  []*KnowledgeWeight `json:"knowledge_bases"`                                                 // 跨知识库检索的知识库列表，与 knowledge_name 合并去重
+	RetrievalMode   string             `json:"retrieval_mode" v:"in:dense,hybrid"`                              // 检索模式：dense 仅向量，hybrid 为 BM25 + 向量（为空时使用配置）
+	Fusion          string             `json:"fusion" v:"in:rrf,weighted"`                                      // hybrid 模式下的融合方式：rrf 或 weighted（为空时使用配置）
+	RewriteStrategy string             `json:"rewrite_strategy" v:"in:none,keyword,hyde,multi_query,step_back"` // 问题改写策略（为空时使用配置 rewrite.strategy）
+
+	Filters           []*MetadataFilter  `json:"filters"`            // 元数据过滤条件，全部满足
+	MetadataCondition *MetadataCondition `json:"metadata_condition"` // Dify 格式的元数据过滤条件，与 filters 同时满足
+}
+
+// KnowledgeWeight 跨知识库检索中的知识库及其权重。各知识库的 rerank 分数乘以权重后合并排序
+type KnowledgeWeight struct {
+	Name   string  `json:"name" v:"required" dc:"knowledge base name"`
+	Weight float64 `json:"weight" v:"min:0" dc:"weight of scores, 1 by default"`
+}
+
+// 可过滤的元数据字段
+const (
+	FilterFieldFileName   = "file_name"   // 文件名
+	FilterFieldExtension  = "extension"   // 文件扩展名，如 .pdf
+	FilterFieldH1         = "h1"          // 一级标题
+	FilterFieldH2         = "h2"          // 二级标题
+	FilterFieldH3         = "h3"          // 三级标题
+	FilterFieldUploadedAt = "uploaded_at" // 上传时间，取值为 RFC3339 时间、日期（2025-01-02）或 Unix 秒级时间戳
+	FilterFieldDocumentID = "document_id" // 文档 ID
+)
+
+// MetadataFilter 元数据过滤条件
+type MetadataFilter struct {
+	Field  string `json:"field" v:"required|in:file_name,extension,h1,h2,h3,uploaded_at,document_id" dc:"metadata field"`
+	Op     string `json:"op" v:"required|in:eq,in,range,prefix" dc:"eq / in / range / prefix, range only applies to uploaded_at"`
+	Value  any    `json:"value" dc:"value of eq and prefix"`
+	Values []any  `json:"values" dc:"values of in"`
+	Gte    any    `json:"gte" dc:"lower bound of range, inclusive"`
+	Lte    any    `json:"lte" dc:"upper bound of range, inclusive"`
 }
 
 // RetrieverRes 定义了文档检索接口的响应结构。
 // 用于返回与问题最相关的文档内容。
 type RetrieverRes struct {
 	g.Meta   `mime:"application/json"` // 指定返回的数据格式为 JSON
-	Document []*schema.Document        `json:"document"` // 检索得到的文档列表（来自 eino/schema 的文档结构）
+	Document []*schema.Document        `json:"document"`  // 检索得到的文档列表（来自 eino/schema 的文档结构）
+	CacheHit bool                      `json:"cache_hit"` // 是否命中语义缓存
+	Rewrites []*rewrite.Query          `json:"rewrites"`  // 实际用于检索的改写问题，便于排查
 }
 
 type RetrieverDifyReq struct {
-	g.Meta           `path:"/v1/dify/retrieval" method:"post" tags:"rag" no_wrap_resp:"true"`
-	KnowledgeID      string            `json:"knowledge_id" v:"required"`
-	Query            string            `json:"query" v:"required"`
-	RetrievalSetting *RetrievalSetting `json:"retrieval_setting" v:"required"`
-	// MetadataCondition map[string]interface{} `json:"metadata_condition"`
+	g.Meta            `path:"/v1/dify/retrieval" method:"post" tags:"rag" no_wrap_resp:"true"`
+	KnowledgeID       string             `json:"knowledge_id" v:"required"`
+	Query             string             `json:"query" v:"required"`
+	RetrievalSetting  *RetrievalSetting  `json:"retrieval_setting" v:"required"`
+	MetadataCondition *MetadataCondition `json:"metadata_condition"`
+}
+
+// MetadataCondition Dify 外部知识库 API 的元数据过滤条件
+type MetadataCondition struct {
+	LogicalOperator string           `json:"logical_operator" v:"in:and,or"` // 条件之间的关系，默认 and
+	Conditions      []*DifyCondition `json:"conditions"`
+}
+
+// DifyCondition Dify 的单个过滤条件。name 为元数据名，除 MetadataFilter 中的字段外，
+// 还支持 Dify 内置的 document_name、upload_date、last_update_date；多个 name 任一满足即可
+type DifyCondition struct {
+	Name               []string `json:"name" v:"required"`
+	ComparisonOperator string   `json:"comparison_operator" v:"required"` // contains、not contains、start with、end with、is、is not、empty、not empty、=、≠、>、<、≥、≤、before、after；大小比较只支持上传时间
+	Value              any      `json:"value"`
 }
 
 type RetrievalSetting struct {
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/grader"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// CorrectiveConfig 纠错检索（Corrective RAG）配置
+type CorrectiveConfig struct {
+	Grader    *grader.Grader                                                      // 检索结果打分
+	Retrieve  func(ctx context.Context, query string) ([]*schema.Document, error) // 知识库检索
+	WebSearch func(ctx context.Context, query string) ([]*schema.Document, error) // 网络搜索，为 nil 时不做兜底
+	MaxRounds int                                                                 // 最大检索轮数（含第一次），默认 2
+}
+
+// CorrectiveResult 纠错检索结果
+type CorrectiveResult struct {
+	References     []*schema.Document // 判断为相关的文档，以及网络搜索的兜底结果
+	Sufficient     bool               // 打分模块是否认为这些文档足够回答问题
+	ReasoningSteps []ReasoningStep    // 检索、打分、改写与兜底的步骤
+}
+
+// CorrectiveExecutor 纠错检索执行器：检索后由打分模块一次性判断全部文档，去掉不相关的文档；
+// 文档不足以回答问题时按建议的问题重新检索，多轮后仍不足时使用网络搜索兜底
+type CorrectiveExecutor struct {
+	config *CorrectiveConfig
+}
+
+// NewCorrectiveExecutor 创建纠错检索执行器
+func NewCorrectiveExecutor(config *CorrectiveConfig) *CorrectiveExecutor {
+	if config.MaxRounds <= 0 {
+		config.MaxRounds = 2
+	}
+	return &CorrectiveExecutor{config: config}
+}
+
+// Run 执行纠错检索。打分失败时保留当前文档直接返回，只有第一次检索失败才返回错误
+func (e *CorrectiveExecutor) Run(ctx context.Context, question string) (*CorrectiveResult, error) {
+	var (
+		res   = &CorrectiveResult{}
+		kept  []*schema.Document
+		seen  = map[string]bool{}
+		query = question
+	)
+	addStep := func(step ReasoningStep) {
+		step.Step = len(res.ReasoningSteps) + 1
+		res.ReasoningSteps = appendStep(ctx, res.ReasoningSteps, step)
+	}
+
+	for round := 1; round <= e.config.MaxRounds; round++ {
+		addStep(ReasoningStep{
+			Type:        "action",
+			Content:     "rag_retriever",
+			ActionInput: map[string]interface{}{"query": query, "round": round},
+		})
+		docs, err := e.config.Retrieve(ctx, query)
+		if err != nil {
+			if round == 1 {
+				return nil, fmt.Errorf("corrective: retrieve failed: %w", err)
+			}
+			g.Log().Warningf(ctx, "corrective: retrieve round %d failed: %v", round, err)
+			addStep(ReasoningStep{Type: "observation", Content: fmt.Sprintf("Error: %v", err)})
+			break
+		}
+		var fresh []*schema.Document
+		for _, doc := range docs {
+			if key := docKey(doc); !seen[key] {
+				seen[key] = true
+				fresh = append(fresh, doc)
+			}
+		}
+		addStep(ReasoningStep{
+			Type:    "observation",
+			Content: fmt.Sprintf("Found %d documents, %d new", len(docs), len(fresh)),
+		})
+		if len(fresh) == 0 && round > 1 {
+			break
+		}
+
+		// 已保留的文档与新文档一起打分，判断合起来是否足够回答
+		candidates := append(append([]*schema.Document(nil), kept...), fresh...)
+		grade, err := e.config.Grader.Grade(ctx, candidates, question)
+		if err != nil {
+			g.Log().Warningf(ctx, "corrective: grade failed, keep documents ungraded: %v", err)
+			addStep(ReasoningStep{Type: "grade", Content: fmt.Sprintf("Grading failed, keep %d documents ungraded: %v", len(candidates), err)})
+			res.References = candidates
+			return res, nil
+		}
+		kept = grade.Relevant(candidates)
+		res.Sufficient = grade.Sufficient && len(kept) > 0
+		addStep(ReasoningStep{
+			Type:    "grade",
+			Content: fmt.Sprintf("%d of %d documents relevant, sufficient: %v", len(kept), len(candidates), res.Sufficient),
+			ActionInput: map[string]interface{}{
+				"verdicts":   grade.Documents,
+				"sufficient": grade.Sufficient,
+				"rewrite":    grade.Rewrite,
+			},
+		})
+		if res.Sufficient {
+			res.References = kept
+			return res, nil
+		}
+		if round == e.config.MaxRounds {
+			break
+		}
+		if grade.Rewrite == "" || grade.Rewrite == query {
+			break
+		}
+		query = grade.Rewrite
+		addStep(ReasoningStep{
+			Type:    "thought",
+			Content: "Documents cannot answer the question, re-querying with: " + query,
+		})
+	}
+	res.References = kept
+
+	// 知识库中找不到足够的资料，使用网络搜索兜底
+	if e.config.WebSearch == nil {
+		return res, nil
+	}
+	addStep(ReasoningStep{
+		Type:        "action",
+		Content:     "web_search",
+		ActionInput: map[string]interface{}{"query": question},
+	})
+	webDocs, err := e.config.WebSearch(ctx, question)
+	if err != nil {
+		g.Log().Warningf(ctx, "corrective: web search failed: %v", err)
+		addStep(ReasoningStep{Type: "observation", Content: fmt.Sprintf("Error: %v", err)})
+		return res, nil
+	}
+	for _, doc := range webDocs {
+		if key := docKey(doc); !seen[key] {
+			seen[key] = true
+			res.References = append(res.References, doc)
+		}
+	}
+	addStep(ReasoningStep{
+		Type:    "observation",
+		Content: fmt.Sprintf("Found %d web results", len(webDocs)),
+	})
+	return res, nil
+}
+
+// docKey 文档去重的 key，网络搜索结果没有 ID 时使用内容
+func docKey(doc *schema.Document) string {
+	if doc.ID != "" {
+		return doc.ID
+	}
+	return doc.Content
+}
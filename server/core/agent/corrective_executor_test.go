@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/grader"
+)
+
+// gradeModel 按调用次序返回打分结果
+type gradeModel struct {
+	replies []string
+}
+
+func (m *gradeModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if len(m.replies) == 0 {
+		return nil, errors.New("no reply")
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return schema.AssistantMessage(reply, nil), nil
+}
+
+func (m *gradeModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestCorrectiveExecutor(t *testing.T) {
+	corpus := map[string][]*schema.Document{
+		"数字商品可以退款吗": {{ID: "1", Content: "公司简介"}, {ID: "2", Content: "退款需在 7 天内申请"}},
+		"数字商品 退款政策": {{ID: "2", Content: "退款需在 7 天内申请"}, {ID: "3", Content: "数字商品不支持退款"}},
+	}
+	var queries []string
+	retrieve := func(ctx context.Context, query string) ([]*schema.Document, error) {
+		queries = append(queries, query)
+		return corpus[query], nil
+	}
+	cm := &gradeModel{replies: []string{
+		`{"documents":[{"index":0,"relevant":false},{"index":1,"relevant":true}],"sufficient":false,"rewrite":"数字商品 退款政策"}`,
+		`{"documents":[{"index":0,"relevant":true},{"index":1,"relevant":true}],"sufficient":true}`,
+	}}
+	res, err := NewCorrectiveExecutor(&CorrectiveConfig{
+		Grader:   grader.NewGrader(cm),
+		Retrieve: retrieve,
+		WebSearch: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			t.Fatal("web search should not be used")
+			return nil, nil
+		},
+	}).Run(context.Background(), "数字商品可以退款吗")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(queries, ",") != "数字商品可以退款吗,数字商品 退款政策" {
+		t.Fatalf("unexpected queries: %v", queries)
+	}
+	if !res.Sufficient || len(res.References) != 2 || res.References[0].ID != "2" || res.References[1].ID != "3" {
+		t.Fatalf("unexpected references: %+v", res.References)
+	}
+	var grades int
+	for _, step := range res.ReasoningSteps {
+		if step.Type == "grade" {
+			grades++
+		}
+	}
+	if grades != 2 {
+		t.Fatalf("expect 2 grade steps, got %d", grades)
+	}
+}
+
+func TestCorrectiveExecutorWebFallback(t *testing.T) {
+	cm := &gradeModel{replies: []string{
+		`{"documents":[{"index":0,"relevant":false}],"sufficient":false}`,
+	}}
+	res, err := NewCorrectiveExecutor(&CorrectiveConfig{
+		Grader: grader.NewGrader(cm),
+		Retrieve: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return []*schema.Document{{ID: "1", Content: "公司简介"}}, nil
+		},
+		WebSearch: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return []*schema.Document{{Content: "web result"}}, nil
+		},
+		MaxRounds: 3,
+	}).Run(context.Background(), "今天的汇率")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sufficient || len(res.References) != 1 || res.References[0].Content != "web result" {
+		t.Fatalf("expect only the web result, got %+v", res.References)
+	}
+
+	// 打分失败时保留检索结果
+	res, err = NewCorrectiveExecutor(&CorrectiveConfig{
+		Grader: grader.NewGrader(&gradeModel{}),
+		Retrieve: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return []*schema.Document{{ID: "1", Content: "公司简介"}}, nil
+		},
+	}).Run(context.Background(), "q")
+	if err != nil || len(res.References) != 1 {
+		t.Fatalf("expect ungraded documents, got %v, %v", res, err)
+	}
+}
@@ -71,6 +71,10 @@ func (hc *HybridIntentClassifier) Classify(ctx context.Context, text string) (*R
 		if llmResult.Confidence > ruleResult.Confidence {
 			g.Log().Infof(ctx, "LLM result better (%.2f > %.2f)", llmResult.Confidence, ruleResult.Confidence)
 			llmResult.ClassificationMethod = "hybrid_llm"
+			// LLM 不知道有哪些知识库，沿用规则识别出的范围约束
+			if llmResult.ScopeConstraint == nil {
+				llmResult.ScopeConstraint = ruleResult.ScopeConstraint
+			}
 
 			elapsed := time.Since(startTime).Milliseconds()
 			g.Log().Debugf(ctx, "Classification time: %dms", elapsed)
@@ -10,6 +10,7 @@ import (
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
 )
 
 // MultiHopConfig 多跳推理配置
@@ -73,7 +74,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 	// 记录分解思考步骤
 	stepNum++
 	now := time.Now().Format(time.RFC3339)
-	steps = append(steps, ReasoningStep{
+	steps = appendStep(ctx, steps, ReasoningStep{
 		Step:      stepNum,
 		Type:      "thought",
 		Content:   fmt.Sprintf("Decomposing question into %d sub-questions (source: %s)", len(subQuestions), decompResult.Source),
@@ -90,7 +91,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 
 		// thought 步骤
 		stepNum++
-		steps = append(steps, ReasoningStep{
+		steps = appendStep(ctx, steps, ReasoningStep{
 			Step:      stepNum,
 			Type:      "thought",
 			Content:   fmt.Sprintf("Analyzing sub-question %d/%d: %q", i+1, len(subQuestions), subQ),
@@ -105,7 +106,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 			"score":          score,
 		}
 		stepNum++
-		steps = append(steps, ReasoningStep{
+		steps = appendStep(ctx, steps, ReasoningStep{
 			Step:        stepNum,
 			Type:        "action",
 			Content:     "rag_retriever",
@@ -119,7 +120,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 			// 工具不存在，跳过
 			now = time.Now().Format(time.RFC3339)
 			stepNum++
-			steps = append(steps, ReasoningStep{
+			steps = appendStep(ctx, steps, ReasoningStep{
 				Step:      stepNum,
 				Type:      "observation",
 				Content:   fmt.Sprintf("Found 0 documents for sub-question %d (tool not available)", i+1),
@@ -133,7 +134,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 		if execErr != nil {
 			// 单个子问题检索失败，跳过继续
 			stepNum++
-			steps = append(steps, ReasoningStep{
+			steps = appendStep(ctx, steps, ReasoningStep{
 				Step:      stepNum,
 				Type:      "observation",
 				Content:   fmt.Sprintf("Found 0 documents for sub-question %d (error: %v)", i+1, execErr),
@@ -147,7 +148,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 
 		// observation 步骤
 		stepNum++
-		steps = append(steps, ReasoningStep{
+		steps = appendStep(ctx, steps, ReasoningStep{
 			Step:      stepNum,
 			Type:      "observation",
 			Content:   fmt.Sprintf("Found %d documents for sub-question %d", len(subDocs), i+1),
@@ -169,8 +170,10 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 	// Step 3: 合并所有文档（去重，保留最高 score）
 	allDocs := mergeAndDeduplicateDocs(subResults, topK*2)
 
+	EmitReferences(ctx, allDocs)
+
 	// Step 4: 构建合成 Prompt，生成最终答案
-	finalAnswer, synthErr := e.synthesizeAnswer(ctx, originalQuestion, subResults)
+	finalAnswer, synthErr := e.synthesizeAnswer(ctx, originalQuestion, subResults, allDocs)
 	if synthErr != nil {
 		// LLM 合成失败，拼接所有子问题答案（若有）或返回 error
 		var parts []string
@@ -189,7 +192,7 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 	// 记录最终答案步骤
 	now = time.Now().Format(time.RFC3339)
 	stepNum++
-	steps = append(steps, ReasoningStep{
+	steps = appendStep(ctx, steps, ReasoningStep{
 		Step:      stepNum,
 		Type:      "final_answer",
 		Content:   fmt.Sprintf("Synthesized answer from %d sub-questions", len(subResults)),
@@ -204,45 +207,44 @@ func (e *MultiHopExecutor) Run(ctx context.Context, intent *RAGIntent, originalQ
 	}, nil
 }
 
-// synthesizeAnswer 调用 LLM 合成最终答案
-func (e *MultiHopExecutor) synthesizeAnswer(ctx context.Context, originalQuestion string, subResults []SubQuestionResult) (string, error) {
-	// 构建子问题与文档摘要
+// synthesizeAnswer 调用 LLM 合成最终答案，文档按 allDocs 的顺序编号，答案中的引用编号与 AllReferences 一致
+func (e *MultiHopExecutor) synthesizeAnswer(ctx context.Context, originalQuestion string, subResults []SubQuestionResult, allDocs []*schema.Document) (string, error) {
+	// 构建子问题列表
 	var sb strings.Builder
 	for i, sr := range subResults {
 		fmt.Fprintf(&sb, "Sub-question %d: %s\n", i+1, sr.SubQuestion)
-		sb.WriteString("Documents: ")
-		for j, doc := range sr.Documents {
-			if j > 0 {
-				sb.WriteString("\n")
-			}
-			content := doc.Content
-			if len(content) > 500 {
-				content = content[:500] + "..."
-			}
-			sb.WriteString(content)
+	}
+
+	// 文档内容过长时截断
+	docs := make([]*schema.Document, 0, len(allDocs))
+	for _, doc := range allDocs {
+		if runes := []rune(doc.Content); len(runes) > 500 {
+			doc = &schema.Document{ID: doc.ID, Content: string(runes[:500]) + "...", MetaData: doc.MetaData}
 		}
-		sb.WriteString("\n\n")
+		docs = append(docs, doc)
 	}
 
 	systemPrompt := fmt.Sprintf(`You are a professional AI assistant synthesizing answers from multiple retrieved documents.
 
 Original question: %s
 
-Sub-questions and retrieved context:
+Sub-questions:
+%s
+Retrieved documents (numbered):
 %s
 
 Instructions:
 1. Synthesize a comprehensive answer to the original question using all the retrieved information
 2. If sub-questions have contradictory information, note the discrepancy
 3. Be concise but complete
-4. Cite specific information from the documents when relevant`, originalQuestion, sb.String())
+4. %s`, originalQuestion, sb.String(), citation.Format(docs), citation.Instruction)
 
 	messages := []*schema.Message{
 		schema.SystemMessage(systemPrompt),
 		schema.UserMessage(originalQuestion),
 	}
 
-	resp, err := e.config.Model.Generate(ctx, messages)
+	resp, err := generate(ctx, e.config.Model, messages, "")
 	if err != nil {
 		return "", fmt.Errorf("multi_hop: llm synthesize failed: %w", err)
 	}
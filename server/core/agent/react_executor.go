@@ -9,6 +9,7 @@ import (
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
 )
 
 // ReactConfig ReAct 执行器配置
@@ -57,6 +58,7 @@ Instructions:
 1. Analyze the question step by step using Thought → Action → Observation cycles
 2. Use tools to retrieve relevant information when needed
 3. When you have enough information to answer, output "Final Answer: <your answer>"
+4. In the final answer, cite the documents from the observations by their numbers right after the sentence they support, e.g. [1] or [1][3]
 
 Format:
 Thought: <your reasoning about what to do>
@@ -79,7 +81,8 @@ Current question: %s`, toolDescs, question)
 	stepNum := 0
 
 	for i := 0; i < e.config.MaxIterations; i++ {
-		resp, err := e.config.Model.Generate(ctx, messages)
+		// 流式执行时 Final Answer 之后的内容实时回调
+		resp, err := generate(ctx, e.config.Model, messages, finalAnswerMarker)
 		if err != nil {
 			return nil, fmt.Errorf("react: llm generate failed at iteration %d: %w", i, err)
 		}
@@ -92,7 +95,7 @@ Current question: %s`, toolDescs, question)
 			// Extract thought if present before final answer
 			if thoughtContent := extractThought(content[:idx]); thoughtContent != "" {
 				stepNum++
-				steps = append(steps, ReasoningStep{
+				steps = appendStep(ctx, steps, ReasoningStep{
 					Step:      stepNum,
 					Type:      "thought",
 					Content:   thoughtContent,
@@ -101,7 +104,7 @@ Current question: %s`, toolDescs, question)
 			}
 			answer := strings.TrimSpace(content[idx+len("Final Answer:"):])
 			stepNum++
-			steps = append(steps, ReasoningStep{
+			steps = appendStep(ctx, steps, ReasoningStep{
 				Step:      stepNum,
 				Type:      "final_answer",
 				Content:   answer,
@@ -118,7 +121,7 @@ Current question: %s`, toolDescs, question)
 		thoughtContent := extractThought(content)
 		if thoughtContent != "" {
 			stepNum++
-			steps = append(steps, ReasoningStep{
+			steps = appendStep(ctx, steps, ReasoningStep{
 				Step:      stepNum,
 				Type:      "thought",
 				Content:   thoughtContent,
@@ -146,7 +149,7 @@ Current question: %s`, toolDescs, question)
 		}
 
 		stepNum++
-		steps = append(steps, ReasoningStep{
+		steps = appendStep(ctx, steps, ReasoningStep{
 			Step:        stepNum,
 			Type:        "action",
 			Content:     actionName,
@@ -164,12 +167,16 @@ Current question: %s`, toolDescs, question)
 			if execErr != nil {
 				observation = fmt.Sprintf("Error: %v", execErr)
 			} else {
+				n := len(allRefs)
 				observation, allRefs = formatToolResult(result, allRefs)
+				if len(allRefs) > n {
+					EmitReferences(ctx, allRefs)
+				}
 			}
 		}
 
 		stepNum++
-		steps = append(steps, ReasoningStep{
+		steps = appendStep(ctx, steps, ReasoningStep{
 			Step:      stepNum,
 			Type:      "observation",
 			Content:   observation,
@@ -185,10 +192,11 @@ Current question: %s`, toolDescs, question)
 
 	// Max iterations reached: ask LLM to summarize
 	messages = append(messages, &schema.Message{
-		Role:    schema.User,
-		Content: "Please summarize your findings and provide a final answer based on what you have gathered so far.",
+		Role: schema.User,
+		Content: "Please summarize your findings and provide a final answer based on what you have gathered so far. " +
+			"Reply with the answer only, without the Thought / Final Answer format.",
 	})
-	resp, err := e.config.Model.Generate(ctx, messages)
+	resp, err := generate(ctx, e.config.Model, messages, "")
 	if err != nil {
 		return nil, fmt.Errorf("react: final summary generate failed: %w", err)
 	}
@@ -199,7 +207,7 @@ Current question: %s`, toolDescs, question)
 		answer = strings.TrimSpace(answer[idx+len("Final Answer:"):])
 	}
 	stepNum++
-	steps = append(steps, ReasoningStep{
+	steps = appendStep(ctx, steps, ReasoningStep{
 		Step:      stepNum,
 		Type:      "final_answer",
 		Content:   answer,
@@ -299,6 +307,8 @@ func formatToolResult(result interface{}, existing []*schema.Document) (string,
 			docsData, _ := json.Marshal(docsRaw)
 			var docs []*schema.Document
 			if err := json.Unmarshal(docsData, &docs); err == nil {
+				// 编号在所有 observation 中连续，与最终返回的 References 顺序一致，供答案引用
+				base := len(existing)
 				existing = append(existing, docs...)
 				// Build a concise summary
 				var sb strings.Builder
@@ -311,7 +321,11 @@ func formatToolResult(result interface{}, existing []*schema.Document) (string,
 					if len(content) > 200 {
 						content = content[:200] + "..."
 					}
-					fmt.Fprintf(&sb, "\n[%d] %s", i+1, content)
+					fmt.Fprintf(&sb, "\n[%d]", base+i+1)
+					if label := citation.Source(d).Label(); label != "" {
+						fmt.Fprintf(&sb, " (source: %s)", label)
+					}
+					fmt.Fprintf(&sb, " %s", content)
 				}
 				return sb.String(), existing
 			}
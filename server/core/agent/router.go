@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// DefaultMinConfidence 意图置信度低于该值时无法判断意图，使用默认策略
+const DefaultMinConfidence = 0.3
+
+// RouterConfig 意图路由配置
+type RouterConfig struct {
+	Intents       map[RAGIntentType]string // 意图到策略的映射，未配置的意图使用分类器推荐的策略（RAGIntent.Strategy）
+	Fallbacks     map[string][]string      // 策略执行失败时依次尝试的策略，已推送答案内容或写入会话历史后不再回退
+	Default       string                   // 默认策略，推荐的策略未注册或置信度过低时使用
+	MinConfidence float64                  // 置信度低于该值时直接使用默认策略
+}
+
+// DefaultRouterConfig 读取 agent.router 配置，intents 与 fallbacks 按键覆盖内置的默认值
+func DefaultRouterConfig(ctx context.Context) *RouterConfig {
+	cfg := &RouterConfig{
+		Intents: map[RAGIntentType]string{
+			RAGIntentMultiHopQA:      StrategyMultiHop,
+			RAGIntentCausalReasoning: StrategyMultiHop,
+			RAGIntentAggregation:     StrategyMultiHop,
+			RAGIntentComparison:      StrategyComparison,
+			RAGIntentSummarization:   StrategySummarization,
+		},
+		Fallbacks: map[string][]string{
+			StrategyReAct:         {StrategySimpleRAG},
+			StrategyMultiHop:      {StrategyReAct, StrategySimpleRAG},
+			StrategyComparison:    {StrategyMultiHop, StrategySimpleRAG},
+			StrategyHybrid:        {StrategySimpleRAG},
+			StrategySummarization: {StrategySimpleRAG},
+			StrategyCorrective:    {StrategySimpleRAG},
+		},
+		Default:       g.Cfg().MustGet(ctx, "agent.router.default", StrategySimpleRAG).String(),
+		MinConfidence: g.Cfg().MustGet(ctx, "agent.router.min_confidence", DefaultMinConfidence).Float64(),
+	}
+	for intent, name := range g.Cfg().MustGet(ctx, "agent.router.intents").MapStrStr() {
+		cfg.Intents[RAGIntentType(intent)] = name
+	}
+	for name, chain := range g.Cfg().MustGet(ctx, "agent.router.fallbacks").MapStrVar() {
+		cfg.Fallbacks[name] = chain.Strings()
+	}
+	return cfg
+}
+
+// Router 按意图选择已注册的策略（见 RegisterStrategy），策略失败时按配置的回退链继续尝试
+type Router struct {
+	cfg *RouterConfig
+	env *StrategyEnv
+}
+
+var _ IntentRouter = (*Router)(nil)
+
+// NewRouter 创建路由器，env 为本次请求的策略依赖
+func NewRouter(cfg *RouterConfig, env *StrategyEnv) *Router {
+	c := *cfg
+	if c.Default == "" {
+		c.Default = StrategySimpleRAG
+	}
+	c.Intents = maps.Clone(cfg.Intents)
+	c.Fallbacks = maps.Clone(cfg.Fallbacks)
+	return &Router{cfg: &c, env: env}
+}
+
+// Resolve 返回意图对应的策略名称：置信度过低时为默认策略，其次为意图映射，再次为分类器推荐的策略，
+// 都没有或未注册时为默认策略
+func (r *Router) Resolve(intent *RAGIntent) string {
+	if intent == nil || intent.Confidence < r.cfg.MinConfidence {
+		return r.cfg.Default
+	}
+	for _, name := range []string{r.cfg.Intents[intent.Type], intent.Strategy} {
+		if name != "" && registered(name) {
+			return name
+		}
+	}
+	return r.cfg.Default
+}
+
+// Route 实现 IntentRouter，返回的策略执行失败时按回退链继续尝试
+func (r *Router) Route(ctx context.Context, intent *RAGIntent) (Strategy, error) {
+	return r.Strategy(r.Resolve(intent))
+}
+
+// Strategy 返回以 name 为首的带回退的策略
+func (r *Router) Strategy(name string) (Strategy, error) {
+	if !registered(name) {
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+	return &fallbackStrategy{router: r, chain: r.Chain(name)}, nil
+}
+
+// Chain 返回 name 及其回退链，去掉重复与未注册的策略
+func (r *Router) Chain(name string) []string {
+	var (
+		chain []string
+		seen  = map[string]bool{}
+	)
+	for _, n := range append([]string{name}, r.cfg.Fallbacks[name]...) {
+		if seen[n] || !registered(n) {
+			continue
+		}
+		seen[n] = true
+		chain = append(chain, n)
+	}
+	return chain
+}
+
+// fallbackStrategy 依次执行回退链中的策略，返回第一个成功的结果。
+// 失败的策略已经推送了答案内容或写入了会话历史时不再回退（见 OutputStarted），直接返回错误
+type fallbackStrategy struct {
+	router *Router
+	chain  []string
+}
+
+func (s *fallbackStrategy) Name() string { return s.chain[0] }
+
+func (s *fallbackStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	ctx = WithOutputTracking(ctx)
+	env := s.router.env.tracked()
+	var lastErr error
+	for i, name := range s.chain {
+		strategy, err := NewStrategy(name, env)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if i > 0 {
+			g.Log().Infof(ctx, "↩️ Falling back to %s", name)
+		}
+		result, err := strategy.Execute(ctx, intent, question)
+		if err == nil {
+			if result.Strategy == "" {
+				result.Strategy = name
+			}
+			return result, nil
+		}
+		g.Log().Warningf(ctx, "Strategy %s failed: %v", name, err)
+		if OutputStarted(ctx) {
+			return nil, fmt.Errorf("strategy %s failed after output started: %w", name, err)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all strategies failed (%v): %w", s.chain, lastErr)
+}
+
+func registered(name string) bool {
+	strategyMu.RLock()
+	defer strategyMu.RUnlock()
+	_, ok := strategyFactories[name]
+	return ok
+}
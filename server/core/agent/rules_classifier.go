@@ -65,7 +65,7 @@ func (rc *RuleBasedClassifier) initRAGRules() {
 			IntentType:        RAGIntentSimpleQA,
 			Keywords:          []string{"什么是", "定义", "explain", "define", "介绍", "含义"},
 			HotWords:          []string{"是什么", "指的是", "意思是"},
-			Patterns:          mustCompilePatterns(`(?i)^(what is|define|explain)\s+\w+\??$`, `^什么是[\u4e00-\u9fa5]+[？?]?$`),
+			Patterns:          mustCompilePatterns(`(?i)^(what is|define|explain)\s+\w+\??$`, `^什么是[\x{4e00}-\x{9fa5}]+[？?]?$`),
 			Weight:            1.0,
 			SuggestedStrategy: "simple_rag",
 			SuggestedTools:    []string{"rag"},
@@ -259,7 +259,7 @@ func (rc *RuleBasedClassifier) initSlotParsers() {
 		},
 		{
 			SlotName: "entity",
-			Pattern:  regexp.MustCompile(`[A-Z][a-z]+(?:\s+[A-Z][a-z]+)*|[\u4e00-\u9fa5]{2,}`),
+			Pattern:  regexp.MustCompile(`[A-Z][a-z]+(?:\s+[A-Z][a-z]+)*|[\x{4e00}-\x{9fa5}]{2,}`),
 		},
 	}
 }
@@ -344,7 +344,7 @@ func (rc *RuleBasedClassifier) Classify(ctx context.Context, text string) (*RAGI
 
 	// 提取时间和范围约束
 	intent.TimeConstraint = extractTimeConstraint(text, slots)
-	intent.ScopeConstraint = extractScopeConstraint(text, slots)
+	intent.ScopeConstraint = extractScopeConstraint(text, slots, knowledgeBasesFromContext(ctx))
 
 	return intent, nil
 }
@@ -542,11 +542,13 @@ func extractTimeConstraint(text string, slots map[string][]string) *TimeConstrai
 	return nil
 }
 
-func extractScopeConstraint(text string, slots map[string][]string) *ScopeConstraint {
-	if entities, ok := slots["entity"]; ok && len(entities) > 0 {
-		return &ScopeConstraint{
-			Entities: entities,
-		}
+func extractScopeConstraint(text string, slots map[string][]string, knowledgeBases []string) *ScopeConstraint {
+	scope := &ScopeConstraint{
+		KnowledgeBases: matchKnowledgeBases(text, knowledgeBases),
+		Entities:       slots["entity"],
 	}
-	return nil
+	if len(scope.KnowledgeBases) == 0 && len(scope.Entities) == 0 {
+		return nil
+	}
+	return scope
 }
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type knowledgeBasesCtxKey struct{}
+
+// allKnowledgeHints 表示检索全部知识库的说法
+var allKnowledgeHints = []string{"所有知识库", "全部知识库", "各个知识库", "每个知识库", "all knowledge bases", "every knowledge base"}
+
+// WithKnowledgeBases 把可检索的知识库名称放入 ctx，规则分类器据此识别问题中提到的知识库（ScopeConstraint.KnowledgeBases）
+func WithKnowledgeBases(ctx context.Context, names []string) context.Context {
+	return context.WithValue(ctx, knowledgeBasesCtxKey{}, names)
+}
+
+func knowledgeBasesFromContext(ctx context.Context) []string {
+	names, _ := ctx.Value(knowledgeBasesCtxKey{}).([]string)
+	return names
+}
+
+// matchKnowledgeBases 返回问题中提到的知识库，提到"所有知识库"等说法时返回全部知识库。
+// 名称按长度从长到短匹配，匹配到的部分从问题中去掉，避免 "产品" 再次命中 "产品手册" 中的字样
+func matchKnowledgeBases(text string, names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	lower := strings.ToLower(text)
+	for _, hint := range allKnowledgeHints {
+		if strings.Contains(lower, hint) {
+			return names
+		}
+	}
+	sorted := append([]string(nil), names...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	var matched []string
+	for _, name := range sorted {
+		n := strings.ToLower(strings.TrimSpace(name))
+		if n == "" || !strings.Contains(lower, n) {
+			continue
+		}
+		matched = append(matched, name)
+		lower = strings.ReplaceAll(lower, n, " ")
+	}
+	return matched
+}
@@ -0,0 +1,350 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 检索范围的上限
+const (
+	maxMergedDocs       = 20 // 混合检索与比较合并后最多保留的文档数
+	summarizationTopK   = 15 // 总结时最少检索的 chunk 数
+	maxSummarizationDoc = 30 // 总结时最多检索的 chunk 数
+)
+
+// SimpleRAGStrategy 检索一次后生成回答
+type SimpleRAGStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *SimpleRAGStrategy) Name() string { return StrategySimpleRAG }
+
+func (s *SimpleRAGStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	docs, err := s.env.Retrieve(ctx, question, 0)
+	if err != nil {
+		return nil, err
+	}
+	return answer(ctx, s.env, docs, nil)
+}
+
+// ReActStrategy ReAct 循环，由模型决定检索的内容与次数，回答由模型在循环中直接给出
+type ReActStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *ReActStrategy) Name() string { return StrategyReAct }
+
+// Execute 多跳推理作为单独的策略（见 MultiHopStrategy），这里不再转交
+func (s *ReActStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	if s.env.Model == nil || s.env.Tools == nil {
+		return nil, errors.New("react: chat model or tools not available")
+	}
+	g.Log().Infof(ctx, "🤖 Executing ReAct agent (intent=%s, estimated_steps=%d)", intent.Type, intent.EstimatedSteps)
+	result, err := NewReactExecutor(&ReactConfig{
+		MaxIterations: s.env.MaxIterations,
+		Model:         s.env.Model,
+		Registry:      s.env.Tools,
+	}).Run(ctx, intent, question, s.env.KnowledgeName, s.env.TopK, s.env.Score)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Infof(ctx, "✅ ReAct completed: %d steps, %d references", len(result.ReasoningSteps), len(result.References))
+	saveAnswer(ctx, s.env, result.Answer)
+	return &StrategyResult{Answer: result.Answer, References: result.References, ReasoningSteps: result.ReasoningSteps}, nil
+}
+
+// MultiHopStrategy 分解为子问题逐个检索，再由模型综合回答
+type MultiHopStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *MultiHopStrategy) Name() string { return StrategyMultiHop }
+
+func (s *MultiHopStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	if s.env.Model == nil || s.env.Tools == nil {
+		return nil, errors.New("multi_hop: chat model or tools not available")
+	}
+	result, err := NewMultiHopExecutor(&MultiHopConfig{
+		Model:    s.env.Model,
+		Registry: s.env.Tools,
+		MaxSubQs: s.env.MaxIterations,
+	}).Run(ctx, intent, question, s.env.KnowledgeName, s.env.TopK, s.env.Score)
+	if err != nil {
+		return nil, err
+	}
+	saveAnswer(ctx, s.env, result.FinalAnswer)
+	return &StrategyResult{Answer: result.FinalAnswer, References: result.AllReferences, ReasoningSteps: result.ReasoningSteps}, nil
+}
+
+// HybridStrategy 知识库检索与网络搜索并行，合并去重后生成回答。意图不需要外部数据或未启用网络搜索时只检索知识库
+type HybridStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *HybridStrategy) Name() string { return StrategyHybrid }
+
+func (s *HybridStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	g.Log().Infof(ctx, "🔍 Executing hybrid search (intent=%s)", intent.Type)
+	doWebSearch := s.env.WebSearch != nil && needsWebSearch(intent)
+	var (
+		ragDocs, webDocs []*schema.Document
+		ragErr, webErr   error
+		wg               sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ragDocs, ragErr = s.env.Retrieve(ctx, question, 0)
+	}()
+	if doWebSearch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			webDocs, webErr = s.env.WebSearch(ctx, question)
+		}()
+	}
+	wg.Wait()
+
+	if ragErr != nil && (webErr != nil || !doWebSearch) {
+		return nil, fmt.Errorf("hybrid search: rag=%w, web=%v", ragErr, webErr)
+	}
+	if ragErr != nil {
+		g.Log().Warningf(ctx, "⚠️ RAG retrieval failed, using only web results: %v", ragErr)
+	}
+	if webErr != nil {
+		g.Log().Warningf(ctx, "⚠️ Web search failed, using only RAG results: %v", webErr)
+	}
+	g.Log().Infof(ctx, "📚 Hybrid results: RAG=%d, Web=%d", len(ragDocs), len(webDocs))
+
+	// 实时查询时网络结果优先，其他情况知识库结果优先
+	primary, secondary := ragDocs, webDocs
+	if intent.Type == RAGIntentRealtimeQuery {
+		primary, secondary = webDocs, ragDocs
+	}
+	limit := s.env.TopK * 2
+	if limit <= 0 {
+		limit = 10
+	}
+	docs := mergeDocs(min(limit, maxMergedDocs), slices.Concat(primary, secondary))
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+	return answer(ctx, s.env, docs, nil)
+}
+
+// needsWebSearch 判断意图是否需要外部数据
+func needsWebSearch(intent *RAGIntent) bool {
+	return intent.RequiresExternal || intent.Type == RAGIntentHybridSearch || intent.Type == RAGIntentRealtimeQuery
+}
+
+// CorrectiveStrategy 纠错检索（CRAG）：检索结果经打分过滤，不足时改写问题重新检索，仍不足时使用网络搜索兜底
+type CorrectiveStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *CorrectiveStrategy) Name() string { return StrategyCorrective }
+
+func (s *CorrectiveStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	if s.env.Grader == nil {
+		return nil, errors.New("corrective_rag: grader not available")
+	}
+	g.Log().Infof(ctx, "🧪 Executing corrective RAG")
+	result, err := NewCorrectiveExecutor(&CorrectiveConfig{
+		Grader: s.env.Grader,
+		Retrieve: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return s.env.Retrieve(ctx, query, 0)
+		},
+		WebSearch: s.env.WebSearch,
+		MaxRounds: s.env.MaxRounds,
+	}).Run(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	return answer(ctx, s.env, result.References, result.ReasoningSteps)
+}
+
+// SummarizationStrategy 总结整篇文档：确定问题涉及的文档后按顺序读取全部 chunk 生成摘要（见 StrategyEnv.Summarize），
+// 基于摘要回答。没有配置或没有找到文档时退回为扩大检索范围，把 chunk 按所属文档归拢后回答
+type SummarizationStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *SummarizationStrategy) Name() string { return StrategySummarization }
+
+func (s *SummarizationStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	if s.env.Summarize != nil {
+		docs, err := s.env.Summarize(ctx, question)
+		if err != nil {
+			g.Log().Warningf(ctx, "summarize documents failed, fallback to retrieval, err=%v", err)
+		}
+		if len(docs) > 0 {
+			names := make([]string, 0, len(docs))
+			for _, doc := range docs {
+				names = append(names, citation.Source(doc).FileName)
+			}
+			steps := appendStep(ctx, nil, ReasoningStep{
+				Step:        1,
+				Type:        "observation",
+				Content:     fmt.Sprintf("Summarized %d whole documents", len(docs)),
+				ActionInput: map[string]interface{}{"documents": names},
+				Timestamp:   time.Now().Format(time.RFC3339),
+			})
+			return answer(ctx, s.env, docs, steps)
+		}
+	}
+
+	topK := min(max(s.env.TopK*3, summarizationTopK), maxSummarizationDoc)
+	docs, err := s.env.Retrieve(ctx, question, topK)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+	return answer(ctx, s.env, groupByDocument(docs), nil)
+}
+
+// groupByDocument 把同一文档的 chunk 排在一起，文档按首次出现的顺序，文档内保持原顺序
+func groupByDocument(docs []*schema.Document) []*schema.Document {
+	var (
+		order  []string
+		groups = map[string][]*schema.Document{}
+	)
+	for _, doc := range docs {
+		src := citation.Source(doc)
+		key := src.FileName
+		if src.KnowledgeDocID != 0 {
+			key = fmt.Sprint(src.KnowledgeDocID)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], doc)
+	}
+	res := make([]*schema.Document, 0, len(docs))
+	for _, key := range order {
+		res = append(res, groups[key]...)
+	}
+	return res
+}
+
+// ComparisonStrategy 把比较问题分解为针对各个对象的子问题并行检索，轮流合并各子问题的结果，
+// 保证每个比较对象都有参考资料，再生成对比回答
+type ComparisonStrategy struct {
+	env *StrategyEnv
+}
+
+func (s *ComparisonStrategy) Name() string { return StrategyComparison }
+
+func (s *ComparisonStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	subQuestions := []string{question}
+	if s.env.Model != nil {
+		if qs, _ := NewSubQuestionDecomposer(s.env.Model).Decompose(ctx, question, intent); len(qs) > 0 {
+			subQuestions = qs
+		}
+	}
+	var steps []ReasoningStep
+	steps = appendStep(ctx, steps, ReasoningStep{
+		Step:        1,
+		Type:        "thought",
+		Content:     fmt.Sprintf("Comparing by %d sub-questions", len(subQuestions)),
+		ActionInput: map[string]interface{}{"sub_questions": subQuestions},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	})
+
+	results := make([][]*schema.Document, len(subQuestions))
+	errs := make([]error, len(subQuestions))
+	var wg sync.WaitGroup
+	for i, q := range subQuestions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = s.env.Retrieve(ctx, q, 0)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			g.Log().Warningf(ctx, "comparison: retrieve %q failed, err=%v", subQuestions[i], err)
+		}
+	}
+
+	limit := s.env.TopK * len(subQuestions)
+	if limit <= 0 {
+		limit = 10
+	}
+	docs := mergeDocs(min(limit, maxMergedDocs), results...)
+	steps = appendStep(ctx, steps, ReasoningStep{
+		Step:      2,
+		Type:      "observation",
+		Content:   fmt.Sprintf("Found %d documents for %d sub-questions", len(docs), len(subQuestions)),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	if len(docs) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoDocuments
+	}
+	return answer(ctx, s.env, docs, steps)
+}
+
+// mergeDocs 轮流从各组中取文档，按 ID（没有 ID 时按内容前 100 字节）去重，最多保留 limit 个。
+// 只有一组时等同于按顺序去重
+func mergeDocs(limit int, groups ...[]*schema.Document) []*schema.Document {
+	var (
+		res  []*schema.Document
+		seen = map[string]bool{}
+	)
+	for i := 0; len(res) < limit; i++ {
+		progressed := false
+		for _, docs := range groups {
+			if i >= len(docs) || len(res) >= limit {
+				continue
+			}
+			progressed = true
+			doc := docs[i]
+			if doc == nil {
+				continue
+			}
+			key := doc.ID
+			if key == "" {
+				key = doc.Content[:min(len(doc.Content), 100)]
+			}
+			if !seen[key] {
+				seen[key] = true
+				res = append(res, doc)
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return res
+}
+
+// answer 基于参考资料生成回答
+func answer(ctx context.Context, env *StrategyEnv, docs []*schema.Document, steps []ReasoningStep) (*StrategyResult, error) {
+	text, err := env.Answer(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+	return &StrategyResult{Answer: text, References: docs, ReasoningSteps: steps}, nil
+}
+
+func saveAnswer(ctx context.Context, env *StrategyEnv, text string) {
+	if env.SaveAnswer == nil {
+		return
+	}
+	if err := env.SaveAnswer(ctx, text); err != nil {
+		g.Log().Errorf(ctx, "save answer failed, err=%v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/grader"
+)
+
+// 内置策略名称
+const (
+	StrategySimpleRAG     = "simple_rag"     // 检索一次后生成回答
+	StrategyReAct         = "react_agent"    // ReAct 循环，由模型决定检索的内容与次数
+	StrategyMultiHop      = "multi_hop"      // 分解为子问题逐个检索后综合回答
+	StrategyHybrid        = "hybrid"         // 知识库与网络搜索并行检索
+	StrategyCorrective    = "corrective_rag" // 检索结果打分，不足时改写重检或网络搜索兜底
+	StrategySummarization = "summarization"  // 确定问题涉及的文档后按全文摘要回答
+	StrategyComparison    = "comparison"     // 按比较对象分别检索，保证每个对象都有参考资料
+)
+
+// ErrNoDocuments 没有检索到参考资料，路由器据此尝试下一个策略
+var ErrNoDocuments = errors.New("no documents retrieved")
+
+// StrategyEnv 策略执行所需的依赖，由调用方按请求构造。
+// Execute 的 question 为检索使用的问题（多轮对话中为改写后的独立问题），Answer 针对用户的原问题生成回答
+type StrategyEnv struct {
+	Model         model.BaseChatModel                                                           // ReAct、多跳推理与比较分解使用的 LLM
+	Retrieve      func(ctx context.Context, query string, topK int) ([]*schema.Document, error) // 知识库检索，topK <= 0 时使用请求的 TopK
+	Answer        func(ctx context.Context, docs []*schema.Document) (string, error)            // 基于参考资料生成回答，并写入会话历史
+	SaveAnswer    func(ctx context.Context, answer string) error                                // 策略自行生成回答（如 ReAct）时写入会话历史，可为 nil
+	WebSearch     func(ctx context.Context, query string) ([]*schema.Document, error)           // 网络搜索，未启用时为 nil
+	Summarize     func(ctx context.Context, query string) ([]*schema.Document, error)           // 按整篇文档总结，返回问题涉及的每篇文档的摘要，可为 nil
+	Tools         *ToolRegistry                                                                 // ReAct 与多跳推理使用的工具
+	Grader        *grader.Grader                                                                // 纠错检索的打分模块
+	KnowledgeName string
+	TopK          int
+	Score         float64
+	MaxIterations int // ReAct 最大推理轮数
+	MaxRounds     int // 纠错检索最大检索轮数
+}
+
+// tracked 返回调用 Answer、SaveAnswer 时在 ctx 中记录已写入会话历史的副本（见 OutputStarted）。
+// Answer 在生成回答前就会写入问题，因此调用即记录
+func (e *StrategyEnv) tracked() *StrategyEnv {
+	env := *e
+	if e.Answer != nil {
+		env.Answer = func(ctx context.Context, docs []*schema.Document) (string, error) {
+			markSaved(ctx)
+			return e.Answer(ctx, docs)
+		}
+	}
+	if e.SaveAnswer != nil {
+		env.SaveAnswer = func(ctx context.Context, answer string) error {
+			markSaved(ctx)
+			return e.SaveAnswer(ctx, answer)
+		}
+	}
+	return &env
+}
+
+// StrategyFactory 按请求的依赖创建策略
+type StrategyFactory func(env *StrategyEnv) Strategy
+
+var (
+	strategyMu        sync.RWMutex
+	strategyFactories = map[string]StrategyFactory{}
+)
+
+// RegisterStrategy 注册策略，name 与路由配置 agent.router.* 中的策略名称对应，已存在时覆盖
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategyMu.Lock()
+	defer strategyMu.Unlock()
+	strategyFactories[name] = factory
+}
+
+// NewStrategy 创建已注册的策略
+func NewStrategy(name string, env *StrategyEnv) (Strategy, error) {
+	strategyMu.RLock()
+	factory, ok := strategyFactories[name]
+	strategyMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+	return factory(env), nil
+}
+
+// StrategyNames 返回已注册的策略名称
+func StrategyNames() []string {
+	strategyMu.RLock()
+	defer strategyMu.RUnlock()
+	names := make([]string, 0, len(strategyFactories))
+	for name := range strategyFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterStrategy(StrategySimpleRAG, func(env *StrategyEnv) Strategy { return &SimpleRAGStrategy{env: env} })
+	RegisterStrategy(StrategyReAct, func(env *StrategyEnv) Strategy { return &ReActStrategy{env: env} })
+	RegisterStrategy(StrategyMultiHop, func(env *StrategyEnv) Strategy { return &MultiHopStrategy{env: env} })
+	RegisterStrategy(StrategyHybrid, func(env *StrategyEnv) Strategy { return &HybridStrategy{env: env} })
+	RegisterStrategy(StrategyCorrective, func(env *StrategyEnv) Strategy { return &CorrectiveStrategy{env: env} })
+	RegisterStrategy(StrategySummarization, func(env *StrategyEnv) Strategy { return &SummarizationStrategy{env: env} })
+	RegisterStrategy(StrategyComparison, func(env *StrategyEnv) Strategy { return &ComparisonStrategy{env: env} })
+}
@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// funcStrategy 测试用策略
+type funcStrategy struct {
+	name string
+	fn   func(question string) (*StrategyResult, error)
+}
+
+func (s *funcStrategy) Name() string { return s.name }
+
+func (s *funcStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	return s.fn(question)
+}
+
+// testEnv 按 query 从 corpus 检索，回答为参考资料 ID 的拼接
+func testEnv(corpus map[string][]*schema.Document) *StrategyEnv {
+	return &StrategyEnv{
+		Retrieve: func(ctx context.Context, query string, topK int) ([]*schema.Document, error) {
+			return corpus[query], nil
+		},
+		Answer: func(ctx context.Context, docs []*schema.Document) (string, error) {
+			return docIDs(docs), nil
+		},
+		TopK: 5,
+	}
+}
+
+func docIDs(docs []*schema.Document) string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestRouterResolve(t *testing.T) {
+	r := NewRouter(DefaultRouterConfig(context.Background()), testEnv(nil))
+	cases := []struct {
+		intent *RAGIntent
+		want   string
+	}{
+		{&RAGIntent{Type: RAGIntentSimpleQA, Confidence: 0.9, Strategy: StrategySimpleRAG}, StrategySimpleRAG},
+		{&RAGIntent{Type: RAGIntentComparison, Confidence: 0.9, Strategy: StrategyReAct}, StrategyComparison},
+		{&RAGIntent{Type: RAGIntentMultiHopQA, Confidence: 0.9, Strategy: StrategyReAct}, StrategyMultiHop},
+		{&RAGIntent{Type: RAGIntentProcedural, Confidence: 0.9, Strategy: StrategyReAct}, StrategyReAct},
+		{&RAGIntent{Type: RAGIntentHybridSearch, Confidence: 0.9, Strategy: StrategyHybrid}, StrategyHybrid},
+		{&RAGIntent{Type: RAGIntentComparison, Confidence: 0.1, Strategy: StrategyReAct}, StrategySimpleRAG},
+		{&RAGIntent{Type: RAGIntentUnknown, Confidence: 0.9, Strategy: "no_such_strategy"}, StrategySimpleRAG},
+	}
+	for _, c := range cases {
+		if got := r.Resolve(c.intent); got != c.want {
+			t.Errorf("Resolve(%s, %.1f, %s) = %s, want %s", c.intent.Type, c.intent.Confidence, c.intent.Strategy, got, c.want)
+		}
+	}
+}
+
+func TestRouterFallback(t *testing.T) {
+	var tried []string
+	RegisterStrategy("test_fail", func(env *StrategyEnv) Strategy {
+		return &funcStrategy{name: "test_fail", fn: func(string) (*StrategyResult, error) {
+			tried = append(tried, "test_fail")
+			return nil, ErrNoDocuments
+		}}
+	})
+	RegisterStrategy("test_ok", func(env *StrategyEnv) Strategy {
+		return &funcStrategy{name: "test_ok", fn: func(q string) (*StrategyResult, error) {
+			tried = append(tried, "test_ok")
+			return &StrategyResult{Answer: "ok: " + q}, nil
+		}}
+	})
+	r := NewRouter(&RouterConfig{
+		Intents:   map[RAGIntentType]string{RAGIntentFactCheck: "test_fail"},
+		Fallbacks: map[string][]string{"test_fail": {"test_fail", "not_registered", "test_ok", StrategySimpleRAG}},
+	}, testEnv(nil))
+
+	strategy, err := r.Route(context.Background(), &RAGIntent{Type: RAGIntentFactCheck, Confidence: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strategy.Name() != "test_fail" {
+		t.Fatalf("unexpected strategy: %s", strategy.Name())
+	}
+	res, err := strategy.Execute(context.Background(), &RAGIntent{}, "q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Strategy != "test_ok" || res.Answer != "ok: q" || strings.Join(tried, ",") != "test_fail,test_ok" {
+		t.Fatalf("unexpected result: %+v, tried %v", res, tried)
+	}
+
+	// 回退链全部失败时返回最后一个错误
+	r = NewRouter(&RouterConfig{}, testEnv(nil))
+	strategy, _ = r.Strategy("test_fail")
+	if _, err = strategy.Execute(context.Background(), &RAGIntent{}, "q"); !errors.Is(err, ErrNoDocuments) {
+		t.Fatalf("expect ErrNoDocuments, got %v", err)
+	}
+	if _, err = r.Strategy("not_registered"); err == nil {
+		t.Fatal("expect error for unknown strategy")
+	}
+}
+
+// outputStrategy 先调用 output 产生输出再失败
+type outputStrategy struct {
+	output func(ctx context.Context)
+}
+
+func (s *outputStrategy) Name() string { return "test_output" }
+
+func (s *outputStrategy) Execute(ctx context.Context, intent *RAGIntent, question string) (*StrategyResult, error) {
+	s.output(ctx)
+	return nil, errors.New("failed after output")
+}
+
+func TestRouterFallbackAfterOutput(t *testing.T) {
+	var answered int
+	env := testEnv(map[string][]*schema.Document{"q": {{ID: "1"}}})
+	env.Answer = func(ctx context.Context, docs []*schema.Document) (string, error) {
+		answered++
+		return "", nil
+	}
+	cases := map[string]func(ctx context.Context, env *StrategyEnv){
+		"saved": func(ctx context.Context, env *StrategyEnv) { _, _ = env.Answer(ctx, nil) },
+		"streamed": func(ctx context.Context, env *StrategyEnv) {
+			EmitToken(ctx, "部分答案")
+		},
+	}
+	for name, output := range cases {
+		answered = 0
+		RegisterStrategy("test_output", func(env *StrategyEnv) Strategy {
+			return &outputStrategy{output: func(ctx context.Context) { output(ctx, env) }}
+		})
+		r := NewRouter(&RouterConfig{Fallbacks: map[string][]string{"test_output": {StrategySimpleRAG}}}, env)
+		strategy, _ := r.Strategy("test_output")
+		ctx := WithStreamHandler(context.Background(), &StreamHandler{OnToken: func(string) {}})
+		if _, err := strategy.Execute(ctx, &RAGIntent{}, "q"); err == nil {
+			t.Fatalf("%s: expect error without falling back", name)
+		}
+		if name == "saved" && answered != 1 {
+			t.Fatalf("%s: fallback should not answer again, answered %d times", name, answered)
+		}
+		if name == "streamed" && answered != 0 {
+			t.Fatalf("%s: fallback should not answer, answered %d times", name, answered)
+		}
+	}
+}
+
+func TestHybridStrategy(t *testing.T) {
+	env := testEnv(map[string][]*schema.Document{
+		"q": {{ID: "r1", Content: "a"}, {ID: "r2", Content: "b"}},
+	})
+	env.WebSearch = func(ctx context.Context, query string) ([]*schema.Document, error) {
+		return []*schema.Document{{ID: "w1", Content: "c"}, {ID: "r1", Content: "a"}}, nil
+	}
+	s, _ := NewStrategy(StrategyHybrid, env)
+
+	res, err := s.Execute(context.Background(), &RAGIntent{Type: RAGIntentHybridSearch}, "q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "r1,r2,w1" {
+		t.Fatalf("unexpected merge: %s", res.Answer)
+	}
+	res, _ = s.Execute(context.Background(), &RAGIntent{Type: RAGIntentRealtimeQuery}, "q")
+	if res.Answer != "w1,r1,r2" {
+		t.Fatalf("realtime query should prefer web results: %s", res.Answer)
+	}
+	res, _ = s.Execute(context.Background(), &RAGIntent{Type: RAGIntentSimpleQA}, "q")
+	if res.Answer != "r1,r2" {
+		t.Fatalf("web search should be skipped: %s", res.Answer)
+	}
+	if _, err = s.Execute(context.Background(), &RAGIntent{Type: RAGIntentSimpleQA}, "empty"); !errors.Is(err, ErrNoDocuments) {
+		t.Fatalf("expect ErrNoDocuments, got %v", err)
+	}
+}
+
+func TestComparisonStrategy(t *testing.T) {
+	env := testEnv(map[string][]*schema.Document{
+		"A 的价格": {{ID: "a1"}, {ID: "a2"}, {ID: "a3"}},
+		"B 的价格": {{ID: "b1"}, {ID: "a2"}},
+	})
+	env.Model = &gradeModel{}
+	env.TopK = 2
+	s, _ := NewStrategy(StrategyComparison, env)
+	res, err := s.Execute(context.Background(), &RAGIntent{
+		Type:         RAGIntentComparison,
+		SubQuestions: []string{"A 的价格", "B 的价格"},
+	}, "A 和 B 哪个便宜")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "a1,b1,a2,a3" || len(res.ReasoningSteps) != 2 {
+		t.Fatalf("unexpected result: %s, %d steps", res.Answer, len(res.ReasoningSteps))
+	}
+}
+
+func TestGroupByDocument(t *testing.T) {
+	doc := func(id, docID string) *schema.Document {
+		return &schema.Document{ID: id, MetaData: map[string]any{"_doc_id": docID}}
+	}
+	docs := groupByDocument([]*schema.Document{doc("1", "10"), doc("2", "20"), doc("3", "10"), doc("4", "30"), doc("5", "20")})
+	if got := docIDs(docs); got != "1,3,2,5,4" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+}
+
+func TestSummarizationStrategy(t *testing.T) {
+	env := testEnv(map[string][]*schema.Document{
+		"总结入职手册": {{ID: "1", MetaData: map[string]any{"_doc_id": "10"}}, {ID: "2", MetaData: map[string]any{"_doc_id": "20"}}, {ID: "3", MetaData: map[string]any{"_doc_id": "10"}}},
+	})
+	s, _ := NewStrategy(StrategySummarization, env)
+	res, err := s.Execute(context.Background(), &RAGIntent{Type: RAGIntentSummarization}, "总结入职手册")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "1,3,2" {
+		t.Fatalf("chunks should be grouped by document: %s", res.Answer)
+	}
+
+	// 有整篇文档的摘要时基于摘要回答
+	env.Summarize = func(ctx context.Context, query string) ([]*schema.Document, error) {
+		return []*schema.Document{{ID: "summary-10", Content: "入职手册摘要", MetaData: map[string]any{"_file_name": "入职手册.pdf"}}}, nil
+	}
+	res, err = s.Execute(context.Background(), &RAGIntent{Type: RAGIntentSummarization}, "总结入职手册")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "summary-10" || len(res.ReasoningSteps) != 1 {
+		t.Fatalf("unexpected result: %s, %d steps", res.Answer, len(res.ReasoningSteps))
+	}
+
+	// 摘要失败时退回检索
+	env.Summarize = func(ctx context.Context, query string) ([]*schema.Document, error) {
+		return nil, errors.New("model unavailable")
+	}
+	if res, err = s.Execute(context.Background(), &RAGIntent{Type: RAGIntentSummarization}, "总结入职手册"); err != nil || res.Answer != "1,3,2" {
+		t.Fatalf("unexpected fallback: %v, %v", res, err)
+	}
+}
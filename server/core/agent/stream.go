@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// finalAnswerMarker ReAct 输出中最终答案的标记
+const finalAnswerMarker = "Final Answer:"
+
+type streamHandlerCtxKey struct{}
+
+// StreamHandler 流式执行的回调，由 WithStreamHandler 放入 ctx。未设置时各执行器与非流式一致
+type StreamHandler struct {
+	OnStep       func(step ReasoningStep)      // 推理步骤产生时回调
+	OnReferences func(docs []*schema.Document) // 生成答案所依据的文档确定（或增加）时回调，以最后一次为准
+	OnToken      func(token string)            // 最终答案的增量内容
+}
+
+// WithStreamHandler 把流式回调放入 ctx，执行器产生推理步骤、生成最终答案时实时回调
+func WithStreamHandler(ctx context.Context, h *StreamHandler) context.Context {
+	return context.WithValue(ctx, streamHandlerCtxKey{}, h)
+}
+
+func getStreamHandler(ctx context.Context) *StreamHandler {
+	h, _ := ctx.Value(streamHandlerCtxKey{}).(*StreamHandler)
+	return h
+}
+
+// IsStreaming 判断 ctx 中是否有最终答案的增量回调
+func IsStreaming(ctx context.Context) bool {
+	h := getStreamHandler(ctx)
+	return h != nil && h.OnToken != nil
+}
+
+// EmitStep 回调推理步骤
+func EmitStep(ctx context.Context, step ReasoningStep) {
+	if h := getStreamHandler(ctx); h != nil && h.OnStep != nil {
+		h.OnStep(step)
+	}
+}
+
+// EmitReferences 回调生成答案所依据的文档
+func EmitReferences(ctx context.Context, docs []*schema.Document) {
+	if h := getStreamHandler(ctx); h != nil && h.OnReferences != nil && len(docs) > 0 {
+		h.OnReferences(docs)
+	}
+}
+
+// EmitToken 回调最终答案的增量内容
+func EmitToken(ctx context.Context, token string) {
+	if h := getStreamHandler(ctx); h != nil && h.OnToken != nil && token != "" {
+		if o := getOutput(ctx); o != nil {
+			o.streamed.Store(true)
+		}
+		h.OnToken(token)
+	}
+}
+
+type outputCtxKey struct{}
+
+// output 记录一次请求是否已经产生对外可见的输出
+type output struct {
+	streamed atomic.Bool // 已推送答案内容
+	saved    atomic.Bool // 已写入会话历史
+}
+
+// WithOutputTracking 在 ctx 中记录本次请求是否已经推送答案内容或写入会话历史（见 OutputStarted），已记录时原样返回
+func WithOutputTracking(ctx context.Context) context.Context {
+	if getOutput(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, outputCtxKey{}, &output{})
+}
+
+// OutputStarted 判断是否已经推送答案内容或写入会话历史。此后执行失败不能再换一种方式重新回答，
+// 否则客户端会收到两份答案，会话历史中也会重复写入问题
+func OutputStarted(ctx context.Context) bool {
+	o := getOutput(ctx)
+	return o != nil && (o.streamed.Load() || o.saved.Load())
+}
+
+func markSaved(ctx context.Context) {
+	if o := getOutput(ctx); o != nil {
+		o.saved.Store(true)
+	}
+}
+
+func getOutput(ctx context.Context) *output {
+	o, _ := ctx.Value(outputCtxKey{}).(*output)
+	return o
+}
+
+// appendStep 追加推理步骤并回调，未设置时间时使用当前时间
+func appendStep(ctx context.Context, steps []ReasoningStep, step ReasoningStep) []ReasoningStep {
+	if step.Timestamp == "" {
+		step.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	EmitStep(ctx, step)
+	return append(steps, step)
+}
+
+// GenerateAnswer 调用模型生成答案，流式执行时全部内容作为答案增量回调
+func GenerateAnswer(ctx context.Context, cm model.BaseChatModel, messages []*schema.Message) (*schema.Message, error) {
+	return generate(ctx, cm, messages, "")
+}
+
+// generate 调用模型生成。流式执行时改用流式接口，marker 为空时全部内容作为答案增量回调，
+// 否则只回调 marker 之后的内容（ReAct 的思考与动作不属于答案）
+func generate(ctx context.Context, cm model.BaseChatModel, messages []*schema.Message, marker string) (*schema.Message, error) {
+	if !IsStreaming(ctx) {
+		return cm.Generate(ctx, messages)
+	}
+	sr, err := cm.Stream(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+	var (
+		chunks  []*schema.Message
+		buf     strings.Builder
+		pos     = -1 // 已回调到 buf 中的位置，-1 表示还没有遇到 marker
+		started bool // 是否已回调过答案内容
+	)
+	if marker == "" {
+		pos = 0
+	}
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+		buf.WriteString(chunk.Content)
+		text := buf.String()
+		if pos < 0 {
+			idx := strings.Index(text, marker)
+			if idx < 0 {
+				continue
+			}
+			pos = idx + len(marker)
+		}
+		if !started {
+			// 跳过答案开头的空白
+			rest := strings.TrimLeft(text[pos:], " \t\r\n")
+			pos = len(text) - len(rest)
+			if rest == "" {
+				continue
+			}
+			started = true
+		}
+		if pos < len(text) {
+			EmitToken(ctx, text[pos:])
+			pos = len(text)
+		}
+	}
+	if len(chunks) == 0 {
+		return schema.AssistantMessage("", nil), nil
+	}
+	return schema.ConcatMessages(chunks)
+}
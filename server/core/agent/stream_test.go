@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// streamModel 按调用次序以流式返回分好片的内容
+type streamModel struct {
+	replies [][]string
+}
+
+func (m *streamModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, errors.New("expect stream")
+}
+
+func (m *streamModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if len(m.replies) == 0 {
+		return nil, errors.New("no reply")
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	chunks := make([]*schema.Message, 0, len(reply))
+	for _, c := range reply {
+		chunks = append(chunks, schema.AssistantMessage(c, nil))
+	}
+	return schema.StreamReaderFromArray(chunks), nil
+}
+
+// searchTool 返回固定文档的检索工具
+type searchTool struct{}
+
+func (searchTool) Name() string        { return "rag_retriever" }
+func (searchTool) Description() string { return "search" }
+func (searchTool) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"documents": []*schema.Document{{ID: "1", Content: "退款需在 7 天内申请"}}}, nil
+}
+
+func TestReactStream(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(searchTool{})
+	cm := &streamModel{replies: [][]string{
+		{"Thought: 需要检索\nAction: rag_retriever\n", "Action Input: {\"query\":\"退款\"}"},
+		{"Thought: 可以回答了\nFinal", " Answer:", "  ", "退款需在", " 7 天内申请[1]"},
+	}}
+	var (
+		steps  []string
+		tokens []string
+		refs   []*schema.Document
+	)
+	ctx := WithStreamHandler(context.Background(), &StreamHandler{
+		OnStep:       func(step ReasoningStep) { steps = append(steps, step.Type) },
+		OnReferences: func(docs []*schema.Document) { refs = docs },
+		OnToken:      func(token string) { tokens = append(tokens, token) },
+	})
+	res, err := NewReactExecutor(&ReactConfig{Model: cm, Registry: registry}).
+		Run(ctx, &RAGIntent{Type: RAGIntentSimpleQA}, "怎么退款", "", 5, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Answer != "退款需在 7 天内申请[1]" || strings.Join(tokens, "") != res.Answer {
+		t.Fatalf("unexpected answer %q, tokens %q", res.Answer, tokens)
+	}
+	// 思考与动作不作为答案推送
+	if tokens[0] != "退款需在" {
+		t.Fatalf("unexpected first token %q", tokens[0])
+	}
+	if got := strings.Join(steps, ","); got != "thought,action,observation,thought,final_answer" {
+		t.Fatalf("unexpected steps: %s", got)
+	}
+	if len(res.References) != 1 || len(refs) != 1 {
+		t.Fatalf("unexpected references: %v, streamed %v", res.References, refs)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/everfid-ever/ThinkForge/core"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
 )
 
 // RagTool 将 core.Rag.Retrieve 封装为 ReAct Agent 可调用的工具
@@ -15,6 +16,11 @@ type RagTool struct {
 	knowledgeName string
 	topK          int
 	score         float64
+	mode          string                  // 检索模式：dense / hybrid
+	fusion        string                  // hybrid 模式下的融合方式
+	filter        *vectorstore.Filter     // 元数据过滤条件
+	kbs           []*core.KnowledgeWeight // 跨知识库检索的知识库，调用时未指定知识库时使用
+	rewrite       string                  // 问题改写策略
 }
 
 // RagToolInput ReAct Agent 调用 RAG 工具的输入参数
@@ -41,6 +47,31 @@ func NewRagTool(ragSvr *core.Rag, knowledgeName string, topK int, score float64)
 	}
 }
 
+// WithRetrievalMode 指定检索模式与融合方式，为空时使用配置
+func (t *RagTool) WithRetrievalMode(mode, fusion string) *RagTool {
+	t.mode = mode
+	t.fusion = fusion
+	return t
+}
+
+// WithFilter 指定检索时的元数据过滤条件
+func (t *RagTool) WithFilter(filter *vectorstore.Filter) *RagTool {
+	t.filter = filter
+	return t
+}
+
+// WithKnowledgeBases 指定跨知识库检索的知识库及权重
+func (t *RagTool) WithKnowledgeBases(kbs []*core.KnowledgeWeight) *RagTool {
+	t.kbs = kbs
+	return t
+}
+
+// WithRewrite 指定问题改写策略，为空时使用配置
+func (t *RagTool) WithRewrite(strategy string) *RagTool {
+	t.rewrite = strategy
+	return t
+}
+
 // Name 工具名称
 func (t *RagTool) Name() string { return "rag_retriever" }
 
@@ -66,8 +97,10 @@ func (t *RagTool) Execute(ctx context.Context, input map[string]interface{}) (in
 	if toolInput.Query == "" {
 		return nil, fmt.Errorf("rag_tool: query is required")
 	}
-	if toolInput.KnowledgeName == "" {
+	var kbs []*core.KnowledgeWeight
+	if toolInput.KnowledgeName == "" || toolInput.KnowledgeName == t.knowledgeName {
 		toolInput.KnowledgeName = t.knowledgeName
+		kbs = t.kbs
 	}
 	if toolInput.TopK <= 0 {
 		toolInput.TopK = t.topK
@@ -77,10 +110,15 @@ func (t *RagTool) Execute(ctx context.Context, input map[string]interface{}) (in
 	}
 
 	docs, err := t.ragSvr.Retrieve(ctx, &core.RetrieveReq{
-		Query:         toolInput.Query,
-		TopK:          toolInput.TopK,
-		Score:         toolInput.Score,
-		KnowledgeName: toolInput.KnowledgeName,
+		Query:          toolInput.Query,
+		TopK:           toolInput.TopK,
+		Score:          toolInput.Score,
+		KnowledgeName:  toolInput.KnowledgeName,
+		KnowledgeBases: kbs,
+		Mode:           t.mode,
+		Fusion:         t.fusion,
+		Filter:         t.filter,
+		Rewrite:        t.rewrite,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("rag_tool: retrieve failed: %w", err)
@@ -116,6 +116,7 @@ type Strategy interface {
 
 // StrategyResult 策略执行结果
 type StrategyResult struct {
+	Strategy       string             `json:"strategy"` // 实际执行的策略，回退时为回退后的策略
 	Answer         string             `json:"answer"`
 	References     []*schema.Document `json:"references"`
 	ReasoningSteps []ReasoningStep    `json:"reasoning_steps,omitempty"`
@@ -127,7 +128,7 @@ type StrategyResult struct {
 // ReasoningStep 推理步骤
 type ReasoningStep struct {
 	Step        int                    `json:"step"`
-	Type        string                 `json:"type"` // thought/action/observation
+	Type        string                 `json:"type"` // thought/action/observation/grade/final_answer
 	Content     string                 `json:"content"`
 	ActionInput map[string]interface{} `json:"action_input,omitempty"`
 	Timestamp   string                 `json:"timestamp"`
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 默认配置
+const (
+	defaultThreshold = 0.95             // 问题向量的余弦相似度不低于该值时视为命中
+	defaultTTL       = 10 * time.Minute // 缓存有效期
+	BackendMemory    = "memory"         // 进程内 LRU，默认后端
+)
+
+// Entry 一条缓存
+type Entry struct {
+	Question       string    `json:"question"`        // 原始问题
+	Vector         []float64 `json:"vector"`          // 问题向量
+	Value          []byte    `json:"value"`           // 序列化后的结果
+	KnowledgeBases []string  `json:"knowledge_bases"` // 结果涉及的知识库
+	ExpireAt       time.Time `json:"expire_at"`       // 过期时间
+}
+
+// Backend 语义缓存的存储后端，条目按 scope 隔离，只在同一个 scope 内比较问题的相似度
+type Backend interface {
+	// Search 返回 scope 下与 vector 余弦相似度最高且未过期的条目及其相似度，没有时返回 nil
+	Search(ctx context.Context, scope string, vector []float64) (*Entry, float64, error)
+	// Put 写入条目
+	Put(ctx context.Context, scope string, entry *Entry) error
+	// Invalidate 删除涉及该知识库的全部条目
+	Invalidate(ctx context.Context, knowledgeName string) error
+}
+
+// BackendFactory 根据配置创建存储后端
+type BackendFactory func(ctx context.Context) (Backend, error)
+
+var (
+	backendMu        sync.RWMutex
+	backendFactories = map[string]BackendFactory{}
+)
+
+// RegisterBackend 注册存储后端，配置 cache.backend 与 name 对应
+func RegisterBackend(name string, factory BackendFactory) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backendFactories[name] = factory
+}
+
+func init() {
+	RegisterBackend(BackendMemory, func(ctx context.Context) (Backend, error) {
+		return NewLRU(g.Cfg().MustGet(ctx, "cache.capacity", defaultCapacity).Int()), nil
+	})
+}
+
+// Key 一次查找的范围：问题之外影响结果的请求参数都应放入 Params，参数不同的请求互不命中。
+// 查找之后可以补充 KnowledgeBases（如按意图扩大的检索范围），补充的知识库只用于失效
+type Key struct {
+	Kind           string   // 缓存类型，如 chat、retriever
+	KnowledgeBases []string // 涉及的知识库，任一知识库的文档变化后失效
+	Params         any      // 其他请求参数
+	Question       string   // 用户问题
+
+	scopeKey string    // 查找时的范围
+	vector   []float64 // 查找时计算的问题向量，写入时复用
+	start    time.Time // 查找时间，晚于该时间的失效会使写入作废
+}
+
+// AddKnowledgeBases 补充涉及的知识库，名称去重并排序
+func (k *Key) AddKnowledgeBases(names ...string) {
+	kbs := append(slices.Clone(k.KnowledgeBases), names...)
+	slices.Sort(kbs)
+	k.KnowledgeBases = slices.Compact(kbs)
+}
+
+func (k *Key) scope() string {
+	kbs := append([]string(nil), k.KnowledgeBases...)
+	sort.Strings(kbs)
+	params, _ := sonic.Marshal(k.Params)
+	sum := md5.Sum(params)
+	return fmt.Sprintf("%s|%s|%s", k.Kind, strings.Join(kbs, ","), hex.EncodeToString(sum[:]))
+}
+
+// Cache 语义缓存：按问题向量的相似度查找同一知识库、同样参数下的历史结果。
+// 知识库中的文档被索引、修改或删除时调用 Invalidate 使相关缓存失效。nil 表示未启用，所有操作都不生效
+type Cache struct {
+	backend   Backend
+	embedder  embedding.Embedder
+	threshold float64
+	ttl       time.Duration
+
+	// invalidated 记录知识库最近一次失效的时间，避免失效前开始的请求把旧结果写回缓存
+	invalidated sync.Map
+}
+
+// New 按配置 cache.* 创建语义缓存，cache.enabled 为 false 时返回 nil
+func New(ctx context.Context, embedder embedding.Embedder) (*Cache, error) {
+	if !g.Cfg().MustGet(ctx, "cache.enabled", true).Bool() {
+		return nil, nil
+	}
+	name := g.Cfg().MustGet(ctx, "cache.backend", BackendMemory).String()
+	backendMu.RLock()
+	factory, ok := backendFactories[name]
+	backendMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown cache backend: %s", name)
+	}
+	backend, err := factory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		backend:   backend,
+		embedder:  embedder,
+		threshold: g.Cfg().MustGet(ctx, "cache.threshold", defaultThreshold).Float64(),
+		ttl:       g.Cfg().MustGet(ctx, "cache.ttl", defaultTTL).Duration(),
+	}
+	if c.threshold <= 0 || c.threshold > 1 {
+		c.threshold = defaultThreshold
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultTTL
+	}
+	return c, nil
+}
+
+// Get 查找相似问题的缓存结果并反序列化到 out，命中时返回 true。
+// 查找失败只记录日志，按未命中处理
+func (c *Cache) Get(ctx context.Context, key *Key, out any) bool {
+	if c == nil {
+		return false
+	}
+	key.start, key.scopeKey = time.Now(), key.scope()
+	vectors, err := c.embedder.EmbedStrings(ctx, []string{key.Question})
+	if err != nil || len(vectors) == 0 {
+		g.Log().Warningf(ctx, "cache embed question failed, err=%v", err)
+		return false
+	}
+	key.vector = vectors[0]
+	entry, sim, err := c.backend.Search(ctx, key.scopeKey, key.vector)
+	if err != nil {
+		g.Log().Warningf(ctx, "cache search failed, err=%v", err)
+		return false
+	}
+	if entry == nil || sim < c.threshold {
+		return false
+	}
+	if err = sonic.Unmarshal(entry.Value, out); err != nil {
+		g.Log().Warningf(ctx, "cache unmarshal failed, err=%v", err)
+		return false
+	}
+	g.Log().Infof(ctx, "cache hit: %q ~ %q, similarity=%.4f", key.Question, entry.Question, sim)
+	return true
+}
+
+// Set 写入结果，key 需先经过 Get 查找。查找之后涉及的知识库发生过失效时不写入
+func (c *Cache) Set(ctx context.Context, key *Key, value any) {
+	if c == nil || key == nil || key.vector == nil {
+		return
+	}
+	for _, kb := range key.KnowledgeBases {
+		if t, ok := c.invalidated.Load(kb); ok && !t.(time.Time).Before(key.start) {
+			return
+		}
+	}
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		g.Log().Warningf(ctx, "cache marshal failed, err=%v", err)
+		return
+	}
+	err = c.backend.Put(ctx, key.scopeKey, &Entry{
+		Question:       key.Question,
+		Vector:         key.vector,
+		Value:          data,
+		KnowledgeBases: key.KnowledgeBases,
+		ExpireAt:       time.Now().Add(c.ttl),
+	})
+	if err != nil {
+		g.Log().Warningf(ctx, "cache put failed, err=%v", err)
+	}
+}
+
+// Invalidate 使涉及该知识库的缓存失效
+func (c *Cache) Invalidate(ctx context.Context, knowledgeName string) {
+	if c == nil {
+		return
+	}
+	c.invalidated.Store(knowledgeName, time.Now())
+	if err := c.backend.Invalidate(ctx, knowledgeName); err != nil {
+		g.Log().Errorf(ctx, "cache invalidate %s failed, err=%v", knowledgeName, err)
+	}
+}
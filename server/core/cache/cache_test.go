@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// fakeEmbedder 按问题返回固定的向量
+type fakeEmbedder map[string][]float64
+
+func (f fakeEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	res := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		res = append(res, f[text])
+	}
+	return res, nil
+}
+
+func newTestCache(capacity int) *Cache {
+	return &Cache{
+		backend: NewLRU(capacity),
+		embedder: fakeEmbedder{
+			"如何重置密码": {1, 0, 0},
+			"怎么重置密码": {0.99, 0.1, 0},
+			"如何申请发票": {0, 1, 0},
+		},
+		threshold: defaultThreshold,
+		ttl:       time.Minute,
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(10)
+	key := func(q string) *Key {
+		return &Key{Kind: "chat", KnowledgeBases: []string{"faq"}, Params: map[string]int{"top_k": 5}, Question: q}
+	}
+
+	k := key("如何重置密码")
+	var answer string
+	if c.Get(ctx, k, &answer) {
+		t.Fatal("expect miss on empty cache")
+	}
+	c.Set(ctx, k, "在设置页点击忘记密码")
+
+	if !c.Get(ctx, key("怎么重置密码"), &answer) || answer != "在设置页点击忘记密码" {
+		t.Fatalf("expect hit for similar question, got %q", answer)
+	}
+	if c.Get(ctx, key("如何申请发票"), &answer) {
+		t.Fatal("expect miss for different question")
+	}
+	other := key("怎么重置密码")
+	other.Params = map[string]int{"top_k": 10}
+	if c.Get(ctx, other, &answer) {
+		t.Fatal("expect miss for different params")
+	}
+
+	c.Invalidate(ctx, "faq")
+	if c.Get(ctx, key("如何重置密码"), &answer) {
+		t.Fatal("expect miss after invalidate")
+	}
+}
+
+func TestCacheSetAfterInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(10)
+	k := &Key{Kind: "chat", KnowledgeBases: []string{"faq"}, Question: "如何重置密码"}
+	var answer string
+	c.Get(ctx, k, &answer)
+	// 查找之后知识库发生变化，旧的结果不能写入
+	c.Invalidate(ctx, "faq")
+	c.Set(ctx, k, "旧答案")
+	if c.backend.(*LRU).Len() != 0 {
+		t.Fatal("expect stale result not cached")
+	}
+}
+
+func TestKeyAddKnowledgeBases(t *testing.T) {
+	key := &Key{KnowledgeBases: []string{"b", "a"}}
+	key.AddKnowledgeBases("a", "c", "b")
+	if got := strings.Join(key.KnowledgeBases, ","); got != "a,b,c" {
+		t.Fatalf("unexpected knowledge bases: %s", got)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	put := func(q string, v []float64, kbs ...string) {
+		_ = lru.Put(ctx, "s", &Entry{Question: q, Vector: v, KnowledgeBases: kbs, ExpireAt: time.Now().Add(time.Minute)})
+	}
+	put("a", []float64{1, 0}, "kb1")
+	put("b", []float64{0, 1}, "kb2")
+	// 访问 a 之后再写入 c，淘汰最久未使用的 b
+	if e, _, _ := lru.Search(ctx, "s", []float64{1, 0}); e == nil || e.Question != "a" {
+		t.Fatalf("expect a, got %+v", e)
+	}
+	put("c", []float64{1, 1}, "kb1", "kb2")
+	if e, _, _ := lru.Search(ctx, "s", []float64{0, 1}); e == nil || e.Question != "c" {
+		t.Fatalf("expect b evicted, got %+v", e)
+	}
+	if e, _, _ := lru.Search(ctx, "other", []float64{1, 0}); e != nil {
+		t.Fatalf("expect scope isolated, got %+v", e)
+	}
+
+	_ = lru.Invalidate(ctx, "kb2")
+	if lru.Len() != 1 {
+		t.Fatalf("expect 1 entry after invalidate, got %d", lru.Len())
+	}
+
+	_ = lru.Put(ctx, "s", &Entry{Question: "d", Vector: []float64{1, 0}, ExpireAt: time.Now().Add(-time.Second)})
+	if e, _, _ := lru.Search(ctx, "s", []float64{1, 0}); e == nil || e.Question != "a" {
+		t.Fatalf("expect expired entry skipped, got %+v", e)
+	}
+}
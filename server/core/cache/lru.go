@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// defaultCapacity 进程内缓存默认的最大条目数
+const defaultCapacity = 1000
+
+// LRU 进程内的缓存后端，超过容量时淘汰最久未使用的条目。
+// 查找时遍历 scope 内的全部条目计算相似度，适合单个 scope 条目不多的场景
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List                        // 最近使用的条目在前
+	scopes   map[string]map[*list.Element]bool // scope 下的条目
+}
+
+type lruItem struct {
+	scope string
+	entry *Entry
+}
+
+// NewLRU 创建进程内缓存后端，capacity 不大于 0 时使用默认容量
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	return &LRU{
+		capacity: capacity,
+		ll:       list.New(),
+		scopes:   map[string]map[*list.Element]bool{},
+	}
+}
+
+func (x *LRU) Search(ctx context.Context, scope string, vector []float64) (*Entry, float64, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var (
+		best    *list.Element
+		bestSim float64
+		now     = time.Now()
+	)
+	for e := range x.scopes[scope] {
+		entry := e.Value.(*lruItem).entry
+		if now.After(entry.ExpireAt) {
+			x.remove(e)
+			continue
+		}
+		if len(entry.Vector) != len(vector) {
+			continue
+		}
+		// Score 的范围为 0-2，减 1 得到余弦相似度
+		sim := vectorstore.Score(vectorstore.SimilarityCosine, vector, entry.Vector) - 1
+		if best == nil || sim > bestSim {
+			best, bestSim = e, sim
+		}
+	}
+	if best == nil {
+		return nil, 0, nil
+	}
+	x.ll.MoveToFront(best)
+	return best.Value.(*lruItem).entry, bestSim, nil
+}
+
+func (x *LRU) Put(ctx context.Context, scope string, entry *Entry) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	e := x.ll.PushFront(&lruItem{scope: scope, entry: entry})
+	if x.scopes[scope] == nil {
+		x.scopes[scope] = map[*list.Element]bool{}
+	}
+	x.scopes[scope][e] = true
+	for x.ll.Len() > x.capacity {
+		x.remove(x.ll.Back())
+	}
+	return nil
+}
+
+func (x *LRU) Invalidate(ctx context.Context, knowledgeName string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for e := x.ll.Front(); e != nil; {
+		next := e.Next()
+		if slices.Contains(e.Value.(*lruItem).entry.KnowledgeBases, knowledgeName) {
+			x.remove(e)
+		}
+		e = next
+	}
+	return nil
+}
+
+// Len 返回当前的条目数
+func (x *LRU) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.ll.Len()
+}
+
+func (x *LRU) remove(e *list.Element) {
+	item := e.Value.(*lruItem)
+	x.ll.Remove(e)
+	delete(x.scopes[item.scope], e)
+	if len(x.scopes[item.scope]) == 0 {
+		delete(x.scopes, item.scope)
+	}
+}
@@ -0,0 +1,190 @@
+package citation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+// Instruction 要求模型标注引用的提示词，与 Format 的编号格式对应
+const Instruction = "Cite the references you use with their numbers in square brackets right after the sentence they support, " +
+	"e.g. [1] or [1][3]. Only cite numbers that appear in the reference list, and do not list the references again at the end."
+
+// markerRe 匹配回答中的引用标记，如 [1]、[1, 3]、[^2]
+var markerRe = regexp.MustCompile(`\[\^?(\d+(?:\s*[,，、]\s*\d+)*)\]`)
+
+// Citation 回答中引用的一个 chunk
+type Citation struct {
+	Index          int      `json:"index"`                      // 引用编号，从 1 开始，对应参考资料的顺序
+	ChunkID        string   `json:"chunk_id"`                   // chunk ID（向量存储中的文档 ID）
+	KnowledgeDocID int64    `json:"knowledge_doc_id,omitempty"` // 所属文档（knowledge_documents.id）
+	KnowledgeName  string   `json:"knowledge_name,omitempty"`   // 所属知识库
+	FileName       string   `json:"file_name,omitempty"`        // 原始文件名，网络搜索结果为标题
+	HeadingPath    string   `json:"heading_path,omitempty"`     // 所在章节的标题路径，如 "安装 > 配置"
+	URL            string   `json:"url,omitempty"`              // 网络搜索结果的地址
+	Sentences      []string `json:"sentences"`                  // 回答中引用该 chunk 的句子
+}
+
+// Format 把参考资料格式化为带编号的文本，编号从 1 开始，来源信息放在编号之后
+func Format(docs []*schema.Document) string {
+	var sb strings.Builder
+	for i, doc := range docs {
+		fmt.Fprintf(&sb, "[%d]", i+1)
+		if label := Source(doc).Label(); label != "" {
+			fmt.Fprintf(&sb, " (source: %s)", label)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimSpace(doc.Content))
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Source 从文档元数据中提取来源信息，Index 与 Sentences 为空
+func Source(doc *schema.Document) *Citation {
+	c := &Citation{ChunkID: doc.ID}
+	ext := extData(doc.MetaData[common.FieldExtra])
+	meta := func(key string) string {
+		if v, ok := doc.MetaData[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		if v, ok := ext[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	c.KnowledgeName = meta(common.KnowledgeName)
+	c.FileName = meta(common.FieldFileName)
+	c.KnowledgeDocID, _ = strconv.ParseInt(meta(common.FieldDocumentID), 10, 64)
+	c.HeadingPath = meta(common.HeadingPath)
+	if c.HeadingPath == "" {
+		var titles []string
+		for _, key := range []string{common.Title1, common.Title2, common.Title3} {
+			if t := strings.TrimSpace(meta(key)); t != "" {
+				titles = append(titles, t)
+			}
+		}
+		c.HeadingPath = strings.Join(titles, " > ")
+	}
+	// 网络搜索结果没有文件名，使用标题
+	if c.FileName == "" {
+		c.FileName = meta("title")
+	}
+	if c.FileName == "" {
+		c.FileName = meta("_source")
+	}
+	c.URL = meta("url")
+	return c
+}
+
+// Label 来源的可读描述：文件名（或网页标题）> 标题路径 > 网址
+func (c *Citation) Label() string {
+	var parts []string
+	for _, s := range []string{c.FileName, c.HeadingPath, c.URL} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// extData ext 字段在向量存储中是 JSON 字符串，经过检索接口后已解析为 map
+func extData(v any) map[string]any {
+	switch ext := v.(type) {
+	case map[string]any:
+		return ext
+	case string:
+		m := map[string]any{}
+		if err := sonic.UnmarshalString(ext, &m); err == nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// Parse 解析回答中的引用标记，按 Format 的编号映射到参考资料，按首次出现的顺序返回。
+// 超出参考资料范围的编号忽略；标记位于句末标点之后时归属前一个句子
+func Parse(answer string, docs []*schema.Document) []*Citation {
+	var (
+		res     []*Citation
+		byIndex = map[int]*Citation{}
+	)
+	for _, m := range markerRe.FindAllStringSubmatchIndex(answer, -1) {
+		sentence := sentenceBefore(answer[:m[0]])
+		for _, s := range strings.FieldsFunc(answer[m[2]:m[3]], func(r rune) bool {
+			return r == ',' || r == '，' || r == '、' || unicode.IsSpace(r)
+		}) {
+			index, err := strconv.Atoi(s)
+			if err != nil || index < 1 || index > len(docs) {
+				continue
+			}
+			c, ok := byIndex[index]
+			if !ok {
+				c = Source(docs[index-1])
+				c.Index = index
+				c.Sentences = []string{}
+				byIndex[index] = c
+				res = append(res, c)
+			}
+			if sentence != "" && !contains(c.Sentences, sentence) {
+				c.Sentences = append(c.Sentences, sentence)
+			}
+		}
+	}
+	return res
+}
+
+// Strip 去掉回答中的引用标记
+func Strip(answer string) string {
+	return markerRe.ReplaceAllString(answer, "")
+}
+
+// sentenceBefore 返回 prefix 末尾的句子（去掉其中的引用标记）
+func sentenceBefore(prefix string) string {
+	// 去掉紧挨着的其他标记，如 [1][2] 中的 [1]
+	for {
+		trimmed := strings.TrimRightFunc(prefix, unicode.IsSpace)
+		loc := markerRe.FindAllStringIndex(trimmed, -1)
+		if len(loc) == 0 || loc[len(loc)-1][1] != len(trimmed) {
+			prefix = trimmed
+			break
+		}
+		prefix = trimmed[:loc[len(loc)-1][0]]
+	}
+	runes := []rune(prefix)
+	start := 0
+	// 末尾的句末标点属于当前句子，从倒数第二个字符开始向前找上一句的结尾
+	for i := len(runes) - 2; i >= 0; i-- {
+		if isSentenceEnd(runes, i) {
+			start = i + 1
+			break
+		}
+	}
+	return strings.TrimSpace(Strip(string(runes[start:])))
+}
+
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '；', '!', '?', ';', '\n':
+		return true
+	case '.':
+		// 英文句号后需要有空白，避免把 3.5 之类的小数断开
+		return i+1 < len(runes) && unicode.IsSpace(runes[i+1])
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package citation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func testDocs() []*schema.Document {
+	return []*schema.Document{
+		{ID: "c1", Content: "退款需在 7 天内申请", MetaData: map[string]any{
+			"_knowledge_name": "faq",
+			"ext":             `{"_file_name":"退款政策.docx","_heading_path":"售后 > 退款","_doc_id":"12"}`,
+		}},
+		{ID: "c2", Content: "数字商品不支持退款", MetaData: map[string]any{
+			"ext": map[string]any{"_file_name": "条款.md", "h1": "条款", "h2": "数字商品"},
+		}},
+		{Content: "web result", MetaData: map[string]any{"title": "Refund FAQ", "url": "https://example.com/refund"}},
+	}
+}
+
+func TestFormat(t *testing.T) {
+	out := Format(testDocs())
+	for _, want := range []string{
+		"[1] (source: 退款政策.docx > 售后 > 退款)\n退款需在 7 天内申请",
+		"[2] (source: 条款.md > 条款 > 数字商品)",
+		"[3] (source: Refund FAQ > https://example.com/refund)\nweb result",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	docs := testDocs()
+	answer := "退款需要在 7 天内申请[1]。数字商品不能退款。[2][1] 版本 3.5 之后也是如此[^3]，参见[7]。"
+	citations := Parse(answer, docs)
+	if len(citations) != 3 {
+		t.Fatalf("expect 3 citations, got %d", len(citations))
+	}
+	c1 := citations[0]
+	if c1.Index != 1 || c1.ChunkID != "c1" || c1.KnowledgeDocID != 12 || c1.KnowledgeName != "faq" ||
+		c1.FileName != "退款政策.docx" || c1.HeadingPath != "售后 > 退款" {
+		t.Fatalf("unexpected citation: %+v", c1)
+	}
+	if len(c1.Sentences) != 2 || c1.Sentences[0] != "退款需要在 7 天内申请" || c1.Sentences[1] != "数字商品不能退款。" {
+		t.Fatalf("unexpected sentences: %q", c1.Sentences)
+	}
+	if c2 := citations[1]; c2.Index != 2 || c2.HeadingPath != "条款 > 数字商品" || c2.Sentences[0] != "数字商品不能退款。" {
+		t.Fatalf("unexpected citation: %+v", c2)
+	}
+	if c3 := citations[2]; c3.Index != 3 || c3.URL != "https://example.com/refund" || c3.Sentences[0] != "版本 3.5 之后也是如此" {
+		t.Fatalf("unexpected citation: %+v", c3)
+	}
+	if got := Strip("结论[1][2]。"); got != "结论。" {
+		t.Fatalf("unexpected strip result: %q", got)
+	}
+}
+
+func TestParseMultiple(t *testing.T) {
+	citations := Parse("Refunds take 7 days [1, 2]. Nothing else.", testDocs())
+	if len(citations) != 2 || citations[1].Index != 2 || citations[1].Sentences[0] != "Refunds take 7 days" {
+		t.Fatalf("unexpected citations: %+v", citations)
+	}
+	if len(Parse("no markers here", testDocs())) != 0 {
+		t.Fatal("expect no citations")
+	}
+}
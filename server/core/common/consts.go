@@ -10,6 +10,7 @@ const (
 	FieldQAContentVector = "qa_content_vector" // 问答内容对应的向量表示字段名
 	FieldExtra           = "ext"               // 扩展字段（用于存放额外的元数据）
 	KnowledgeName        = "_knowledge_name"   // 知识库名称字段，用于标识该文档所属的知识库
+	FieldStatus          = "_status"           // chunk 启用状态字段，与 knowledge_chunks.status 保持同步
 
 	RetrieverFieldKey = "_retriever_field" // 检索字段标识，用于动态选择检索字段（例如 content_vector 或 qa_content_vector）
 
@@ -19,17 +20,44 @@ const (
 	Title3 = "h3" // 三级标题
 
 	XlsxRow = "_row"
+
+	// 可用于检索过滤的结构化元数据，同时保存在 ext 中
+	FieldFileName   = "_file_name"   // 原始文件名
+	FieldExtension  = "_extension"   // 文件扩展名
+	FieldDocumentID = "_doc_id"      // 所属文档（knowledge_documents.id）
+	FieldUploadedAt = "_uploaded_at" // 文档上传时间，UTC RFC3339 格式，可按字符串比较先后
+
+	// 解析器产生的结构化元数据
+	HeadingPath = "_heading_path" // Word 文档中所在章节的标题路径，如 "安装 > 配置"
+	SlideNum    = "_slide"        // PPT 页码，从 1 开始
+	RowNum      = "_row_num"      // CSV / JSONL 的行号或 JSON 数组下标，从 1 开始
+	CodeSymbol  = "_symbol"       // 代码片段所在的函数或类型名
+)
+
+// chunk 在 ES 中的启用状态，缺少该字段的历史数据视为启用
+const (
+	ChunkStatusEnabled  = "enabled"
+	ChunkStatusDisabled = "disabled"
 )
 
 var (
 	// ExtKeys 定义在 ext（扩展信息）中需要保存的键名。
 	// 这些键通常用于描述文档的元信息，如来源、文件名、章节标题等。
 	ExtKeys = []string{
-		"_extension", // 文件扩展名（例如 .pdf, .docx）
-		"_file_name", // 原始文件名
-		"_source",    // 文档来源（如网页URL、本地路径）
-		Title1,       // 一级标题
-		Title2,       // 二级标题
-		Title3,       // 三级标题
+		FieldExtension,  // 文件扩展名（例如 .pdf, .docx）
+		FieldFileName,   // 原始文件名
+		"_source",       // 文档来源（如网页URL、本地路径）
+		Title1,          // 一级标题
+		Title2,          // 二级标题
+		Title3,          // 三级标题
+		HeadingPath,     // 标题路径
+		SlideNum,        // PPT 页码
+		RowNum,          // 行号
+		CodeSymbol,      // 函数或类型名
+		FieldDocumentID, // 所属文档
+		FieldUploadedAt, // 上传时间
 	}
+
+	// MetaFields 写入索引独立字段的元数据，检索时可按这些字段过滤
+	MetaFields = []string{FieldFileName, FieldExtension, Title1, Title2, Title3, FieldDocumentID, FieldUploadedAt}
 )
@@ -29,11 +29,11 @@ import (
 func NewEmbedding(ctx context.Context, conf *config.Config) (eb embedding.Embedder, err error) {
 	// 初始化 embedding 模型配置
 	econf := &openai.EmbeddingConfig{
-		APIKey:     conf.APIKey,         // OpenAI API Key（模型访问凭证）
-		Model:      conf.EmbeddingModel, // 向量化模型名称
-		Dimensions: Of(1024),            // 向量维度（默认 1024 维，可根据模型不同调整）
-		Timeout:    0,                   // 超时时间，0 表示不限制
-		BaseURL:    conf.BaseURL,        // 模型服务地址（可为自定义代理或本地部署）
+		APIKey:     conf.APIKey,          // OpenAI API Key（模型访问凭证）
+		Model:      conf.EmbeddingModel,  // 向量化模型名称
+		Dimensions: Of(VectorDims(conf)), // 向量维度（未配置时默认 1024 维）
+		Timeout:    0,                    // 超时时间，0 表示不限制
+		BaseURL:    conf.BaseURL,         // 模型服务地址（可为自定义代理或本地部署）
 	}
 
 	// 若配置中未指定 API Key，则尝试从环境变量读取
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"github.com/everfid-ever/ThinkForge/core/config"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// DefaultVectorDims 向量维度，需与 embedding 模型一致
+const DefaultVectorDims = 1024
+
+// NewIndexSpec 按配置的向量维度与相似度返回知识库索引的字段定义，由具体的向量存储转换为各自的映射。
+// 包含文本字段、关键词字段和向量字段，向量字段用于语义检索。
+func NewIndexSpec(conf *config.Config) *vectorstore.IndexSpec {
+	spec := &vectorstore.IndexSpec{
+		Fields: map[string]vectorstore.FieldType{
+			// 文本字段：存储文档的主要内容
+			FieldContent: vectorstore.FieldTypeText,
+
+			// 扩展字段：存储额外元数据或附加信息
+			FieldExtra: vectorstore.FieldTypeText,
+
+			// 知识名称字段：用于关键字检索（不分词）
+			KnowledgeName: vectorstore.FieldTypeKeyword,
+
+			// chunk 启用状态：检索时过滤掉已禁用的 chunk
+			FieldStatus: vectorstore.FieldTypeKeyword,
+
+			// 向量字段1：用于存储内容的向量表示（嵌入）
+			FieldContentVector: vectorstore.FieldTypeVector,
+
+			// 向量字段2：用于存储问答内容的向量表示
+			FieldQAContentVector: vectorstore.FieldTypeVector,
+		},
+		Dims:       VectorDims(conf),
+		Similarity: VectorSimilarity(conf),
+	}
+	// 结构化元数据：文件名、扩展名、标题、文档 ID、上传时间，用于检索过滤（不分词）
+	for _, field := range MetaFields {
+		spec.Fields[field] = vectorstore.FieldTypeKeyword
+	}
+	return spec
+}
+
+// VectorDims 返回配置的向量维度，未配置时使用 DefaultVectorDims
+func VectorDims(conf *config.Config) int {
+	if conf.Dims > 0 {
+		return conf.Dims
+	}
+	return DefaultVectorDims
+}
+
+// VectorSimilarity 返回配置的向量相似度，未配置时使用 cosine
+func VectorSimilarity(conf *config.Config) string {
+	if conf.Similarity != "" {
+		return conf.Similarity
+	}
+	return vectorstore.SimilarityCosine
+}
+
+// VectorFields 检索时通常不需要返回的向量字段
+var VectorFields = []string{FieldContentVector, FieldQAContentVector}
@@ -2,91 +2,103 @@ package common
 
 import (
 	"context"
-	"fmt"
-	"io"
+	"reflect"
+	"sync"
 	"time"
 
-	"github.com/bytedance/sonic"
-	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/sse"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
-	"github.com/google/uuid"
 )
 
-type StreamData struct {
-	Id       string             `json:"id"`      // 同一个消息里面的id是相同的
-	Created  int64              `json:"created"` // 消息初始生成时间
-	Content  string             `json:"content"` // 消息具体内容
-	Document []*schema.Document `json:"document"`
-}
+// 流式接口的默认配置，可通过配置文件 sse 节点覆盖
+const (
+	defaultSSEHeartbeat   = 15 * time.Second // 空闲多久发送一次心跳
+	defaultSSERetry       = 3 * time.Second  // 建议客户端的重连间隔
+	defaultSSEReplayTTL   = 5 * time.Minute  // 流结束后事件缓存保留的时长
+	defaultSSEReplayLimit = 10000            // 单个流最多缓存的事件数
+)
 
-func StreamResponse(ctx context.Context, streamReader *schema.StreamReader[*schema.Message], docs []*schema.Document) (err error) {
-	// 获取HTTP响应对象
-	httpReq := ghttp.RequestFromCtx(ctx)
-	httpResp := httpReq.Response
-	// 设置响应头
-	httpResp.Header().Set("Content-Type", "text/event-stream")
-	httpResp.Header().Set("Cache-Control", "no-cache")
-	httpResp.Header().Set("Connection", "keep-alive")
-	httpResp.Header().Set("X-Accel-Buffering", "no") // 禁用Nginx缓冲
-	httpResp.Header().Set("Access-Control-Allow-Origin", "*")
-	sd := &StreamData{
-		Id:      uuid.NewString(),
-		Created: time.Now().Unix(),
-	}
-	if len(docs) > 0 {
-		sd.Document = docs
-		marshal, _ := sonic.Marshal(sd)
-		writeSSEDocuments(httpResp, string(marshal))
-	}
-	sd.Document = nil // 置空，发一次就够了
-	// 处理流式响应
-	for {
-		chunk, err := streamReader.Recv()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			writeSSEError(httpResp, err)
-			break
-		}
-		if len(chunk.Content) == 0 {
-			continue
-		}
+var (
+	sseReplay     *sse.Replay
+	sseReplayOnce sync.Once
+)
 
-		sd.Content = chunk.Content
-		marshal, _ := sonic.Marshal(sd)
-		// 发送数据事件
-		writeSSEData(httpResp, string(marshal))
-	}
-	// 发送结束事件
-	writeSSEDone(httpResp)
-	return nil
+// getSSEReplay 返回进程内共享的事件缓存，首次使用时按配置创建
+func getSSEReplay(ctx context.Context) *sse.Replay {
+	sseReplayOnce.Do(func() {
+		sseReplay = sse.NewReplay(
+			g.Cfg().MustGet(ctx, "sse.replayTTL", defaultSSEReplayTTL).Duration(),
+			g.Cfg().MustGet(ctx, "sse.replayLimit", defaultSSEReplayLimit).Int(),
+		)
+	})
+	return sseReplay
 }
 
-// writeSSEData 写入SSE事件
-func writeSSEData(resp *ghttp.Response, data string) {
-	if len(data) == 0 {
-		return
+// NewSSEWriter 为当前请求创建 SSE 写入器（协议见 sse 包），心跳与重连间隔取自配置。
+// replay 为 true 时缓存发送的事件，客户端断线后可携带 Last-Event-ID 续传（见 ResumeSSE）
+func NewSSEWriter(ctx context.Context, replay bool) *sse.Writer {
+	cfg := &sse.Config{
+		Heartbeat: g.Cfg().MustGet(ctx, "sse.heartbeat", defaultSSEHeartbeat).Duration(),
+		Retry:     g.Cfg().MustGet(ctx, "sse.retry", defaultSSERetry).Duration(),
 	}
-	// g.Log().Infof(context.Background(), "data: %s", data)
-	resp.Writeln(fmt.Sprintf("data:%s\n", data))
-	resp.Flush()
-}
-
-func writeSSEDone(resp *ghttp.Response) {
-	resp.Writeln(fmt.Sprintf("data:%s\n", "[DONE]"))
-	resp.Flush()
+	if replay {
+		cfg.Replay = getSSEReplay(ctx)
+	}
+	r := ghttp.RequestFromCtx(ctx)
+	cfg.Context = r.Context()
+	return sse.NewWriter(r.Response.BufferWriter, cfg)
 }
 
-func writeSSEDocuments(resp *ghttp.Response, data string) {
-	resp.Writeln(fmt.Sprintf("documents:%s\n", data))
-	resp.Flush()
+// ResumeSSE 请求带有 Last-Event-ID 且该流仍在缓存中时，补发断点之后的事件并持续推送到流结束，返回 true；
+// 否则返回 false，调用方按新请求处理
+func ResumeSSE(ctx context.Context) bool {
+	r := ghttp.RequestFromCtx(ctx)
+	lastEventID := r.Header.Get(sse.HeaderLastEventID)
+	if lastEventID == "" {
+		return false
+	}
+	w := sse.NewWriter(r.Response.BufferWriter, &sse.Config{
+		Context:   r.Context(),
+		Heartbeat: g.Cfg().MustGet(ctx, "sse.heartbeat", defaultSSEHeartbeat).Duration(),
+	})
+	defer w.Close()
+	ok, err := getSSEReplay(ctx).Resume(ctx, w, lastEventID)
+	if err != nil {
+		g.Log().Warningf(ctx, "resume sse stream %s failed, err=%v", lastEventID, err)
+	}
+	return ok
 }
 
-// writeSSEError 写入SSE错误
-func writeSSEError(resp *ghttp.Response, err error) {
-	g.Log().Error(context.Background(), err)
-	resp.Writeln(fmt.Sprintf("event: error\ndata: %s\n\n", err.Error()))
-	resp.Flush()
+// PollResponse 以 SSE 的形式周期性推送 poll 返回的数据：先发送 kind 类型的 meta 事件，
+// 之后数据变化时发送 progress 事件，直到 poll 返回 done（发送携带最终数据的 done 事件）、出错或客户端断开连接
+func PollResponse(ctx context.Context, kind string, interval time.Duration, poll func(ctx context.Context) (data any, done bool, err error)) error {
+	w := NewSSEWriter(ctx, false)
+	defer w.Close()
+	_ = w.Meta(kind, nil)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last any
+	for {
+		data, done, err := poll(ctx)
+		if err != nil {
+			g.Log().Error(ctx, err)
+			_ = w.Error(err)
+			return w.Done(nil)
+		}
+		if done {
+			return w.Done(data)
+		}
+		if !reflect.DeepEqual(data, last) {
+			last = data
+			if err = w.Send(sse.EventProgress, data); err != nil {
+				return err // 客户端已断开
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
 }
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/sse"
+)
+
+// UsageCounter 通过模型回调累计 token 用量，由 WithUsageCounter 放入 ctx
+type UsageCounter struct {
+	mu    sync.Mutex
+	wg    sync.WaitGroup // 等待流式输出的回调读完
+	usage sse.Usage
+}
+
+// WithUsageCounter 在 ctx 上注册模型回调，此后使用该 ctx 调用的模型（含流式调用）的 token 用量都计入返回的计数器
+func WithUsageCounter(ctx context.Context) (context.Context, *UsageCounter) {
+	c := &UsageCounter{}
+	handler := callbacks.NewHandlerBuilder().
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if out := model.ConvCallbackOutput(output); out != nil {
+				c.add(out.TokenUsage)
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			// 流式输出的用量在最后的分片中，异步读完以免阻塞答案的推送
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				defer output.Close()
+				for {
+					chunk, err := output.Recv()
+					if err != nil { // io.EOF 或出错
+						return
+					}
+					if out := model.ConvCallbackOutput(chunk); out != nil {
+						c.add(out.TokenUsage)
+					}
+				}
+			}()
+			return ctx
+		}).
+		Build()
+	return callbacks.InitCallbacks(ctx, &callbacks.RunInfo{}, handler), c
+}
+
+func (c *UsageCounter) add(u *model.TokenUsage) {
+	if u == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.PromptTokens += u.PromptTokens
+	c.usage.CompletionTokens += u.CompletionTokens
+	c.usage.TotalTokens += u.TotalTokens
+}
+
+// Usage 等待进行中的流式回调结束后返回累计的用量
+func (c *UsageCounter) Usage() *sse.Usage {
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usage := c.usage
+	return &usage
+}
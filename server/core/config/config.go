@@ -2,19 +2,21 @@ package config
 
 import (
 	"github.com/cloudwego/eino-ext/components/model/openai"
-	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
 )
 
 // Config 是系统的全局配置结构体，
-// 用于统一管理 Elasticsearch、OpenAI 模型、Embedding 模型等配置信息。
+// 用于统一管理向量存储、OpenAI 模型、Embedding 模型等配置信息。
 type Config struct {
-	Client    *elasticsearch.Client // Elasticsearch 客户端连接实例
-	IndexName string                // Elasticsearch 索引名称
+	Store     vectorstore.VectorStore // 向量存储（Elasticsearch 或内置存储）
+	IndexName string                  // 索引名称
 
 	// 以下字段用于 Embedding（向量化）或 Chat 模型
 	APIKey         string // OpenAI 或兼容接口的 API Key
 	BaseURL        string // 模型服务的 Base URL，可用于自定义代理或私有部署
 	EmbeddingModel string // 向量模型名称（用于文本向量化）
+	Dims           int    // 向量维度，需与 EmbeddingModel 的输出一致
+	Similarity     string // 向量相似度：cosine / dot_product / l2_norm
 	ChatModel      string // 聊天模型名称（用于问答或对话）
 }
 
@@ -35,13 +37,15 @@ func (x *Config) GetChatModelConfig() *openai.ChatModelConfig {
 // 以防止在运行时被修改或造成并发读写问题。
 func (x *Config) Copy() *Config {
 	return &Config{
-		Client:    x.Client,    // 保持相同的 Elasticsearch 客户端引用
+		Store:     x.Store,     // 保持相同的向量存储引用
 		IndexName: x.IndexName, // 复制索引名
 
 		// 以下是 Embedding / Chat 模型的配置复制
 		APIKey:         x.APIKey,
 		BaseURL:        x.BaseURL,
 		EmbeddingModel: x.EmbeddingModel,
+		Dims:           x.Dims,
+		Similarity:     x.Similarity,
 		ChatModel:      x.ChatModel,
 	}
 }
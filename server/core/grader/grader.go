@@ -3,6 +3,7 @@ package grader
 import (
 	"context"
 	"fmt"
+	"github.com/bytedance/sonic"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 	"github.com/gogf/gf/v2/frame/g"
@@ -51,3 +52,70 @@ func isPass(msg string) bool {
 	msg = strings.ToLower(msg)
 	return strings.Contains(msg, "yes")
 }
+
+// DocGrade 单个文档的相关性判断
+type DocGrade struct {
+	Index    int    `json:"index"`        // 文档在输入中的下标
+	ID       string `json:"id,omitempty"` // 文档 ID
+	Relevant bool   `json:"relevant"`     // 是否与问题相关
+	Reason   string `json:"reason"`       // 判断理由
+}
+
+// Grade 一次批量打分的结果
+type Grade struct {
+	Documents  []*DocGrade `json:"documents"`         // 每个文档的判断，与输入顺序一致
+	Sufficient bool        `json:"sufficient"`        // 相关文档合起来是否足够回答问题
+	Rewrite    string      `json:"rewrite,omitempty"` // 不足以回答时建议的新检索问题
+}
+
+// Relevant 返回判断为相关的文档
+func (x *Grade) Relevant(docs []*schema.Document) []*schema.Document {
+	var res []*schema.Document
+	for _, d := range x.Documents {
+		if d.Relevant {
+			res = append(res, docs[d.Index])
+		}
+	}
+	return res
+}
+
+// Grade 在一次调用中判断每个文档是否与问题相关、相关文档是否足够回答问题，不足时给出改写后的检索问题。
+// 模型没有判断的文档视为相关，避免误删
+func (x *Grader) Grade(ctx context.Context, docs []*schema.Document, question string) (*Grade, error) {
+	if len(docs) == 0 {
+		return &Grade{}, nil
+	}
+	result, err := x.cm.Generate(ctx, gradeMessages(docs, question))
+	if err != nil {
+		return nil, fmt.Errorf("grade the retrieved documents (failed): %w", err)
+	}
+	return parseGrade(result.Content, docs)
+}
+
+func parseGrade(content string, docs []*schema.Document) (*Grade, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no json object in grade result: %s", content)
+	}
+	raw := &Grade{}
+	if err := sonic.UnmarshalString(content[start:end+1], raw); err != nil {
+		return nil, fmt.Errorf("unmarshal grade result failed: %w", err)
+	}
+	res := &Grade{
+		Documents:  make([]*DocGrade, len(docs)),
+		Sufficient: raw.Sufficient,
+		Rewrite:    strings.TrimSpace(raw.Rewrite),
+	}
+	for _, d := range raw.Documents {
+		if d != nil && d.Index >= 0 && d.Index < len(docs) && res.Documents[d.Index] == nil {
+			res.Documents[d.Index] = d
+		}
+	}
+	for i, doc := range docs {
+		if res.Documents[i] == nil {
+			res.Documents[i] = &DocGrade{Index: i, Relevant: true, Reason: "not graded"}
+		}
+		res.Documents[i].ID = doc.ID
+	}
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package grader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeChatModel 返回固定的内容
+type fakeChatModel string
+
+func (f fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(string(f), nil), nil
+}
+
+func (f fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, nil
+}
+
+func TestGrade(t *testing.T) {
+	docs := []*schema.Document{
+		{ID: "a", Content: "退款需在 7 天内申请"},
+		{ID: "b", Content: "公司成立于 2010 年"},
+		{ID: "c", Content: "数字商品不支持退款 {见条款}"},
+	}
+	cm := fakeChatModel("```json\n" + `{"documents":[{"index":0,"relevant":true,"reason":"退款期限"},` +
+		`{"index":1,"relevant":false,"reason":"无关"},{"index":9,"relevant":true}],"sufficient":false,"rewrite":" 数字商品 退款政策 "}` + "\n```")
+	grade, err := NewGrader(cm).Grade(context.Background(), docs, "数字商品可以退款吗")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grade.Documents) != 3 || grade.Sufficient || grade.Rewrite != "数字商品 退款政策" {
+		t.Fatalf("unexpected grade: %+v", grade)
+	}
+	// 没有判断的文档视为相关
+	relevant := grade.Relevant(docs)
+	if len(relevant) != 2 || relevant[0].ID != "a" || relevant[1].ID != "c" || grade.Documents[2].Reason != "not graded" {
+		t.Fatalf("unexpected relevant docs: %v", relevant)
+	}
+
+	if _, err = NewGrader(fakeChatModel("yes")).Grade(context.Background(), docs, "q"); err == nil {
+		t.Fatal("expect error without json")
+	}
+}
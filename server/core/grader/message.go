@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
+	"strings"
 )
 
 // createRetrieverTemplate 判断检索到的文档是否足够回答用户问题
@@ -85,3 +86,33 @@ func docRelatedMessages(doc *schema.Document, question string) ([]*schema.Messag
 	}
 	return messages, nil
 }
+
+// gradeDocMaxRunes 批量打分时每个文档保留的最大字符数
+const gradeDocMaxRunes = 800
+
+var gradeSystem = "You are an expert who assesses retrieved documents for a user's question.\n" +
+	"1. For each document, decide whether it is relevant to the question. " +
+	"This doesn't need to be a rigorous test; the goal is to filter out incorrect searches.\n" +
+	"2. Decide whether the relevant documents together are sufficient to answer the question.\n" +
+	"3. If they are not sufficient, write a new search query that is more likely to retrieve the missing information.\n" +
+	"Return only a JSON object without any further explanation, for example:\n" +
+	"{\"documents\":[{\"index\":0,\"relevant\":true,\"reason\":\"mentions the refund period\"}]," +
+	"\"sufficient\":false,\"rewrite\":\"refund policy for digital products\"}"
+
+// gradeMessages 批量打分的消息，文档内容包含花括号，不使用 FString 模板
+func gradeMessages(docs []*schema.Document, question string) []*schema.Message {
+	var sb strings.Builder
+	sb.WriteString("These are the retrieved documents:\n")
+	for i, doc := range docs {
+		content := []rune(doc.Content)
+		if len(content) > gradeDocMaxRunes {
+			content = content[:gradeDocMaxRunes]
+		}
+		fmt.Fprintf(&sb, "docs[%d]: %s\n", i, strings.ReplaceAll(string(content), "\n", " "))
+	}
+	fmt.Fprintf(&sb, "\nThis is a user's problem: %s", question)
+	return []*schema.Message{
+		schema.SystemMessage(gradeSystem),
+		schema.UserMessage(sb.String()),
+	}
+}
@@ -2,22 +2,28 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+
 	"github.com/bytedance/sonic"
 	"github.com/cloudwego/eino/schema"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/indexer"
 	"github.com/everfid-ever/ThinkForge/core/retriever"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
 	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
 	"github.com/everfid-ever/ThinkForge/internal/model/entity"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gctx"
-	"time"
+	"github.com/gogf/gf/v2/os/gtime"
 
 	"github.com/cloudwego/eino/components/document"
 	"github.com/everfid-ever/ThinkForge/core/common"
 )
 
+// statusBatchSize 回填状态时每次按 ID 更新的 chunk 数
+const statusBatchSize = 500
+
 type IndexReq struct {
 	URI           string // 文档地址，可以是文件路径（pdf，html，md等），也可以是网址
 	KnowledgeName string // 知识库名称
@@ -36,77 +42,262 @@ type IndexAsyncByDocsIDReq struct {
 	DocumentsId   int64  // 文档ID
 }
 
+// Index 同步执行加载、切分与向量写入，随后登记一个 QA 阶段的索引任务交给后台 worker 处理。
+// 任务持久化在 knowledge_index_jobs 中，进程重启后会继续执行，失败会按退避策略重试。
 func (x *Rag) Index(ctx context.Context, req *IndexReq) (ids []string, err error) {
-	s := document.Source{
-		URI: req.URI,
+	jobId, err := knowledge.CreateIndexJob(ctx, entity.KnowledgeIndexJobs{
+		KnowledgeDocId:    req.DocumentsId,
+		KnowledgeBaseName: req.KnowledgeName,
+		Uri:               req.URI,
+		Stage:             v1.JobStageIndex,
+		Status:            int(v1.JobRunning),
+		Attempts:          1,
+	})
+	if err != nil {
+		return
 	}
-	ctx = context.WithValue(ctx, common.KnowledgeName, req.KnowledgeName)
-	ids, err = x.idxer.Invoke(ctx, s)
+	job := entity.KnowledgeIndexJobs{Id: jobId, KnowledgeDocId: req.DocumentsId}
+	stop := knowledge.KeepIndexJobAlive(ctx, jobId, knowledge.IndexJobLease(ctx)/3)
+	defer stop()
+	ctx, flush := withJobProgress(ctx, job)
+	defer flush()
+	ids, err = x.indexByURI(ctx, req.URI, req.KnowledgeName, req.DocumentsId)
 	if err != nil {
+		// 同步阶段的错误直接返回给调用方，不再重试
+		_ = knowledge.FailIndexJob(ctx, job, err)
 		return
 	}
-	go func() {
-		// 测试下来这里必须 sleep 一段时间，否则下面的 indexAsyncByDocsID 在es里面搜索不到该条数据，应该是es本身会有一定延迟
-		// 这里会有一定隐患，刚提交index后项目就崩了，可能会有几条chunk没有生成QA
-		// 但是这个场景几乎不会出现，且不影响用户使用，可以忽略
-		time.Sleep(time.Second)
-		ctxN := gctx.New()
-		defer func() {
-			if e := recover(); e != nil {
-				g.Log().Errorf(ctxN, "recover indexAsyncByDocsID failed, err=%v", e)
-			}
-		}()
-		_, err = x.indexAsyncByDocsID(ctxN, &IndexAsyncByDocsIDReq{
-			DocsIDs:       ids,
-			KnowledgeName: req.KnowledgeName,
-			DocumentsId:   req.DocumentsId,
-		})
+	err = x.enqueueQAStage(ctx, jobId, ids)
+	return
+}
+
+// EnqueueIndex 只登记索引任务，加载、切分与向量写入全部交给后台 worker 执行，适合大文件上传
+func (x *Rag) EnqueueIndex(ctx context.Context, req *IndexReq) (jobId int64, err error) {
+	return knowledge.CreateIndexJob(ctx, entity.KnowledgeIndexJobs{
+		KnowledgeDocId:    req.DocumentsId,
+		KnowledgeBaseName: req.KnowledgeName,
+		Uri:               req.URI,
+		Stage:             v1.JobStageIndex,
+		Status:            int(v1.JobPending),
+	})
+}
+
+// RunIndexJob 执行一个索引任务，由后台 worker 调用，重建知识库索引的任务见 runReindexJob。
+// index 阶段的任务说明上次同步写入未完成（如进程中途退出），需要先清除已写入的部分 chunk，
+// 再从原始文件重新走一遍完整流程。
+func (x *Rag) RunIndexJob(ctx context.Context, job entity.KnowledgeIndexJobs) (err error) {
+	ctx, flush := withJobProgress(ctx, job)
+	defer flush()
+	if job.Stage == v1.JobStageReindex {
+		return x.runReindexJob(ctx, job)
+	}
+	var ids []string
+	if job.Stage == v1.JobStageIndex || len(job.ChunkIds) == 0 {
+		if err = x.clearDocumentChunks(ctx, job.KnowledgeBaseName, job.KnowledgeDocId); err != nil {
+			return fmt.Errorf("clear chunks of document %d failed: %w", job.KnowledgeDocId, err)
+		}
+		ids, err = x.indexByURI(ctx, job.Uri, job.KnowledgeBaseName, job.KnowledgeDocId)
 		if err != nil {
-			g.Log().Errorf(ctxN, "indexAsyncByDocsID failed, err=%v", err)
+			return
 		}
-	}()
+		// 记录 chunk id 后在当前 worker 中直接继续执行 QA 阶段
+		if err = knowledge.UpdateIndexJob(ctx, job.Id, g.Map{
+			"chunk_ids": chunkIdsString(ids),
+			"stage":     v1.JobStageQA,
+		}); err != nil {
+			return
+		}
+	} else if err = sonic.UnmarshalString(job.ChunkIds, &ids); err != nil {
+		return fmt.Errorf("unmarshal chunk ids of job %d failed: %w", job.Id, err)
+	}
+	_, err = x.indexAsyncByDocsID(ctx, &IndexAsyncByDocsIDReq{
+		DocsIDs:       ids,
+		KnowledgeName: job.KnowledgeBaseName,
+		DocumentsId:   job.KnowledgeDocId,
+	})
 	return
 }
 
+// clearDocumentChunks 删除文档已写入索引与 knowledge_chunks 的 chunk。
+// 索引中按文档 ID 删除，同时按 knowledge_chunks 中记录的 chunk_id 删除缺少文档 ID 的历史 chunk
+func (x *Rag) clearDocumentChunks(ctx context.Context, knowledgeName string, documentsId int64) error {
+	if documentsId == 0 {
+		return nil
+	}
+	sp, err := x.space(ctx, knowledgeName)
+	if err != nil {
+		return err
+	}
+	chunks, err := knowledge.GetAllChunksByDocId(ctx, documentsId, "chunk_id")
+	if err != nil {
+		return err
+	}
+	filter := vectorstore.NewFilter().Or(
+		vectorstore.NewFilter(vectorstore.Eq(common.FieldDocumentID, strconv.FormatInt(documentsId, 10))),
+	)
+	if len(chunks) > 0 {
+		chunkIds := make([]string, 0, len(chunks))
+		for _, c := range chunks {
+			chunkIds = append(chunkIds, c.ChunkId)
+		}
+		filter.Or(vectorstore.NewFilter(vectorstore.In(vectorstore.IDField, chunkIds)))
+	}
+	deleted, err := x.store.DeleteByFilter(ctx, sp.index(), filter)
+	if err != nil {
+		return err
+	}
+	if err = knowledge.DeleteChunksByDocId(ctx, documentsId); err != nil {
+		return err
+	}
+	if deleted > 0 || len(chunks) > 0 {
+		g.Log().Infof(ctx, "cleared %d indexed chunks and %d records of document %d before reindexing", deleted, len(chunks), documentsId)
+		x.cache.Invalidate(ctx, knowledgeName)
+	}
+	return nil
+}
+
+func (x *Rag) indexByURI(ctx context.Context, uri, knowledgeName string, documentsId int64) (ids []string, err error) {
+	s := document.Source{
+		URI: uri,
+	}
+	sp, err := x.space(ctx, knowledgeName)
+	if err != nil {
+		return
+	}
+	if ctx, err = withChunkProfile(ctx, knowledgeName); err != nil {
+		return
+	}
+	if ctx, err = withDocumentMeta(ctx, documentsId); err != nil {
+		return
+	}
+	ctx = context.WithValue(ctx, common.KnowledgeName, knowledgeName)
+	ids, err = sp.idxer.Invoke(ctx, s, indexer.ProgressFromContext(ctx).IndexerOptions()...)
+	if err != nil {
+		return
+	}
+	x.cache.Invalidate(ctx, knowledgeName)
+	return
+}
+
+// withChunkProfile 把知识库的切分配置放入 ctx，未在 knowledge_base 中登记的知识库使用默认配置
+func withChunkProfile(ctx context.Context, knowledgeName string) (context.Context, error) {
+	kb, found, err := knowledge.GetKnowledgeBaseByName(ctx, knowledgeName)
+	if err != nil || !found {
+		return ctx, err
+	}
+	p := &indexer.ChunkProfile{
+		Splitter:     kb.Splitter,
+		ChunkSize:    kb.ChunkSize,
+		OverlapSize:  kb.ChunkOverlap,
+		HeaderLevels: kb.HeaderLevels,
+		MergeLength:  kb.MergeLength,
+	}
+	if kb.Separators != "" {
+		if err = sonic.UnmarshalString(kb.Separators, &p.Separators); err != nil {
+			return ctx, fmt.Errorf("invalid separators of knowledge base %s: %w", knowledgeName, err)
+		}
+	}
+	return indexer.WithChunkProfile(ctx, p), nil
+}
+
+// withDocumentMeta 把文档 ID 与上传时间放入 ctx，写入每个 chunk 用于检索过滤
+func withDocumentMeta(ctx context.Context, documentsId int64) (context.Context, error) {
+	if documentsId == 0 {
+		return ctx, nil
+	}
+	doc, err := knowledge.GetDocumentById(ctx, documentsId)
+	if err != nil {
+		return ctx, err
+	}
+	m := &indexer.DocumentMeta{DocumentID: documentsId}
+	if doc.CreatedAt != nil {
+		m.UploadedAt = doc.CreatedAt.Time
+	}
+	return indexer.WithDocumentMeta(ctx, m), nil
+}
+
+// withJobProgress 为任务创建进度跟踪器并放入 ctx，进度变化写回任务表，
+// 返回的 flush 需在任务结束时调用，写入被节流的剩余进度
+func withJobProgress(ctx context.Context, job entity.KnowledgeIndexJobs) (context.Context, func()) {
+	var data *v1.IndexJobProgress
+	if len(job.Progress) > 0 {
+		if err := sonic.UnmarshalString(job.Progress, &data); err != nil {
+			g.Log().Warningf(ctx, "unmarshal progress of job %d failed, err=%v", job.Id, err)
+		}
+	}
+	p := indexer.NewProgress(data, func(progress string) {
+		_ = knowledge.UpdateIndexJob(ctx, job.Id, g.Map{"progress": progress})
+	})
+	return indexer.WithProgress(ctx, p), p.Flush
+}
+
+// enqueueQAStage 记录已写入的 chunk id，并把任务切换到 QA 阶段等待 worker 执行
+func (x *Rag) enqueueQAStage(ctx context.Context, jobId int64, ids []string) error {
+	return knowledge.UpdateIndexJob(ctx, jobId, g.Map{
+		"chunk_ids":   chunkIdsString(ids),
+		"stage":       v1.JobStageQA,
+		"status":      int(v1.JobPending),
+		"attempts":    0,
+		"last_error":  "",
+		"next_run_at": gtime.Now(),
+	})
+}
+
+func chunkIdsString(ids []string) string {
+	s, _ := sonic.MarshalString(ids)
+	return s
+}
+
 func (x *Rag) IndexAsync(ctx context.Context, req *IndexAsyncReq) (ids []string, err error) {
+	sp, err := x.space(ctx, req.KnowledgeName)
+	if err != nil {
+		return
+	}
 	ctx = context.WithValue(ctx, common.KnowledgeName, req.KnowledgeName)
-	ids, err = x.idxerAsync.Invoke(ctx, req.Docs)
+	ids, err = sp.idxerAsync.Invoke(ctx, req.Docs, indexer.ProgressFromContext(ctx).AsyncOptions()...)
 	if err != nil {
 		return
 	}
+	x.cache.Invalidate(ctx, req.KnowledgeName)
 	return
 }
 
 // 通过docIDs 异步 生成QA&embedding
 // 这个方法不用暴露出去
 func (x *Rag) indexAsyncByDocsID(ctx context.Context, req *IndexAsyncByDocsIDReq) (ids []string, err error) {
-	esQuery := &types.Query{
-		Bool: &types.BoolQuery{
-			Must: []types.Query{
-				{Match: map[string]types.MatchQuery{common.KnowledgeName: {Query: req.KnowledgeName}}},
-				{Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{"_id": req.DocsIDs}}},
-			},
-		},
-	}
-
-	sreq := search.NewRequest()
-	sreq.Query = esQuery
-	resp, err := search.NewSearchFunc(x.client)().
-		Index(x.conf.IndexName).
-		Request(sreq).
-		Do(ctx)
+	sp, err := x.space(ctx, req.KnowledgeName)
 	if err != nil {
 		return
 	}
+	// 刚写入的数据需要 refresh 之后才能被搜索到
+	if err = x.store.Refresh(ctx, sp.index()); err != nil {
+		return
+	}
+	records, err := x.store.Query(ctx, sp.index(), &vectorstore.QueryRequest{
+		Filter: vectorstore.NewFilter(
+			vectorstore.Eq(common.KnowledgeName, req.KnowledgeName),
+			vectorstore.In(vectorstore.IDField, req.DocsIDs),
+		),
+		Size:     len(req.DocsIDs),
+		Excludes: common.VectorFields,
+	})
+	if err != nil {
+		return
+	}
+	if len(records) == 0 {
+		err = fmt.Errorf("no chunks found for document %d", req.DocumentsId)
+		return
+	}
 	var docs []*schema.Document
 	var chunks []entity.KnowledgeChunks
-	for _, hit := range resp.Hits.Hits {
-		doc := &schema.Document{}
-		doc, err = retriever.EsHit2Document(ctx, hit)
+	for _, record := range records {
+		var doc *schema.Document
+		doc, err = retriever.Record2Document(ctx, record)
 		if err != nil {
-			g.Log().Errorf(ctx, "EsHit2Document failed, err=%v", err)
+			g.Log().Errorf(ctx, "Record2Document failed, err=%v", err)
 			return
 		}
+		status := doc.MetaData[common.FieldStatus]
 		docParseExt(doc)
 		docs = append(docs, doc)
 		ext, err := sonic.Marshal(doc.MetaData)
@@ -114,6 +305,10 @@ func (x *Rag) indexAsyncByDocsID(ctx context.Context, req *IndexAsyncByDocsIDReq
 			g.Log().Errorf(ctx, "sonic.Marshal failed, err=%v", err)
 			continue
 		}
+		// ext 会覆盖 MetaData，这里保留 chunk 的启用状态，避免重新写入时被重置
+		if status != nil {
+			doc.MetaData[common.FieldStatus] = status
+		}
 		chunks = append(chunks, entity.KnowledgeChunks{
 			KnowledgeDocId: req.DocumentsId,
 			ChunkId:        doc.ID,
@@ -122,8 +317,9 @@ func (x *Rag) indexAsyncByDocsID(ctx context.Context, req *IndexAsyncByDocsIDReq
 		})
 	}
 	if err = knowledge.SaveChunksData(ctx, req.DocumentsId, chunks); err != nil {
-		// 这里不返回err，不影响用户使用
+		// chunk 未落库时交给任务重试，避免文档在 MySQL 中缺失分块
 		g.Log().Errorf(ctx, "indexAsyncByDocsID insert chunks failed, err=%v", err)
+		return
 	}
 
 	asyncReq := &IndexAsyncReq{
@@ -152,6 +348,72 @@ func docParseExt(doc *schema.Document) {
 	}
 }
 
-func (x *Rag) DeleteDocument(ctx context.Context, documentID string) error {
-	return common.DeleteDocument(ctx, x.conf.Client, documentID)
+// DeleteDocument 从知识库所在的索引中删除单个 chunk
+func (x *Rag) DeleteDocument(ctx context.Context, knowledgeName, documentID string) error {
+	sp, err := x.space(ctx, knowledgeName)
+	if err != nil {
+		return err
+	}
+	if err = x.store.Delete(ctx, sp.index(), documentID); err != nil {
+		return err
+	}
+	x.cache.Invalidate(ctx, knowledgeName)
+	return nil
+}
+
+// UpdateChunkStatus 将 chunk 的启用状态同步到知识库所在的索引，禁用后的 chunk 不会再被检索到
+func (x *Rag) UpdateChunkStatus(ctx context.Context, knowledgeName string, chunkIDs []string, enabled bool) error {
+	sp, err := x.space(ctx, knowledgeName)
+	if err != nil {
+		return err
+	}
+	if err = x.updateChunkStatus(ctx, sp.index(), chunkIDs, enabled); err != nil {
+		return err
+	}
+	x.cache.Invalidate(ctx, knowledgeName)
+	return nil
+}
+
+func (x *Rag) updateChunkStatus(ctx context.Context, index string, chunkIDs []string, enabled bool) error {
+	status := common.ChunkStatusDisabled
+	if enabled {
+		status = common.ChunkStatusEnabled
+	}
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+	_, err := x.store.UpdateByFilter(ctx, index,
+		vectorstore.NewFilter(vectorstore.In(vectorstore.IDField, chunkIDs)),
+		map[string]any{common.FieldStatus: status})
+	return err
+}
+
+// BackfillChunkStatus 为默认索引及各知识库的独立索引回填 chunk 状态：
+// 缺少状态字段的历史数据标记为启用，再按 MySQL 中的记录把已禁用的 chunk 同步到向量存储
+func (x *Rag) BackfillChunkStatus(ctx context.Context) error {
+	indexes, err := x.indexes(ctx)
+	if err != nil {
+		return err
+	}
+	disabled, err := knowledge.GetChunkIdsByStatus(ctx, v1.ChunkStatusDisabled)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		updated, err := x.store.UpdateByFilter(ctx, index,
+			(&vectorstore.Filter{}).Not(vectorstore.Exists(common.FieldStatus)),
+			map[string]any{common.FieldStatus: common.ChunkStatusEnabled})
+		if err != nil {
+			return err
+		}
+		// chunk id 全局唯一，不在当前索引中的 id 不会被更新
+		for i := 0; i < len(disabled); i += statusBatchSize {
+			batch := disabled[i:min(i+statusBatchSize, len(disabled))]
+			if err = x.updateChunkStatus(ctx, index, batch, false); err != nil {
+				return err
+			}
+		}
+		g.Log().Infof(ctx, "backfill chunk status of %s done, enabled=%d, disabled=%d", index, updated, len(disabled))
+	}
+	return nil
 }
@@ -10,12 +10,12 @@ import (
 
 func BuildIndexerAsync(ctx context.Context, conf *config.Config) (r compose.Runnable[[]*schema.Document, []string], err error) {
 	const (
-		Indexer = "Indexer"
-		QA      = "QA"
+		Indexer = NodeIndexer
+		QA      = NodeQA
 	)
 
 	g := compose.NewGraph[[]*schema.Document, []string]()
-	indexer2KeyOfIndexer, err := newIndexer(ctx, conf)
+	indexer2KeyOfIndexer, err := newAsyncIndexer(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
@@ -3,9 +3,10 @@ package indexer
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/bytedance/sonic"
-	"github.com/cloudwego/eino-ext/components/indexer/es8"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	"github.com/everfid-ever/ThinkForge/core/common"
@@ -14,14 +15,15 @@ import (
 )
 
 func newIndexer(ctx context.Context, conf *config.Config) (idr indexer.Indexer, err error) {
-	// 构建 ES 索引器配置
-	indexerConfig := &es8.IndexerConfig{
-		Client:    conf.Client,    // ES 客户端
-		Index:     conf.IndexName, // 索引名称
-		BatchSize: 10,             // 批量写入大小（可调优）
-
-		// DocumentToFields: 将 schema.Document 转换为 Elasticsearch 字段映射
-		DocumentToFields: func(ctx context.Context, doc *schema.Document) (field2Value map[string]es8.FieldValue, err error) {
+	// 构建向量存储索引器配置
+	indexerConfig := &storeIndexerConfig{
+		Store:     conf.Store,              // 向量存储
+		Index:     conf.IndexName,          // 索引名称
+		BatchSize: embeddingBatchSize,      // 批量写入大小（可调优）
+		Dims:      common.VectorDims(conf), // 向量维度（与索引映射一致）
+
+		// DocumentToFields: 将 schema.Document 转换为向量存储的字段映射
+		DocumentToFields: func(ctx context.Context, doc *schema.Document) (field2Value map[string]FieldValue, err error) {
 			var knowledgeName string
 
 			// 从 context 中提取知识库名称
@@ -39,12 +41,12 @@ func newIndexer(ctx context.Context, conf *config.Config) (idr indexer.Indexer,
 
 			// 若存在元数据（MetaData），将其序列化保存至 "ext" 字段
 			if doc.MetaData != nil {
-				marshal, _ := sonic.Marshal(doc.MetaData)
-				doc.MetaData[common.KnowledgeName] = string(marshal)
+				marshal, _ := sonic.Marshal(getExtData(doc))
+				doc.MetaData[common.FieldExtra] = string(marshal)
 			}
 
-			// 返回字段与值的映射，用于 ES 索引写入
-			return map[string]es8.FieldValue{
+			// 返回字段与值的映射，用于写入向量存储
+			return withMetaFields(doc, map[string]FieldValue{
 				// 主内容字段：用于语义向量检索
 				common.FieldContent: {
 					Value:    doc.Content,               // 文档内容
@@ -61,12 +63,17 @@ func newIndexer(ctx context.Context, conf *config.Config) (idr indexer.Indexer,
 					Value: knowledgeName,
 				},
 
+				// chunk 启用状态（检索时过滤已禁用的 chunk）
+				common.FieldStatus: {
+					Value: getChunkStatus(doc),
+				},
+
 				// 可选：问答内容字段（如需对 QA 对进行单独向量化，可启用）
 				// common.FieldQAContent: {
 				// 	Value:    doc.MetaData[common.FieldQAContent],
 				// 	EmbedKey: common.FieldQAContentVector,
 				// },
-			}, nil
+			}), nil
 		},
 	}
 
@@ -77,15 +84,68 @@ func newIndexer(ctx context.Context, conf *config.Config) (idr indexer.Indexer,
 	}
 	indexerConfig.Embedding = embeddingIns
 
-	// 初始化 ES8 索引器
-	idr, err = es8.NewIndexer(ctx, indexerConfig)
+	// 初始化向量存储索引器
+	idr, err = newStoreIndexer(indexerConfig)
 	if err != nil {
-		return nil, fmt.Errorf("init es8 indexer failed: %w", err)
+		return nil, fmt.Errorf("init indexer failed: %w", err)
 	}
 
 	return idr, nil
 }
 
+// getChunkStatus 读取文档上携带的启用状态，未设置时默认为启用
+func getChunkStatus(doc *schema.Document) string {
+	if status, ok := doc.MetaData[common.FieldStatus].(string); ok && len(status) > 0 {
+		return status
+	}
+	return common.ChunkStatusEnabled
+}
+
+// withMetaFields 把文档上可过滤的结构化元数据（见 common.MetaFields）写入独立字段
+func withMetaFields(doc *schema.Document, fields map[string]FieldValue) map[string]FieldValue {
+	for _, key := range common.MetaFields {
+		if v, ok := doc.MetaData[key]; ok && v != nil {
+			fields[key] = FieldValue{Value: v}
+		}
+	}
+	return fields
+}
+
+type documentMetaCtxKey struct{}
+
+// DocumentMeta 文档级元数据，写入该文档的每个 chunk
+type DocumentMeta struct {
+	DocumentID int64     // knowledge_documents.id
+	UploadedAt time.Time // 上传时间
+}
+
+// WithDocumentMeta 把文档级元数据放入 ctx，切分后的 chunk 会带上这些元数据
+func WithDocumentMeta(ctx context.Context, m *DocumentMeta) context.Context {
+	return context.WithValue(ctx, documentMetaCtxKey{}, m)
+}
+
+// setDocumentMeta 为 chunk 设置 ctx 中的文档 ID 与上传时间
+func setDocumentMeta(ctx context.Context, doc *schema.Document) {
+	m, ok := ctx.Value(documentMetaCtxKey{}).(*DocumentMeta)
+	if !ok || m == nil {
+		return
+	}
+	if doc.MetaData == nil {
+		doc.MetaData = map[string]any{}
+	}
+	if m.DocumentID > 0 {
+		doc.MetaData[common.FieldDocumentID] = strconv.FormatInt(m.DocumentID, 10)
+	}
+	if !m.UploadedAt.IsZero() {
+		doc.MetaData[common.FieldUploadedAt] = FormatUploadedAt(m.UploadedAt)
+	}
+}
+
+// FormatUploadedAt 上传时间统一为 UTC 的 RFC3339 格式，保证按字符串比较即可判断先后
+func FormatUploadedAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
 func getExtData(doc *schema.Document) map[string]any {
 	if doc.MetaData == nil {
 		return nil
@@ -5,20 +5,20 @@ import (
 	"fmt"
 
 	"github.com/bytedance/sonic"
-	"github.com/cloudwego/eino-ext/components/indexer/es8"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	"github.com/everfid-ever/ThinkForge/core/common"
 	"github.com/everfid-ever/ThinkForge/core/config"
 )
 
-// newAsyncIndexer component initialization function of node 'Indexer2' in graph 'rag'
+// newAsyncIndexer component initialization function of node 'Indexer' in graph 'indexer_async'
 func newAsyncIndexer(ctx context.Context, conf *config.Config) (idr indexer.Indexer, err error) {
-	indexerConfig := &es8.IndexerConfig{
-		Client:    conf.Client,
+	indexerConfig := &storeIndexerConfig{
+		Store:     conf.Store,
 		Index:     conf.IndexName,
-		BatchSize: 10,
-		DocumentToFields: func(ctx context.Context, doc *schema.Document) (field2Value map[string]es8.FieldValue, err error) {
+		BatchSize: embeddingBatchSize,
+		Dims:      common.VectorDims(conf),
+		DocumentToFields: func(ctx context.Context, doc *schema.Document) (field2Value map[string]FieldValue, err error) {
 			var knowledgeName string
 			if value, ok := ctx.Value(common.KnowledgeName).(string); ok {
 				knowledgeName = value
@@ -31,7 +31,7 @@ func newAsyncIndexer(ctx context.Context, conf *config.Config) (idr indexer.Inde
 				marshal, _ := sonic.Marshal(getExtData(doc))
 				doc.MetaData[common.FieldExtra] = string(marshal)
 			}
-			return map[string]es8.FieldValue{
+			return withMetaFields(doc, map[string]FieldValue{
 				common.FieldContent: {
 					Value:    doc.Content,
 					EmbedKey: common.FieldContentVector,
@@ -42,11 +42,14 @@ func newAsyncIndexer(ctx context.Context, conf *config.Config) (idr indexer.Inde
 				common.KnowledgeName: {
 					Value: knowledgeName,
 				},
+				common.FieldStatus: {
+					Value: getChunkStatus(doc),
+				},
 				common.FieldQAContent: {
 					Value:    doc.MetaData[common.FieldQAContent],
 					EmbedKey: common.FieldQAContentVector,
 				},
-			}, nil
+			}), nil
 		},
 	}
 	embeddingIns11, err := common.NewEmbedding(ctx, conf)
@@ -54,7 +57,7 @@ func newAsyncIndexer(ctx context.Context, conf *config.Config) (idr indexer.Inde
 		return nil, err
 	}
 	indexerConfig.Embedding = embeddingIns11
-	idr, err = es8.NewIndexer(ctx, indexerConfig)
+	idr, err = newStoreIndexer(indexerConfig)
 	if err != nil {
 		return nil, err
 	}
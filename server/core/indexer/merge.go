@@ -13,13 +13,44 @@ import (
 )
 
 // docAddIDAndMerge component initialization function of node 'Lambda1' in graph 't'
+// 一次可能加载多个文件（如压缩包、目录），按来源与扩展名分组后各自使用对应的合并策略，组间保持原有顺序
 func docAddIDAndMerge(ctx context.Context, docs []*schema.Document) (output []*schema.Document, err error) {
 	if len(docs) == 0 {
 		return docs, nil
 	}
 	for _, doc := range docs {
 		doc.ID = uuid.New().String() // 覆盖之前的id
+		setDocumentMeta(ctx, doc)
 	}
+	output = make([]*schema.Document, 0, len(docs))
+	for _, group := range groupBySource(docs) {
+		merged, err := mergeByExtension(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, merged...)
+	}
+	return output, nil
+}
+
+// groupBySource 按来源与扩展名分组，组的顺序为首次出现的顺序
+func groupBySource(docs []*schema.Document) [][]*schema.Document {
+	var groups [][]*schema.Document
+	index := make(map[string]int)
+	for _, doc := range docs {
+		key := fmt.Sprintf("%v\x00%v", doc.MetaData[file.MetaKeySource], doc.MetaData[file.MetaKeyExtension])
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], doc)
+	}
+	return groups
+}
+
+func mergeByExtension(ctx context.Context, docs []*schema.Document) ([]*schema.Document, error) {
 	switch docs[0].MetaData[file.MetaKeyExtension] {
 	case ".md":
 		return mergeMD(ctx, docs)
@@ -33,7 +64,7 @@ func docAddIDAndMerge(ctx context.Context, docs []*schema.Document) (output []*s
 func mergeMD(ctx context.Context, docs []*schema.Document) (output []*schema.Document, err error) {
 	ndocs := make([]*schema.Document, 0, len(docs))
 	var nd *schema.Document
-	maxLen := 512
+	maxLen := chunkProfileFromContext(ctx).MergeLength
 	for _, doc := range docs {
 		// 不是同一个文件的就不要放一起了
 		if nd != nil && doc.MetaData[file.MetaKeySource] != nd.MetaData[file.MetaKeySource] {
@@ -17,7 +17,7 @@ import (
 // 参数：
 //
 //	ctx  - 上下文（控制超时与取消）
-//	conf - 配置信息（包括 ES 客户端、模型配置等）
+//	conf - 配置信息（包括向量存储、模型配置等）
 //
 // 返回：
 //
@@ -25,10 +25,10 @@ import (
 //	err - 构建失败时的错误信息
 func BuildIndexer(ctx context.Context, conf *config.Config) (r compose.Runnable[any, []string], err error) {
 	const (
-		Loader1              = "Loader"              // 文档加载节点
-		Indexer2             = "Indexer"             // 向量索引节点
-		DocumentTransformer3 = "DocumentTransformer" // 文档切分节点
-		DocAddIDAndMerge     = "DocAddIDAndMerge"    // 文档ID添加与元数据合并节点
+		Loader1              = NodeLoader              // 文档加载节点
+		Indexer2             = NodeIndexer             // 向量索引节点
+		DocumentTransformer3 = NodeDocumentTransformer // 文档切分节点
+		DocAddIDAndMerge     = NodeDocAddIDAndMerge    // 文档ID添加与元数据合并节点
 		// QA                   = "QA"               // （可选）问答生成节点（目前注释掉）
 	)
 
@@ -42,15 +42,15 @@ func BuildIndexer(ctx context.Context, conf *config.Config) (r compose.Runnable[
 	}
 	_ = g.AddLoaderNode(Loader1, loader1KeyOfLoader)
 
-	// 2️. 初始化 Indexer 节点 —— 负责将文本内容向量化后写入 Elasticsearch
-	indexer2KeyOfIndexer, err := newAsyncIndexer(ctx, conf)
+	// 2️. 初始化 Indexer 节点 —— 负责将文本内容向量化后写入向量存储
+	indexer2KeyOfIndexer, err := newIndexer(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
 	_ = g.AddIndexerNode(Indexer2, indexer2KeyOfIndexer)
 
 	// 3️. 初始化 DocumentTransformer 节点 —— 负责将文档按段落/语义块切分
-	documentTransformer2KeyOfDocumentTransformer, err := newDocumentTransformer(ctx)
+	documentTransformer2KeyOfDocumentTransformer, err := newDocumentTransformer(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
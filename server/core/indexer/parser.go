@@ -3,10 +3,12 @@ package indexer
 import (
 	"context"
 
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
 	"github.com/cloudwego/eino-ext/components/document/parser/html"
 	"github.com/cloudwego/eino-ext/components/document/parser/pdf"
 	"github.com/cloudwego/eino-ext/components/document/parser/xlsx"
 	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
 	"github.com/everfid-ever/ThinkForge/core/common"
 )
 
@@ -17,15 +19,16 @@ import (
 // 用途：
 //
 //	在 RAG（Retrieval-Augmented Generation）流程中，
-//	负责将原始文件内容（HTML/PDF/TXT 等）解析为结构化文本，
+//	负责将原始文件内容（HTML/PDF/Word/PPT/CSV/JSON/源代码等）解析为结构化文本，
 //	以便后续进行向量化和检索。
 //
 // 逻辑步骤：
 //  1. 初始化默认的文本解析器（textParser）；
 //  2. 创建 HTML 解析器并配置选择器；
 //  3. 创建 PDF 解析器用于提取 PDF 文本内容；
-//  4. 将各类型解析器注册到 ExtParser（扩展解析器）；
-//  5. 返回可自动选择解析策略的综合解析器实例。
+//  4. 创建 Word、PPT、CSV、JSON 与源代码解析器，它们会在元数据中记录标题路径、页码、行号、函数名等结构信息；
+//  5. 将各类型解析器注册到 ExtParser（扩展解析器）；
+//  6. 返回可自动选择解析策略的综合解析器实例。
 func newParser(ctx context.Context) (p parser.Parser, err error) {
 	// 默认文本解析器，用于处理纯文本文件或未知格式
 	textParser := parser.TextParser{}
@@ -49,13 +52,25 @@ func newParser(ctx context.Context) (p parser.Parser, err error) {
 
 	// 创建“扩展解析器”（ExtParser），将不同格式的解析器统一管理。
 	// 它会根据文件扩展名自动选择合适的解析逻辑。
+	parsers := map[string]parser.Parser{
+		".html":  htmlParser,              // 处理 HTML 文件
+		".pdf":   pdfParser,               // 处理 PDF 文件
+		".xlsx":  xlsxParser,              // 处理 Excel 文件
+		".docx":  &docxParser{},           // 处理 Word 文件，按标题切分章节
+		".pptx":  &pptxParser{},           // 处理 PPT 文件，每页一个文档
+		".csv":   &csvParser{comma: ','},  // 处理 CSV 文件，每行一个文档
+		".tsv":   &csvParser{comma: '\t'}, // 处理 TSV 文件，每行一个文档
+		".json":  &jsonParser{},           // 处理 JSON 文件，数组按元素拆分
+		".jsonl": &jsonlParser{},          // 处理 JSON Lines 文件，每行一个文档
+	}
+	// 源代码按顶层函数、类型定义切分
+	for _, ext := range codeExts {
+		parsers[ext] = &codeParser{ext: ext}
+	}
+
 	p, err = parser.NewExtParser(ctx, &parser.ExtParserConfig{
 		// 注册特定扩展名对应的解析器
-		Parsers: map[string]parser.Parser{
-			".html": htmlParser, // 处理 HTML 文件
-			".pdf":  pdfParser,  // 处理 PDF 文件
-			".xlsx": xlsxParser, // 处理 Excel 文件
-		},
+		Parsers: parsers,
 		// 设置默认解析器，用于未知或纯文本格式
 		FallbackParser: textParser,
 	})
@@ -66,3 +81,16 @@ func newParser(ctx context.Context) (p parser.Parser, err error) {
 	// 返回最终综合解析器
 	return
 }
+
+// newParsedDoc 创建解析结果文档，合并 loader 传入的文件元数据（扩展名、文件名、来源）与解析器产生的结构化元数据
+func newParsedDoc(option *parser.Options, content string, meta map[string]any) *schema.Document {
+	metaData := make(map[string]any, len(option.ExtraMeta)+len(meta)+1)
+	metaData[file.MetaKeySource] = option.URI
+	for k, v := range option.ExtraMeta {
+		metaData[k] = v
+	}
+	for k, v := range meta {
+		metaData[k] = v
+	}
+	return &schema.Document{Content: content, MetaData: metaData}
+}
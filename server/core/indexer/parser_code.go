@@ -0,0 +1,219 @@
+package indexer
+
+import (
+	"context"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+// codeParser 源代码解析器：按顶层的函数、类型定义切分，每段一个文档，元数据中记录函数或类型名（CodeSymbol）。
+// Go 代码使用标准库语法树解析；其余语言按 symbolPatterns 中的正则匹配顶层定义，定义之前紧邻的注释与注解归入该段，
+// 没有匹配规则的语言整个文件一个文档
+type codeParser struct {
+	ext string
+}
+
+// symbolPatterns 各语言顶层定义的匹配规则，第一个分组为名称
+var symbolPatterns = map[string][]*regexp.Regexp{
+	".py": {
+		regexp.MustCompile(`^(?:async\s+)?def\s+(\w+)`),
+		regexp.MustCompile(`^class\s+(\w+)`),
+	},
+	".js":  jsSymbolPatterns,
+	".jsx": jsSymbolPatterns,
+	".ts":  jsSymbolPatterns,
+	".tsx": jsSymbolPatterns,
+	".java": {
+		regexp.MustCompile(`^(?:(?:public|protected|private|abstract|final|static|sealed)\s+)*(?:class|interface|enum|record|@interface)\s+(\w+)`),
+	},
+	".kt": {
+		regexp.MustCompile(`^(?:(?:public|internal|private|abstract|open|data|sealed|enum)\s+)*(?:class|interface|object)\s+(\w+)`),
+		regexp.MustCompile(`^(?:(?:public|internal|private|suspend|inline)\s+)*fun\s+(?:<[^>]*>\s*)?([\w.]+)`),
+	},
+	".rs": {
+		regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(?:fn|struct|enum|trait|mod|impl(?:<[^>]*>)?)\s+(\w+)`),
+	},
+	".c":   cSymbolPatterns,
+	".h":   cSymbolPatterns,
+	".cc":  cSymbolPatterns,
+	".cpp": cSymbolPatterns,
+	".hpp": cSymbolPatterns,
+	".cs": {
+		regexp.MustCompile(`^\s{0,4}(?:(?:public|internal|protected|private|abstract|sealed|static|partial)\s+)*(?:class|interface|struct|enum|record)\s+(\w+)`),
+	},
+	".rb": {
+		regexp.MustCompile(`^(?:def|class|module)\s+([\w.:]+)`),
+	},
+	".php": {
+		regexp.MustCompile(`^(?:(?:abstract|final)\s+)?(?:function|class|interface|trait)\s+(\w+)`),
+	},
+	".sh": {
+		regexp.MustCompile(`^(?:function\s+)?(\w+)\s*\(\)`),
+	},
+}
+
+var (
+	jsSymbolPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+(\w+)`),
+		regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`),
+		regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(\w+)`),
+		regexp.MustCompile(`^(?:export\s+)?const\s+(\w+)\s*=\s*(?:async\s*)?(?:\([^)]*\)|\w+)\s*=>`),
+	}
+	cSymbolPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:typedef\s+)?(?:struct|class|enum|union|namespace)\s+(\w+)\s*[:{]?\s*$`),
+		// 返回类型 + 函数名 + 参数，且不以分号结尾（排除声明）
+		regexp.MustCompile(`^(?:[\w*&:<>,]+\s+)+\**([\w:~]+)\s*\([^;]*$`),
+	}
+	// codeCommentRe 定义之前的注释与注解行
+	codeCommentRe = regexp.MustCompile(`^\s*(?:#|//|/\*|\*|@|\[)`)
+)
+
+// codeExts 使用 codeParser 解析的源代码扩展名
+var codeExts = []string{".go", ".py", ".js", ".jsx", ".ts", ".tsx", ".java", ".kt", ".rs", ".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".rb", ".php", ".sh"}
+
+// codeSegment 源代码中的一段
+type codeSegment struct {
+	symbol  string
+	content string
+}
+
+func (x *codeParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	src := string(data)
+	var segments []codeSegment
+	if x.ext == ".go" {
+		segments = splitGoSource(src)
+	}
+	if segments == nil {
+		segments = splitSourceByPatterns(src, symbolPatterns[x.ext])
+	}
+	docs := make([]*schema.Document, 0, len(segments))
+	for _, seg := range segments {
+		content := strings.TrimSpace(seg.content)
+		if content == "" {
+			continue
+		}
+		meta := map[string]any{}
+		if seg.symbol != "" {
+			meta[common.CodeSymbol] = seg.symbol
+		}
+		docs = append(docs, newParsedDoc(option, content, meta))
+	}
+	return docs, nil
+}
+
+// splitGoSource 按顶层声明切分 Go 代码，每段从上一个声明结束处开始，包含声明前的注释。
+// package、import、const、var 等没有名称的声明与相邻的同类声明合并；语法错误时返回 nil
+func splitGoSource(src string) []codeSegment {
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "", src, goparser.ParseComments|goparser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	var segments []codeSegment
+	start := 0
+	for _, decl := range f.Decls {
+		end := fset.Position(decl.End()).Offset
+		segments = appendSegment(segments, goSymbol(decl), src[start:end])
+		start = end
+	}
+	if start < len(src) {
+		segments = appendSegment(segments, "", src[start:])
+	}
+	return segments
+}
+
+// goSymbol 返回声明的名称：方法为 "接收者.方法名"，类型声明为类型名，其余为空
+func goSymbol(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			if recv := receiverName(d.Recv.List[0].Type); recv != "" {
+				return recv + "." + d.Name.Name
+			}
+		}
+		return d.Name.Name
+	case *ast.GenDecl:
+		if d.Tok != token.TYPE {
+			return ""
+		}
+		names := make([]string, 0, len(d.Specs))
+		for _, spec := range d.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				names = append(names, ts.Name.Name)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	return ""
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// splitSourceByPatterns 在匹配到顶层定义的行处切分，定义之前紧邻的注释与注解归入该段
+func splitSourceByPatterns(src string, patterns []*regexp.Regexp) []codeSegment {
+	lines := strings.SplitAfter(src, "\n")
+	var segments []codeSegment
+	start, symbol := 0, ""
+	for i, line := range lines {
+		name := matchSymbol(line, patterns)
+		if name == "" {
+			continue
+		}
+		begin := i
+		for begin > start && codeCommentRe.MatchString(lines[begin-1]) {
+			begin--
+		}
+		segments = appendSegment(segments, symbol, strings.Join(lines[start:begin], ""))
+		start, symbol = begin, name
+	}
+	return appendSegment(segments, symbol, strings.Join(lines[start:], ""))
+}
+
+func matchSymbol(line string, patterns []*regexp.Regexp) string {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// appendSegment 追加一段代码，没有名称的相邻片段合并为一段
+func appendSegment(segments []codeSegment, symbol, content string) []codeSegment {
+	if strings.TrimSpace(content) == "" {
+		if n := len(segments); n > 0 {
+			segments[n-1].content += content
+		}
+		return segments
+	}
+	if n := len(segments); n > 0 && symbol == "" && segments[n-1].symbol == "" {
+		segments[n-1].content += content
+		return segments
+	}
+	return append(segments, codeSegment{symbol: symbol, content: content})
+}
@@ -0,0 +1,330 @@
+package indexer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+// docxParser Word 文档解析器：按标题把正文切成章节，每个章节一个文档，
+// 元数据中记录标题路径（HeadingPath）与 h1-h3，表格按行转换为 "单元格 | 单元格" 的文本
+type docxParser struct{}
+
+// pptxParser PPT 解析器：每页幻灯片一个文档，元数据中记录页码（SlideNum），页标题记为 h1
+type pptxParser struct{}
+
+var slideFileRe = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+
+// heading Word 文档中的标题
+type heading struct {
+	level int
+	text  string
+}
+
+// docxSection 两个标题之间的内容
+type docxSection struct {
+	headings []heading
+	lines    []string
+}
+
+func (x *docxParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	zr, err := openZip(reader)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readZipFile(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	sections, err := parseDocxSections(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse docx failed: %w", err)
+	}
+	var docs []*schema.Document
+	for _, section := range sections {
+		content := strings.TrimSpace(strings.Join(section.lines, "\n"))
+		if content == "" {
+			continue
+		}
+		meta := map[string]any{}
+		path := make([]string, 0, len(section.headings))
+		for _, h := range section.headings {
+			path = append(path, h.text)
+			if h.level <= 3 {
+				meta[fmt.Sprintf("h%d", h.level)] = h.text
+			}
+		}
+		if len(path) > 0 {
+			meta[common.HeadingPath] = strings.Join(path, " > ")
+		}
+		docs = append(docs, newParsedDoc(option, content, meta))
+	}
+	return docs, nil
+}
+
+// parseDocxSections 遍历 word/document.xml，按标题段落划分章节。
+// 文本框等嵌套在段落中的段落并入外层段落，兼容内容（Fallback）与 Choice 重复，直接跳过
+func parseDocxSections(data []byte) ([]*docxSection, error) {
+	var (
+		sections = []*docxSection{{}}
+		para     strings.Builder
+		level    int
+		pDepth   int
+		inTable  int
+		cell     []string
+		row      []string
+	)
+	current := func() *docxSection { return sections[len(sections)-1] }
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Fallback":
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+			case "p":
+				if pDepth == 0 {
+					para.Reset()
+					level = 0
+				}
+				pDepth++
+			case "pStyle":
+				if l := headingLevel(xmlAttr(t, "val")); l > 0 {
+					level = l
+				}
+			case "outlineLvl":
+				if n, e := strconv.Atoi(xmlAttr(t, "val")); e == nil && n < 9 {
+					level = n + 1
+				}
+			case "t":
+				var text string
+				if err = d.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+				para.WriteString(text)
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				para.WriteString("\n")
+			case "tbl":
+				inTable++
+			case "tr":
+				row = nil
+			case "tc":
+				cell = nil
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				pDepth--
+				if pDepth > 0 {
+					para.WriteString("\n")
+					continue
+				}
+				text := strings.TrimSpace(para.String())
+				switch {
+				case text == "":
+				case inTable > 0:
+					cell = append(cell, text)
+				case level > 0:
+					sections = append(sections, newDocxSection(current().headings, level, text))
+				default:
+					current().lines = append(current().lines, text)
+				}
+			case "tc":
+				row = append(row, strings.Join(cell, " "))
+			case "tr":
+				if strings.TrimSpace(strings.Join(row, "")) != "" {
+					current().lines = append(current().lines, strings.Join(row, " | "))
+				}
+			case "tbl":
+				inTable--
+			}
+		}
+	}
+	return sections, nil
+}
+
+// newDocxSection 以标题开始新章节，标题路径中去掉同级及更低级的标题
+func newDocxSection(parent []heading, level int, text string) *docxSection {
+	headings := make([]heading, 0, len(parent)+1)
+	for _, h := range parent {
+		if h.level < level {
+			headings = append(headings, h)
+		}
+	}
+	headings = append(headings, heading{level: level, text: text})
+	return &docxSection{headings: headings, lines: []string{text}}
+}
+
+// headingLevel 按段落样式判断标题级别：英文版 Word 为 Heading1，中文版为 1
+func headingLevel(style string) int {
+	s := strings.TrimPrefix(strings.ToLower(strings.ReplaceAll(style, " ", "")), "heading")
+	if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= 9 {
+		return n
+	}
+	return 0
+}
+
+func (x *pptxParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	zr, err := openZip(reader)
+	if err != nil {
+		return nil, err
+	}
+	// 按文件名中的序号排序，与幻灯片的默认顺序一致
+	slides := map[int]*zip.File{}
+	var nums []int
+	for _, f := range zr.File {
+		if m := slideFileRe.FindStringSubmatch(f.Name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			slides[n] = f
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+
+	var docs []*schema.Document
+	for i, n := range nums {
+		data, err := readZipEntry(slides[n])
+		if err != nil {
+			return nil, err
+		}
+		title, content, err := parseSlide(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse slide %d failed: %w", n, err)
+		}
+		if content == "" {
+			continue
+		}
+		meta := map[string]any{common.SlideNum: i + 1}
+		if title != "" {
+			meta[common.Title1] = title
+		}
+		docs = append(docs, newParsedDoc(option, content, meta))
+	}
+	return docs, nil
+}
+
+// parseSlide 提取幻灯片中的文本，返回标题占位符中的文本与全部文本
+func parseSlide(data []byte) (title, content string, err error) {
+	var (
+		lines   []string
+		shape   []string
+		para    strings.Builder
+		inShape bool
+		isTitle bool
+	)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, e := d.Token()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return "", "", e
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sp":
+				inShape, isTitle, shape = true, false, nil
+			case "ph":
+				if typ := xmlAttr(t, "type"); typ == "title" || typ == "ctrTitle" {
+					isTitle = true
+				}
+			case "p":
+				para.Reset()
+			case "br":
+				para.WriteString("\n")
+			case "t":
+				var text string
+				if err = d.DecodeElement(&text, &t); err != nil {
+					return "", "", err
+				}
+				para.WriteString(text)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				text := strings.TrimSpace(para.String())
+				if text == "" {
+					continue
+				}
+				if inShape {
+					shape = append(shape, text)
+				} else {
+					// 表格等不在形状中的文本
+					lines = append(lines, text)
+				}
+			case "sp":
+				text := strings.Join(shape, "\n")
+				if isTitle && title == "" {
+					title = text
+				}
+				if text != "" {
+					lines = append(lines, text)
+				}
+				inShape = false
+			}
+		}
+	}
+	return title, strings.Join(lines, "\n"), nil
+}
+
+func openZip(reader io.Reader) (*zip.Reader, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return readZipEntry(f)
+		}
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
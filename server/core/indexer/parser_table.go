@@ -0,0 +1,118 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+// csvParser CSV / TSV 解析器：首行作为表头，其余每行一个文档，
+// 内容为 "列名: 值" 的多行文本，元数据中记录行号（RowNum）
+type csvParser struct {
+	comma rune
+}
+
+// jsonParser JSON 解析器：顶层为数组时每个元素一个文档，否则整个文件一个文档
+type jsonParser struct{}
+
+// jsonlParser JSON Lines 解析器：每行一个文档
+type jsonlParser struct{}
+
+func (x *csvParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	r := csv.NewReader(skipBOM(reader))
+	r.Comma = x.comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv failed: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, nil
+	}
+	headers := rows[0]
+	var docs []*schema.Document
+	for i, row := range rows[1:] {
+		lines := make([]string, 0, len(row))
+		for j, value := range row {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			if j < len(headers) && headers[j] != "" {
+				lines = append(lines, fmt.Sprintf("%s: %s", strings.TrimSpace(headers[j]), value))
+			} else {
+				lines = append(lines, value)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		docs = append(docs, newParsedDoc(option, strings.Join(lines, "\n"), map[string]any{common.RowNum: i + 1}))
+	}
+	return docs, nil
+}
+
+func (x *jsonParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	data, err := io.ReadAll(skipBOM(reader))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("invalid json")
+	}
+	if data[0] != '[' {
+		return []*schema.Document{newParsedDoc(option, string(data), nil)}, nil
+	}
+	var items []json.RawMessage
+	if err = json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse json failed: %w", err)
+	}
+	docs := make([]*schema.Document, 0, len(items))
+	for i, item := range items {
+		docs = append(docs, newParsedDoc(option, string(item), map[string]any{common.RowNum: i + 1}))
+	}
+	return docs, nil
+}
+
+func (x *jsonlParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	option := parser.GetCommonOptions(&parser.Options{}, opts...)
+	scanner := bufio.NewScanner(skipBOM(reader))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var docs []*schema.Document
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, fmt.Errorf("invalid json at line %d", n)
+		}
+		docs = append(docs, newParsedDoc(option, string(line), map[string]any{common.RowNum: n}))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// skipBOM 去掉 Excel 等工具导出文件开头的 UTF-8 BOM
+func skipBOM(reader io.Reader) io.Reader {
+	br := bufio.NewReader(reader)
+	if r, _, err := br.ReadRune(); err != nil || r != '\uFEFF' {
+		_ = br.UnreadRune()
+	}
+	return br
+}
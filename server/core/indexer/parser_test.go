@@ -0,0 +1,144 @@
+package indexer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+func zipFiles(t *testing.T, files map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func parse(t *testing.T, p parser.Parser, reader *bytes.Reader) []*schema.Document {
+	docs, err := p.Parse(context.Background(), reader, parser.WithExtraMeta(map[string]any{"_file_name": "test"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		if doc.MetaData["_file_name"] != "test" {
+			t.Fatalf("file meta missing: %+v", doc.MetaData)
+		}
+	}
+	return docs
+}
+
+func TestDocxParser(t *testing.T) {
+	body := `<w:document xmlns:w="w"><w:body>
+<w:p><w:r><w:t>前言</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>安装</w:t></w:r></w:p>
+<w:p><w:r><w:t>下载安装包</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="2"/></w:pPr><w:r><w:t>配置</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>key</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>value</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>使用</w:t></w:r></w:p>
+</w:body></w:document>`
+	docs := parse(t, &docxParser{}, zipFiles(t, map[string]string{"word/document.xml": body}))
+	if len(docs) != 4 {
+		t.Fatalf("expect 4 sections, got %d", len(docs))
+	}
+	if docs[2].MetaData[common.HeadingPath] != "安装 > 配置" || docs[2].MetaData[common.Title1] != "安装" {
+		t.Fatalf("unexpected meta %+v", docs[2].MetaData)
+	}
+	if !strings.Contains(docs[2].Content, "key | value") {
+		t.Fatalf("table row missing: %q", docs[2].Content)
+	}
+	if docs[3].MetaData[common.HeadingPath] != "使用" {
+		t.Fatalf("unexpected meta %+v", docs[3].MetaData)
+	}
+}
+
+func TestPptxParser(t *testing.T) {
+	slide := func(title, text string) string {
+		return `<p:sld xmlns:p="p" xmlns:a="a"><p:cSld><p:spTree>
+<p:sp><p:nvSpPr><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>` + title + `</a:t></a:r></a:p></p:txBody></p:sp>
+<p:sp><p:txBody><a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp>
+</p:spTree></p:cSld></p:sld>`
+	}
+	docs := parse(t, &pptxParser{}, zipFiles(t, map[string]string{
+		"ppt/slides/slide10.xml": slide("结尾", "谢谢"),
+		"ppt/slides/slide2.xml":  slide("目录", "第一章"),
+	}))
+	if len(docs) != 2 {
+		t.Fatalf("expect 2 slides, got %d", len(docs))
+	}
+	if docs[0].MetaData[common.SlideNum] != 1 || docs[0].MetaData[common.Title1] != "目录" || docs[0].Content != "目录\n第一章" {
+		t.Fatalf("unexpected slide %+v", docs[0])
+	}
+}
+
+func TestCSVParser(t *testing.T) {
+	docs := parse(t, &csvParser{comma: ','}, bytes.NewReader([]byte("\uFEFFname,age\nAlice,30\n,\nBob,\n")))
+	if len(docs) != 2 {
+		t.Fatalf("expect 2 rows, got %d", len(docs))
+	}
+	if docs[0].Content != "name: Alice\nage: 30" || docs[1].MetaData[common.RowNum] != 3 {
+		t.Fatalf("unexpected rows %+v %+v", docs[0], docs[1])
+	}
+}
+
+func TestJSONParser(t *testing.T) {
+	docs := parse(t, &jsonParser{}, bytes.NewReader([]byte(`[{"a":1},{"b":2}]`)))
+	if len(docs) != 2 || docs[1].Content != `{"b":2}` || docs[1].MetaData[common.RowNum] != 2 {
+		t.Fatalf("unexpected docs %+v", docs)
+	}
+	docs = parse(t, &jsonlParser{}, bytes.NewReader([]byte("{\"a\":1}\n\n{\"b\":2}\n")))
+	if len(docs) != 2 || docs[1].MetaData[common.RowNum] != 3 {
+		t.Fatalf("unexpected docs %+v", docs)
+	}
+	if _, err := (&jsonlParser{}).Parse(context.Background(), strings.NewReader("{")); err == nil {
+		t.Fatal("expect error for invalid json line")
+	}
+}
+
+func TestCodeParser(t *testing.T) {
+	src := `package demo
+
+import "fmt"
+
+// Greeter 打招呼
+type Greeter struct{}
+
+// Hello 打招呼
+func (g *Greeter) Hello() { fmt.Println("hi") }
+
+func main() {}
+`
+	docs := parse(t, &codeParser{ext: ".go"}, bytes.NewReader([]byte(src)))
+	var symbols []any
+	for _, doc := range docs {
+		symbols = append(symbols, doc.MetaData[common.CodeSymbol])
+	}
+	if len(docs) != 4 || symbols[1] != "Greeter" || symbols[2] != "Greeter.Hello" || symbols[3] != "main" {
+		t.Fatalf("unexpected symbols %v", symbols)
+	}
+	if !strings.HasPrefix(docs[2].Content, "// Hello") {
+		t.Fatalf("doc comment not kept: %q", docs[2].Content)
+	}
+
+	py := "import os\n\n@cache\ndef load():\n    pass\n\nclass Store:\n    def get(self):\n        pass\n"
+	docs = parse(t, &codeParser{ext: ".py"}, bytes.NewReader([]byte(py)))
+	if len(docs) != 3 || docs[1].MetaData[common.CodeSymbol] != "load" || !strings.HasPrefix(docs[1].Content, "@cache") ||
+		docs[2].MetaData[common.CodeSymbol] != "Store" {
+		t.Fatalf("unexpected python docs %+v", docs)
+	}
+}
@@ -0,0 +1,83 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+)
+
+// 切分方式
+const (
+	SplitterAuto      = "auto"      // 按文件类型选择：Markdown 按标题切分，其余递归切分
+	SplitterRecursive = "recursive" // 按分隔符递归切分
+	SplitterMarkdown  = "markdown"  // 按 Markdown 标题切分
+	SplitterToken     = "token"     // 按 token 数递归切分，长度受 embedding 模型输入上限约束
+	SplitterSemantic  = "semantic"  // 在相邻句子向量相似度下降处切分
+)
+
+// 默认切分配置，知识库未单独配置的项使用这些值
+const (
+	defaultChunkSize    = 1000 // 每段内容最多 1000 字
+	defaultOverlapSize  = 100  // 段落之间保留 100 字重叠，提高上下文连续性
+	defaultHeaderLevels = 3    // 按 #、##、### 三级标题切分
+	defaultMergeLength  = 512  // Markdown 相邻小段合并后的最大长度
+)
+
+var defaultSeparators = []string{"\n", ".", "?", "!"}
+
+// ChunkProfile 知识库的切分配置，零值字段使用默认值
+type ChunkProfile struct {
+	Splitter     string   // 切分方式，见 Splitter* 常量
+	ChunkSize    int      // 每段的最大长度，token 切分时以 token 为单位
+	OverlapSize  int      // 递归切分时相邻段落的重叠长度，token 切分时以 token 为单位
+	Separators   []string // 递归切分使用的分隔符，按优先级排列
+	HeaderLevels int      // Markdown 按几级标题切分（1-6）
+	MergeLength  int      // Markdown 相邻小段合并后的最大长度
+}
+
+type chunkProfileCtxKey struct{}
+
+// WithChunkProfile 把知识库的切分配置放入 ctx，索引流程中的切分与合并节点据此执行
+func WithChunkProfile(ctx context.Context, p *ChunkProfile) context.Context {
+	return context.WithValue(ctx, chunkProfileCtxKey{}, p)
+}
+
+// chunkProfileFromContext 获取 ctx 中的切分配置并补齐默认值
+func chunkProfileFromContext(ctx context.Context) *ChunkProfile {
+	p := &ChunkProfile{}
+	if v, ok := ctx.Value(chunkProfileCtxKey{}).(*ChunkProfile); ok && v != nil {
+		*p = *v
+	}
+	if p.Splitter == "" {
+		p.Splitter = SplitterAuto
+	}
+	if p.ChunkSize <= 0 {
+		p.ChunkSize = defaultChunkSize
+	}
+	if p.OverlapSize <= 0 {
+		p.OverlapSize = defaultOverlapSize
+	}
+	if len(p.Separators) == 0 {
+		p.Separators = defaultSeparators
+	}
+	if p.OverlapSize >= p.ChunkSize {
+		p.OverlapSize = p.ChunkSize / 10
+	}
+	if p.HeaderLevels <= 0 || p.HeaderLevels > 6 {
+		p.HeaderLevels = defaultHeaderLevels
+	}
+	if p.MergeLength <= 0 {
+		p.MergeLength = defaultMergeLength
+	}
+	return p
+}
+
+// headers 返回 Markdown 标题前缀到元数据键的映射，如 "##" -> "h2"（与 common.Title1 等一致）
+func (p *ChunkProfile) headers() map[string]string {
+	headers := make(map[string]string, p.HeaderLevels)
+	prefix := ""
+	for level := 1; level <= p.HeaderLevels; level++ {
+		prefix += "#"
+		headers[prefix] = fmt.Sprintf("h%d", level)
+	}
+	return headers
+}
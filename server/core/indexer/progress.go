@@ -0,0 +1,278 @@
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// 索引流程中的节点 key，同时作为进度上报的阶段名称
+const (
+	NodeLoader              = "Loader"
+	NodeDocumentTransformer = "DocumentTransformer"
+	NodeDocAddIDAndMerge    = "DocAddIDAndMerge"
+	NodeIndexer             = "Indexer"
+	NodeQA                  = "QA"
+	StageAsyncIndexer       = "AsyncIndexer" // 异步流程中的 Indexer 节点，与同步流程区分上报
+)
+
+// embeddingBatchSize 两个索引器每批向量化的文本数
+const embeddingBatchSize = 10
+
+// progressWriteInterval 批次级进度（向量化批次、单个 chunk 的 QA）两次持久化之间的最小间隔，
+// 阶段状态变化与全部批次完成时不受限制
+const progressWriteInterval = time.Second
+
+var stageNames = []string{NodeLoader, NodeDocumentTransformer, NodeDocAddIDAndMerge, NodeIndexer, NodeQA, StageAsyncIndexer}
+
+type progressCtxKey struct{}
+
+// Progress 记录一次索引任务在各节点上的执行进度，变化通过 onChange 回调持久化：
+// 阶段状态变化立即写入，批次级进度按 progressWriteInterval 节流，任务结束时需调用 Flush 写入剩余变化。
+// 方法对 nil 接收者安全，未开启进度跟踪时可以直接调用
+type Progress struct {
+	mu        sync.Mutex
+	data      *v1.IndexJobProgress
+	onChange  func(data string)
+	interval  time.Duration
+	lastWrite time.Time
+	dirty     bool
+}
+
+// NewProgress 创建进度跟踪器，data 为上一次执行保存的进度（任务重试时沿用已完成阶段的记录）
+func NewProgress(data *v1.IndexJobProgress, onChange func(data string)) *Progress {
+	if data == nil {
+		data = &v1.IndexJobProgress{}
+	}
+	if len(data.Stages) == 0 {
+		for _, name := range stageNames {
+			data.Stages = append(data.Stages, &v1.IndexStage{Name: name, Status: v1.StagePending})
+		}
+	}
+	return &Progress{data: data, onChange: onChange, interval: progressWriteInterval}
+}
+
+// WithProgress 把进度跟踪器放入 ctx，供节点内部（如 QA 生成）上报细粒度进度
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressCtxKey{}, p)
+}
+
+// ProgressFromContext 获取 ctx 中的进度跟踪器，不存在时返回 nil
+func ProgressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressCtxKey{}).(*Progress)
+	return p
+}
+
+// IndexerOptions 返回 BuildIndexer 流程调用时需要附带的回调选项
+func (p *Progress) IndexerOptions() []compose.Option {
+	if p == nil {
+		return nil
+	}
+	return []compose.Option{
+		compose.WithCallbacks(p.handler(NodeLoader, 0)).DesignateNode(NodeLoader),
+		compose.WithCallbacks(p.handler(NodeDocumentTransformer, 0)).DesignateNode(NodeDocumentTransformer),
+		compose.WithCallbacks(p.handler(NodeDocAddIDAndMerge, 0)).DesignateNode(NodeDocAddIDAndMerge),
+		compose.WithCallbacks(p.handler(NodeIndexer, 1)).DesignateNode(NodeIndexer),
+	}
+}
+
+// AsyncOptions 返回 BuildIndexerAsync 流程调用时需要附带的回调选项
+func (p *Progress) AsyncOptions() []compose.Option {
+	if p == nil {
+		return nil
+	}
+	return []compose.Option{
+		compose.WithCallbacks(p.handler(NodeQA, 0)).DesignateNode(NodeQA),
+		// 异步索引同时向量化 content 和 qa_content 两个字段
+		compose.WithCallbacks(p.handler(StageAsyncIndexer, 2)).DesignateNode(NodeIndexer),
+	}
+}
+
+// handler 构建单个节点的回调，embedFields 表示每个文档需要向量化的字段数，用于估算批次数
+func (p *Progress) handler(stage string, embedFields int) callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if isStageComponent(info) {
+				p.start(stage, countDocs(input), embedFields)
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			switch {
+			case info != nil && info.Component == components.ComponentOfEmbedding:
+				p.embeddingBatchDone(stage)
+			case isStageComponent(info):
+				p.finish(stage, countDocs(output))
+			}
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if isStageComponent(info) {
+				p.fail(stage, err)
+			}
+			return ctx
+		}).
+		Build()
+}
+
+func (p *Progress) start(stage string, input, embedFields int) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		s := p.stage(stage)
+		s.Status = v1.StageRunning
+		s.Input = input
+		s.Output = 0
+		s.Error = ""
+		s.StartedAt = gtime.Now()
+		s.FinishedAt = nil
+		if embedFields > 0 {
+			s.EmbeddingBatches = (input*embedFields + embeddingBatchSize - 1) / embeddingBatchSize
+			s.EmbeddingBatchesDone = 0
+			s.EmbeddingBatchesRemaining = s.EmbeddingBatches
+		}
+		return true
+	})
+}
+
+func (p *Progress) finish(stage string, output int) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		s := p.stage(stage)
+		s.Status = v1.StageDone
+		s.Output = output
+		s.FinishedAt = gtime.Now()
+		if stage == NodeDocAddIDAndMerge {
+			d.Chunks = output
+		}
+		return true
+	})
+}
+
+func (p *Progress) fail(stage string, err error) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		s := p.stage(stage)
+		s.Status = v1.StageFailed
+		s.Error = err.Error()
+		s.FinishedAt = gtime.Now()
+		return true
+	})
+}
+
+func (p *Progress) embeddingBatchDone(stage string) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		s := p.stage(stage)
+		s.EmbeddingBatchesDone++
+		s.EmbeddingBatchesRemaining = max(s.EmbeddingBatches-s.EmbeddingBatchesDone, 0)
+		return s.EmbeddingBatchesRemaining == 0
+	})
+}
+
+// qaStart 开始为 total 个 chunk 生成 QA
+func (p *Progress) qaStart(total int) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		d.QATotal = total
+		d.QADone = 0
+		d.QAFailed = 0
+		return true
+	})
+}
+
+// qaFinish 单个 chunk 的 QA 生成结束
+func (p *Progress) qaFinish(err error) {
+	p.update(func(d *v1.IndexJobProgress) bool {
+		if err != nil {
+			d.QAFailed++
+		} else {
+			d.QADone++
+		}
+		return d.QADone+d.QAFailed >= d.QATotal
+	})
+}
+
+// update 在锁内修改进度并持久化，保证写入顺序与修改顺序一致。
+// fn 返回 true 表示需要立即写入，否则距上次写入不足 interval 时只标记待写入
+func (p *Progress) update(fn func(d *v1.IndexJobProgress) bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dirty = true
+	if fn(p.data) || time.Since(p.lastWrite) >= p.interval {
+		p.write()
+	}
+}
+
+// Flush 写入被节流的剩余进度，任务结束时调用
+func (p *Progress) Flush() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dirty {
+		p.write()
+	}
+}
+
+func (p *Progress) write() {
+	p.dirty = false
+	p.lastWrite = time.Now()
+	if p.onChange != nil {
+		data, _ := sonic.MarshalString(p.data)
+		p.onChange(data)
+	}
+}
+
+func (p *Progress) stage(name string) *v1.IndexStage {
+	for _, s := range p.data.Stages {
+		if s.Name == name {
+			return s
+		}
+	}
+	s := &v1.IndexStage{Name: name, Status: v1.StagePending}
+	p.data.Stages = append(p.data.Stages, s)
+	return s
+}
+
+func isStageComponent(info *callbacks.RunInfo) bool {
+	if info == nil {
+		return false
+	}
+	switch info.Component {
+	case components.ComponentOfLoader, components.ComponentOfTransformer,
+		components.ComponentOfIndexer, compose.ComponentOfLambda:
+		return true
+	}
+	return false
+}
+
+func countDocs(v any) int {
+	switch t := v.(type) {
+	case []*schema.Document:
+		return len(t)
+	case []string:
+		return len(t)
+	case document.Source, *document.LoaderCallbackInput:
+		return 1
+	case *document.LoaderCallbackOutput:
+		return len(t.Docs)
+	case *document.TransformerCallbackInput:
+		return len(t.Input)
+	case *document.TransformerCallbackOutput:
+		return len(t.Output)
+	case *indexer.CallbackInput:
+		return len(t.Docs)
+	case *indexer.CallbackOutput:
+		return len(t.IDs)
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package indexer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProgressThrottle(t *testing.T) {
+	writes := 0
+	p := NewProgress(nil, func(string) { writes++ })
+	p.interval = time.Hour
+
+	p.start(NodeIndexer, 50, 1)
+	if writes != 1 {
+		t.Fatalf("stage start should be written immediately, got %d writes", writes)
+	}
+	for i := 0; i < 4; i++ {
+		p.embeddingBatchDone(NodeIndexer)
+	}
+	if writes != 1 {
+		t.Fatalf("embedding batches should be throttled, got %d writes", writes)
+	}
+	p.embeddingBatchDone(NodeIndexer)
+	if writes != 2 {
+		t.Fatalf("last embedding batch should be written, got %d writes", writes)
+	}
+
+	p.qaStart(3)
+	p.qaFinish(nil)
+	p.qaFinish(errors.New("timeout"))
+	if writes != 3 {
+		t.Fatalf("qa progress should be throttled, got %d writes", writes)
+	}
+	p.Flush()
+	if writes != 4 {
+		t.Fatalf("flush should write pending progress, got %d writes", writes)
+	}
+	p.Flush()
+	if writes != 4 {
+		t.Fatalf("flush without changes should not write, got %d writes", writes)
+	}
+	p.qaFinish(nil)
+	if writes != 5 || p.data.QADone != 2 || p.data.QAFailed != 1 {
+		t.Fatalf("last qa chunk should be written, got %d writes, progress %+v", writes, p.data)
+	}
+}
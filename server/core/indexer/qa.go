@@ -18,12 +18,15 @@ func qa(ctx context.Context, docs []*schema.Document) (output []*schema.Document
 		err = fmt.Errorf("必须提供知识库名称")
 		return
 	}
+	progress := ProgressFromContext(ctx)
+	progress.qaStart(len(docs))
 	wg := &sync.WaitGroup{}
 	for _, doc := range docs {
 		wg.Add(1)
 		go func(doc *schema.Document) {
 			defer wg.Done()
 			qaContent, e := getQAContent(ctx, doc, knowledgeName)
+			progress.qaFinish(e)
 			if e != nil {
 				g.Log().Errorf(ctx, "getQAContent failed, err=%v", e)
 				return
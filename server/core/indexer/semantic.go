@@ -0,0 +1,151 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// semanticBreakpointPercentile 相邻句子的向量距离高于该百分位时断开
+const semanticBreakpointPercentile = 95
+
+func init() {
+	RegisterSplitter(SplitterSemantic, newSemanticSplitter)
+}
+
+// semanticSplitter 语义分割器：先按句切分并向量化，在相邻句子相似度明显下降处断开，
+// 每段长度不超过 ChunkSize，适合没有明显结构（标题、空行）的长文本
+type semanticSplitter struct {
+	emb    embedding.Embedder
+	maxLen int
+}
+
+func newSemanticSplitter(ctx context.Context, p *ChunkProfile, env *SplitterEnv) (document.Transformer, error) {
+	if env == nil || env.Embedding == nil {
+		return nil, fmt.Errorf("semantic splitter requires an embedding model")
+	}
+	return &semanticSplitter{emb: env.Embedding, maxLen: p.ChunkSize}, nil
+}
+
+func (s *semanticSplitter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	var output []*schema.Document
+	for _, doc := range docs {
+		sentences := splitSentences(doc.Content)
+		chunks := sentences
+		if len(sentences) > 1 {
+			vectors, err := s.embed(ctx, sentences)
+			if err != nil {
+				return nil, err
+			}
+			chunks = groupSentences(sentences, vectors, s.maxLen)
+		}
+		for _, chunk := range chunks {
+			chunk = strings.TrimSpace(chunk)
+			if chunk == "" {
+				continue
+			}
+			output = append(output, &schema.Document{
+				ID:       doc.ID,
+				Content:  chunk,
+				MetaData: maps.Clone(doc.MetaData),
+			})
+		}
+	}
+	return output, nil
+}
+
+// embed 按批向量化句子
+func (s *semanticSplitter) embed(ctx context.Context, sentences []string) ([][]float64, error) {
+	vectors := make([][]float64, 0, len(sentences))
+	for i := 0; i < len(sentences); i += embeddingBatchSize {
+		batch := sentences[i:min(i+embeddingBatchSize, len(sentences))]
+		res, err := s.emb.EmbedStrings(embeddingCtx(ctx, s.emb), batch)
+		if err != nil {
+			return nil, fmt.Errorf("embedding sentences failed, %w", err)
+		}
+		if len(res) != len(batch) {
+			return nil, fmt.Errorf("invalid vector length, expected=%d, got=%d", len(batch), len(res))
+		}
+		vectors = append(vectors, res...)
+	}
+	return vectors, nil
+}
+
+// groupSentences 在相邻句子向量距离超过阈值或长度超过 maxLen 处断开，合并其余句子
+func groupSentences(sentences []string, vectors [][]float64, maxLen int) []string {
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		// Score 返回 cos + 1，距离为 1 - cos
+		distances[i] = 2 - vectorstore.Score(vectorstore.SimilarityCosine, vectors[i], vectors[i+1])
+	}
+	threshold := percentile(distances, semanticBreakpointPercentile)
+
+	var chunks []string
+	current := sentences[0]
+	for i := 1; i < len(sentences); i++ {
+		if distances[i-1] > threshold || len(current)+len(sentences[i]) > maxLen {
+			chunks = append(chunks, current)
+			current = sentences[i]
+			continue
+		}
+		current += sentences[i]
+	}
+	return append(chunks, current)
+}
+
+// percentile 线性插值计算百分位数
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (pos-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// splitSentences 按中英文句末标点与换行切分句子，标点保留在句尾；
+// 英文句号等只有后跟空白或位于结尾时才断开，避免拆开小数和缩写
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		end := false
+		switch r {
+		case '\n', '。', '！', '？', '；':
+			end = true
+		case '.', '!', '?', ';':
+			end = i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+		}
+		if !end {
+			continue
+		}
+		sentence := string(runes[start : i+1])
+		start = i + 1
+		// 只有空白的片段并入上一句
+		if strings.TrimSpace(sentence) == "" && len(sentences) > 0 {
+			sentences[len(sentences)-1] += sentence
+			continue
+		}
+		sentences = append(sentences, sentence)
+	}
+	if start < len(runes) {
+		rest := string(runes[start:])
+		if strings.TrimSpace(rest) == "" && len(sentences) > 0 {
+			sentences[len(sentences)-1] += rest
+		} else {
+			sentences = append(sentences, rest)
+		}
+	}
+	return sentences
+}
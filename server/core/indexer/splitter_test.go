@@ -0,0 +1,136 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+)
+
+// topicEmbedder 按句子中是否包含 "cat" 返回两个正交的向量，模拟话题切换
+type topicEmbedder struct{}
+
+func (topicEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	res := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		if strings.Contains(text, "cat") {
+			res = append(res, []float64{1, 0})
+		} else {
+			res = append(res, []float64{0, 1})
+		}
+	}
+	return res, nil
+}
+
+// wordTokenizer 每个单词计为一个 token
+type wordTokenizer struct{}
+
+func (wordTokenizer) Count(text string) int { return len(strings.Fields(text)) }
+
+func TestTokenSplitterUsesTokenizer(t *testing.T) {
+	ctx := context.Background()
+	sp, err := newTokenSplitter(ctx, &ChunkProfile{ChunkSize: 4, OverlapSize: 1, Separators: []string{"\n"}},
+		&SplitterEnv{Tokenizer: wordTokenizer{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := sp.Transform(ctx, []*schema.Document{{Content: "a b c\nd e f\ng h i"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每行 3 个 token，按 4 个 token 的上限每行单独成块
+	if len(docs) != 3 {
+		t.Fatalf("expect 3 chunks, got %d", len(docs))
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	sentences := splitSentences("Pi is 3.14. 今天天气很好。\n\nOK")
+	if len(sentences) != 3 {
+		t.Fatalf("expect 3 sentences, got %d: %q", len(sentences), sentences)
+	}
+	if sentences[0] != "Pi is 3.14." {
+		t.Fatalf("unexpected first sentence %q", sentences[0])
+	}
+}
+
+func TestSemanticSplitter(t *testing.T) {
+	ctx := context.Background()
+	trans, err := newSemanticSplitter(ctx, &ChunkProfile{ChunkSize: 1000}, &SplitterEnv{Embedding: topicEmbedder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &schema.Document{
+		ID:       "doc",
+		Content:  "The cat sleeps. The cat eats. Rain falls. Rain stops.",
+		MetaData: map[string]any{"k": "v"},
+	}
+	chunks, err := trans.Transform(ctx, []*schema.Document{doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只在话题切换处断开
+	if len(chunks) != 2 {
+		t.Fatalf("expect 2 chunks, got %d", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[1].Content, "Rain") || chunks[1].MetaData["k"] != "v" {
+		t.Fatalf("unexpected chunk %+v", chunks[1])
+	}
+	if _, err = newSemanticSplitter(ctx, &ChunkProfile{}, &SplitterEnv{}); err == nil {
+		t.Fatal("expect error without embedding model")
+	}
+}
+
+func TestTransformerRouting(t *testing.T) {
+	ctx := WithChunkProfile(context.Background(), &ChunkProfile{ChunkSize: 50})
+	x := &transformer{env: &SplitterEnv{TokenBudget: defaultTokenBudget}}
+	md := &schema.Document{
+		Content:  "# A\nfirst\n# B\nsecond",
+		MetaData: map[string]any{file.MetaKeyExtension: ".md"},
+	}
+	txt := &schema.Document{
+		Content:  "# A\nfirst\n# B\nsecond",
+		MetaData: map[string]any{file.MetaKeyExtension: ".txt"},
+	}
+	output, err := x.Transform(ctx, []*schema.Document{md, txt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Markdown 按标题切成两段，文本文档未超过长度保持一段
+	if len(output) != 3 {
+		t.Fatalf("expect 3 chunks, got %d", len(output))
+	}
+	if output[0].MetaData["h1"] != "A" || output[1].MetaData["h1"] != "B" {
+		t.Fatalf("markdown document not split by headers: %+v", output[:2])
+	}
+}
+
+func TestDocAddIDAndMergeByExtension(t *testing.T) {
+	md := func(source, h1, content string) *schema.Document {
+		return &schema.Document{Content: content, MetaData: map[string]any{
+			file.MetaKeySource: source, file.MetaKeyExtension: ".md", common.Title1: h1,
+		}}
+	}
+	row := &schema.Document{Content: "raw", MetaData: map[string]any{
+		file.MetaKeySource: "b.xlsx", file.MetaKeyExtension: ".xlsx", common.XlsxRow: map[string]any{"name": "x"},
+	}}
+	docs, err := docAddIDAndMerge(context.Background(), []*schema.Document{
+		row, md("a.md", "intro", "one"), md("a.md", "intro", "two"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expect 2 docs, got %d", len(docs))
+	}
+	if docs[0].Content != `{"name":"x"}` {
+		t.Fatalf("xlsx row should be merged as json: %q", docs[0].Content)
+	}
+	if docs[1].Content != "h1:intro \nonetwo" {
+		t.Fatalf("markdown sections should be merged: %q", docs[1].Content)
+	}
+}
@@ -0,0 +1,176 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+const storeIndexerType = "VectorStore"
+
+// FieldValue 写入向量存储的字段值，EmbedKey 不为空时会将 Value 向量化后写入 EmbedKey 字段
+type FieldValue struct {
+	Value    any
+	EmbedKey string
+}
+
+// storeIndexerConfig 向量存储索引器配置
+type storeIndexerConfig struct {
+	Store     vectorstore.VectorStore
+	Index     string
+	BatchSize int // 每批向量化的文本数
+	Dims      int // 索引的向量维度，大于 0 时拒绝写入维度不一致的向量
+	// DocumentToFields 将 schema.Document 转换为待写入的字段
+	DocumentToFields func(ctx context.Context, doc *schema.Document) (map[string]FieldValue, error)
+	Embedding        embedding.Embedder
+}
+
+// storeIndexer 基于 VectorStore 的 eino 索引器组件，按批向量化后写入存储
+type storeIndexer struct {
+	conf *storeIndexerConfig
+}
+
+func newStoreIndexer(conf *storeIndexerConfig) (*storeIndexer, error) {
+	if conf.Store == nil {
+		return nil, fmt.Errorf("vector store not provided")
+	}
+	if conf.DocumentToFields == nil {
+		return nil, fmt.Errorf("DocumentToFields method not provided")
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = embeddingBatchSize
+	}
+	return &storeIndexer{conf: conf}, nil
+}
+
+func (i *storeIndexer) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) (ids []string, err error) {
+	ctx = callbacks.EnsureRunInfo(ctx, i.GetType(), components.ComponentOfIndexer)
+	ctx = callbacks.OnStart(ctx, &indexer.CallbackInput{Docs: docs})
+	defer func() {
+		if err != nil {
+			callbacks.OnError(ctx, err)
+		}
+	}()
+
+	options := indexer.GetCommonOptions(&indexer.Options{
+		Embedding: i.conf.Embedding,
+	}, opts...)
+
+	if err = i.add(ctx, docs, options.Embedding); err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+
+	callbacks.OnEnd(ctx, &indexer.CallbackOutput{IDs: ids})
+	return ids, nil
+}
+
+// add 收集需要向量化的文本，凑满一批后调用 embedding 并写入存储
+func (i *storeIndexer) add(ctx context.Context, docs []*schema.Document, emb embedding.Embedder) error {
+	var (
+		records []*vectorstore.Record
+		targets []target // records 中需要回填向量的位置
+		texts   []string
+	)
+	flush := func() error {
+		if len(texts) > 0 {
+			if emb == nil {
+				return fmt.Errorf("embedding method not provided")
+			}
+			vectors, err := emb.EmbedStrings(embeddingCtx(ctx, emb), texts)
+			if err != nil {
+				return fmt.Errorf("embedding failed, %w", err)
+			}
+			if len(vectors) != len(texts) {
+				return fmt.Errorf("invalid vector length, expected=%d, got=%d", len(texts), len(vectors))
+			}
+			for idx, t := range targets {
+				if i.conf.Dims > 0 && len(vectors[idx]) != i.conf.Dims {
+					return fmt.Errorf("%w, index=%s, expected=%d, got=%d",
+						vectorstore.ErrDimsMismatch, i.conf.Index, i.conf.Dims, len(vectors[idx]))
+				}
+				t.record.Fields[t.field] = vectors[idx]
+			}
+		}
+		if err := i.conf.Store.Upsert(ctx, i.conf.Index, records); err != nil {
+			return err
+		}
+		records, targets, texts = records[:0], targets[:0], texts[:0]
+		return nil
+	}
+
+	for _, doc := range docs {
+		fields, err := i.conf.DocumentToFields(ctx, doc)
+		if err != nil {
+			return fmt.Errorf("FieldMapping failed, %w", err)
+		}
+		embSize := 0
+		for _, v := range fields {
+			if v.EmbedKey != "" {
+				embSize++
+			}
+		}
+		if embSize > i.conf.BatchSize {
+			return fmt.Errorf("needEmbeddingFields length over batch size, batch size=%d, got size=%d", i.conf.BatchSize, embSize)
+		}
+		if len(texts)+embSize > i.conf.BatchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+		record := &vectorstore.Record{ID: doc.ID, Fields: make(map[string]any, len(fields)+embSize)}
+		for k, v := range fields {
+			record.Fields[k] = v.Value
+			if v.EmbedKey == "" {
+				continue
+			}
+			if _, found := fields[v.EmbedKey]; found {
+				return fmt.Errorf("duplicate key for origin key, key=%s", k)
+			}
+			text, ok := v.Value.(string)
+			if !ok {
+				return fmt.Errorf("assert value as string failed, key=%s, emb_key=%s", k, v.EmbedKey)
+			}
+			targets = append(targets, target{record: record, field: v.EmbedKey})
+			texts = append(texts, text)
+		}
+		records = append(records, record)
+	}
+	if len(records) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+type target struct {
+	record *vectorstore.Record
+	field  string
+}
+
+// embeddingCtx 为 embedding 调用设置独立的 RunInfo，进度跟踪依赖它统计向量化批次
+func embeddingCtx(ctx context.Context, emb embedding.Embedder) context.Context {
+	runInfo := &callbacks.RunInfo{
+		Component: components.ComponentOfEmbedding,
+	}
+	if embType, ok := components.GetType(emb); ok {
+		runInfo.Type = embType
+	}
+	runInfo.Name = runInfo.Type + string(runInfo.Component)
+	return callbacks.ReuseHandlers(ctx, runInfo)
+}
+
+func (i *storeIndexer) GetType() string {
+	return storeIndexerType
+}
+
+func (i *storeIndexer) IsCallbacksEnabled() bool {
+	return true
+}
@@ -0,0 +1,51 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive"
+	"github.com/cloudwego/eino/components/document"
+)
+
+// defaultTokenBudget 未登记模型的单次输入 token 上限
+const defaultTokenBudget = 8192
+
+// embeddingTokenBudgets 常用 embedding 模型单次输入的 token 上限
+var embeddingTokenBudgets = map[string]int{
+	"BAAI/bge-m3":            8192,
+	"BAAI/bge-large-zh-v1.5": 512,
+	"BAAI/bge-large-en-v1.5": 512,
+	"text-embedding-3-large": 8191,
+	"text-embedding-3-small": 8191,
+	"text-embedding-ada-002": 8191,
+}
+
+func init() {
+	RegisterSplitter(SplitterToken, newTokenSplitter)
+}
+
+func embeddingTokenBudget(model string) int {
+	if budget, ok := embeddingTokenBudgets[model]; ok {
+		return budget
+	}
+	return defaultTokenBudget
+}
+
+// newTokenSplitter 按 token 数切分的递归分割器：ChunkSize 与 OverlapSize 以 token 为单位（按 embedding 模型计数），
+// 且不超过 embedding 模型的输入上限，避免超长 chunk 在向量化时被截断
+func newTokenSplitter(ctx context.Context, p *ChunkProfile, env *SplitterEnv) (document.Transformer, error) {
+	size := p.ChunkSize
+	if env != nil && env.TokenBudget > 0 && size > env.TokenBudget {
+		size = env.TokenBudget
+	}
+	overlap := p.OverlapSize
+	if overlap >= size {
+		overlap = size / 10
+	}
+	return recursive.NewSplitter(ctx, &recursive.Config{
+		ChunkSize:   size,
+		OverlapSize: overlap,
+		Separators:  p.Separators,
+		LenFunc:     env.tokenizer().Count,
+	})
+}
@@ -2,74 +2,115 @@ package indexer
 
 import (
 	"context"
-	"github.com/everfid-ever/ThinkForge/core/common"
+	"fmt"
+	"sync"
 
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
 	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/markdown"
 	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive"
 	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/config"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+)
+
+// SplitterEnv 创建分割器时可用的模型资源
+type SplitterEnv struct {
+	Embedding   embedding.Embedder  // 知识库使用的 embedding 模型，语义切分用它计算句子向量
+	TokenBudget int                 // embedding 模型单次输入的 token 上限
+	Tokenizer   tokenizer.Tokenizer // embedding 模型的 token 计数器，为空时按默认方式估算
+}
+
+func (e *SplitterEnv) tokenizer() tokenizer.Tokenizer {
+	if e == nil || e.Tokenizer == nil {
+		return tokenizer.For("")
+	}
+	return e.Tokenizer
+}
+
+// SplitterFactory 按切分配置创建分割器
+type SplitterFactory func(ctx context.Context, p *ChunkProfile, env *SplitterEnv) (document.Transformer, error)
+
+var (
+	splitterMu        sync.RWMutex
+	splitterFactories = map[string]SplitterFactory{}
+
+	// extSplitters 切分方式为 auto 时按文件类型选择的分割器，未列出的类型使用递归切分
+	extSplitters = map[string]string{
+		".md": SplitterMarkdown,
+	}
 )
 
+// RegisterSplitter 注册分割器，知识库切分配置中的 splitter 与 name 对应
+func RegisterSplitter(name string, factory SplitterFactory) {
+	splitterMu.Lock()
+	defer splitterMu.Unlock()
+	splitterFactories[name] = factory
+}
+
+func getSplitterFactory(name string) (SplitterFactory, bool) {
+	splitterMu.RLock()
+	defer splitterMu.RUnlock()
+	factory, ok := splitterFactories[name]
+	return factory, ok
+}
+
+func init() {
+	RegisterSplitter(SplitterRecursive, newRecursiveSplitter)
+	RegisterSplitter(SplitterMarkdown, newMarkdownSplitter)
+}
+
+// newRecursiveSplitter 递归分割器：将长文档按语义和标点递归拆分，便于后续 Embedding 处理
+func newRecursiveSplitter(ctx context.Context, p *ChunkProfile, env *SplitterEnv) (document.Transformer, error) {
+	return recursive.NewSplitter(ctx, &recursive.Config{
+		ChunkSize:   p.ChunkSize,
+		OverlapSize: p.OverlapSize,
+		Separators:  p.Separators,
+	})
+}
+
+// newMarkdownSplitter Markdown 分割器：按标题层级（#，##，### ...）切割文档，保留信息结构
+func newMarkdownSplitter(ctx context.Context, p *ChunkProfile, env *SplitterEnv) (document.Transformer, error) {
+	return markdown.NewHeaderSplitter(ctx, &markdown.HeaderConfig{
+		Headers:     p.headers(),
+		TrimHeaders: false, //保留标题文本
+	})
+}
+
 // transformer 是一个复合型文档 Transformer，
-// 根据文档类型自动选择对应的分割方式。
-//   - Markdown 文档使用 markdown.Splitter
-//   - 普通文本使用 recursive.Splitter
+// 逐个文档按知识库的切分配置或文件类型选择分割器：
+//   - 切分配置指定了 splitter 时所有文档都使用该分割器；
+//   - 否则按文件类型选择，Markdown 文档使用 markdown 分割器，其余使用 recursive 分割器。
 type transformer struct {
-	markdown  document.Transformer // 处理 Markdown 文档
-	recursive document.Transformer // 处理普通文本
+	env *SplitterEnv
 }
 
 // newDocumentTransformer 初始化文档分割器（Transformer）组件。
 // 该函数作为 RAG 图节点 “DocumentTransformer3” 的初始化逻辑，
-// 负责根据文档类型（普通文本 / Markdown）选择不同的分割策略。
-//
-// 功能概述：
-//   - 对普通文本使用递归分割器（Recursive Splitter），将长文档分割为小段；
-//   - 对 Markdown 文档使用标题分割器（Markdown Header Splitter），
-//     根据标题层级智能拆分段落内容。
+// 分割器在每次执行时按 ctx 中的切分配置（见 WithChunkProfile）创建。
 //
 // 参数：
 //   - ctx: 上下文，用于控制超时和取消。
+//   - conf: 知识库的 embedding 配置，语义切分与 token 切分依赖它。
 //
 // 返回：
 //   - document.Transformer: 可自动选择合适分割策略的 Transformer 实例。
 //   - error: 初始化过程中出现的错误。
-func newDocumentTransformer(ctx context.Context) (tfr document.Transformer, err error) {
-	trans := &transformer{}
-
-	// 配置递归分割器
-	// 将长文档语义和标点递归拆分，便于后续 Embedding 处理
-	config := &recursive.Config{
-		ChunkSize:   1000, // 每段内容最多 1000 字
-		OverlapSize: 100,  // 段落之间保留 100 字重叠，提高上下文连续性
-		Separators:  []string{"\n", ".", "?", "!"},
-	}
-	recTrans, err := recursive.NewSplitter(ctx, config)
-	if err != nil {
-		return nil, err
-	}
-
-	// 配置 Markdown 分割器
-	// 按标题层级（#，##，###）切割文档，保留信息结构
-	mdTrans, err := markdown.NewHeaderSplitter(ctx, &markdown.HeaderConfig{
-		Headers:     map[string]string{"#": common.Title1, "##": common.Title2, "###": common.Title3},
-		TrimHeaders: false, //保留标题文本
-	})
+func newDocumentTransformer(ctx context.Context, conf *config.Config) (tfr document.Transformer, err error) {
+	emb, err := common.NewEmbedding(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
-
-	// 将两种分割器绑定到自定义 transformer
-	trans.recursive = mdTrans
-	trans.markdown = recTrans
-
-	return trans, nil
+	return &transformer{env: &SplitterEnv{
+		Embedding:   emb,
+		TokenBudget: embeddingTokenBudget(conf.EmbeddingModel),
+		Tokenizer:   tokenizer.For(conf.EmbeddingModel),
+	}}, nil
 }
 
-// Transform 对输入文档执行分割操作。
-// 根据文档的扩展名（_extension）判断使用哪种分割策略：
-//   - 若是 `.md` 文件，使用 Markdown 分割；
-//   - 否则使用递归文本分割。
+// Transform 对输入文档执行分割操作，每个文档单独选择分割器，输出保持输入顺序。
 //
 // 参数：
 //   - ctx: 上下文，用于控制超时或取消；
@@ -80,19 +121,41 @@ func newDocumentTransformer(ctx context.Context) (tfr document.Transformer, err
 //   - []*schema.Document: 分割后的文档列表；
 //   - error: 执行过程中产生的错误。
 func (x *transformer) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
-	isMd := false
-
-	// 检查文档是否为 Markdown，仅需要检测第一个文档
+	p := chunkProfileFromContext(ctx)
+	splitters := map[string]document.Transformer{}
+	output := make([]*schema.Document, 0, len(docs))
 	for _, doc := range docs {
-		if doc.MetaData["_extension"] == ".md" {
-			isMd = true
-			break
+		name := splitterName(p, doc)
+		trans, ok := splitters[name]
+		if !ok {
+			factory, found := getSplitterFactory(name)
+			if !found {
+				return nil, fmt.Errorf("unknown splitter: %s", name)
+			}
+			var err error
+			if trans, err = factory(ctx, p, x.env); err != nil {
+				return nil, fmt.Errorf("create splitter %s failed: %w", name, err)
+			}
+			splitters[name] = trans
+		}
+		res, err := trans.Transform(ctx, []*schema.Document{doc}, opts...)
+		if err != nil {
+			return nil, err
 		}
+		output = append(output, res...)
 	}
+	return output, nil
+}
 
-	// 根据类型选择对应的分割器执行 Transformer
-	if isMd {
-		return x.markdown.Transform(ctx, docs, opts...)
+// splitterName 返回文档使用的分割器
+func splitterName(p *ChunkProfile, doc *schema.Document) string {
+	if p.Splitter != SplitterAuto {
+		return p.Splitter
+	}
+	if ext, ok := doc.MetaData[file.MetaKeyExtension].(string); ok {
+		if name, found := extSplitters[ext]; found {
+			return name
+		}
 	}
-	return x.recursive.Transform(ctx, docs, opts...)
+	return SplitterRecursive
 }
@@ -0,0 +1,230 @@
+// Package memory 对话记忆：在模型的上下文预算内装入参考文档与历史消息。
+// 近期消息原样保留，超出预算的较早消息由 LLM 折叠进滚动摘要，摘要随会话保存（见 State）
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 默认预算
+const (
+	DefaultContextWindow    = 32768
+	DefaultReservedOutput   = 2048
+	DefaultHistoryRatio     = 0.4
+	DefaultKeepRatio        = 0.5
+	DefaultSummaryMaxTokens = 512
+)
+
+// Message 会话中的一条历史消息，ID 随写入顺序递增
+type Message struct {
+	ID uint64
+	*schema.Message
+}
+
+// State 会话的记忆，以 JSON 随会话保存
+type State struct {
+	Summary         string `json:"summary"`          // 较早对话的滚动摘要
+	SummarizedUntil uint64 `json:"summarized_until"` // 已并入摘要的最后一条消息的 ID
+	UpdatedAt       int64  `json:"updated_at"`       // 摘要更新时间，Unix 秒
+}
+
+// Config 记忆管理的配置，零值字段使用默认值
+type Config struct {
+	Model            model.BaseChatModel // 生成摘要的模型
+	Tokenizer        tokenizer.Tokenizer // 对话模型的 token 计数器，见 tokenizer.For
+	ContextWindow    int                 // 对话模型的上下文长度
+	ReservedOutput   int                 // 预留给回答的 token 数
+	HistoryRatio     float64             // 摘要与历史消息最多占可用预算的比例，其余留给参考文档
+	KeepRatio        float64             // 折叠后近期消息占历史预算的比例，留出余量，避免每一轮都要折叠
+	SummaryMaxTokens int                 // 摘要的目标长度
+}
+
+// Input 一次生成的上下文
+type Input struct {
+	State   *State             // 会话当前的记忆，可为 nil
+	History []*Message         // 按时间顺序的历史消息，已并入摘要的消息会被忽略
+	Docs    []*schema.Document // 检索到的参考文档，按相关性排序
+	Fixed   []*schema.Message  // 必须完整保留的部分：系统提示与当前问题
+}
+
+// Usage 各部分占用的 token 数
+type Usage struct {
+	Budget      int `json:"budget"` // 上下文长度减去预留给回答的部分
+	Fixed       int `json:"fixed"`
+	Summary     int `json:"summary"`
+	History     int `json:"history"`
+	Docs        int `json:"docs"`
+	Folded      int `json:"folded"`       // 本次并入摘要的消息数
+	DroppedMsgs int `json:"dropped_msgs"` // 未能装入的历史消息数
+	DroppedDocs int `json:"dropped_docs"` // 未能装入的参考文档数
+}
+
+// Context 装入预算后的上下文
+type Context struct {
+	History []*schema.Message  // 摘要（有时）作为一条系统消息在前，之后是保留的近期消息
+	Docs    []*schema.Document // 保留的参考文档，是输入的前缀，引用编号不变；最后一个可能被截断
+	State   *State             // 更新后的记忆
+	Changed bool               // State 有更新，需要保存
+	Usage   *Usage
+}
+
+type Manager struct {
+	cfg Config
+}
+
+func NewManager(cfg *Config) *Manager {
+	c := *cfg
+	if c.Tokenizer == nil {
+		c.Tokenizer = tokenizer.For("")
+	}
+	if c.ContextWindow <= 0 {
+		c.ContextWindow = DefaultContextWindow
+	}
+	if c.ReservedOutput <= 0 {
+		c.ReservedOutput = DefaultReservedOutput
+	}
+	if c.HistoryRatio <= 0 || c.HistoryRatio > 1 {
+		c.HistoryRatio = DefaultHistoryRatio
+	}
+	if c.KeepRatio <= 0 || c.KeepRatio > 1 {
+		c.KeepRatio = DefaultKeepRatio
+	}
+	if c.SummaryMaxTokens <= 0 {
+		c.SummaryMaxTokens = DefaultSummaryMaxTokens
+	}
+	return &Manager{cfg: c}
+}
+
+// Tokenizer 返回使用的 token 计数器
+func (x *Manager) Tokenizer() tokenizer.Tokenizer {
+	return x.cfg.Tokenizer
+}
+
+// Prepare 把历史消息与参考文档装入预算：
+//  1. 固定部分之外的预算按 HistoryRatio 划出历史预算；
+//  2. 摘要与未折叠的消息超出历史预算时，把较早的消息连同原摘要交给 LLM 生成新摘要，只保留 KeepRatio 以内的近期消息；
+//     摘要失败时保留原摘要，较早的消息本次不放入上下文，下次再尝试折叠；
+//  3. 历史实际占用之外的预算全部留给参考文档，按顺序装入，装不下的第一个截断，其后的丢弃
+func (x *Manager) Prepare(ctx context.Context, in *Input) *Context {
+	t := x.cfg.Tokenizer
+	usage := &Usage{
+		Budget: x.cfg.ContextWindow - x.cfg.ReservedOutput,
+		Fixed:  CountMessages(t, in.Fixed),
+	}
+	available := max(0, usage.Budget-usage.Fixed)
+	historyBudget := int(float64(available) * x.cfg.HistoryRatio)
+
+	res := &Context{State: &State{}, Usage: usage}
+	if in.State != nil {
+		*res.State = *in.State
+	}
+	pending := make([]*Message, 0, len(in.History))
+	for _, m := range in.History {
+		if m.ID > res.State.SummarizedUntil {
+			pending = append(pending, m)
+		}
+	}
+
+	if x.summaryTokens(res.State.Summary)+countHistory(t, pending) > historyBudget {
+		keep := recentStart(t, pending, max(0, int(float64(historyBudget)*x.cfg.KeepRatio)-x.cfg.SummaryMaxTokens))
+		if folded := pending[:keep]; len(folded) > 0 {
+			summary, err := x.summarize(ctx, res.State.Summary, folded)
+			if err != nil {
+				g.Log().Warningf(ctx, "fold %d messages into the summary failed, drop them this time, err=%v", len(folded), err)
+				usage.DroppedMsgs += len(folded)
+			} else {
+				res.State = &State{Summary: summary, SummarizedUntil: folded[len(folded)-1].ID, UpdatedAt: time.Now().Unix()}
+				res.Changed = true
+				usage.Folded = len(folded)
+			}
+		}
+		pending = pending[keep:]
+	}
+
+	// 摘要超出预期长度时，从最早的消息开始丢弃；摘要本身放不下时不放入
+	usage.Summary = x.summaryTokens(res.State.Summary)
+	if usage.Summary > historyBudget {
+		usage.Summary = 0
+	}
+	for len(pending) > 0 && usage.Summary+countHistory(t, pending) > historyBudget {
+		pending = pending[1:]
+		usage.DroppedMsgs++
+	}
+	if usage.Summary > 0 {
+		res.History = append(res.History, summaryMessage(res.State.Summary))
+	}
+	for _, m := range pending {
+		res.History = append(res.History, m.Message)
+	}
+	usage.History = countHistory(t, pending)
+
+	res.Docs, usage.Docs = fitDocs(t, in.Docs, available-usage.Summary-usage.History)
+	usage.DroppedDocs = len(in.Docs) - len(res.Docs)
+	return res
+}
+
+func (x *Manager) summaryTokens(summary string) int {
+	if summary == "" {
+		return 0
+	}
+	return CountMessages(x.cfg.Tokenizer, []*schema.Message{summaryMessage(summary)})
+}
+
+// recentStart 返回在 budget 内可以保留的近期消息的起始下标，保留的部分从用户消息开始，保证问答成对
+func recentStart(t tokenizer.Tokenizer, msgs []*Message, budget int) int {
+	start, used := len(msgs), 0
+	for start > 0 {
+		n := t.Count(msgs[start-1].Content) + messageOverhead
+		if used+n > budget {
+			break
+		}
+		used += n
+		start--
+	}
+	for start < len(msgs) && msgs[start].Role != schema.User {
+		start++
+	}
+	return start
+}
+
+func countHistory(t tokenizer.Tokenizer, msgs []*Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += t.Count(m.Content) + messageOverhead
+	}
+	return n
+}
+
+// fitDocs 按顺序装入参考文档，返回保留的文档与占用的 token 数。
+// 装不下时截断该文档并丢弃其后的全部文档，保留的文档是输入的前缀，回答中的引用编号与原列表一致
+func fitDocs(t tokenizer.Tokenizer, docs []*schema.Document, budget int) ([]*schema.Document, int) {
+	res := make([]*schema.Document, 0, len(docs))
+	used := 0
+	for i, doc := range docs {
+		n := t.Count(citation.Format(docs[i : i+1]))
+		if used+n <= budget {
+			res = append(res, doc)
+			used += n
+			continue
+		}
+		// 按剩余预算所占比例截断，截断后仍需估算一次，避免超出
+		content := []rune(doc.Content)
+		for keep := len(content) * (budget - used) / n; keep > 0; keep = keep * 9 / 10 {
+			truncated := &schema.Document{ID: doc.ID, Content: string(content[:keep]) + "…", MetaData: doc.MetaData}
+			if m := t.Count(citation.Format([]*schema.Document{truncated})); used+m <= budget {
+				res = append(res, truncated)
+				used += m
+				break
+			}
+		}
+		break
+	}
+	return res, used
+}
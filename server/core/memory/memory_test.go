@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+)
+
+// fakeChatModel 返回固定的摘要并记录收到的消息
+type fakeChatModel struct {
+	reply string
+	err   error
+	input []*schema.Message
+}
+
+func (f *fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	f.input = input
+	if f.err != nil {
+		return nil, f.err
+	}
+	return schema.AssistantMessage(f.reply, nil), nil
+}
+
+func (f *fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, nil
+}
+
+// turns 生成 n 轮问答，按 CharsPerToken 为 4 估算，每条消息 23 个 token（含固定开销）
+func turns(n int) []*Message {
+	var msgs []*Message
+	for i := 0; i < n; i++ {
+		msgs = append(msgs,
+			&Message{ID: uint64(2*i + 1), Message: schema.UserMessage(fmt.Sprintf("question %d %s", i, strings.Repeat("word ", 16)))},
+			&Message{ID: uint64(2*i + 2), Message: schema.AssistantMessage(fmt.Sprintf("answer %d %s", i, strings.Repeat("word ", 16)), nil)},
+		)
+	}
+	return msgs
+}
+
+func TestPrepareFits(t *testing.T) {
+	cm := &fakeChatModel{reply: "summary"}
+	m := NewManager(&Config{Model: cm, ContextWindow: 4000, ReservedOutput: 1000})
+	docs := []*schema.Document{{ID: "a", Content: "退款需在 7 天内申请"}}
+	res := m.Prepare(context.Background(), &Input{History: turns(3), Docs: docs, Fixed: []*schema.Message{schema.UserMessage("q")}})
+	if res.Changed || cm.input != nil {
+		t.Fatal("expect no summarization within the budget")
+	}
+	if len(res.History) != 6 || len(res.Docs) != 1 || res.Usage.DroppedDocs != 0 {
+		t.Fatalf("unexpected context: %+v", res.Usage)
+	}
+}
+
+func TestPrepareFolds(t *testing.T) {
+	cm := &fakeChatModel{reply: "用户在咨询退款"}
+	// 可用 1000，历史预算 400，折叠后近期消息不超过 400*0.5-50=150，即最近 3 轮
+	m := NewManager(&Config{Model: cm, Tokenizer: &tokenizer.Estimator{CJKPerToken: 1, CharsPerToken: 4}, ContextWindow: 1200, ReservedOutput: 200, SummaryMaxTokens: 50})
+	state := &State{Summary: "old", SummarizedUntil: 2}
+	res := m.Prepare(context.Background(), &Input{State: state, History: turns(10)})
+	if !res.Changed || res.State.Summary != "用户在咨询退款" || res.State.SummarizedUntil != 14 {
+		t.Fatalf("unexpected state: %+v", res.State)
+	}
+	if res.Usage.Folded != 12 || len(res.History) != 7 || res.History[0].Role != schema.System || res.History[1].Role != schema.User {
+		t.Fatalf("unexpected history: %+v", res.Usage)
+	}
+	// 原摘要与待折叠的消息一起交给模型，已并入摘要的消息不再出现
+	prompt := cm.input[1].Content
+	if !strings.Contains(prompt, "old") || !strings.Contains(prompt, "question 1 ") || strings.Contains(prompt, "question 0 ") {
+		t.Fatalf("unexpected prompt: %s", prompt)
+	}
+	if state.Summary != "old" {
+		t.Fatal("expect the input state unchanged")
+	}
+
+	// 摘要失败时保留原摘要，较早的消息本次不放入
+	cm.err = errors.New("boom")
+	res = m.Prepare(context.Background(), &Input{State: state, History: turns(10)})
+	if res.Changed || res.State.Summary != "old" || res.Usage.DroppedMsgs != 12 || len(res.History) != 7 {
+		t.Fatalf("unexpected fallback: %+v", res.Usage)
+	}
+}
+
+func TestPrepareDocs(t *testing.T) {
+	m := NewManager(&Config{ContextWindow: 300, ReservedOutput: 100})
+	docs := []*schema.Document{
+		{ID: "a", Content: strings.Repeat("word ", 100)},
+		{ID: "b", Content: strings.Repeat("word ", 100)},
+		{ID: "c", Content: "short"},
+	}
+	res := m.Prepare(context.Background(), &Input{Docs: docs})
+	// 第二个文档被截断，其后的文档即使放得下也丢弃，保持引用编号
+	if len(res.Docs) != 2 || res.Docs[0] != docs[0] || !strings.HasSuffix(res.Docs[1].Content, "…") || res.Usage.DroppedDocs != 1 {
+		t.Fatalf("unexpected docs: %d, %+v", len(res.Docs), res.Usage)
+	}
+	if res.Usage.Docs > res.Usage.Budget {
+		t.Fatalf("docs exceed the budget: %+v", res.Usage)
+	}
+}
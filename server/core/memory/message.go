@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// summaryPrefix 摘要放入对话历史时的说明
+const summaryPrefix = "Summary of the earlier conversation (older messages are not shown):\n"
+
+var summarizeSystem = "You maintain a running summary of a conversation between a user and an AI assistant.\n" +
+	"1. Merge the previous summary and the new messages into one updated summary.\n" +
+	"2. Keep the facts, names, numbers, decisions, open questions and user preferences that later questions may refer to; drop greetings and repetition.\n" +
+	"3. Write in the same language as the conversation, in plain sentences or a short list.\n" +
+	"4. Keep it under %d tokens.\n" +
+	"Return only the summary without any further explanation."
+
+func summaryMessage(summary string) *schema.Message {
+	return schema.SystemMessage(summaryPrefix + summary)
+}
+
+// summarize 把原摘要与较早的消息合并为新摘要，消息内容可能包含花括号，不使用 FString 模板
+func (x *Manager) summarize(ctx context.Context, summary string, msgs []*Message) (string, error) {
+	if x.cfg.Model == nil {
+		return "", fmt.Errorf("no model to summarize the conversation")
+	}
+	var sb strings.Builder
+	sb.WriteString("Previous summary:\n")
+	if summary == "" {
+		summary = "(none)"
+	}
+	sb.WriteString(summary)
+	sb.WriteString("\n\nNew messages:\n")
+	for _, m := range msgs {
+		fmt.Fprintf(&sb, "%s: %s\n", m.Role, strings.TrimSpace(m.Content))
+	}
+	result, err := x.cfg.Model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(fmt.Sprintf(summarizeSystem, x.cfg.SummaryMaxTokens)),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarize the conversation failed: %w", err)
+	}
+	res := strings.TrimSpace(result.Content)
+	if res == "" {
+		return "", fmt.Errorf("summarize the conversation failed: empty summary")
+	}
+	return res, nil
+}
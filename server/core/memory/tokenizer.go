@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+)
+
+// messageOverhead 每条消息除内容外的固定开销（角色与分隔符）
+const messageOverhead = 4
+
+// CountMessages 计算消息列表的 token 数，含每条消息的固定开销
+func CountMessages(t tokenizer.Tokenizer, messages []*schema.Message) int {
+	n := 0
+	for _, m := range messages {
+		n += t.Count(m.Content) + messageOverhead
+	}
+	return n
+}
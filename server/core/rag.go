@@ -2,20 +2,18 @@ package core
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/compose"
-	"github.com/cloudwego/eino/schema"
-	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/everfid-ever/ThinkForge/core/cache"
 	"github.com/everfid-ever/ThinkForge/core/common"
 	"github.com/everfid-ever/ThinkForge/core/config"
 	"github.com/everfid-ever/ThinkForge/core/grader"
-	"github.com/everfid-ever/ThinkForge/core/indexer"
-	"github.com/everfid-ever/ThinkForge/core/retriever"
+	"github.com/everfid-ever/ThinkForge/core/summarize"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+	"github.com/everfid-ever/ThinkForge/core/verifier"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
@@ -27,120 +25,126 @@ const (
 
 // Rag 是整个 RAG 系统的核心结构体，封装了检索、索引、问答等组件。
 type Rag struct {
-	idxer      compose.Runnable[any, []string]                // 同步索引构建器
-	idxerAsync compose.Runnable[[]*schema.Document, []string] // 异步索引构建器
-	rtrvr      compose.Runnable[string, []*schema.Document]   // 普通文档检索器
-	qaRtrvr    compose.Runnable[string, []*schema.Document]   // 问答专用检索器（针对 QA 向量字段）
-	client     *elasticsearch.Client                          // Elasticsearch 客户端
-	cm         model.BaseChatModel                            // 大语言模型（ChatModel，用于生成答案）
-
-	grader    *grader.Grader // 打分模块暂未启用，开启后会明显降低性能
-	conf      *config.Config // 全局配置
-	rankScore float64        // 排名分数
+	def    *space                  // 默认空间：全局共享索引及配置的 embedding 模型
+	spaces sync.Map                // 知识库独立索引对应的空间，key 见 spaceKey
+	mu     sync.Mutex              // 避免并发构建同一个空间
+	store  vectorstore.VectorStore // 向量存储
+	cm     model.BaseChatModel     // 大语言模型（ChatModel，用于生成答案）
+	cache  *cache.Cache            // 语义缓存，未启用时为 nil
+
+	grader     *grader.Grader        // 检索结果打分，用于纠错检索（CRAG），按请求开启，会增加一次模型调用
+	verifier   *verifier.Verifier    // 回答校验，判断回答中的论断是否被参考资料支持
+	summarizer *summarize.Summarizer // 整篇文档的摘要，用于总结类问题
+	conf       *config.Config        // 全局配置
+	rankScore  float64               // 排名分数
 }
 
 // New 创建并初始化一个 RAG 核心实例。
 // 主要执行：
-//  1. 确保 Elasticsearch 索引存在；
-//  2. 构建索引器与检索器组件；
+//  1. 确保默认索引存在，且向量维度与配置一致；
+//  2. 构建默认索引的索引器与检索器组件；
 //  3. 初始化大语言模型；
+//  4. 按配置 cache.* 初始化语义缓存；
+//  5. 按配置 summarize.* 初始化文档摘要；
 func New(ctx context.Context, conf *config.Config) (*Rag, error) {
 	if len(conf.IndexName) == 0 {
 		return nil, fmt.Errorf("indexName is empty")
 	}
-
-	// ① 如果 ES 索引不存在则自动创建
-	err := common.CreateIndexIfNotExists(ctx, conf.Client, conf.IndexName)
-	if err != nil {
-		return nil, err
+	if conf.Store == nil {
+		return nil, fmt.Errorf("vector store is nil")
 	}
 
-	// ② 构建索引器（同步）
-	buildIndex, err := indexer.BuildIndexer(ctx, conf)
+	// ① 创建默认索引及其索引器、检索器
+	def, err := newSpace(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	// ③ 构建索引器（异步，用于批量导入）
-	buildIndexAsync, err := indexer.BuildIndexerAsync(ctx, conf)
+	// ② 初始化聊天模型（大语言模型，如 OpenAI、Claude、Moonshot 等）
+	cm, err := common.GetChatModel(ctx, conf.GetChatModelConfig())
 	if err != nil {
+		g.Log().Error(ctx, "GetChatModel failed, err=%v", err)
 		return nil, err
 	}
 
-	// ④ 构建普通文档检索器（Retriever）
-	buildRetriever, err := retriever.BuildRetriever(ctx, conf)
+	// ③ 初始化语义缓存，问题向量统一使用默认的 embedding 模型
+	eb, err := common.NewEmbedding(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
-
-	// ⑤ 构建 QA 检索器，检索时使用 QA 向量字段（如 question/answer 嵌入）
-	qaCtx := context.WithValue(ctx, common.RetrieverFieldKey, common.FieldQAContentVector)
-	qaRetriever, err := retriever.BuildRetriever(qaCtx, conf)
+	c, err := cache.New(ctx, eb)
 	if err != nil {
 		return nil, err
 	}
 
-	// ⑥ 初始化聊天模型（大语言模型，如 OpenAI、Claude、Moonshot 等）
-	cm, err := common.GetChatModel(ctx, conf.GetChatModelConfig())
-	if err != nil {
-		g.Log().Error(ctx, "GetChatModel failed, err=%v", err)
-		return nil, err
-	}
+	// ④ 文档摘要使用聊天模型，token 按该模型估算
+	sm := summarize.New(&summarize.Config{
+		Model:         cm,
+		Tokenizer:     tokenizer.For(g.Cfg().MustGet(ctx, "chat.model").String()),
+		BatchTokens:   g.Cfg().MustGet(ctx, "summarize.batchTokens", summarize.DefaultBatchTokens).Int(),
+		SummaryTokens: g.Cfg().MustGet(ctx, "summarize.summaryTokens", summarize.DefaultSummaryTokens).Int(),
+		Concurrency:   g.Cfg().MustGet(ctx, "summarize.concurrency", summarize.DefaultConcurrency).Int(),
+	})
 
-	// ⑦ 返回 RAG 实例
+	// ⑤ 返回 RAG 实例
 	return &Rag{
-		idxer:      buildIndex,
-		idxerAsync: buildIndexAsync,
-		rtrvr:      buildRetriever,
-		qaRtrvr:    qaRetriever,
-		client:     conf.Client,
+		def:        def,
+		store:      conf.Store,
 		cm:         cm,
+		cache:      c,
 		conf:       conf,
-		// grader:  grader.NewGrader(cm), // 暂未启用
+		grader:     grader.NewGrader(cm),
+		verifier:   verifier.NewVerifier(cm),
+		summarizer: sm,
 	}, nil
 }
 
-// GetKnowledgeBaseList 从 Elasticsearch 中获取所有知识库（Knowledge Base）的列表。
-// 通过聚合（Aggregation）方式对文档的 knowledge_name 字段去重汇总。
-func (x *Rag) GetKnowledgeBaseList(ctx context.Context) (list []string, err error) {
-	names := "distinct_knowledge_names"
-
-	// 构建一个 Search Request，只做聚合，不返回文档内容。
-	query := search.NewRequest()
-	query.Size = common.Of(0) // 不返回实际文档，只做统计
-	query.Aggregations = map[string]types.Aggregations{
-		names: {
-			Terms: &types.TermsAggregation{
-				Field: common.Of(common.KnowledgeName), // 按 KnowledgeName 字段分组聚合
-				Size:  common.Of(10000),                // 最多返回 10000 个不同知识库名
-			},
-		},
-	}
+// Cache 返回语义缓存，未启用时为 nil（nil 上的操作都不生效）
+func (x *Rag) Cache() *cache.Cache {
+	return x.cache
+}
 
-	// 调用 Elasticsearch 搜索接口执行查询
-	res, err := search.NewSearchFunc(x.client)().
-		Request(query).
-		Do(ctx)
-	if err != nil {
-		return
-	}
+// Grader 返回检索结果打分模块
+func (x *Rag) Grader() *grader.Grader {
+	return x.grader
+}
 
-	// 若聚合结果为空则直接返回
-	if res.Aggregations == nil {
-		g.Log().Infof(ctx, "No aggregations found")
-		return
-	}
+// Verifier 返回回答校验模块
+func (x *Rag) Verifier() *verifier.Verifier {
+	return x.verifier
+}
+
+// Summarizer 返回文档摘要模块
+func (x *Rag) Summarizer() *summarize.Summarizer {
+	return x.summarizer
+}
 
-	// 解析聚合结果
-	termsAgg, ok := res.Aggregations[names].(*types.StringTermsAggregate)
-	if !ok || termsAgg == nil {
-		err = errors.New("failed to parse terms aggregation")
+// GetKnowledgeBaseList 从向量存储中获取所有知识库（Knowledge Base）的列表。
+// 通过聚合（Aggregation）方式对默认索引及各知识库独立索引中文档的 knowledge_name 字段去重汇总。
+func (x *Rag) GetKnowledgeBaseList(ctx context.Context) (list []string, err error) {
+	indexes, err := x.indexes(ctx)
+	if err != nil {
 		return
 	}
-
-	// 提取每个分桶的 Key（即知识库名称）
-	for _, bucket := range termsAgg.Buckets.([]types.StringTermsBucket) {
-		list = append(list, bucket.Key.(string))
+	// 重建索引后，旧的共享索引中仍保留该知识库的数据，这里需要去重
+	seen := map[string]bool{}
+	for _, index := range indexes {
+		var buckets []*vectorstore.Bucket
+		buckets, err = x.store.Aggregate(ctx, index, &vectorstore.AggregateRequest{
+			Field: common.KnowledgeName, // 按 KnowledgeName 字段分组聚合
+			Size:  10000,                // 最多返回 10000 个不同知识库名
+		})
+		if err != nil {
+			return
+		}
+
+		// 提取每个分桶的 Key（即知识库名称）
+		for _, bucket := range buckets {
+			if !seen[bucket.Key] {
+				seen[bucket.Key] = true
+				list = append(list, bucket.Key)
+			}
+		}
 	}
 	return
 }
@@ -0,0 +1,368 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/schema"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/indexer"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// reindexWaitInterval 重建索引等待知识库中进行中的索引任务时的轮询间隔
+const reindexWaitInterval = 2 * time.Second
+
+// ReindexReq 重建知识库索引的参数，embedding 配置为空时沿用知识库当前的配置。
+// 作为任务参数保存在 knowledge_index_jobs.params 中
+type ReindexReq struct {
+	KnowledgeName  string `json:"knowledge_name"`  // 知识库名称
+	EmbeddingModel string `json:"embedding_model"` // 新的向量模型
+	EmbeddingDims  int    `json:"embedding_dims"`  // 新的向量维度
+	Similarity     string `json:"similarity"`      // 新的向量相似度
+	RegenerateQA   bool   `json:"regenerate_qa"`   // 重新生成 QA 内容；否则沿用旧索引中的 QA 内容，只重新向量化
+}
+
+// ReindexRes 重建索引的结果，保存在 knowledge_index_jobs.result 中，字段与 v1.ReindexResult 一致
+type ReindexRes struct {
+	Alias    string   `json:"alias"`    // 知识库读写使用的别名
+	Index    string   `json:"index"`    // 新的版本化索引
+	Previous []string `json:"previous"` // 切换前使用的索引，保留用于回滚
+	Chunks   int      `json:"chunks"`   // 重新向量化的 chunk 数
+	Deleted  int      `json:"deleted"`  // 重建期间被删除、从新索引中移除的 chunk 数
+}
+
+// EnqueueReindex 登记重建知识库索引的任务，由后台 worker 执行，同一知识库同时只能有一个重建任务
+func (x *Rag) EnqueueReindex(ctx context.Context, req *ReindexReq) (jobId int64, err error) {
+	return x.createReindexJob(ctx, req, v1.JobPending)
+}
+
+// ReindexNow 在当前进程中同步重建索引，供命令行使用。与 EnqueueReindex 一样登记重建任务并受同一限制，
+// 任务以执行中状态创建并保持心跳，不会被后台 worker 领取，结束后记录结果
+func (x *Rag) ReindexNow(ctx context.Context, req *ReindexReq) (res *ReindexRes, err error) {
+	jobId, err := x.createReindexJob(ctx, req, v1.JobRunning)
+	if err != nil {
+		return
+	}
+	job := entity.KnowledgeIndexJobs{Id: jobId}
+	stop := knowledge.KeepIndexJobAlive(ctx, jobId, knowledge.IndexJobLease(ctx)/3)
+	defer stop()
+	res, err = x.Reindex(ctx, req)
+	if err != nil {
+		_ = knowledge.FailIndexJob(ctx, job, err)
+		return
+	}
+	result, err := sonic.MarshalString(res)
+	if err != nil {
+		return
+	}
+	err = knowledge.UpdateIndexJob(ctx, jobId, g.Map{
+		"status":     int(v1.JobSucceeded),
+		"last_error": "",
+		"result":     result,
+	})
+	return
+}
+
+// createReindexJob 校验知识库存在且没有进行中的重建任务后登记任务
+func (x *Rag) createReindexJob(ctx context.Context, req *ReindexReq, status v1.JobStatus) (jobId int64, err error) {
+	_, found, err := knowledge.GetKnowledgeBaseByName(ctx, req.KnowledgeName)
+	if err != nil {
+		return
+	}
+	if !found {
+		return 0, fmt.Errorf("knowledge base %s not found", req.KnowledgeName)
+	}
+	n, err := knowledge.CountActiveIndexJobs(ctx, req.KnowledgeName, v1.JobStageReindex)
+	if err != nil {
+		return
+	}
+	if n > 0 {
+		return 0, fmt.Errorf("knowledge base %s is already being reindexed", req.KnowledgeName)
+	}
+	params, err := sonic.MarshalString(req)
+	if err != nil {
+		return
+	}
+	job := entity.KnowledgeIndexJobs{
+		KnowledgeBaseName: req.KnowledgeName,
+		Stage:             v1.JobStageReindex,
+		Status:            int(status),
+		Params:            params,
+	}
+	if status == v1.JobRunning {
+		job.Attempts = 1
+	}
+	return knowledge.CreateIndexJob(ctx, job)
+}
+
+// runReindexJob 执行重建索引任务，并把结果写回任务
+func (x *Rag) runReindexJob(ctx context.Context, job entity.KnowledgeIndexJobs) error {
+	req := &ReindexReq{}
+	if err := sonic.UnmarshalString(job.Params, req); err != nil {
+		return fmt.Errorf("unmarshal params of job %d failed: %w", job.Id, err)
+	}
+	res, err := x.Reindex(ctx, req)
+	if err != nil {
+		return err
+	}
+	result, err := sonic.MarshalString(res)
+	if err != nil {
+		return err
+	}
+	return knowledge.UpdateIndexJob(ctx, job.Id, g.Map{"result": result})
+}
+
+// Reindex 按 knowledge_chunks 中保存的内容把知识库的所有 chunk 重新向量化写入新的版本化索引，
+// 完成后原子地把知识库的别名切换到新索引并记录新的 embedding 配置。
+// 重建期间旧索引照常提供读写：切换前先等待知识库中进行中的索引任务结束（QA 阶段完成后 chunk 才会写入
+// knowledge_chunks），再补齐期间新增、修改与删除的 chunk；切换前最后一次补齐之后写入旧索引的变更在切换后再补齐一次。
+// 旧索引不会删除，可把别名切回去回滚。
+func (x *Rag) Reindex(ctx context.Context, req *ReindexReq) (res *ReindexRes, err error) {
+	kb, found, err := knowledge.GetKnowledgeBaseByName(ctx, req.KnowledgeName)
+	if err != nil {
+		return
+	}
+	if !found {
+		return nil, fmt.Errorf("knowledge base %s not found", req.KnowledgeName)
+	}
+	// 旧索引只用于读取 QA 内容，即使维度与当前配置不一致也不影响
+	oldIndex := x.def.index()
+	if kb.IndexName != "" {
+		oldIndex = kb.IndexName
+	}
+
+	target := kb
+	if req.EmbeddingModel != "" {
+		target.EmbeddingModel = req.EmbeddingModel
+	}
+	if req.EmbeddingDims > 0 {
+		target.EmbeddingDims = req.EmbeddingDims
+	}
+	if req.Similarity != "" {
+		target.Similarity = req.Similarity
+	}
+	if target.IndexName == "" {
+		// 使用共享索引的历史知识库迁移到独立索引
+		target.IndexName = x.kbAlias(kb)
+	}
+	conf := x.kbConf(target)
+	conf.IndexName = indexVersion(target.IndexName)
+	sp, err := newSpace(ctx, conf)
+	if err != nil {
+		return
+	}
+	g.Log().Infof(ctx, "reindex knowledge base %s into %s", req.KnowledgeName, sp.index())
+
+	res = &ReindexRes{Alias: target.IndexName, Index: sp.index()}
+	written := make(map[string]struct{})
+	start := gtime.Now()
+	if err = x.reindexChunks(ctx, sp, oldIndex, req, nil, written); err != nil {
+		return nil, err
+	}
+	if err = x.waitIndexJobs(ctx, req.KnowledgeName); err != nil {
+		return nil, err
+	}
+	// 补齐重建期间新增、修改与删除的 chunk
+	mark := gtime.Now()
+	if err = x.reindexChunks(ctx, sp, oldIndex, req, start, written); err != nil {
+		return nil, err
+	}
+	if res.Deleted, err = x.removeDeletedChunks(ctx, sp.index(), req.KnowledgeName, written); err != nil {
+		return nil, err
+	}
+	if err = x.store.Refresh(ctx, sp.index()); err != nil {
+		return nil, err
+	}
+
+	if res.Previous, err = x.store.SwitchAlias(ctx, target.IndexName, sp.index()); err != nil {
+		return nil, err
+	}
+	if kb.IndexName == "" {
+		res.Previous = append(res.Previous, oldIndex)
+	}
+	if err = knowledge.SaveKnowledgeBaseIndex(ctx, target); err != nil {
+		return nil, err
+	}
+	if kb.IndexName != "" {
+		x.spaces.Delete(spaceKey(x.kbConf(kb)))
+	}
+	// 最后一次补齐到切换之间的变更写入了旧索引，切换后新的变更直接写入新索引，这里再补齐一次
+	if err = x.reindexChunks(ctx, sp, oldIndex, req, mark, written); err != nil {
+		return nil, err
+	}
+	n, err := x.removeDeletedChunks(ctx, sp.index(), req.KnowledgeName, written)
+	if err != nil {
+		return nil, err
+	}
+	res.Chunks, res.Deleted = len(written), res.Deleted+n
+	// 新索引的 embedding 与切分可能不同，检索结果随之变化
+	x.cache.Invalidate(ctx, req.KnowledgeName)
+	g.Log().Infof(ctx, "reindex knowledge base %s done, alias=%s, index=%s, previous=%v, chunks=%d, deleted=%d",
+		req.KnowledgeName, res.Alias, res.Index, res.Previous, res.Chunks, res.Deleted)
+	return res, nil
+}
+
+// reindexChunks 逐个文档把 chunk 写入新索引，since 不为空时只处理此后新增或修改的 chunk，写入的 chunk id 记录在 written 中
+func (x *Rag) reindexChunks(ctx context.Context, sp *space, oldIndex string, req *ReindexReq, since *gtime.Time, written map[string]struct{}) (err error) {
+	docIds, err := knowledge.GetDocumentIdsByKnowledgeName(ctx, req.KnowledgeName)
+	if err != nil {
+		return
+	}
+	ctx = context.WithValue(ctx, common.KnowledgeName, req.KnowledgeName)
+	for _, docId := range docIds {
+		var chunks []entity.KnowledgeChunks
+		if since == nil {
+			chunks, err = knowledge.GetAllChunksByDocId(ctx, docId)
+		} else {
+			chunks, err = knowledge.GetChunksByDocIdUpdatedSince(ctx, docId, since)
+		}
+		if err != nil {
+			return
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		docs := make([]*schema.Document, 0, len(chunks))
+		for _, chunk := range chunks {
+			docs = append(docs, chunkToDocument(ctx, chunk))
+		}
+		if !req.RegenerateQA {
+			if err = x.fillQAContent(ctx, oldIndex, req.KnowledgeName, docs); err != nil {
+				return
+			}
+		}
+		if _, err = sp.idxerAsync.Invoke(ctx, docs); err != nil {
+			return fmt.Errorf("reindex document %d failed: %w", docId, err)
+		}
+		for _, doc := range docs {
+			written[doc.ID] = struct{}{}
+		}
+	}
+	return
+}
+
+// removeDeletedChunks 从新索引中删除已写入、但在 knowledge_chunks 中已不存在的 chunk（重建期间被删除的 chunk 或文档），
+// 返回删除的数量
+func (x *Rag) removeDeletedChunks(ctx context.Context, index, knowledgeName string, written map[string]struct{}) (int, error) {
+	docIds, err := knowledge.GetDocumentIdsByKnowledgeName(ctx, knowledgeName)
+	if err != nil {
+		return 0, err
+	}
+	chunkIds, err := knowledge.GetChunkIdsByDocIds(ctx, docIds)
+	if err != nil {
+		return 0, err
+	}
+	current := make(map[string]struct{}, len(chunkIds))
+	for _, id := range chunkIds {
+		current[id] = struct{}{}
+	}
+	var stale []string
+	for id := range written {
+		if _, ok := current[id]; !ok {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	if _, err = x.store.DeleteByFilter(ctx, index, vectorstore.NewFilter(vectorstore.In(vectorstore.IDField, stale))); err != nil {
+		return 0, err
+	}
+	for _, id := range stale {
+		delete(written, id)
+	}
+	return len(stale), nil
+}
+
+// waitIndexJobs 等待知识库中进行中（待处理或执行中）的索引与 QA 任务结束，超过 indexJob.reindexWait 时返回错误，
+// 重建任务随后按退避策略重试
+func (x *Rag) waitIndexJobs(ctx context.Context, knowledgeName string) error {
+	timeout := g.Cfg().MustGet(ctx, "indexJob.reindexWait", "30m").Duration()
+	deadline := time.Now().Add(timeout)
+	for {
+		n, err := knowledge.CountActiveIndexJobs(ctx, knowledgeName, v1.JobStageIndex, v1.JobStageQA)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("knowledge base %s still has %d unfinished index jobs after %s", knowledgeName, n, timeout)
+		}
+		g.Log().Debugf(ctx, "reindex knowledge base %s is waiting for %d unfinished index jobs", knowledgeName, n)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reindexWaitInterval):
+		}
+	}
+}
+
+// fillQAContent 从旧索引读取已生成的 QA 内容，QA 节点遇到已有内容时不会再调用模型
+func (x *Rag) fillQAContent(ctx context.Context, index, knowledgeName string, docs []*schema.Document) error {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	records, err := x.store.Query(ctx, index, &vectorstore.QueryRequest{
+		Filter: vectorstore.NewFilter(
+			vectorstore.Eq(common.KnowledgeName, knowledgeName),
+			vectorstore.In(vectorstore.IDField, ids),
+		),
+		Size:     len(ids),
+		Excludes: common.VectorFields,
+	})
+	if err != nil {
+		return err
+	}
+	qaContent := make(map[string]any, len(records))
+	for _, record := range records {
+		if content, ok := record.Fields[common.FieldQAContent].(string); ok && len(content) > 0 {
+			qaContent[record.ID] = content
+		}
+	}
+	for _, doc := range docs {
+		if content, ok := qaContent[doc.ID]; ok {
+			doc.MetaData[common.FieldQAContent] = content
+		}
+	}
+	return nil
+}
+
+// chunkToDocument 按 knowledge_chunks 中的记录还原写入索引的文档，保留元数据与启用状态
+func chunkToDocument(ctx context.Context, chunk entity.KnowledgeChunks) *schema.Document {
+	doc := &schema.Document{
+		ID:      chunk.ChunkId,
+		Content: chunk.Content,
+	}
+	if chunk.Ext != "" {
+		if err := sonic.UnmarshalString(chunk.Ext, &doc.MetaData); err != nil {
+			g.Log().Errorf(ctx, "unmarshal ext of chunk %s failed, err=%v", chunk.ChunkId, err)
+		}
+	}
+	if doc.MetaData == nil {
+		doc.MetaData = map[string]any{}
+	}
+	// 补齐历史 chunk 缺少的过滤字段，上传时间以 chunk 的创建时间近似
+	if _, ok := doc.MetaData[common.FieldDocumentID]; !ok {
+		doc.MetaData[common.FieldDocumentID] = strconv.FormatInt(chunk.KnowledgeDocId, 10)
+	}
+	if _, ok := doc.MetaData[common.FieldUploadedAt]; !ok && chunk.CreatedAt != nil {
+		doc.MetaData[common.FieldUploadedAt] = indexer.FormatUploadedAt(chunk.CreatedAt.Time)
+	}
+	doc.MetaData[common.FieldStatus] = common.ChunkStatusEnabled
+	if chunk.Status == v1.ChunkStatusDisabled {
+		doc.MetaData[common.FieldStatus] = common.ChunkStatusDisabled
+	}
+	return doc
+}
@@ -0,0 +1,182 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 默认配置
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 2
+	retryBackoff      = 200 * time.Millisecond
+)
+
+// 各接口的默认地址
+var defaultBaseURLs = map[string]string{
+	ProviderJina:   "https://api.jina.ai/v1",
+	ProviderVoyage: "https://api.voyageai.com/v1",
+}
+
+// Conf 重排接口的配置
+type Conf struct {
+	BaseURL    string        // 接口地址，请求 {BaseURL}/rerank
+	APIKey     string        // 鉴权使用的 API Key
+	Model      string        // 重排模型
+	Timeout    time.Duration // 单次请求超时
+	MaxRetries int           // 网络错误、429 或 5xx 时的最大重试次数
+}
+
+func init() {
+	for _, name := range []string{ProviderHTTP, ProviderJina, ProviderVoyage} {
+		RegisterProvider(name, func(ctx context.Context) (Reranker, error) {
+			return newHTTPReranker(name, GetConf(ctx, name)), nil
+		})
+	}
+}
+
+// GetConf 读取重排接口的配置：http 使用 rerank 下的配置，其他接口使用 rerank.<provider> 下的配置，
+// 超时与重试次数未单独配置时使用 rerank 下的公共配置。每次调用都重新读取，修改配置后无需重启
+func GetConf(ctx context.Context, provider string) *Conf {
+	section := "rerank"
+	if provider != ProviderHTTP {
+		section += "." + provider
+	}
+	shared := func(key string, def any) *g.Var {
+		if v := g.Cfg().MustGet(ctx, section+"."+key); !v.IsNil() {
+			return v
+		}
+		return g.Cfg().MustGet(ctx, "rerank."+key, def)
+	}
+	conf := &Conf{
+		BaseURL:    g.Cfg().MustGet(ctx, section+".baseURL", defaultBaseURLs[provider]).String(),
+		APIKey:     g.Cfg().MustGet(ctx, section+".apiKey").String(),
+		Model:      g.Cfg().MustGet(ctx, section+".model").String(),
+		Timeout:    shared("timeout", defaultTimeout).Duration(),
+		MaxRetries: shared("maxRetries", defaultMaxRetries).Int(),
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+	return conf
+}
+
+// httpReranker 调用 /rerank 接口的重排实现。SiliconFlow、Cohere 与 Jina 的请求与响应格式相同，
+// Voyage 使用 top_k 参数并在 data 中返回结果
+type httpReranker struct {
+	provider string
+	conf     *Conf
+	client   *http.Client
+}
+
+func newHTTPReranker(provider string, conf *Conf) *httpReranker {
+	return &httpReranker{
+		provider: provider,
+		conf:     conf,
+		client:   &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+type httpResp struct {
+	Results []*Result `json:"results"` // SiliconFlow、Cohere、Jina
+	Data    []*Result `json:"data"`    // Voyage
+}
+
+func (r *httpReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*Result, error) {
+	body := map[string]any{
+		"model":            r.conf.Model,
+		"query":            query,
+		"documents":        contents(docs),
+		"return_documents": false,
+	}
+	switch r.provider {
+	case ProviderVoyage:
+		body["top_k"] = topN
+	case ProviderHTTP:
+		body["top_n"] = topN
+		body["max_chunks_per_doc"] = 1024
+		body["overlap_tokens"] = 80
+	default:
+		body["top_n"] = topN
+	}
+	payload, err := sonic.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		data  []byte
+		retry bool
+	)
+	for attempt := 0; ; attempt++ {
+		data, retry, err = r.do(ctx, payload)
+		if err == nil || !retry || attempt >= r.conf.MaxRetries {
+			break
+		}
+		g.Log().Warningf(ctx, "rerank %s attempt %d failed, retrying, err=%v", r.provider, attempt+1, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryBackoff << attempt):
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := &httpResp{}
+	if err = sonic.Unmarshal(data, res); err != nil {
+		return nil, fmt.Errorf("unmarshal rerank response failed: %w", err)
+	}
+	if res.Results == nil {
+		res.Results = res.Data
+	}
+	return res.Results, nil
+}
+
+// do 发送一次请求，retry 表示失败原因可以重试
+func (r *httpReranker) do(ctx context.Context, payload []byte) (data []byte, retry bool, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.conf.BaseURL+"/rerank", bytes.NewReader(payload))
+	if err != nil {
+		return nil, false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.conf.APIKey))
+	resp, err := r.client.Do(request)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return nil, retry, fmt.Errorf("rerank status %d: %s", resp.StatusCode, truncate(string(data), 200))
+	}
+	return data, false, nil
+}
+
+func contents(docs []*schema.Document) []string {
+	res := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		res = append(res, doc.Content)
+	}
+	return res
+}
+
+// truncate 按字符截断文本
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
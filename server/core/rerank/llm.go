@@ -0,0 +1,94 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// llmDocMaxRunes 发给大模型打分时每个文档保留的最大字符数
+const llmDocMaxRunes = 500
+
+const llmSystem = "You are a relevance grader for a retrieval system.\n" +
+	"Given a query and a numbered list of documents, rate how relevant each document is to the query " +
+	"with a score between 0 and 1 (1 means it directly answers the query, 0 means unrelated).\n" +
+	"Return only a JSON array sorted by score in descending order, containing at most {top_n} items, " +
+	"like [{\"index\":0,\"score\":0.92}], without any additional explanation.\n"
+
+func init() {
+	RegisterProvider(ProviderLLM, func(ctx context.Context) (Reranker, error) {
+		// 配置 rerank.llm 时使用其中的对话模型，否则使用 rerank 下的配置
+		var cfg *openai.ChatModelConfig
+		if v := g.Cfg().MustGet(ctx, "rerank.llm"); !v.IsNil() {
+			cfg = &openai.ChatModelConfig{}
+			if err := v.Scan(cfg); err != nil {
+				return nil, err
+			}
+		}
+		cm, err := common.GetRerankModel(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &llmReranker{cm: cm}, nil
+	})
+}
+
+// llmReranker 由大模型为文档打分的重排实现，不需要专门的重排模型，但速度较慢
+type llmReranker struct {
+	cm model.BaseChatModel
+}
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*Result, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\n\nDocuments:\n", query)
+	for i, doc := range docs {
+		fmt.Fprintf(&sb, "[%d] %s\n", i, strings.ReplaceAll(truncate(doc.Content, llmDocMaxRunes), "\n", " "))
+	}
+	msg, err := r.cm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(strings.ReplaceAll(llmSystem, "{top_n}", fmt.Sprint(topN))),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseLLMResults(msg.Content, len(docs), topN)
+}
+
+// parseLLMResults 解析大模型返回的 JSON 数组，忽略越界与重复的下标，按分数排序后最多返回 topN 个
+func parseLLMResults(content string, n, topN int) ([]*Result, error) {
+	start, end := strings.Index(content, "["), strings.LastIndex(content, "]")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no json array in llm rerank result: %s", truncate(content, 200))
+	}
+	var items []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	if err := sonic.UnmarshalString(content[start:end+1], &items); err != nil {
+		return nil, fmt.Errorf("unmarshal llm rerank result failed: %w", err)
+	}
+	seen := make(map[int]bool, len(items))
+	res := make([]*Result, 0, len(items))
+	for _, item := range items {
+		if item.Index < 0 || item.Index >= n || seen[item.Index] {
+			continue
+		}
+		seen[item.Index] = true
+		res = append(res, &Result{Index: item.Index, RelevanceScore: min(max(item.Score, 0), 1)})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].RelevanceScore > res[j].RelevanceScore
+	})
+	if len(res) > topN {
+		res = res[:topN]
+	}
+	return res, nil
+}
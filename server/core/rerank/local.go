@@ -0,0 +1,102 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// defaultRetrievalWeight 本地重排中召回分数的默认权重，其余为词法相似度
+const defaultRetrievalWeight = 0.5
+
+func init() {
+	RegisterProvider(ProviderLocal, func(ctx context.Context) (Reranker, error) {
+		w := g.Cfg().MustGet(ctx, "rerank.local.retrievalWeight", defaultRetrievalWeight).Float64()
+		return &localReranker{retrievalWeight: min(max(w, 0), 1)}, nil
+	})
+}
+
+// localReranker 不依赖网络的兜底重排：问题与文档的词法余弦相似度，与召回分数（向量检索时即 embedding 的余弦相似度）
+// 按最高分归一化后加权，重排接口不可用时检索仍然可用
+type localReranker struct {
+	retrievalWeight float64
+}
+
+func (r *localReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*Result, error) {
+	var maxScore float64
+	for _, doc := range docs {
+		maxScore = max(maxScore, doc.Score())
+	}
+	q := termFreq(query)
+	res := make([]*Result, 0, len(docs))
+	for i, doc := range docs {
+		var retrieval float64
+		if maxScore > 0 {
+			retrieval = max(doc.Score(), 0) / maxScore
+		}
+		score := r.retrievalWeight*retrieval + (1-r.retrievalWeight)*cosine(q, termFreq(doc.Content))
+		res = append(res, &Result{Index: i, RelevanceScore: score})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].RelevanceScore > res[j].RelevanceScore
+	})
+	if len(res) > topN {
+		res = res[:topN]
+	}
+	return res, nil
+}
+
+// termFreq 统计词频：字母数字按词切分并转小写，中日韩文字取单字及相邻两字，弥补没有分词的不足
+func termFreq(text string) map[string]float64 {
+	var (
+		tf   = map[string]float64{}
+		word strings.Builder
+		prev rune
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tf[word.String()]++
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tf[string(r)]++
+			if prev != 0 {
+				tf[string([]rune{prev, r})]++
+			}
+			prev = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+		prev = 0
+	}
+	flush()
+	return tf
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for t, f := range a {
+		dot += f * b[t]
+		na += f * f
+	}
+	for _, f := range b {
+		nb += f * f
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -1,130 +1,109 @@
 package rerank
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"sort"
+	"sync"
 
-	"github.com/bytedance/sonic"
 	"github.com/cloudwego/eino/schema"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
-type Conf struct {
-	Model           string `json:"model"`
-	ReturnDocuments bool   `json:"return_documents"`
-	MaxChunksPerDoc int    `json:"max_chunks_per_doc"`
-	OverlapTokens   int    `json:"overlap_tokens"`
-	url             string
-	apiKey          string
-}
-type Data struct {
-	Query     string   `json:"query"`
-	Documents []string `json:"documents"`
-	TopN      int      `json:"top_n"`
-}
-
-type Req struct {
-	*Data
-	*Conf
-}
+// 重排实现
+const (
+	ProviderHTTP   = "http"   // SiliconFlow / Cohere 风格的 /rerank 接口（默认）
+	ProviderJina   = "jina"   // Jina 的 /rerank 接口
+	ProviderVoyage = "voyage" // Voyage 的 /rerank 接口，参数为 top_k，结果在 data 中
+	ProviderLLM    = "llm"    // 由大模型为文档打分（common.GetRerankModel）
+	ProviderLocal  = "local"  // 不依赖网络的词法相似度与召回分数加权，作为兜底
+)
 
+// Result 一个文档的重排结果
 type Result struct {
-	Index          int     `json:"index"`
-	RelevanceScore float64 `json:"relevance_score"`
+	Index          int     `json:"index"`           // 文档在输入中的下标
+	RelevanceScore float64 `json:"relevance_score"` // 相关性分数，范围 0-1
 }
 
-type Resp struct {
-	ID      string    `json:"id"`
-	Results []*Result `json:"results"`
+// Reranker 重排实现，按相关性从高到低返回最多 topN 个结果
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*Result, error)
 }
 
-var rerankCfg *Conf
+// ProviderFactory 根据配置创建重排实现
+type ProviderFactory func(ctx context.Context) (Reranker, error)
 
-func NewRerank(ctx context.Context, query string, docs []*schema.Document, topK int) (output []*schema.Document, err error) {
-	output, err = rerank(ctx, query, docs, topK)
-	if err != nil {
-		return
-	}
-	return
+var (
+	providerMu        sync.RWMutex
+	providerFactories = map[string]ProviderFactory{}
+)
+
+// RegisterProvider 注册重排实现，配置 rerank.providers 中的名称与 name 对应
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerFactories[name] = factory
 }
 
-func GetConf(ctx context.Context) *Conf {
-	if rerankCfg != nil {
-		return rerankCfg
-	}
-	baseUrl := g.Cfg().MustGet(ctx, "rerank.baseURL").String()
-	apiKey := g.Cfg().MustGet(ctx, "rerank.apiKey").String()
-	model := g.Cfg().MustGet(ctx, "rerank.model").String()
-	url := fmt.Sprintf("%s/rerank", baseUrl)
-	rerankCfg = &Conf{
-		apiKey:          apiKey,
-		Model:           model,
-		ReturnDocuments: false,
-		MaxChunksPerDoc: 1024,
-		OverlapTokens:   80,
-		url:             url,
+func getProvider(ctx context.Context, name string) (Reranker, error) {
+	providerMu.RLock()
+	factory, ok := providerFactories[name]
+	providerMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown rerank provider: %s", name)
 	}
-	return rerankCfg
+	return factory(ctx)
 }
 
-func rerank(ctx context.Context, query string, docs []*schema.Document, topK int) (output []*schema.Document, err error) {
-	data := &Data{
-		Query: query,
-		TopN:  topK,
-	}
-	// g.Log().Infof(ctx, "docs num: %d", len(docs))
-	for _, doc := range docs {
-		data.Documents = append(data.Documents, doc.Content)
-	}
-	// 重排
-	results, err := rerankDoHttp(ctx, data)
-	if err != nil {
+// Rerank 按配置 rerank.providers 的顺序尝试重排，前一个失败时切换到下一个，全部失败才返回错误。
+// 文档的分数替换为重排分数，按分数从高到低返回最多 topK 个
+func Rerank(ctx context.Context, query string, docs []*schema.Document, topK int) (output []*schema.Document, err error) {
+	if len(docs) == 0 {
 		return
 	}
-	// 重新组装数据
-	for _, result := range results {
-		doc := docs[result.Index]
-		// g.Log().Infof(ctx, "content: %s, score_old: %f, score_new: %f", doc.Content, doc.Score(), result.RelevanceScore)
-		doc.WithScore(result.RelevanceScore)
-		output = append(output, docs[result.Index])
+	providers := g.Cfg().MustGet(ctx, "rerank.providers", []string{ProviderHTTP, ProviderLocal}).Strings()
+	var errs []error
+	for _, name := range providers {
+		var r Reranker
+		if r, err = getProvider(ctx, name); err == nil {
+			if output, err = rerankDocs(ctx, r, query, docs, topK); err == nil {
+				return
+			}
+		}
+		g.Log().Warningf(ctx, "rerank provider %s failed, err=%v", name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
 	}
-	return
+	return nil, errors.Join(errs...)
 }
 
-func rerankDoHttp(ctx context.Context, data *Data) ([]*Result, error) {
-	cfg := GetConf(ctx)
-	reqData := &Req{
-		Data: data,
-		Conf: cfg,
-	}
-
-	marshal, err := sonic.Marshal(reqData)
-	if err != nil {
-		return nil, err
+// rerankDocs 调用重排实现并重新组装文档
+func rerankDocs(ctx context.Context, r Reranker, query string, docs []*schema.Document, topK int) (output []*schema.Document, err error) {
+	if topK <= 0 || topK > len(docs) {
+		topK = len(docs)
 	}
-	payload := bytes.NewReader(marshal)
-	request, err := http.NewRequest("POST", cfg.url, payload)
+	results, err := r.Rerank(ctx, query, docs, topK)
 	if err != nil {
-		return nil, err
+		return
 	}
-	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cfg.apiKey))
-	do, err := g.Client().Do(request)
-	if err != nil {
-		return nil, err
+	// 先校验全部结果，避免失败时部分文档的分数已被替换，影响下一个重排实现
+	seen := make(map[int]bool, len(results))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(docs) || seen[result.Index] {
+			return nil, fmt.Errorf("invalid rerank result index: %d", result.Index)
+		}
+		seen[result.Index] = true
 	}
-	defer do.Body.Close()
-	body, err := ioutil.ReadAll(do.Body)
-	if err != nil {
-		return nil, err
+	for _, result := range results {
+		doc := docs[result.Index]
+		doc.WithScore(result.RelevanceScore)
+		output = append(output, doc)
 	}
-	res := Resp{}
-	err = sonic.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	sort.SliceStable(output, func(i, j int) bool {
+		return output[i].Score() > output[j].Score()
+	})
+	if len(output) > topK {
+		output = output[:topK]
 	}
-	return res.Results, nil
+	return
 }
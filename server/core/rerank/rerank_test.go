@@ -1,21 +1,32 @@
 package rerank
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
-	
+	"time"
+
 	"github.com/cloudwego/eino/schema"
 	"github.com/gogf/gf/v2/os/gctx"
 )
 
 func TestRerank(t *testing.T) {
-	rerankCfg = &Conf{
-		apiKey:          "sk-***",
-		Model:           "BAAI/bge-reranker-v2-m3",
-		ReturnDocuments: false,
-		MaxChunksPerDoc: 1024,
-		OverlapTokens:   80,
-		url:             "https://api.siliconflow.cn/v1/rerank",
+	apiKey := os.Getenv("RERANK_API_KEY")
+	if apiKey == "" {
+		t.Skip("RERANK_API_KEY not set")
 	}
+	r := newHTTPReranker(ProviderHTTP, &Conf{
+		BaseURL: "https://api.siliconflow.cn/v1",
+		APIKey:  apiKey,
+		Model:   "BAAI/bge-reranker-v2-m3",
+		Timeout: defaultTimeout,
+	})
 	ctx := gctx.New()
 	docs := []*schema.Document{
 		{Content: "banana"},
@@ -23,7 +34,7 @@ func TestRerank(t *testing.T) {
 		{Content: "apple"},
 		{Content: "vegetable"},
 	}
-	output, err := Rerank(ctx, "水果", docs, 2)
+	output, err := rerankDocs(ctx, r, "水果", docs, 2)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,3 +42,104 @@ func TestRerank(t *testing.T) {
 		t.Logf("content: %v, score: %v", doc.Content, doc.Score())
 	}
 }
+
+func testDocs(contents ...string) []*schema.Document {
+	docs := make([]*schema.Document, 0, len(contents))
+	for i, content := range contents {
+		docs = append(docs, &schema.Document{ID: fmt.Sprint(i), Content: content})
+	}
+	return docs
+}
+
+func TestHTTPReranker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if strings.Contains(string(body), `"top_k"`) {
+			_, _ = w.Write([]byte(`{"data":[{"index":1,"relevance_score":0.9}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"index":2,"relevance_score":0.8},{"index":0,"relevance_score":0.3}]}`))
+	}))
+	defer srv.Close()
+	conf := &Conf{BaseURL: srv.URL, Timeout: time.Second, MaxRetries: 1}
+
+	// 第一次 503 后重试成功
+	output, err := rerankDocs(context.Background(), newHTTPReranker(ProviderHTTP, conf), "q", testDocs("a", "b", "c"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) != 2 || output[0].Content != "c" || output[0].Score() != 0.8 || calls.Load() != 2 {
+		t.Fatalf("unexpected output: %+v, calls=%d", output, calls.Load())
+	}
+
+	// Voyage 的结果在 data 中
+	output, err = rerankDocs(context.Background(), newHTTPReranker(ProviderVoyage, conf), "q", testDocs("a", "b", "c"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) != 1 || output[0].Content != "b" {
+		t.Fatalf("unexpected voyage output: %+v", output)
+	}
+
+	// 非 2xx 的状态码不能当作空结果
+	calls.Store(0)
+	conf.MaxRetries = 0
+	if _, err = newHTTPReranker(ProviderHTTP, conf).Rerank(context.Background(), "q", testDocs("a"), 1); err == nil {
+		t.Fatal("expect error on 503")
+	}
+}
+
+func TestLocalReranker(t *testing.T) {
+	docs := testDocs("如何申请发票", "重置密码需要在设置页点击忘记密码", "Reset your password in settings")
+	for i, doc := range docs {
+		doc.WithScore(0.5 + float64(i)*0.1)
+	}
+	output, err := rerankDocs(context.Background(), &localReranker{retrievalWeight: 0.3}, "怎么重置密码", docs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) != 2 || output[0].ID != "1" {
+		t.Fatalf("expect the lexical match first, got %+v", output)
+	}
+	for _, doc := range output {
+		if doc.Score() < 0 || doc.Score() > 1 {
+			t.Fatalf("score out of range: %v", doc.Score())
+		}
+	}
+}
+
+func TestParseLLMResults(t *testing.T) {
+	res, err := parseLLMResults("```json\n[{\"index\":1,\"score\":0.4},{\"index\":0,\"score\":1.2},{\"index\":5,\"score\":0.9},{\"index\":1,\"score\":0.1}]\n```", 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].Index != 0 || res[0].RelevanceScore != 1 || res[1].Index != 1 {
+		t.Fatalf("unexpected results: %+v %+v", res[0], res[1])
+	}
+	if _, err = parseLLMResults("no idea", 3, 2); err == nil {
+		t.Fatal("expect error without json array")
+	}
+}
+
+type failReranker struct{}
+
+func (failReranker) Rerank(ctx context.Context, query string, docs []*schema.Document, topN int) ([]*Result, error) {
+	return []*Result{{Index: 0, RelevanceScore: 0.9}, {Index: 9, RelevanceScore: 0.8}}, nil
+}
+
+func TestRerankInvalidIndex(t *testing.T) {
+	docs := testDocs("a", "b")
+	docs[0].WithScore(0.5)
+	if _, err := rerankDocs(context.Background(), failReranker{}, "q", docs, 2); err == nil {
+		t.Fatal("expect error on invalid index")
+	}
+	// 校验失败时不修改文档分数，下一个重排实现仍能使用召回分数
+	if docs[0].Score() != 0.5 {
+		t.Fatalf("score should not change, got %v", docs[0].Score())
+	}
+}
@@ -2,26 +2,43 @@ package core
 
 import (
 	"context"
-	"github.com/cloudwego/eino-ext/components/retriever/es8"
+	"errors"
+	"github.com/cloudwego/eino/components/model"
 	er "github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	"github.com/everfid-ever/ThinkForge/core/common"
 	"github.com/everfid-ever/ThinkForge/core/rerank"
+	"github.com/everfid-ever/ThinkForge/core/retriever"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
 	"github.com/gogf/gf/v2/frame/g"
 	"sort"
+	"strings"
 	"sync"
 )
 
 type RetrieveReq struct {
-	Query         string   // 检索关键词
-	TopK          int      // 检索结果数量
-	Score         float64  // 分数阀值(0-2, 0 完全相反，1 毫不相干，2 完全相同,一般需要传入一个大于1的数字，如1.5)
-	KnowledgeName string   // 知识库名字
-	optQuery      string   // 优化后的检索关键词
-	excludeIDs    []string // 要排除的 _id 列表
-	rankScore     float64  // 排名分数，原本的score是0-2（实际是1-2），需要在这里改成0-1
+	Query          string              // 检索关键词
+	TopK           int                 // 检索结果数量
+	Score          float64             // 分数阀值(0-2, 0 完全相反，1 毫不相干，2 完全相同,一般需要传入一个大于1的数字，如1.5)
+	KnowledgeName  string              // 知识库名字
+	KnowledgeBases []*KnowledgeWeight  // 跨知识库检索的知识库列表，不为空时忽略 KnowledgeName
+	Mode           string              // 检索模式：dense（仅向量）或 hybrid（BM25 + 向量），为空时读取配置 retriever.mode
+	Fusion         string              // hybrid 模式下的融合方式：rrf 或 weighted，为空时读取配置 retriever.fusion
+	Filter         *vectorstore.Filter // 元数据过滤条件（见 common.MetaFields），与知识库、启用状态等条件同时满足
+	Rewrite        string              // 问题改写策略（见 rewrite.Strategy*），为空时读取配置 rewrite.strategy
+	optQuery       string              // 优化后的检索关键词
+	embedQuery     string              // 向量检索使用的文本，HyDE 策略下为假设文档，其余与 optQuery 相同
+	excludeIDs     []string            // 要排除的 _id 列表
+	rankScore      float64             // 排名分数，原本的score是0-2（实际是1-2），需要在这里改成0-1
+	space          *space              // 知识库所在的索引空间
+}
+
+// KnowledgeWeight 跨知识库检索中的一个知识库及其权重，权重不大于 0 时按 1 处理
+type KnowledgeWeight struct {
+	Name   string
+	Weight float64
 }
 
 func (x *RetrieveReq) copy() *RetrieveReq {
@@ -30,106 +47,252 @@ func (x *RetrieveReq) copy() *RetrieveReq {
 		TopK:          x.TopK,
 		Score:         x.Score,
 		KnowledgeName: x.KnowledgeName,
+		Mode:          x.Mode,
+		Fusion:        x.Fusion,
+		Filter:        x.Filter,
+		Rewrite:       x.Rewrite,
 		optQuery:      x.optQuery,
+		embedQuery:    x.embedQuery,
 		excludeIDs:    x.excludeIDs,
 		rankScore:     x.rankScore,
+		space:         x.space,
 	}
 }
 
-// Retrieve 检索
+// Retrieve 检索：按改写策略生成检索问题，每得到一条就开始检索，合并各问题的结果后按分数截取 TopK。
+// 跨知识库检索时改写只执行一次，每条检索问题在各知识库中分别检索与 rerank，
+// 原始分数乘以知识库权重后合并，文档元数据中的 common.KnowledgeName 标记其来源知识库。
+// 部分知识库检索失败时只记录日志，全部失败才返回错误
 func (x *Rag) Retrieve(ctx context.Context, req *RetrieveReq) (msg []*schema.Document, err error) {
-	var (
-		used        = ""          // 记录已经使用过的关键词
-		relatedDocs = &sync.Map{} // 记录相关docs
-	)
+	if req.Mode == "" {
+		req.Mode = g.Cfg().MustGet(ctx, "retriever.mode", retriever.ModeDense).String()
+	}
+	if req.Fusion == "" {
+		req.Fusion = g.Cfg().MustGet(ctx, "retriever.fusion", retriever.FusionRRF).String()
+	}
 	req.rankScore = req.Score
 	// 大于1的需要-1
 	if req.rankScore >= 1 {
 		req.rankScore -= 1
 	}
-	rewriteModel, err := common.GetRewriteModel(ctx, nil)
+	targets, err := x.retrieveTargets(ctx, req)
 	if err != nil {
 		return
 	}
-	wg := &sync.WaitGroup{}
-	// 尝试N次重写关键词进行搜索,后续可以考虑做成配置
-	for i := 0; i < 3; i++ {
-		question := req.Query
-		var (
-			optMessages    []*schema.Message
-			rewriteMessage *schema.Message
-		)
-		optMessages, err = getOptimizedQueryMessages(used, question, req.KnowledgeName)
-		if err != nil {
-			return
-		}
-		rewriteMessage, err = rewriteModel.Generate(ctx, optMessages)
-		if err != nil {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.req.KnowledgeName)
+	}
+	opts := rewrite.ResolveOptions(ctx, &rewrite.Options{
+		Strategy:      req.Rewrite,
+		KnowledgeName: strings.Join(names, "、"),
+	})
+	var rewriteModel model.BaseChatModel
+	if opts.Strategy != rewrite.StrategyNone {
+		if rewriteModel, err = common.GetRewriteModel(ctx, nil); err != nil {
 			return
 		}
-		optimizedQuery := rewriteMessage.Content
-		used += optimizedQuery + " "
-		req.optQuery = optimizedQuery
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			rDocs := make([]*schema.Document, 0)
-			rDocs, err = x.retrieveDoOnce(ctx, req.copy())
-			if err != nil {
-				g.Log().Errorf(ctx, "retrieveDoOnce failed, err=%v", err)
-				return
+	}
+	var (
+		wg = &sync.WaitGroup{}
+		mu sync.Mutex
+	)
+	err = rewrite.New(rewriteModel).Rewrite(ctx, req.Query, opts, func(q *rewrite.Query) {
+		rewrite.Record(ctx, q)
+		for _, t := range targets {
+			if t.req.space == nil {
+				continue
 			}
-			for _, doc := range rDocs {
-				if old, e := relatedDocs.LoadOrStore(doc.ID, doc); e {
-					// 同文档则保存较高分的结果（对于不同的optQuery，rerank可能会有不同的结果）
-					if doc.Score() > old.(*schema.Document).Score() {
-						relatedDocs.Store(doc.ID, doc)
+			onceReq := t.req.copy()
+			onceReq.optQuery = q.Text
+			onceReq.embedQuery = q.EmbedText()
+			mu.Lock()
+			t.searched++
+			mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rDocs, e := x.retrieveDoOnce(ctx, onceReq)
+				if e != nil {
+					g.Log().Errorf(ctx, "retrieveDoOnce failed, knowledge base=%s, err=%v", onceReq.KnowledgeName, e)
+					mu.Lock()
+					t.errs = append(t.errs, e)
+					mu.Unlock()
+					return
+				}
+				for _, doc := range rDocs {
+					if old, loaded := t.docs.LoadOrStore(doc.ID, doc); loaded {
+						// 同文档则保存较高分的结果（对于不同的optQuery，rerank可能会有不同的结果）
+						if doc.Score() > old.(*schema.Document).Score() {
+							t.docs.Store(doc.ID, doc)
+						}
 					}
 				}
-			}
+			}()
+		}
+	})
+	wg.Wait()
+	if err != nil {
+		return
+	}
+	if len(req.KnowledgeBases) == 0 {
+		return targets[0].results(req.TopK, false)
+	}
+	return mergeKnowledgeBases(ctx, targets, req.TopK)
+}
 
-		}()
+// retrieveTarget 一次检索涉及的一个知识库，汇总该知识库在各检索问题下的结果
+type retrieveTarget struct {
+	req      *RetrieveReq
+	weight   float64
+	docs     sync.Map
+	searched int
+	errs     []error
+}
+
+// retrieveTargets 解析需要检索的知识库及其索引空间。跨知识库检索时，索引空间不可用的知识库记为失败并跳过
+func (x *Rag) retrieveTargets(ctx context.Context, req *RetrieveReq) ([]*retrieveTarget, error) {
+	if len(req.KnowledgeBases) == 0 {
+		t := &retrieveTarget{req: req.copy(), weight: 1}
+		sp, err := x.space(ctx, req.KnowledgeName)
+		if err != nil {
+			return nil, err
+		}
+		t.req.space = sp
+		return []*retrieveTarget{t}, nil
 	}
-	wg.Wait()
-	// 整理需要返回的数据
-	relatedDocs.Range(func(key, value any) bool {
+	kbs := common.RemoveDuplicates(req.KnowledgeBases, func(kb *KnowledgeWeight) string { return kb.Name })
+	targets := make([]*retrieveTarget, 0, len(kbs))
+	for _, kb := range kbs {
+		t := &retrieveTarget{req: req.copy(), weight: kb.Weight}
+		if t.weight <= 0 {
+			t.weight = 1
+		}
+		t.req.KnowledgeName = kb.Name
+		sp, err := x.space(ctx, kb.Name)
+		if err != nil {
+			g.Log().Errorf(ctx, "retrieve knowledge base %s failed, err=%v", kb.Name, err)
+			t.errs = append(t.errs, err)
+		}
+		t.req.space = sp
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// results 按分数降序返回该知识库的前 topK 个结果，tag 为 true 时在元数据中标记来源知识库。
+// 所有检索问题都失败时返回错误
+func (t *retrieveTarget) results(topK int, tag bool) (msg []*schema.Document, err error) {
+	if len(t.errs) > 0 && len(t.errs) >= t.searched {
+		return nil, errors.Join(t.errs...)
+	}
+	t.docs.Range(func(key, value any) bool {
 		msg = append(msg, value.(*schema.Document))
 		return true
 	})
 	sort.Slice(msg, func(i, j int) bool {
 		return msg[i].Score() > msg[j].Score()
 	})
-	if len(msg) > req.TopK {
-		msg = msg[:req.TopK]
+	if len(msg) > topK {
+		msg = msg[:topK]
+	}
+	if tag {
+		for _, doc := range msg {
+			if doc.MetaData == nil {
+				doc.MetaData = map[string]any{}
+			}
+			doc.MetaData[common.KnowledgeName] = t.req.KnowledgeName
+		}
+	}
+	return
+}
+
+// mergeKnowledgeBases 合并跨知识库检索的结果：各知识库经同一 rerank 打分，原始分数乘以权重后合并
+func mergeKnowledgeBases(ctx context.Context, targets []*retrieveTarget, topK int) (msg []*schema.Document, err error) {
+	var (
+		lists   = make([][]*schema.Document, 0, len(targets))
+		weights = make([]float64, 0, len(targets))
+	)
+	for _, t := range targets {
+		docs, e := t.results(topK, true)
+		if e != nil {
+			g.Log().Errorf(ctx, "retrieve knowledge base %s failed, err=%v", t.req.KnowledgeName, e)
+			err = e
+			continue
+		}
+		lists = append(lists, docs)
+		weights = append(weights, t.weight)
+	}
+	if len(lists) == 0 {
+		return
+	}
+	err = nil
+	// 只有一个知识库时保留原始分数
+	if len(targets) == 1 {
+		return lists[0], nil
+	}
+	msg = retriever.FuseScaled(weights, lists...)
+	if len(msg) > topK {
+		msg = msg[:topK]
 	}
 	return
 }
 
 func (x *Rag) retrieveDoOnce(ctx context.Context, req *RetrieveReq) (relatedDocs []*schema.Document, err error) {
 	var (
-		docs   []*schema.Document
-		qaDocs []*schema.Document
+		docs        []*schema.Document
+		qaDocs      []*schema.Document
+		keywordDocs []*schema.Document
+		qaErr       error
+		keywordErr  error
+		hybrid      = req.Mode == retriever.ModeHybrid
+		wg          = &sync.WaitGroup{}
 	)
-	g.Log().Infof(ctx, "query: %v", req.optQuery)
+	g.Log().Infof(ctx, "query: %v, mode: %v", req.optQuery, req.Mode)
+	// 通过qa检索
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		qaDocs, qaErr = x.retrieve(ctx, req, true)
+	}()
+	// 混合模式下同时进行关键词检索
+	if hybrid {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keywordDocs, keywordErr = x.keywordRetrieve(ctx, req)
+		}()
+	}
 	// 通过内容检索
 	docs, err = x.retrieve(ctx, req, false)
+	wg.Wait()
 	if err != nil {
 		g.Log().Errorf(ctx, "retrieve failed, err=%v", err)
 		return
 	}
-	// 通过qa检索
-	qaDocs, err = x.retrieve(ctx, req, true)
-	if err != nil {
+	if qaErr != nil {
+		err = qaErr
 		g.Log().Errorf(ctx, "qa retrieve failed, err=%v", err)
 		return
 	}
 	docs = append(docs, qaDocs...)
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Score() > docs[j].Score()
+	})
 	// 去重
 	docs = common.RemoveDuplicates(docs, func(doc *schema.Document) string {
 		return doc.ID
 	})
+	if hybrid {
+		if keywordErr != nil {
+			// 关键词检索失败时退化为纯向量检索
+			g.Log().Errorf(ctx, "keyword retrieve failed, err=%v", keywordErr)
+		} else {
+			docs = fuse(ctx, req.Fusion, docs, keywordDocs)
+		}
+	}
 	// 重排
-	docs, err = rerank.NewRerank(ctx, req.optQuery, docs, req.TopK)
+	docs, err = rerank.Rerank(ctx, req.optQuery, docs, req.TopK)
 	if err != nil {
 		g.Log().Errorf(ctx, "Rerank failed, err=%v", err)
 		return
@@ -145,33 +308,20 @@ func (x *Rag) retrieveDoOnce(ctx context.Context, req *RetrieveReq) (relatedDocs
 }
 
 func (x *Rag) retrieve(ctx context.Context, req *RetrieveReq, qa bool) (msg []*schema.Document, err error) {
-	esQuery := []types.Query{
-		{
-			Bool: &types.BoolQuery{
-				Must: []types.Query{{Match: map[string]types.MatchQuery{common.KnowledgeName: {Query: req.KnowledgeName}}}},
-			},
-		},
-	}
-	if len(req.excludeIDs) > 0 {
-		esQuery[0].Bool.MustNot = []types.Query{
-			{
-				Terms: &types.TermsQuery{
-					TermsQuery: map[string]types.TermsQueryField{
-						"_id": req.excludeIDs,
-					},
-				},
-			},
-		}
-	}
-	r := x.rtrvr
+	r := req.space.rtrvr
 	if qa {
-		r = x.qaRtrvr
+		r = req.space.qaRtrvr
+	}
+	// 内容向量使用 embedQuery（HyDE 的假设文档），QA 向量是问题的向量，仍使用 optQuery
+	query := req.embedQuery
+	if qa || query == "" {
+		query = req.optQuery
 	}
-	msg, err = r.Invoke(ctx, req.optQuery,
+	msg, err = r.Invoke(ctx, query,
 		compose.WithRetrieverOption(
 			// er.WithScoreThreshold(req.Score), // 不限制分数，只限制数量，最终分数由rerank给
 			er.WithTopK(esTopK),
-			es8.WithFilters(esQuery),
+			retriever.WithFilter(retrieveFilter(req)),
 		),
 	)
 	for _, s := range msg {
@@ -185,3 +335,58 @@ func (x *Rag) retrieve(ctx context.Context, req *RetrieveReq, qa bool) (msg []*s
 	}
 	return
 }
+
+// keywordRetrieve 在 content 字段上做 BM25 检索，弥补向量检索对产品编号、错误码等精确词的召回不足。
+// 原始问题与重写后的关键词任一命中即可。
+func (x *Rag) keywordRetrieve(ctx context.Context, req *RetrieveReq) (msg []*schema.Document, err error) {
+	records, err := x.store.KeywordSearch(ctx, req.space.index(), &vectorstore.KeywordRequest{
+		Field:   common.FieldContent,
+		Queries: []string{req.Query, req.optQuery},
+		TopK:    esTopK,
+		Filter:  retrieveFilter(req),
+		// 关键词检索不需要返回向量
+		Excludes: common.VectorFields,
+	})
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		var doc *schema.Document
+		doc, err = retriever.Record2Document(ctx, record)
+		if err != nil {
+			return
+		}
+		msg = append(msg, doc)
+	}
+	return
+}
+
+// retrieveFilter 构建检索的过滤条件：限定知识库、排除已禁用及指定的 chunk
+func retrieveFilter(req *RetrieveReq) *vectorstore.Filter {
+	filter := vectorstore.NewFilter(vectorstore.Eq(common.KnowledgeName, req.KnowledgeName))
+	// 过滤已禁用的 chunk，缺少状态字段的历史数据视为启用
+	filter.Not(vectorstore.Eq(common.FieldStatus, common.ChunkStatusDisabled))
+	if len(req.excludeIDs) > 0 {
+		filter.Not(vectorstore.In(vectorstore.IDField, req.excludeIDs))
+	}
+	// 调用方的元数据过滤条件
+	if !req.Filter.Empty() {
+		filter.Nest(req.Filter)
+	}
+	return filter
+}
+
+// fuse 融合向量检索与关键词检索的结果，截断后交给 rerank
+func fuse(ctx context.Context, fusion string, dense, keyword []*schema.Document) (docs []*schema.Document) {
+	switch fusion {
+	case retriever.FusionWeighted:
+		w := g.Cfg().MustGet(ctx, "retriever.denseWeight", 0.5).Float64()
+		docs = retriever.FuseWeighted([]float64{w, 1 - w}, dense, keyword)
+	default:
+		docs = retriever.FuseRRF(g.Cfg().MustGet(ctx, "retriever.rrfK", retriever.DefaultRRFK).Int(), dense, keyword)
+	}
+	if len(docs) > esTopK*2 {
+		docs = docs[:esTopK*2]
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package retriever
+
+import (
+	"sort"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 检索模式
+const (
+	ModeDense  = "dense"  // 仅向量检索
+	ModeHybrid = "hybrid" // BM25 关键词检索 + 向量检索融合
+)
+
+// 混合检索的融合方式
+const (
+	FusionRRF      = "rrf"      // Reciprocal Rank Fusion，只看排名，不受分数尺度影响
+	FusionWeighted = "weighted" // 各路分数 min-max 归一化后加权求和
+)
+
+// DefaultRRFK RRF 公式 1/(k+rank) 中的平滑常数
+const DefaultRRFK = 60
+
+// FuseRRF 使用 Reciprocal Rank Fusion 融合多路检索结果。
+// 每路结果需已按相关性降序排列，文档按 ID 去重，融合后的分数写回 Score 并按分数降序返回。
+func FuseRRF(k int, lists ...[]*schema.Document) []*schema.Document {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	scores := make(map[string]float64)
+	docs := make(map[string]*schema.Document)
+	var order []string
+	for _, list := range lists {
+		for rank, doc := range list {
+			if _, ok := docs[doc.ID]; !ok {
+				docs[doc.ID] = doc
+				order = append(order, doc.ID)
+			}
+			scores[doc.ID] += 1 / float64(k+rank+1)
+		}
+	}
+	return collect(order, docs, scores)
+}
+
+// FuseWeighted 将每路结果的分数 min-max 归一化到 [0,1] 后按权重求和。
+// weights 与 lists 一一对应，缺省的权重按 1 处理。
+func FuseWeighted(weights []float64, lists ...[]*schema.Document) []*schema.Document {
+	scores := make(map[string]float64)
+	docs := make(map[string]*schema.Document)
+	var order []string
+	for i, list := range lists {
+		if len(list) == 0 {
+			continue
+		}
+		w := 1.0
+		if i < len(weights) {
+			w = weights[i]
+		}
+		minScore, maxScore := list[0].Score(), list[0].Score()
+		for _, doc := range list {
+			minScore = min(minScore, doc.Score())
+			maxScore = max(maxScore, doc.Score())
+		}
+		for _, doc := range list {
+			if _, ok := docs[doc.ID]; !ok {
+				docs[doc.ID] = doc
+				order = append(order, doc.ID)
+			}
+			norm := 1.0
+			if maxScore > minScore {
+				norm = (doc.Score() - minScore) / (maxScore - minScore)
+			}
+			scores[doc.ID] += w * norm
+		}
+	}
+	return collect(order, docs, scores)
+}
+
+// FuseScaled 将每路结果的原始分数乘以权重后合并，同一文档取最高分。
+// 适用于各路分数可直接比较的场景（如同一 rerank 模型打分的多个知识库），不会像 min-max 归一化那样放大弱结果。
+// weights 与 lists 一一对应，缺省的权重按 1 处理。
+func FuseScaled(weights []float64, lists ...[]*schema.Document) []*schema.Document {
+	scores := make(map[string]float64)
+	docs := make(map[string]*schema.Document)
+	var order []string
+	for i, list := range lists {
+		w := 1.0
+		if i < len(weights) {
+			w = weights[i]
+		}
+		for _, doc := range list {
+			score := w * doc.Score()
+			if _, ok := docs[doc.ID]; !ok {
+				docs[doc.ID] = doc
+				order = append(order, doc.ID)
+			} else if score <= scores[doc.ID] {
+				continue
+			}
+			scores[doc.ID] = score
+		}
+	}
+	return collect(order, docs, scores)
+}
+
+func collect(order []string, docs map[string]*schema.Document, scores map[string]float64) []*schema.Document {
+	res := make([]*schema.Document, 0, len(order))
+	for _, id := range order {
+		res = append(res, docs[id].WithScore(scores[id]))
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Score() > res[j].Score()
+	})
+	return res
+}
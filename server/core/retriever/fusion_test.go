@@ -0,0 +1,59 @@
+package retriever
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func scored(id string, score float64) *schema.Document {
+	return (&schema.Document{ID: id}).WithScore(score)
+}
+
+func TestFuseRRF(t *testing.T) {
+	dense := []*schema.Document{scored("a", 0.9), scored("b", 0.8), scored("c", 0.7)}
+	keyword := []*schema.Document{scored("c", 12), scored("d", 8)}
+	output := FuseRRF(60, dense, keyword)
+	if len(output) != 4 {
+		t.Fatalf("expect 4 docs, got %d", len(output))
+	}
+	// c 同时出现在两路结果中，应排在第一
+	if output[0].ID != "c" {
+		t.Fatalf("expect c first, got %s", output[0].ID)
+	}
+	for _, doc := range output {
+		t.Logf("id: %v, score: %v", doc.ID, doc.Score())
+	}
+}
+
+func TestFuseWeighted(t *testing.T) {
+	dense := []*schema.Document{scored("a", 0.9), scored("b", 0.5)}
+	keyword := []*schema.Document{scored("b", 20), scored("c", 10)}
+	output := FuseWeighted([]float64{0.5, 0.5}, dense, keyword)
+	if len(output) != 3 {
+		t.Fatalf("expect 3 docs, got %d", len(output))
+	}
+	// a: 0.5*1，b: 0.5*0 + 0.5*1，c: 0.5*0
+	if output[len(output)-1].ID != "c" {
+		t.Fatalf("expect c last, got %s", output[len(output)-1].ID)
+	}
+	if output[0].Score() != 0.5 || output[1].Score() != 0.5 {
+		t.Fatalf("unexpected scores: %v, %v", output[0].Score(), output[1].Score())
+	}
+}
+
+func TestFuseScaled(t *testing.T) {
+	// 知识库 b 只有弱相关的结果，不应因归一化排到 a 的强相关结果前面
+	a := []*schema.Document{scored("a1", 0.9), scored("a2", 0.8)}
+	b := []*schema.Document{scored("b1", 0.3), scored("a1", 0.2)}
+	output := FuseScaled([]float64{1, 2}, a, b)
+	if len(output) != 3 {
+		t.Fatalf("expect 3 docs, got %d", len(output))
+	}
+	if output[0].ID != "a1" || output[1].ID != "a2" || output[2].ID != "b1" {
+		t.Fatalf("unexpected order: %s, %s, %s", output[0].ID, output[1].ID, output[2].ID)
+	}
+	if output[0].Score() != 0.9 || output[2].Score() != 0.6 {
+		t.Fatalf("unexpected scores: %v, %v", output[0].Score(), output[2].Score())
+	}
+}
@@ -16,7 +16,7 @@ import (
 //
 // 参数：
 //   - ctx：上下文，用于控制生命周期与传递参数。
-//   - conf：全局配置，包含 Elasticsearch 客户端、索引名称等信息。
+//   - conf：全局配置，包含向量存储、索引名称等信息。
 //
 // 返回值：
 //   - r：编译好的可执行检索组件（Runnable），输入 string 查询文本，输出 []*schema.Document 检索结果。
@@ -27,7 +27,7 @@ func BuildRetriever(ctx context.Context, conf *config.Config) (r compose.Runnabl
 	// 创建一个新的执行图（Graph），输入为 string，输出为 []*schema.Document
 	g := compose.NewGraph[string, []*schema.Document]()
 
-	// 调用 newRetriever 创建具体的检索器（基于 Elasticsearch 的 Retriever 实例）
+	// 调用 newRetriever 创建具体的检索器（基于向量存储的 Retriever 实例）
 	retrieverKeyOfRetriever, err := newRetriever(ctx, conf)
 	if err != nil {
 		return nil, err
@@ -4,19 +4,24 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/bytedance/sonic"
-	"github.com/cloudwego/eino-ext/components/retriever/es8"
-	"github.com/cloudwego/eino-ext/components/retriever/es8/search_mode"
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	"github.com/everfid-ever/ThinkForge/core/common"
 	"github.com/everfid-ever/ThinkForge/core/config"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+const (
+	storeRetrieverType = "VectorStore"
+	defaultTopK        = 10
 )
 
 // newRetriever 组件初始化函数
-// 此函数用于创建一个基于 Elasticsearch 8 的向量检索器（Retriever）。
-// 它会根据配置初始化检索参数（包括索引、搜索模式、embedding 模型等），
+// 此函数用于创建一个基于向量存储的向量检索器（Retriever）。
+// 它会根据配置初始化检索参数（包括索引、向量字段、embedding 模型等），
 // 用于在知识库中执行语义相似度搜索。
 //
 // 节点名称：'Retriever1' in graph 'rag'
@@ -24,7 +29,7 @@ import (
 // 参数：
 //
 //	ctx  - 上下文，用于控制超时、取消及传递自定义检索参数
-//	conf - 全局配置对象，包含 ES 客户端、索引名、API Key、模型名称等
+//	conf - 全局配置对象，包含向量存储、索引名、API Key、模型名称等
 //
 // 返回：
 //
@@ -39,37 +44,121 @@ func newRetriever(ctx context.Context, conf *config.Config) (rtr retriever.Retri
 		vectorField = value
 	}
 
-	// 构建 ES 8 检索器配置
-	retrieverConfig := &es8.RetrieverConfig{
-		Client: conf.Client, // ES 客户端实例
-		Index:  conf.IndexName,
-		// 指定搜索模式：基于密集向量相似度
-		SearchMode: search_mode.SearchModeDenseVectorSimilarity(
-			search_mode.DenseVectorSimilarityTypeCosineSimilarity, // 使用余弦相似度
-			vectorField,                                           // 向量字段名
-		),
-		// 指定搜索结果的解析函数（将 ES 命中结果转为 schema.Document）
-		ResultParser: EsHit2Document,
-	}
-
 	// 创建 embedding 实例，用于将查询文本转为向量
 	embeddingIns11, err := common.NewEmbedding(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
-	retrieverConfig.Embedding = embeddingIns11
 
-	// 构建 Retriever 组件
-	rtr, err = es8.NewRetriever(ctx, retrieverConfig)
+	return &storeRetriever{
+		store:       conf.Store,
+		index:       conf.IndexName,
+		vectorField: vectorField,
+		similarity:  common.VectorSimilarity(conf),
+		embedding:   embeddingIns11,
+	}, nil
+}
+
+// storeRetriever 基于 VectorStore 的 eino 检索器组件：将查询向量化后按索引的相似度检索，分数范围 0-2
+type storeRetriever struct {
+	store       vectorstore.VectorStore
+	index       string
+	vectorField string
+	similarity  string
+	embedding   embedding.Embedder
+}
+
+// options 检索器的自定义选项
+type options struct {
+	filter *vectorstore.Filter
+}
+
+// WithFilter 设置检索时的过滤条件
+func WithFilter(filter *vectorstore.Filter) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *options) {
+		o.filter = filter
+	})
+}
+
+func (r *storeRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) (docs []*schema.Document, err error) {
+	co := retriever.GetCommonOptions(&retriever.Options{
+		Index:     &r.index,
+		TopK:      common.Of(defaultTopK),
+		Embedding: r.embedding,
+	}, opts...)
+	io := retriever.GetImplSpecificOptions(&options{}, opts...)
+
+	ctx = callbacks.EnsureRunInfo(ctx, r.GetType(), components.ComponentOfRetriever)
+	ctx = callbacks.OnStart(ctx, &retriever.CallbackInput{
+		Query:          query,
+		TopK:           *co.TopK,
+		ScoreThreshold: co.ScoreThreshold,
+	})
+	defer func() {
+		if err != nil {
+			callbacks.OnError(ctx, err)
+		}
+	}()
+
+	if co.Embedding == nil {
+		return nil, fmt.Errorf("embedding not provided")
+	}
+	vectors, err := co.Embedding.EmbedStrings(embeddingCtx(ctx, co.Embedding), []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed, %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("vector size invalid, expect=1, got=%d", len(vectors))
+	}
+
+	records, err := r.store.KNN(ctx, *co.Index, &vectorstore.KNNRequest{
+		Field:      r.vectorField,
+		Vector:     vectors[0],
+		TopK:       *co.TopK,
+		Filter:     io.filter,
+		Similarity: r.similarity,
+	})
 	if err != nil {
 		return nil, err
 	}
+	docs = make([]*schema.Document, 0, len(records))
+	for _, record := range records {
+		if co.ScoreThreshold != nil && record.Score < *co.ScoreThreshold {
+			continue
+		}
+		var doc *schema.Document
+		doc, err = Record2Document(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
 
-	return rtr, nil
+	callbacks.OnEnd(ctx, &retriever.CallbackOutput{Docs: docs})
+	return docs, nil
 }
 
-// EsHit2Document 将 Elasticsearch 的命中结果（Hit）转换为通用文档结构（schema.Document）
-// 在 Retriever 查询时，每条命中结果（Hit）都会被传入该函数进行转换。
+func (r *storeRetriever) GetType() string {
+	return storeRetrieverType
+}
+
+func (r *storeRetriever) IsCallbacksEnabled() bool {
+	return true
+}
+
+func embeddingCtx(ctx context.Context, emb embedding.Embedder) context.Context {
+	runInfo := &callbacks.RunInfo{
+		Component: components.ComponentOfEmbedding,
+	}
+	if embType, ok := components.GetType(emb); ok {
+		runInfo.Type = embType
+	}
+	runInfo.Name = runInfo.Type + string(runInfo.Component)
+	return callbacks.ReuseHandlers(ctx, runInfo)
+}
+
+// Record2Document 将向量存储返回的记录转换为通用文档结构（schema.Document）
+// 检索和按 ID 读取 chunk 时，每条记录都会经过该函数转换。
 // 函数负责：
 //  1. 解析文档 ID 与内容
 //  2. 读取密集向量（Dense Vector）
@@ -78,40 +167,32 @@ func newRetriever(ctx context.Context, conf *config.Config) (rtr retriever.Retri
 //
 // 参数：
 //
-//	ctx - 上下文
-//	hit - ES 返回的一条命中记录
+//	ctx    - 上下文
+//	record - 向量存储返回的一条记录
 //
 // 返回：
 //
 //	doc - 转换后的文档对象（包含内容、元数据、向量等）
 //	err - 转换过程中可能发生的错误
-func EsHit2Document(ctx context.Context, hit types.Hit) (doc *schema.Document, err error) {
+func Record2Document(ctx context.Context, record *vectorstore.Record) (doc *schema.Document, err error) {
 	// 初始化基础文档对象
 	doc = &schema.Document{
-		ID:       *hit.Id_,         // ES 文档 ID
+		ID:       record.ID,        // 记录 ID（即 chunk_id）
 		MetaData: map[string]any{}, // 初始化空元数据
 	}
 
-	// 解析原始 JSON 源数据（_source）
-	var src map[string]any
-	if err = sonic.Unmarshal(hit.Source_, &src); err != nil {
-		return nil, err
-	}
-
-	// 遍历 ES 文档的字段，根据类型填充 Document 对象
-	for field, val := range src {
+	// 遍历记录的字段，根据类型填充 Document 对象
+	for field, val := range record.Fields {
 		switch field {
 		case common.FieldContent:
 			// 文档正文内容
 			doc.Content = val.(string)
 
 		case common.FieldContentVector:
-			// 向量字段（dense vector），将 interface{} 转为 []float64
-			var v []float64
-			for _, item := range val.([]interface{}) {
-				v = append(v, item.(float64))
+			// 向量字段（dense vector）
+			if v, ok := vectorstore.Float64s(val); ok {
+				doc.WithDenseVector(v)
 			}
-			doc.WithDenseVector(v)
 
 		case common.FieldQAContentVector, common.FieldQAContent:
 			// QA 字段（问答内容与向量）不返回给调用方，直接跳过
@@ -127,15 +208,23 @@ func EsHit2Document(ctx context.Context, hit types.Hit) (doc *schema.Document, e
 			// 所属知识库名称
 			doc.MetaData[common.KnowledgeName] = val.(string)
 
+		case common.FieldStatus:
+			// chunk 启用状态
+			doc.MetaData[common.FieldStatus] = val.(string)
+
+		case common.FieldFileName, common.FieldExtension, common.Title1, common.Title2, common.Title3,
+			common.FieldDocumentID, common.FieldUploadedAt:
+			// 结构化元数据只用于过滤，ext 中已包含相同内容，不重复返回
+
 		default:
 			// 发现未定义字段，返回错误方便调试
 			return nil, fmt.Errorf("unexpected field=%s, val=%v", field, val)
 		}
 	}
 
-	// 如果有打分信息（ES 返回 _score 字段），附加到文档
-	if hit.Score_ != nil {
-		doc.WithScore(float64(*hit.Score_))
+	// 附加检索得分
+	if record.Score != 0 {
+		doc.WithScore(record.Score)
 	}
 
 	return doc, nil
@@ -0,0 +1,75 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+var keywordSystem = "You are very skilled at using rag for data retrieval." +
+	"Your goal is to perform vectorized retrieval after fully understanding the user's question.\n" +
+	"Current time {time_now}\n" +
+	"You need to optimize and extract the search query content." +
+	"Please rewrite the query according to the following rules: \n" +
+	"- Rewrite the keywords that should be searched based on the user's question and context.\n" +
+	"- If time is required, the specific date and time information to be queried will be provided based on the current time.\n" +
+	"- Keep your search concise; your search should typically contain no more than three keywords, and at most five.\n" +
+	"- Rewrite keywords according to Elasticsearch search query conventions." +
+	"- Return the optimized search terms directly without any additional explanation.\n" +
+	"- Try to avoid using the following keywords that have already been used, as searching with these keywords previously will not yield the expected results. Keywords already used: {used}\n" +
+	"- Try to avoid using keywords contained in the knowledge base name \"{knowledgeBase}\".\n"
+
+var hydeSystem = "You are an expert writer for the knowledge base \"{knowledgeBase}\".\n" +
+	"Current time {time_now}\n" +
+	"Write a short passage (no more than 150 words) that directly answers the user's question, " +
+	"in the style of a document that could appear in the knowledge base.\n" +
+	"- It is fine to make up plausible details; the passage is only used for similarity search.\n" +
+	"- Write in the same language as the question.\n" +
+	"- Return the passage directly without any additional explanation.\n"
+
+var multiQuerySystem = "You are very skilled at using rag for data retrieval.\n" +
+	"Current time {time_now}\n" +
+	"Generate {n} different search queries for the user's question to retrieve relevant documents " +
+	"from the knowledge base \"{knowledgeBase}\".\n" +
+	"- Each query should look at the question from a different perspective, using different wording or synonyms.\n" +
+	"- Keep each query concise and in the same language as the question.\n" +
+	"- Return one query per line, without numbering or any additional explanation.\n"
+
+var stepBackSystem = "You are an expert at world knowledge.\n" +
+	"Your task is to step back and paraphrase the user's question into a more generic step-back question, " +
+	"which is easier to answer and retrieves the background knowledge needed for the original question.\n" +
+	"- Keep the step-back question short and in the same language as the question.\n" +
+	"- Return the step-back question directly without any additional explanation.\n"
+
+// createTemplate 创建并返回一个配置好的聊天模板
+func createTemplate(system string) prompt.ChatTemplate {
+	return prompt.FromMessages(schema.FString,
+		// 系统消息模板
+		schema.SystemMessage(system),
+		// 用户消息模板
+		schema.UserMessage(
+			"The following are user questions: {question}"),
+	)
+}
+
+// formatMessages 格式化消息并处理错误
+func formatMessages(template prompt.ChatTemplate, data map[string]any) ([]*schema.Message, error) {
+	messages, err := template.Format(context.Background(), data)
+	if err != nil {
+		return nil, fmt.Errorf("template formatting failed: %w", err)
+	}
+	return messages, nil
+}
+
+// getMessages 按策略的提示词生成消息，data 中补充当前时间与用户问题
+func getMessages(system, question string, data map[string]any) ([]*schema.Message, error) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["time_now"] = time.Now().Format(time.RFC3339)
+	data["question"] = question
+	return formatMessages(createTemplate(system), data)
+}
@@ -0,0 +1,59 @@
+package rewrite
+
+import (
+	"context"
+	"sync"
+)
+
+type recorderCtxKey struct{}
+
+// Recorder 记录一次请求中实际用于检索的问题，便于在接口响应中排查改写效果
+type Recorder struct {
+	mu      sync.Mutex
+	queries []*Query
+	seen    map[Query]bool
+	parent  *Recorder
+}
+
+// WithRecorder 在 ctx 中放入新的记录器，ctx 中已有记录器时新记录器的内容同时记录到已有记录器
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{seen: map[Query]bool{}, parent: recorderFromContext(ctx)}
+	return context.WithValue(ctx, recorderCtxKey{}, r), r
+}
+
+// Record 记录到 ctx 中的记录器，没有记录器时不生效
+func Record(ctx context.Context, queries ...*Query) {
+	recorderFromContext(ctx).Add(queries...)
+}
+
+func recorderFromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderCtxKey{}).(*Recorder)
+	return r
+}
+
+// Add 记录检索问题，相同的问题只记录一次。nil 上调用不生效
+func (r *Recorder) Add(queries ...*Query) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	for _, q := range queries {
+		if q == nil || r.seen[*q] {
+			continue
+		}
+		r.seen[*q] = true
+		r.queries = append(r.queries, q)
+	}
+	r.mu.Unlock()
+	r.parent.Add(queries...)
+}
+
+// Queries 返回按记录顺序排列的检索问题
+func (r *Recorder) Queries() []*Query {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Query(nil), r.queries...)
+}
@@ -0,0 +1,234 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 问题改写策略
+const (
+	StrategyNone       = "none"        // 不改写，直接使用原问题检索
+	StrategyKeyword    = "keyword"     // 改写为检索关键词，多轮之间避开已用过的关键词（默认）
+	StrategyHyDE       = "hyde"        // 生成假设的答案文档，用其向量检索，关键词检索与重排仍使用原问题
+	StrategyMultiQuery = "multi_query" // 一次调用生成多个不同角度的问题
+	StrategyStepBack   = "step_back"   // 生成更抽象的退一步问题，与原问题一起检索背景知识
+)
+
+// 默认配置
+const (
+	defaultRounds      = 3
+	defaultParallelism = 1
+	maxRounds          = 10
+)
+
+// Query 一条改写后用于检索的问题
+type Query struct {
+	Strategy string `json:"strategy"`        // 产生该问题的策略
+	Text     string `json:"text"`            // 关键词检索与重排使用的问题
+	Embed    string `json:"embed,omitempty"` // 向量检索使用的文本，为空时使用 Text
+}
+
+// EmbedText 返回向量检索使用的文本
+func (q *Query) EmbedText() string {
+	if q.Embed != "" {
+		return q.Embed
+	}
+	return q.Text
+}
+
+// Options 改写参数
+type Options struct {
+	Strategy      string // 改写策略，为空时读取配置 rewrite.strategy
+	Rounds        int    // 改写轮数：keyword、hyde 为调用次数，multi_query 为生成的问题数，为 0 时读取配置 rewrite.rounds
+	Parallelism   int    // 同时进行的改写调用数，为 0 时读取配置 rewrite.parallelism；keyword 策略并行时只能避开已完成的关键词
+	KnowledgeName string // 知识库名称，用于提示词
+}
+
+// Rewriter 问题改写器
+type Rewriter struct {
+	cm model.BaseChatModel
+}
+
+// New 创建问题改写器
+func New(cm model.BaseChatModel) *Rewriter {
+	return &Rewriter{cm: cm}
+}
+
+// ResolveOptions 用配置补齐未指定的改写参数
+func ResolveOptions(ctx context.Context, opts *Options) *Options {
+	o := &Options{}
+	if opts != nil {
+		*o = *opts
+	}
+	if o.Strategy == "" {
+		o.Strategy = g.Cfg().MustGet(ctx, "rewrite.strategy", StrategyKeyword).String()
+	}
+	if o.Rounds <= 0 {
+		o.Rounds = g.Cfg().MustGet(ctx, "rewrite.rounds", defaultRounds).Int()
+	}
+	if o.Rounds <= 0 {
+		o.Rounds = defaultRounds
+	}
+	o.Rounds = min(o.Rounds, maxRounds)
+	if o.Parallelism <= 0 {
+		o.Parallelism = g.Cfg().MustGet(ctx, "rewrite.parallelism", defaultParallelism).Int()
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = defaultParallelism
+	}
+	return o
+}
+
+// Rewrite 按策略改写问题，每得到一条检索问题就调用一次 emit，调用方可以立即开始检索。
+// emit 可能被并发调用；部分改写失败时只记录日志，全部失败才返回错误
+func (x *Rewriter) Rewrite(ctx context.Context, question string, opts *Options, emit func(*Query)) error {
+	switch opts.Strategy {
+	case StrategyNone:
+		emit(&Query{Strategy: StrategyNone, Text: question})
+		return nil
+	case StrategyKeyword:
+		return x.keyword(ctx, question, opts, emit)
+	case StrategyHyDE:
+		return x.rounds(ctx, opts, emit, func(ctx context.Context) (*Query, error) {
+			passage, err := x.generate(ctx, hydeSystem, question, map[string]any{"knowledgeBase": opts.KnowledgeName})
+			if err != nil {
+				return nil, err
+			}
+			return &Query{Strategy: StrategyHyDE, Text: question, Embed: passage}, nil
+		})
+	case StrategyMultiQuery:
+		return x.multiQuery(ctx, question, opts, emit)
+	case StrategyStepBack:
+		emit(&Query{Strategy: StrategyStepBack, Text: question})
+		stepBack, err := x.generate(ctx, stepBackSystem, question, nil)
+		if err != nil {
+			// 原问题已经可以检索，退一步问题失败不影响结果
+			g.Log().Errorf(ctx, "step-back rewrite failed, err=%v", err)
+			return nil
+		}
+		emit(&Query{Strategy: StrategyStepBack, Text: stepBack})
+		return nil
+	default:
+		return fmt.Errorf("unknown rewrite strategy: %s", opts.Strategy)
+	}
+}
+
+// keyword 改写为检索关键词。每轮把已完成的关键词放入提示词，并行度为 1 时与逐轮改写一致
+func (x *Rewriter) keyword(ctx context.Context, question string, opts *Options, emit func(*Query)) error {
+	var (
+		mu   sync.Mutex
+		used []string // 记录已经使用过的关键词
+	)
+	return x.rounds(ctx, opts, emit, func(ctx context.Context) (*Query, error) {
+		mu.Lock()
+		usedStr := strings.Join(used, " ")
+		mu.Unlock()
+		keywords, err := x.generate(ctx, keywordSystem, question, map[string]any{
+			"used":          usedStr,
+			"knowledgeBase": opts.KnowledgeName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		used = append(used, keywords)
+		mu.Unlock()
+		return &Query{Strategy: StrategyKeyword, Text: keywords}, nil
+	})
+}
+
+// multiQuery 一次调用生成 opts.Rounds 个问题，并附上原问题
+func (x *Rewriter) multiQuery(ctx context.Context, question string, opts *Options, emit func(*Query)) error {
+	content, err := x.generate(ctx, multiQuerySystem, question, map[string]any{
+		"n":             opts.Rounds,
+		"knowledgeBase": opts.KnowledgeName,
+	})
+	if err != nil {
+		return err
+	}
+	emit(&Query{Strategy: StrategyMultiQuery, Text: question})
+	for _, q := range parseLines(content, opts.Rounds) {
+		if q != question {
+			emit(&Query{Strategy: StrategyMultiQuery, Text: q})
+		}
+	}
+	return nil
+}
+
+// rounds 调用 opts.Rounds 次 fn，同时最多 opts.Parallelism 个
+func (x *Rewriter) rounds(ctx context.Context, opts *Options, emit func(*Query), fn func(ctx context.Context) (*Query, error)) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, max(opts.Parallelism, 1))
+	)
+	for i := 0; i < opts.Rounds; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			q, err := fn(ctx)
+			if err != nil {
+				g.Log().Errorf(ctx, "%s rewrite failed, err=%v", opts.Strategy, err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			emit(q)
+		}()
+	}
+	wg.Wait()
+	if len(errs) == opts.Rounds {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (x *Rewriter) generate(ctx context.Context, system, question string, data map[string]any) (string, error) {
+	messages, err := getMessages(system, question, data)
+	if err != nil {
+		return "", err
+	}
+	msg, err := x.cm.Generate(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(msg.Content)
+	if content == "" {
+		return "", fmt.Errorf("empty rewrite result")
+	}
+	return content, nil
+}
+
+// listMarker 行首的列表符号或编号，编号后需有空白（"、" 除外），避免去掉问题本身以数字开头的部分，如 "2025 年营收"、"3.5 Turbo"
+var listMarker = regexp.MustCompile(`^(?:(?:[-*•]|\d+[.)])\s+|\d+、\s*)`)
+
+// parseLines 解析每行一个的问题，去掉编号、列表符号与空行，最多返回 n 个
+func parseLines(content string, n int) []string {
+	var res []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = listMarker.ReplaceAllString(line, "")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		res = append(res, line)
+		if len(res) >= n {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,173 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeChatModel 按调用次序返回 replies，同时记录每次调用的系统提示词
+type fakeChatModel struct {
+	mu      sync.Mutex
+	replies []string
+	err     error
+	systems []string
+}
+
+func (f *fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.systems = append(f.systems, input[0].Content)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.replies) == 0 {
+		return nil, fmt.Errorf("no reply")
+	}
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	return schema.AssistantMessage(reply, nil), nil
+}
+
+func (f *fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func collect(t *testing.T, cm *fakeChatModel, question string, opts *Options) []*Query {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		queries []*Query
+	)
+	err := New(cm).Rewrite(context.Background(), question, opts, func(q *Query) {
+		mu.Lock()
+		queries = append(queries, q)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	return queries
+}
+
+func texts(queries []*Query) []string {
+	res := make([]string, 0, len(queries))
+	for _, q := range queries {
+		res = append(res, q.Text)
+	}
+	return res
+}
+
+func TestRewriteKeyword(t *testing.T) {
+	cm := &fakeChatModel{replies: []string{"重置 密码", "找回 账号"}}
+	queries := collect(t, cm, "忘记密码怎么办", &Options{Strategy: StrategyKeyword, Rounds: 2, Parallelism: 1})
+	if got := strings.Join(texts(queries), ","); got != "重置 密码,找回 账号" {
+		t.Fatalf("unexpected queries: %s", got)
+	}
+	// 逐轮改写时，后一轮的提示词中包含前一轮的关键词
+	if !strings.Contains(cm.systems[1], "Keywords already used: 重置 密码") {
+		t.Fatalf("second round should avoid used keywords, got %q", cm.systems[1])
+	}
+}
+
+func TestRewriteParallel(t *testing.T) {
+	cm := &fakeChatModel{replies: []string{"a", "b", "c"}}
+	queries := collect(t, cm, "q", &Options{Strategy: StrategyKeyword, Rounds: 3, Parallelism: 3})
+	got := texts(queries)
+	sort.Strings(got)
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Fatalf("unexpected queries: %v", got)
+	}
+}
+
+func TestRewriteStrategies(t *testing.T) {
+	question := "为什么天空是蓝色的"
+
+	queries := collect(t, &fakeChatModel{}, question, &Options{Strategy: StrategyNone, Rounds: 3})
+	if len(queries) != 1 || queries[0].Text != question {
+		t.Fatalf("none should keep the question, got %v", texts(queries))
+	}
+
+	queries = collect(t, &fakeChatModel{replies: []string{"瑞利散射使短波长的蓝光散射更强。"}}, question,
+		&Options{Strategy: StrategyHyDE, Rounds: 1, Parallelism: 1})
+	if len(queries) != 1 || queries[0].Text != question || queries[0].EmbedText() != "瑞利散射使短波长的蓝光散射更强。" {
+		t.Fatalf("hyde should embed the passage, got %+v", queries)
+	}
+
+	queries = collect(t, &fakeChatModel{replies: []string{"1. 天空颜色的成因\n- 光的散射原理\n\n大气对阳光的影响\n多余的问题"}}, question,
+		&Options{Strategy: StrategyMultiQuery, Rounds: 3})
+	if got := strings.Join(texts(queries), ","); got != question+",天空颜色的成因,光的散射原理,大气对阳光的影响" {
+		t.Fatalf("unexpected multi-query result: %s", got)
+	}
+
+	queries = collect(t, &fakeChatModel{replies: []string{"光的散射有哪些规律"}}, question, &Options{Strategy: StrategyStepBack})
+	if got := strings.Join(texts(queries), ","); got != question+",光的散射有哪些规律" {
+		t.Fatalf("unexpected step-back result: %s", got)
+	}
+	// 退一步问题失败时仍保留原问题
+	queries = collect(t, &fakeChatModel{err: errors.New("timeout")}, question, &Options{Strategy: StrategyStepBack})
+	if len(queries) != 1 || queries[0].Text != question {
+		t.Fatalf("step-back should fall back to the question, got %v", texts(queries))
+	}
+}
+
+func TestRewriteFailed(t *testing.T) {
+	cm := &fakeChatModel{err: errors.New("timeout")}
+	err := New(cm).Rewrite(context.Background(), "q", &Options{Strategy: StrategyKeyword, Rounds: 2, Parallelism: 2}, func(*Query) {
+		t.Fatal("unexpected query")
+	})
+	if err == nil {
+		t.Fatal("expect error when all rounds failed")
+	}
+	if err = New(cm).Rewrite(context.Background(), "q", &Options{Strategy: "unknown"}, func(*Query) {}); err == nil {
+		t.Fatal("expect error for unknown strategy")
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	content := "1. 2025 revenue by region\n- 3.5 Turbo pricing\n\n2、如何重置密码\n3) 错误码 E1024\n2025 revenue\n3.5 Turbo"
+	expect := []string{"2025 revenue by region", "3.5 Turbo pricing", "如何重置密码", "错误码 E1024", "2025 revenue", "3.5 Turbo"}
+	if got := parseLines(content, 10); strings.Join(got, "|") != strings.Join(expect, "|") {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+	if got := parseLines(content, 2); len(got) != 2 {
+		t.Fatalf("expect 2 lines, got %q", got)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx, parent := WithRecorder(context.Background())
+	ctx, child := WithRecorder(ctx)
+	q := &Query{Strategy: StrategyKeyword, Text: "a"}
+	Record(ctx, q, &Query{Strategy: StrategyKeyword, Text: "a"}, &Query{Strategy: StrategyKeyword, Text: "b"})
+	if len(child.Queries()) != 2 || len(parent.Queries()) != 2 {
+		t.Fatalf("expect deduplicated queries in both recorders, got %d and %d", len(child.Queries()), len(parent.Queries()))
+	}
+	Record(context.Background(), q) // 没有记录器时不生效
+}
+
+func TestStandalone(t *testing.T) {
+	ctx := context.Background()
+	cm := &fakeChatModel{replies: []string{"“第二个套餐的价格是多少”\n"}}
+	if q, err := New(cm).Standalone(ctx, "第二个呢", nil); err != nil || q != "第二个呢" || len(cm.systems) != 0 {
+		t.Fatalf("expect the question unchanged without history, got %q, %v", q, err)
+	}
+	history := []*schema.Message{
+		schema.UserMessage("有哪些套餐"),
+		schema.AssistantMessage("有基础版和专业版 {见价格表}", nil),
+	}
+	q, err := New(cm).Standalone(ctx, "第二个呢", history)
+	if err != nil || q != "第二个套餐的价格是多少" {
+		t.Fatalf("unexpected standalone question %q, %v", q, err)
+	}
+	if _, err = New(&fakeChatModel{err: errors.New("timeout")}).Standalone(ctx, "第二个呢", history); err == nil {
+		t.Fatal("expect error when the model failed")
+	}
+}
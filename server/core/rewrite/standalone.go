@@ -0,0 +1,48 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// historyMaxRunes 改写独立问题时每条历史消息保留的最大字符数，较早的回答通常很长，只需要其中提到的对象
+const historyMaxRunes = 500
+
+var standaloneSystem = "You rewrite follow-up questions of a conversation into standalone questions for document retrieval.\n" +
+	"- Resolve pronouns, ellipsis and references such as \"the second one\" or \"it\" using the conversation history.\n" +
+	"- Keep the meaning, constraints and language of the follow-up question; do not answer it or add new information.\n" +
+	"- If the question is already standalone, return it unchanged.\n" +
+	"- Return only the standalone question without any additional explanation.\n"
+
+// Standalone 结合对话历史把追问改写为可以独立理解与检索的问题，history 为空时原样返回。
+// 历史中的摘要等系统消息作为背景放在最前面；消息内容可能包含花括号，不使用 FString 模板
+func (x *Rewriter) Standalone(ctx context.Context, question string, history []*schema.Message) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("Conversation history:\n")
+	for _, m := range history {
+		content := strings.TrimSpace(m.Content)
+		if runes := []rune(content); len(runes) > historyMaxRunes {
+			content = string(runes[:historyMaxRunes]) + "…"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", m.Role, content)
+	}
+	fmt.Fprintf(&sb, "\nFollow-up question: %s", question)
+	msg, err := x.cm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(standaloneSystem),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("rewrite standalone question failed: %w", err)
+	}
+	standalone := strings.Trim(strings.TrimSpace(msg.Content), "\"“”")
+	if standalone == "" {
+		return "", fmt.Errorf("rewrite standalone question failed: empty result")
+	}
+	return standalone, nil
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/config"
+	"github.com/everfid-ever/ThinkForge/core/indexer"
+	"github.com/everfid-ever/ThinkForge/core/retriever"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// space 一个索引及其 embedding 配置对应的索引器与检索器。
+// 不同 embedding 模型的向量维度不同，不能写入同一个索引，因此知识库可以使用独立的索引。
+type space struct {
+	conf       *config.Config                                 // 索引名及 embedding 配置
+	idxer      compose.Runnable[any, []string]                // 同步索引构建器
+	idxerAsync compose.Runnable[[]*schema.Document, []string] // 异步索引构建器
+	rtrvr      compose.Runnable[string, []*schema.Document]   // 普通文档检索器
+	qaRtrvr    compose.Runnable[string, []*schema.Document]   // 问答专用检索器（针对 QA 向量字段）
+}
+
+// newSpace 创建索引并构建对应的索引器与检索器。
+// 索引已存在时会补充缺失的字段映射（如 chunk 状态），向量维度与配置不一致时返回 vectorstore.ErrDimsMismatch。
+func newSpace(ctx context.Context, conf *config.Config) (*space, error) {
+	// ① 如果索引不存在则自动创建
+	err := conf.Store.CreateIndex(ctx, conf.IndexName, common.NewIndexSpec(conf))
+	if err != nil {
+		return nil, fmt.Errorf("prepare index %s failed: %w", conf.IndexName, err)
+	}
+
+	// ② 构建索引器（同步）
+	buildIndex, err := indexer.BuildIndexer(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// ③ 构建索引器（异步，用于批量导入）
+	buildIndexAsync, err := indexer.BuildIndexerAsync(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// ④ 构建普通文档检索器（Retriever）
+	buildRetriever, err := retriever.BuildRetriever(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// ⑤ 构建 QA 检索器，检索时使用 QA 向量字段（如 question/answer 嵌入）
+	qaCtx := context.WithValue(ctx, common.RetrieverFieldKey, common.FieldQAContentVector)
+	qaRetriever, err := retriever.BuildRetriever(qaCtx, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &space{
+		conf:       conf,
+		idxer:      buildIndex,
+		idxerAsync: buildIndexAsync,
+		rtrvr:      buildRetriever,
+		qaRtrvr:    qaRetriever,
+	}, nil
+}
+
+func (s *space) index() string {
+	return s.conf.IndexName
+}
+
+// spaceKey 索引名与 embedding 配置都相同时复用同一个空间
+func spaceKey(conf *config.Config) string {
+	return fmt.Sprintf("%s|%s|%d|%s", conf.IndexName, conf.EmbeddingModel, conf.Dims, conf.Similarity)
+}
+
+// space 返回知识库所在的空间，未配置独立索引（或未在 knowledge_base 中登记）的知识库使用默认空间
+func (x *Rag) space(ctx context.Context, knowledgeName string) (*space, error) {
+	if knowledgeName == "" {
+		return x.def, nil
+	}
+	kb, found, err := knowledge.GetKnowledgeBaseByName(ctx, knowledgeName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return x.def, nil
+	}
+	return x.spaceOf(ctx, kb)
+}
+
+func (x *Rag) spaceOf(ctx context.Context, kb entity.KnowledgeBase) (*space, error) {
+	if kb.IndexName == "" {
+		return x.def, nil
+	}
+	conf := x.kbConf(kb)
+	key := spaceKey(conf)
+	if s, ok := x.spaces.Load(key); ok {
+		return s.(*space), nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if s, ok := x.spaces.Load(key); ok {
+		return s.(*space), nil
+	}
+	s, err := newSpace(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	x.spaces.Store(key, s)
+	return s, nil
+}
+
+// kbConf 返回知识库的索引及 embedding 配置，未登记的配置项使用全局默认值
+func (x *Rag) kbConf(kb entity.KnowledgeBase) *config.Config {
+	conf := x.conf.Copy()
+	conf.IndexName = kb.IndexName
+	if kb.EmbeddingModel != "" {
+		conf.EmbeddingModel = kb.EmbeddingModel
+	}
+	if kb.EmbeddingDims > 0 {
+		conf.Dims = kb.EmbeddingDims
+	}
+	if kb.Similarity != "" {
+		conf.Similarity = kb.Similarity
+	}
+	return conf
+}
+
+// kbAlias 知识库独立索引的别名，读写都通过别名进行，重建索引时切换别名指向的版本化索引
+func (x *Rag) kbAlias(kb entity.KnowledgeBase) string {
+	return fmt.Sprintf("%s_kb_%d", x.conf.IndexName, kb.Id)
+}
+
+// indexVersion 返回别名下新的版本化索引名
+func indexVersion(alias string) string {
+	return fmt.Sprintf("%s_v%s", alias, gtime.Now().Format("YmdHis"))
+}
+
+// PrepareKnowledgeBase 为知识库创建独立索引，并把别名与 embedding 配置记录到 knowledge_base。
+// 未指定的 embedding 配置使用全局默认值；之后该知识库的写入与检索都只通过这个别名进行。
+func (x *Rag) PrepareKnowledgeBase(ctx context.Context, kb entity.KnowledgeBase) error {
+	if kb.EmbeddingModel == "" {
+		kb.EmbeddingModel = x.conf.EmbeddingModel
+	}
+	if kb.EmbeddingDims <= 0 {
+		kb.EmbeddingDims = common.VectorDims(x.conf)
+	}
+	if kb.Similarity == "" {
+		kb.Similarity = common.VectorSimilarity(x.conf)
+	}
+	if kb.IndexName == "" {
+		kb.IndexName = x.kbAlias(kb)
+	}
+	conf := x.kbConf(kb)
+	index := indexVersion(kb.IndexName)
+	if err := x.store.CreateIndex(ctx, index, common.NewIndexSpec(conf)); err != nil {
+		return err
+	}
+	if _, err := x.store.SwitchAlias(ctx, kb.IndexName, index); err != nil {
+		return err
+	}
+	if _, err := x.spaceOf(ctx, kb); err != nil {
+		return err
+	}
+	return knowledge.SaveKnowledgeBaseIndex(ctx, kb)
+}
+
+// PrepareKnowledgeBases 启动时校验所有知识库的独立索引：
+// 向量维度与知识库登记的配置不一致时返回错误，该知识库在修复（重建索引）前无法写入与检索
+func (x *Rag) PrepareKnowledgeBases(ctx context.Context) error {
+	list, err := knowledge.GetKnowledgeBasesWithIndex(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, kb := range list {
+		if _, err = x.spaceOf(ctx, kb); err != nil {
+			g.Log().Errorf(ctx, "prepare knowledge base %s failed, err=%v", kb.Name, err)
+			errs = append(errs, fmt.Errorf("knowledge base %s: %w", kb.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// indexes 返回默认索引及所有知识库独立索引的名称
+func (x *Rag) indexes(ctx context.Context) ([]string, error) {
+	list, err := knowledge.GetKnowledgeBasesWithIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indexes := []string{x.def.index()}
+	seen := map[string]bool{x.def.index(): true}
+	for _, kb := range list {
+		if !seen[kb.IndexName] {
+			seen[kb.IndexName] = true
+			indexes = append(indexes, kb.IndexName)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,189 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event 一个 SSE 事件
+type Event struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Decode 把事件数据解析到 v，如 token 事件解析到 Token
+func (e *Event) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Reader 从响应体中逐个读取事件，忽略心跳等注释行
+type Reader struct {
+	r     *bufio.Reader
+	retry time.Duration
+}
+
+// NewReader 创建事件读取器
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Retry 返回服务端建议的重连间隔，未下发时为 0
+func (r *Reader) Retry() time.Duration {
+	return r.retry
+}
+
+// Next 读取下一个事件，流结束时返回 io.EOF
+func (r *Reader) Next() (*Event, error) {
+	var (
+		e    = &Event{}
+		data [][]byte
+		seen bool // 是否读到了事件字段
+	)
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+			if errors.Is(err, io.EOF) && seen {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !seen {
+				continue
+			}
+			if e.Event == "" {
+				e.Event = "message"
+			}
+			e.Data = bytes.Join(data, []byte("\n"))
+			return e, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // 注释（心跳）
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			e.ID, seen = value, true
+		case "event":
+			e.Event, seen = value, true
+		case "data":
+			data, seen = append(data, []byte(value)), true
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// Client 订阅流式接口，连接意外断开时携带 Last-Event-ID 重连，直到收到 done 事件。
+// 服务端无法续传时会作为新请求重新执行，此时会再次收到 meta 事件，可据此丢弃已收到的内容
+type Client struct {
+	HTTPClient *http.Client  // 为空时使用 http.DefaultClient
+	MaxRetries int           // 最大重连次数，0 表示不重连
+	RetryDelay time.Duration // 重连间隔，服务端下发 retry 时以其为准
+}
+
+// Post 以 JSON 形式 POST body 并订阅返回的事件，见 Stream
+func (c *Client) Post(ctx context.Context, url string, body any, handle func(*Event) error) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.Stream(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, handle)
+}
+
+// Stream 发起请求并逐个回调事件，收到 done 事件后返回。newRequest 在每次连接（含重连）时调用，
+// 需要返回新的请求；handle 返回错误时停止订阅并返回该错误
+func (c *Client) Stream(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error), handle func(*Event) error) error {
+	s := &subscription{client: c.HTTPClient, newRequest: newRequest, handle: handle, delay: c.RetryDelay}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	if s.delay <= 0 {
+		s.delay = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := s.connect(ctx)
+		if !retry || attempt >= c.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.delay):
+		}
+	}
+}
+
+// subscription 一次订阅的状态，在多次连接之间保留最后收到的事件 id 与重连间隔
+type subscription struct {
+	client     *http.Client
+	newRequest func(ctx context.Context) (*http.Request, error)
+	handle     func(*Event) error
+	lastID     string
+	delay      time.Duration
+}
+
+// connect 建立一次连接并读取事件，收到 done 事件时返回 nil。retry 表示连接意外断开，可以重连
+func (s *subscription) connect(ctx context.Context) (retry bool, err error) {
+	req, err := s.newRequest(ctx)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.lastID != "" {
+		req.Header.Set(HeaderLastEventID, s.lastID)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode >= http.StatusInternalServerError,
+			fmt.Errorf("sse: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	r := NewReader(resp.Body)
+	for {
+		e, err := r.Next()
+		if err != nil {
+			if r.Retry() > 0 {
+				s.delay = r.Retry()
+			}
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF // 没有收到 done 事件就结束了
+			}
+			return ctx.Err() == nil, err
+		}
+		if e.ID != "" {
+			s.lastID = e.ID
+		}
+		if err = s.handle(e); err != nil {
+			return false, err
+		}
+		if e.Event == EventDone {
+			return false, nil
+		}
+	}
+}
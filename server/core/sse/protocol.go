@@ -0,0 +1,73 @@
+// Package sse 流式接口使用的 SSE 协议：服务端的写入器与断线续传缓存，以及供调用方使用的客户端。
+//
+// 每个事件都带有 event 名称与 id，id 的格式为 "<stream_id>:<seq>"，seq 从 1 开始递增。
+// 流以 meta 事件开始、done 事件结束，中间的事件按流的类型而定：
+//
+//	对话：reasoning → references → token … → citation … → usage
+//	任务进度：progress …
+//
+// 出错时先发送 error 事件再发送 done 事件。空闲时发送以冒号开头的注释行作为心跳，客户端应忽略。
+// 客户端断线后携带 Last-Event-ID 重新请求，服务端仍保留该流时从断点之后继续推送
+package sse
+
+import (
+	"github.com/cloudwego/eino/schema"
+)
+
+// Version 协议版本，写在 meta 事件与响应头 HeaderProtocol 中
+const Version = "thinkforge.sse.v1"
+
+// HeaderProtocol 声明协议版本的响应头
+const HeaderProtocol = "X-SSE-Protocol"
+
+// HeaderLastEventID 断线重连时客户端携带的最后一个事件 id
+const HeaderLastEventID = "Last-Event-ID"
+
+// 事件名称
+const (
+	EventMeta       = "meta"       // 流的元信息，第一个事件，数据为 Meta
+	EventReferences = "references" // 参考文档，数据为 References；可能发送多次，以最后一次为准
+	EventToken      = "token"      // 答案的增量内容，数据为 Token
+	EventReasoning  = "reasoning"  // 推理步骤（思考、动作、观察、打分等）
+	EventCitation   = "citation"   // 答案中的一个引用，每个引用一个事件
+	EventUsage      = "usage"      // 模型调用的 token 用量，数据为 Usage
+	EventProgress   = "progress"   // 后台任务的最新状态
+	EventError      = "error"      // 出错，数据为 Error
+	EventDone       = "done"       // 结束，最后一个事件，数据为 Done
+)
+
+// Meta meta 事件的数据
+type Meta struct {
+	Version  string         `json:"version"`         // 协议版本
+	StreamID string         `json:"stream_id"`       // 流 ID，事件 id 的前缀
+	Kind     string         `json:"kind"`            // 流的类型，如 chat、index_job
+	Created  int64          `json:"created"`         // 创建时间，Unix 秒
+	Extra    map[string]any `json:"extra,omitempty"` // 与流类型相关的信息
+}
+
+// References references 事件的数据
+type References struct {
+	Documents []*schema.Document `json:"documents"`
+}
+
+// Token token 事件的数据
+type Token struct {
+	Content string `json:"content"`
+}
+
+// Usage usage 事件的数据
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Error error 事件的数据
+type Error struct {
+	Message string `json:"message"`
+}
+
+// Done done 事件的数据，Result 为接口的完整结果（如对话的 ChatRes），出错时为空
+type Done struct {
+	Result any `json:"result,omitempty"`
+}
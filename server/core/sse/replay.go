@@ -0,0 +1,143 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errDropped 续传过程中后续事件超出缓存上限被丢弃
+var errDropped = errors.New("sse: events dropped from replay buffer")
+
+// Replay 在内存中缓存各个流发送过的事件，客户端断线后携带 Last-Event-ID 重连时，
+// 从断点之后补发，流尚未结束时继续推送后续事件。只在单个服务实例内有效
+type Replay struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	limit   int
+	streams map[string]*buffer
+}
+
+// NewReplay 创建事件缓存。ttl 为流结束后保留的时长，limit 为单个流最多缓存的事件数（超出时丢弃最早的事件）
+func NewReplay(ttl time.Duration, limit int) *Replay {
+	return &Replay{ttl: ttl, limit: limit, streams: make(map[string]*buffer)}
+}
+
+// buffer 单个流的事件缓存
+type buffer struct {
+	mu      sync.Mutex
+	events  []*Event
+	dropped int           // 因超出 limit 丢弃的事件数
+	limit   int           // 最多缓存的事件数，<=0 不限制
+	done    bool          // 流是否已结束
+	notify  chan struct{} // 有新事件或流结束时关闭并替换，唤醒等待的续传
+	onDone  func()
+}
+
+func (r *Replay) open(streamID string) *buffer {
+	b := &buffer{limit: r.limit, notify: make(chan struct{})}
+	b.onDone = func() {
+		time.AfterFunc(r.ttl, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.streams[streamID] == b {
+				delete(r.streams, streamID)
+			}
+		})
+	}
+	r.mu.Lock()
+	r.streams[streamID] = b
+	r.mu.Unlock()
+	return b
+}
+
+func (b *buffer) append(e *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, e)
+	if b.limit > 0 && len(b.events) > b.limit {
+		n := len(b.events) - b.limit
+		b.events = append(b.events[:0:0], b.events[n:]...)
+		b.dropped += n
+	}
+	b.wake()
+}
+
+func (b *buffer) finish() {
+	b.mu.Lock()
+	b.done = true
+	b.wake()
+	b.mu.Unlock()
+	b.onDone()
+}
+
+// wake 唤醒等待中的续传，调用方需持有锁
+func (b *buffer) wake() {
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// since 返回序号 seq 之后的事件、流是否已结束以及下一次等待用的通道。seq 之后的事件已被丢弃时 ok 为 false
+func (b *buffer) since(seq int) (events []*Event, done bool, notify <-chan struct{}, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq < b.dropped {
+		return nil, false, nil, false
+	}
+	if idx := seq - b.dropped; idx < len(b.events) {
+		events = b.events[idx:]
+	}
+	return events, b.done, b.notify, true
+}
+
+// Resume 按 lastEventID 续传：向 w 补发断点之后的事件（保持原来的 id），流未结束时持续推送直到结束或 ctx 取消。
+// 流不存在、已过期或断点之后的事件已被丢弃时返回 false，调用方应按新请求处理
+func (r *Replay) Resume(ctx context.Context, w *Writer, lastEventID string) (bool, error) {
+	streamID, seq, ok := ParseID(lastEventID)
+	if !ok {
+		return false, nil
+	}
+	r.mu.Lock()
+	b := r.streams[streamID]
+	r.mu.Unlock()
+	if b == nil {
+		return false, nil
+	}
+	resumed := false
+	for {
+		events, done, notify, ok := b.since(seq)
+		if !ok {
+			if resumed {
+				return true, errDropped
+			}
+			return false, nil
+		}
+		resumed = true
+		for _, e := range events {
+			if err := w.forward(e); err != nil {
+				return true, err
+			}
+		}
+		seq += len(events)
+		if done {
+			return true, nil
+		}
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// forward 以原来的 id 写入缓存中的事件
+func (w *Writer) forward(e *Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	w.write(encode(e.ID, e.Event, e.Data))
+	return w.err
+}
@@ -0,0 +1,168 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, body string) []*Event {
+	t.Helper()
+	r := NewReader(strings.NewReader(body))
+	var events []*Event
+	for {
+		e, err := r.Next()
+		if err != nil {
+			break
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestWriterReader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewWriter(rec, &Config{StreamID: "s", Retry: time.Second})
+	_ = w.Meta("chat", nil)
+	_ = w.Send(EventToken, &Token{Content: "第一行\n第二行"})
+	_ = w.Done(map[string]string{"answer": "ok"})
+	if err := w.Send(EventToken, &Token{}); err == nil {
+		t.Fatal("expect error after done")
+	}
+	if rec.Header().Get(HeaderProtocol) != Version {
+		t.Fatalf("unexpected header %v", rec.Header())
+	}
+
+	r := NewReader(strings.NewReader(rec.Body.String()))
+	var events []*Event
+	for {
+		e, err := r.Next()
+		if err != nil {
+			break
+		}
+		events = append(events, e)
+	}
+	if r.Retry() != time.Second {
+		t.Fatalf("unexpected retry %v", r.Retry())
+	}
+	if len(events) != 3 || events[0].Event != EventMeta || events[2].Event != EventDone {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if events[1].ID != "s:2" {
+		t.Fatalf("unexpected id %s", events[1].ID)
+	}
+	var token Token
+	if err := events[1].Decode(&token); err != nil || token.Content != "第一行\n第二行" {
+		t.Fatalf("unexpected token %q, err=%v", token.Content, err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewWriter(rec, &Config{Heartbeat: 10 * time.Millisecond})
+	time.Sleep(50 * time.Millisecond)
+	w.Close()
+	body := rec.Body.String()
+	if !strings.Contains(body, ": ping\n\n") {
+		t.Fatalf("expect heartbeat, got %q", body)
+	}
+	if events := readAll(t, body); len(events) != 0 {
+		t.Fatalf("heartbeat should be ignored, got %+v", events)
+	}
+}
+
+func TestWriterClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	w := NewWriter(rec, &Config{Context: ctx})
+	defer w.Close()
+	if err := w.Send(EventToken, &Token{Content: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.Send(EventToken, &Token{Content: "b"}); err == nil {
+		t.Fatal("expect error after the client is gone")
+	}
+	if events := readAll(t, rec.Body.String()); len(events) != 1 {
+		t.Fatalf("expect 1 event, got %d", len(events))
+	}
+}
+
+func TestResume(t *testing.T) {
+	replay := NewReplay(time.Minute, 0)
+	w := NewWriter(httptest.NewRecorder(), &Config{StreamID: "s", Replay: replay})
+	_ = w.Meta("chat", nil)
+	_ = w.Send(EventToken, &Token{Content: "a"})
+	_ = w.Send(EventToken, &Token{Content: "b"})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = w.Send(EventToken, &Token{Content: "c"})
+		_ = w.Done(nil)
+	}()
+
+	rec := httptest.NewRecorder()
+	ok, err := replay.Resume(context.Background(), NewWriter(rec, nil), "s:2")
+	if !ok || err != nil {
+		t.Fatalf("resume failed: ok=%v err=%v", ok, err)
+	}
+	var ids []string
+	for _, e := range readAll(t, rec.Body.String()) {
+		ids = append(ids, e.ID)
+	}
+	if got := strings.Join(ids, ","); got != "s:3,s:4,s:5" {
+		t.Fatalf("unexpected resumed ids %s", got)
+	}
+	if ok, _ = replay.Resume(context.Background(), NewWriter(httptest.NewRecorder(), nil), "other:1"); ok {
+		t.Fatal("unknown stream should not resume")
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	replay := NewReplay(time.Minute, 0)
+	var lastIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		lastID := r.Header.Get(HeaderLastEventID)
+		lastIDs = append(lastIDs, lastID)
+		if lastID != "" {
+			if ok, _ := replay.Resume(r.Context(), NewWriter(rw, nil), lastID); ok {
+				return
+			}
+		}
+		w := NewWriter(rw, &Config{StreamID: "s", Replay: replay, Retry: time.Millisecond})
+		_ = w.Meta("chat", nil)
+		_ = w.Send(EventToken, &Token{Content: "a"})
+		// 模拟连接断开：后续事件只进入缓存
+		w.mu.Lock()
+		w.w, w.flusher = httptest.NewRecorder(), nil
+		w.mu.Unlock()
+		_ = w.Send(EventToken, &Token{Content: "b"})
+		_ = w.Done(nil)
+	}))
+	defer srv.Close()
+
+	var got []string
+	err := (&Client{MaxRetries: 1}).Post(context.Background(), srv.URL, map[string]string{"question": "q"}, func(e *Event) error {
+		if e.Event == EventToken {
+			var token Token
+			if err := e.Decode(&token); err != nil {
+				return err
+			}
+			got = append(got, token.Content)
+		} else {
+			got = append(got, e.Event)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Join(got, ","); s != "meta,a,b,done" {
+		t.Fatalf("unexpected events %s", s)
+	}
+	if len(lastIDs) != 2 || lastIDs[1] != "s:2" {
+		t.Fatalf("unexpected Last-Event-ID %q", lastIDs)
+	}
+}
@@ -0,0 +1,210 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errClosed 写入器已关闭
+var errClosed = errors.New("sse: writer closed")
+
+// Config 写入器配置
+type Config struct {
+	Context   context.Context // 请求的 ctx，结束（如客户端断开）后写入返回错误；为空时只能通过写入错误发现断开
+	StreamID  string          // 流 ID，为空时生成
+	Heartbeat time.Duration   // 空闲多久发送一次心跳，<=0 时不发送
+	Retry     time.Duration   // 建议客户端的重连间隔，>0 时在流的开头写入 retry 字段
+	Replay    *Replay         // 不为空时记录发送的事件，供断线重连的客户端续传
+}
+
+// Writer 按协议写入事件，每个事件带有递增的 id，写入后立即 flush。可在多个协程中使用
+type Writer struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	w        http.ResponseWriter
+	flusher  http.Flusher
+	streamID string
+	seq      int
+	buf      *buffer
+	err      error     // 第一次写入失败的错误，之后不再写入
+	last     time.Time // 最后一次写入的时间
+	closed   bool
+	stop     chan struct{}
+}
+
+// NewWriter 设置 SSE 响应头并返回写入器。配置了心跳时启动心跳协程，需调用 Close（或 Done）结束
+func NewWriter(w http.ResponseWriter, cfg *Config) *Writer {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	setHeader(w.Header())
+	sw := &Writer{
+		ctx:      cfg.Context,
+		w:        w,
+		streamID: cfg.StreamID,
+		last:     time.Now(),
+		stop:     make(chan struct{}),
+	}
+	sw.flusher, _ = w.(http.Flusher)
+	if sw.streamID == "" {
+		sw.streamID = uuid.NewString()
+	}
+	if cfg.Replay != nil {
+		sw.buf = cfg.Replay.open(sw.streamID)
+	}
+	if cfg.Retry > 0 {
+		sw.write(fmt.Sprintf("retry: %d\n\n", cfg.Retry.Milliseconds()))
+	}
+	if cfg.Heartbeat > 0 {
+		go sw.heartbeat(cfg.Heartbeat)
+	}
+	return sw
+}
+
+func setHeader(h http.Header) {
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // 禁用Nginx缓冲
+	h.Set("Access-Control-Allow-Origin", "*")
+	h.Set(HeaderProtocol, Version)
+}
+
+// StreamID 返回流 ID
+func (w *Writer) StreamID() string {
+	return w.streamID
+}
+
+// Meta 发送 meta 事件
+func (w *Writer) Meta(kind string, extra map[string]any) error {
+	return w.Send(EventMeta, &Meta{
+		Version:  Version,
+		StreamID: w.streamID,
+		Kind:     kind,
+		Created:  time.Now().Unix(),
+		Extra:    extra,
+	})
+}
+
+// Send 发送事件，data 序列化为 JSON
+func (w *Writer) Send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errClosed
+	}
+	w.seq++
+	id := FormatID(w.streamID, w.seq)
+	if w.buf != nil {
+		w.buf.append(&Event{ID: id, Event: event, Data: payload})
+	}
+	w.write(encode(id, event, payload))
+	return w.err
+}
+
+// Error 发送 error 事件
+func (w *Writer) Error(err error) error {
+	return w.Send(EventError, &Error{Message: err.Error()})
+}
+
+// Done 发送 done 事件并关闭写入器
+func (w *Writer) Done(result any) error {
+	err := w.Send(EventDone, &Done{Result: result})
+	w.Close()
+	return err
+}
+
+// Close 停止心跳并不再接受事件，可重复调用。记录了事件的流在缓存中保留一段时间后清理
+func (w *Writer) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.stop)
+	if w.buf != nil {
+		w.buf.finish()
+	}
+}
+
+// heartbeat 空闲超过 interval 时写入注释行，避免代理与客户端因长时间没有数据断开连接
+func (w *Writer) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed && time.Since(w.last) >= interval {
+				w.write(": ping\n\n")
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// write 写入并 flush，调用方需持有锁。带缓冲的 ResponseWriter（如 GoFrame 的 Response）在客户端断开后写入也不会出错，
+// 因此先检查请求的 ctx
+func (w *Writer) write(s string) {
+	if w.err != nil {
+		return
+	}
+	if w.ctx != nil && w.ctx.Err() != nil {
+		w.err = fmt.Errorf("sse: client disconnected: %w", w.ctx.Err())
+		return
+	}
+	if _, w.err = io.WriteString(w.w, s); w.err != nil {
+		return
+	}
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+	w.last = time.Now()
+}
+
+// encode 按 SSE 格式编码事件，data 中的换行拆为多个 data 字段
+func encode(id, event string, data []byte) string {
+	var sb strings.Builder
+	sb.WriteString("id: " + id + "\n")
+	sb.WriteString("event: " + event + "\n")
+	for _, line := range strings.Split(string(data), "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// FormatID 生成事件 id
+func FormatID(streamID string, seq int) string {
+	return streamID + ":" + strconv.Itoa(seq)
+}
+
+// ParseID 解析事件 id，格式不正确时 ok 为 false
+func ParseID(id string) (streamID string, seq int, ok bool) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.Atoi(id[i+1:])
+	if err != nil || seq < 0 {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
@@ -0,0 +1,219 @@
+// Package summarize 整篇文档的摘要：按 token 预算把有序的 chunk 分批，各批分别生成摘要（map），
+// 再逐层合并摘要（reduce），直到只剩一篇
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+)
+
+// 默认预算
+const (
+	DefaultBatchTokens   = 6000
+	DefaultSummaryTokens = 500
+	DefaultConcurrency   = 4
+)
+
+// maxRounds reduce 的最大层数，每层至少两两合并，正常情况下远达不到
+const maxRounds = 8
+
+var (
+	mapSystem = "You summarize a document or one part of a longer document.\n" +
+		"1. Keep the key points, facts, names, numbers, definitions and conclusions; drop examples, repetition and boilerplate.\n" +
+		"2. Keep the order in which topics appear and mention section headings when they help.\n" +
+		"3. Write in the same language as the document, in plain sentences or a short list.\n" +
+		"4. Keep it under %d tokens.\n" +
+		"Return only the summary without any further explanation."
+
+	reduceSystem = "You merge summaries of consecutive parts of one document into a single summary of the whole.\n" +
+		"1. Keep the overall structure and the most important points of every part; merge duplicates.\n" +
+		"2. Keep facts, names and numbers exactly as given; do not add information.\n" +
+		"3. Write in the same language as the summaries, in plain sentences or a short list.\n" +
+		"4. Keep it under %d tokens.\n" +
+		"Return only the summary without any further explanation."
+)
+
+// Config 摘要的配置，零值字段使用默认值
+type Config struct {
+	Model         model.BaseChatModel
+	Tokenizer     tokenizer.Tokenizer // 生成摘要的模型的 token 计数器，见 tokenizer.For
+	BatchTokens   int                 // 每次调用输入的 chunk 或摘要的 token 上限，至少为 SummaryTokens 的 4 倍
+	SummaryTokens int                 // 每篇摘要的目标长度
+	Concurrency   int                 // 同一层并行调用的上限
+}
+
+// Result 摘要结果
+type Result struct {
+	Summary string `json:"summary"`
+	Calls   int    `json:"calls"`  // 调用 LLM 的次数
+	Rounds  int    `json:"rounds"` // 层数，只调用一次时为 1
+	Tokens  int    `json:"tokens"` // 原文的 token 数
+}
+
+type Summarizer struct {
+	cfg Config
+}
+
+func New(cfg *Config) *Summarizer {
+	c := *cfg
+	if c.Tokenizer == nil {
+		c.Tokenizer = tokenizer.For("")
+	}
+	if c.SummaryTokens <= 0 {
+		c.SummaryTokens = DefaultSummaryTokens
+	}
+	if c.BatchTokens <= 0 {
+		c.BatchTokens = DefaultBatchTokens
+	}
+	// 保证每批至少能放下 4 篇摘要，reduce 每层都能收敛
+	c.BatchTokens = max(c.BatchTokens, 4*c.SummaryTokens)
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultConcurrency
+	}
+	return &Summarizer{cfg: c}
+}
+
+// Summarize 按顺序摘要 title 对应文档的全部 chunk。全文放得下一批时只调用一次，
+// 否则分批生成摘要，再把相邻的摘要分批合并，直到只剩一篇
+func (x *Summarizer) Summarize(ctx context.Context, title string, chunks []string) (*Result, error) {
+	if x.cfg.Model == nil {
+		return nil, fmt.Errorf("no model to summarize the document")
+	}
+	res := &Result{}
+	var parts []string
+	for _, chunk := range chunks {
+		if chunk = strings.TrimSpace(chunk); chunk == "" {
+			continue
+		}
+		n := x.cfg.Tokenizer.Count(chunk)
+		res.Tokens += n
+		parts = append(parts, x.split(chunk, n)...)
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("document %q has no content", title)
+	}
+
+	system := mapSystem
+	for {
+		res.Rounds++
+		batches := x.batch(parts)
+		// 合并没有收敛时（如模型没有遵守长度要求）强制两两合并
+		if res.Rounds > 1 && len(batches) == len(parts) {
+			batches = pairs(parts)
+		}
+		summaries, err := x.run(ctx, fmt.Sprintf(system, x.cfg.SummaryTokens), title, batches)
+		res.Calls += len(batches)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) == 1 {
+			res.Summary = summaries[0]
+			return res, nil
+		}
+		if res.Rounds >= maxRounds {
+			res.Summary = strings.Join(summaries, "\n\n")
+			return res, nil
+		}
+		parts, system = summaries, reduceSystem
+	}
+}
+
+// split 把超过一批的 chunk 按字符数等分
+func (x *Summarizer) split(chunk string, tokens int) []string {
+	if tokens <= x.cfg.BatchTokens {
+		return []string{chunk}
+	}
+	runes := []rune(chunk)
+	n := (tokens + x.cfg.BatchTokens - 1) / x.cfg.BatchTokens
+	size := (len(runes) + n - 1) / n
+	parts := make([]string, 0, n)
+	for i := 0; i < len(runes); i += size {
+		parts = append(parts, string(runes[i:min(i+size, len(runes))]))
+	}
+	return parts
+}
+
+// batch 按顺序把相邻的片段装入不超过 BatchTokens 的批次
+func (x *Summarizer) batch(parts []string) [][]string {
+	var (
+		batches [][]string
+		cur     []string
+		used    int
+	)
+	for _, p := range parts {
+		n := x.cfg.Tokenizer.Count(p)
+		if len(cur) > 0 && used+n > x.cfg.BatchTokens {
+			batches = append(batches, cur)
+			cur, used = nil, 0
+		}
+		cur = append(cur, p)
+		used += n
+	}
+	if len(cur) > 0 {
+		batches = append(batches, cur)
+	}
+	return batches
+}
+
+func pairs(parts []string) [][]string {
+	batches := make([][]string, 0, (len(parts)+1)/2)
+	for i := 0; i < len(parts); i += 2 {
+		batches = append(batches, parts[i:min(i+2, len(parts))])
+	}
+	return batches
+}
+
+// run 并行摘要各批，结果与批次顺序一致，任意一批失败时返回错误
+func (x *Summarizer) run(ctx context.Context, system, title string, batches [][]string) ([]string, error) {
+	summaries := make([]string, len(batches))
+	errs := make([]error, len(batches))
+	sem := make(chan struct{}, x.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, b := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			summaries[i], errs[i] = x.generate(ctx, system, title, i, len(batches), b)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return summaries, nil
+}
+
+// generate 摘要一批内容，内容可能包含花括号，不使用 FString 模板
+func (x *Summarizer) generate(ctx context.Context, system, title string, index, total int, parts []string) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Document: %s\n", title)
+	if total > 1 {
+		fmt.Fprintf(&sb, "Part %d of %d\n", index+1, total)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(strings.Join(parts, "\n\n"))
+	msg, err := x.cfg.Model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(system),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarize document failed: %w", err)
+	}
+	summary := strings.TrimSpace(msg.Content)
+	if summary == "" {
+		return "", fmt.Errorf("summarize document failed: empty result")
+	}
+	return summary, nil
+}
@@ -0,0 +1,99 @@
+package summarize
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
+)
+
+// headModel 返回输入正文的前 5 个词，记录每次调用的输入
+type headModel struct {
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (m *headModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	content := input[len(input)-1].Content
+	m.mu.Lock()
+	m.inputs = append(m.inputs, content)
+	m.mu.Unlock()
+	_, body, _ := strings.Cut(content, "\n\n")
+	words := strings.Fields(body)
+	return schema.AssistantMessage(strings.Join(words[:min(5, len(words))], " "), nil), nil
+}
+
+func (m *headModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not implemented")
+}
+
+func chunk(word string, n int) string {
+	return strings.TrimSpace(strings.Repeat(word+" ", n))
+}
+
+func TestSummarizeMapReduce(t *testing.T) {
+	cm := &headModel{}
+	s := New(&Config{
+		Model:         cm,
+		Tokenizer:     &tokenizer.Estimator{CJKPerToken: 1, CharsPerToken: 4},
+		BatchTokens:   20,
+		SummaryTokens: 5,
+	})
+	var chunks []string
+	for _, w := range []string{"c0", "c1", "c2", "c3", "c4", "c5", "c6", "c7", "c8", "c9"} {
+		chunks = append(chunks, chunk(w, 10))
+	}
+	res, err := s.Summarize(context.Background(), "handbook", chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10 个 chunk 两两成批 5 次，5 篇摘要按 4+1 合并 2 次，最后合并 1 次
+	if res.Calls != 8 || res.Rounds != 3 || res.Tokens != 100 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Summary != chunk("c0", 5) {
+		t.Fatalf("unexpected summary: %q", res.Summary)
+	}
+	// 合并时摘要保持原文顺序
+	var reduced bool
+	for _, in := range cm.inputs {
+		if strings.Contains(in, "Part 1 of 2") {
+			i0, i2, i6 := strings.Index(in, "c0"), strings.Index(in, "c2"), strings.Index(in, "c6")
+			if i0 < 0 || i0 > i2 || i2 > i6 {
+				t.Fatalf("summaries out of order: %q", in)
+			}
+			reduced = true
+		}
+	}
+	if !reduced {
+		t.Fatal("expect a reduce round with 2 parts")
+	}
+}
+
+func TestSummarizeSingleBatch(t *testing.T) {
+	cm := &headModel{}
+	s := New(&Config{Model: cm, Tokenizer: &tokenizer.Estimator{CJKPerToken: 1, CharsPerToken: 4}})
+	res, err := s.Summarize(context.Background(), "faq", []string{"退款需在七天内申请", "", "数字商品不支持退款"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Calls != 1 || res.Rounds != 1 || strings.Contains(cm.inputs[0], "Part") {
+		t.Fatalf("unexpected result: %+v, input %q", res, cm.inputs[0])
+	}
+	if _, err = s.Summarize(context.Background(), "empty", []string{" "}); err == nil {
+		t.Fatal("expect error for empty document")
+	}
+}
+
+func TestSplitLongChunk(t *testing.T) {
+	s := New(&Config{Tokenizer: &tokenizer.Estimator{CJKPerToken: 1, CharsPerToken: 4}, BatchTokens: 20, SummaryTokens: 5})
+	parts := s.split(strings.Repeat("字", 50), 50)
+	if len(parts) != 3 || len([]rune(parts[0])) != 17 || len([]rune(parts[2])) != 16 {
+		t.Fatalf("unexpected parts: %d", len(parts))
+	}
+}
@@ -0,0 +1,113 @@
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 默认配置
+const (
+	defaultRemoteTimeout = 3 * time.Second
+	defaultCacheSize     = 4096
+)
+
+// RemoteConfig 使用推理服务分词的模型配置
+type RemoteConfig struct {
+	Model    string        `json:"model"`   // 模型名称，同时作为注册的前缀
+	BaseURL  string        `json:"baseURL"` // 推理服务地址，需提供 vLLM 兼容的 POST /tokenize 接口
+	Timeout  time.Duration `json:"timeout"` // 单次请求超时，默认 3s
+	Fallback Tokenizer     `json:"-"`       // 接口不可用时使用的估算器，默认按模型名称选择
+}
+
+// Remote 调用推理服务的 /tokenize 接口计算真实的 token 数，结果按文本缓存；请求失败时退回估算
+type Remote struct {
+	cfg    RemoteConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[uint64]int
+}
+
+func NewRemote(cfg *RemoteConfig) *Remote {
+	c := *cfg
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	if c.Timeout <= 0 {
+		c.Timeout = defaultRemoteTimeout
+	}
+	if c.Fallback == nil {
+		c.Fallback = defaultTokenizer
+	}
+	return &Remote{
+		cfg:    c,
+		client: &http.Client{Timeout: c.Timeout},
+		cache:  make(map[uint64]int),
+	}
+}
+
+// Count 返回 text 的 token 数
+func (x *Remote) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(text))
+	key := h.Sum64()
+	x.mu.Lock()
+	n, ok := x.cache[key]
+	x.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n, err := x.tokenize(text)
+	if err != nil {
+		g.Log().Warningf(context.Background(), "tokenize with %s failed, fall back to estimation, err=%v", x.cfg.BaseURL, err)
+		return x.cfg.Fallback.Count(text)
+	}
+	x.mu.Lock()
+	if len(x.cache) >= defaultCacheSize {
+		clear(x.cache)
+	}
+	x.cache[key] = n
+	x.mu.Unlock()
+	return n
+}
+
+func (x *Remote) tokenize(text string) (int, error) {
+	body, err := json.Marshal(map[string]any{
+		"model":              x.cfg.Model,
+		"prompt":             text,
+		"add_special_tokens": false,
+	})
+	if err != nil {
+		return 0, err
+	}
+	resp, err := x.client.Post(x.cfg.BaseURL+"/tokenize", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var res struct {
+		Count  *int  `json:"count"`
+		Tokens []int `json:"tokens"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return 0, err
+	}
+	if res.Count != nil {
+		return *res.Count, nil
+	}
+	return len(res.Tokens), nil
+}
@@ -0,0 +1,141 @@
+// Package tokenizer 计算文本的 token 数，对话记忆、文档摘要与按 token 切分共用。
+// 按模型名称前缀注册计数器：配置了推理服务 /tokenize 接口的模型使用真实的分词结果（见 Remote），
+// 其余模型按字符类别估算（见 Estimator）
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// Tokenizer 计算文本的 token 数
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Estimator 按字符类别估算 token 数：CJK 字符按固定比例计算，其余按单词长度计算，标点各占一个。
+// 没有加载模型的词表，结果略偏大，用于预算控制
+type Estimator struct {
+	CJKPerToken   float64 // 每个 token 平均包含的 CJK 字符数
+	CharsPerToken float64 // 每个 token 平均包含的拉丁字母与数字数
+}
+
+// Count 估算 token 数
+func (e *Estimator) Count(text string) int {
+	var (
+		cjk, punct int
+		word       int     // 当前单词的字符数
+		words      float64 // 单词累计的 token 数
+	)
+	flush := func() {
+		if word > 0 {
+			// 短单词通常是一个 token
+			words += max(1, float64(word)/e.CharsPerToken)
+			word = 0
+		}
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			cjk++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			punct++
+		}
+	}
+	flush()
+	return int(float64(cjk)/e.CJKPerToken+words+0.5) + punct
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+var (
+	mu sync.RWMutex
+	// tokenizers 按模型名称前缀（小写，不含组织前缀）注册的计数器，匹配最长的前缀
+	tokenizers = map[string]Tokenizer{
+		"qwen":     &Estimator{CJKPerToken: 1.4, CharsPerToken: 4},
+		"deepseek": &Estimator{CJKPerToken: 1.4, CharsPerToken: 4},
+		"glm":      &Estimator{CJKPerToken: 1.3, CharsPerToken: 4},
+		"gpt-4o":   &Estimator{CJKPerToken: 1.2, CharsPerToken: 4},
+		"gpt-4":    &Estimator{CJKPerToken: 0.8, CharsPerToken: 4},
+		"gpt-3.5":  &Estimator{CJKPerToken: 0.8, CharsPerToken: 4},
+		// embedding 模型
+		"text-embedding": &Estimator{CJKPerToken: 0.8, CharsPerToken: 4},
+		"bge":            &Estimator{CJKPerToken: 1, CharsPerToken: 4},
+	}
+	// defaultTokenizer 未注册的模型按每个 CJK 字符一个 token 估算
+	defaultTokenizer Tokenizer = &Estimator{CJKPerToken: 1, CharsPerToken: 3.5}
+
+	configOnce sync.Once
+)
+
+// Register 注册模型的计数器，prefix 为模型名称的前缀（不区分大小写，可带组织前缀），已存在时覆盖
+func Register(prefix string, t Tokenizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	tokenizers[modelName(prefix)] = t
+}
+
+// For 返回模型的计数器。模型名称可带有组织前缀（如 "Qwen/Qwen3-14B"、"Pro/deepseek-ai/DeepSeek-V3"）。
+// 首次调用时注册配置文件 tokenizer.remote 中的推理服务
+func For(model string) Tokenizer {
+	configOnce.Do(registerFromConfig)
+	return lookup(model)
+}
+
+func lookup(model string) Tokenizer {
+	name := modelName(model)
+	mu.RLock()
+	defer mu.RUnlock()
+	var (
+		best    Tokenizer
+		bestLen int
+	)
+	for prefix, t := range tokenizers {
+		if strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = t, len(prefix)
+		}
+	}
+	if best == nil {
+		return defaultTokenizer
+	}
+	return best
+}
+
+// modelName 去掉组织前缀并转为小写
+func modelName(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// registerFromConfig 为配置文件 tokenizer.remote 中的模型注册 Remote 计数器，接口不可用时退回该模型原来的估算器
+func registerFromConfig() {
+	ctx := gctx.New()
+	var list []*RemoteConfig
+	if err := g.Cfg().MustGet(ctx, "tokenizer.remote").Scan(&list); err != nil {
+		g.Log().Warningf(ctx, "invalid tokenizer.remote config, err=%v", err)
+		return
+	}
+	for _, c := range list {
+		if c == nil || c.Model == "" || c.BaseURL == "" {
+			continue
+		}
+		c.Fallback = lookup(c.Model)
+		Register(c.Model, NewRemote(c))
+	}
+}
@@ -0,0 +1,65 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEstimator(t *testing.T) {
+	tk := For("Pro/deepseek-ai/DeepSeek-V3")
+	if tk != tokenizers["deepseek"] {
+		t.Fatal("expect the deepseek estimator")
+	}
+	if For("gpt-4o-mini") != tokenizers["gpt-4o"] {
+		t.Fatal("expect the longest prefix to win")
+	}
+	if For("unknown") != defaultTokenizer {
+		t.Fatal("expect the default estimator")
+	}
+	if n := tk.Count("the refund policy"); n != 4 {
+		t.Fatalf("expect 4 tokens, got %d", n)
+	}
+	if n := tk.Count("退款需在七天内申请。"); n < 5 || n > 10 {
+		t.Fatalf("unexpected count of chinese text: %d", n)
+	}
+	if tk.Count("") != 0 {
+		t.Fatal("expect 0 tokens of empty text")
+	}
+}
+
+func TestRemote(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]any{"count": len(req.Prompt), "tokens": []int{}})
+	}))
+	tk := NewRemote(&RemoteConfig{Model: "m", BaseURL: srv.URL + "/"})
+	if n := tk.Count("hello"); n != 5 {
+		t.Fatalf("expect 5 tokens from the server, got %d", n)
+	}
+	// 相同文本使用缓存
+	tk.Count("hello")
+	if calls != 1 {
+		t.Fatalf("expect 1 call, got %d", calls)
+	}
+
+	// 服务不可用时退回估算
+	srv.Close()
+	if n := tk.Count("the refund policy"); n != (&Estimator{CJKPerToken: 1, CharsPerToken: 3.5}).Count("the refund policy") {
+		t.Fatalf("expect the fallback estimation, got %d", n)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tk := &Estimator{CJKPerToken: 2, CharsPerToken: 2}
+	Register("Org/Custom-Model", tk)
+	if For("other/custom-model-v2") != tk {
+		t.Fatal("expect the registered tokenizer")
+	}
+}
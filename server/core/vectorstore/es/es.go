@@ -0,0 +1,551 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/closepointintime"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/deletebyquery"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/openpointintime"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/updatebyquery"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/create"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/exists"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/existsalias"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/getalias"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/getmapping"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/putmapping"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/refresh"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/indices/updatealiases"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/conflicts"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// similarityScripts 各相似度对应的打分脚本，分数统一映射到 0-2。
+// cosine 与原 es8 检索器 DenseVectorSimilarity 模式一致
+var similarityScripts = map[string]string{
+	vectorstore.SimilarityCosine:     "cosineSimilarity(params.embedding, '%s') + 1.0",
+	vectorstore.SimilarityDotProduct: "Math.max(dotProduct(params.embedding, '%s') + 1.0, 0)",
+	vectorstore.SimilarityL2Norm:     "2 / (1 + l2norm(params.embedding, '%s'))",
+}
+
+// queryPageSize Query 分页读取时每页的记录数，需小于 ES 的 index.max_result_window
+const queryPageSize = 1000
+
+// pitKeepAlive 分页读取期间 point in time 的保活时间，每页请求都会续期
+const pitKeepAlive = "1m"
+
+// updateScript 把 params.fields 中的字段逐个写入 _source
+const updateScript = "for (e in params.fields.entrySet()) { ctx._source[e.getKey()] = e.getValue() }"
+
+// Store 基于 Elasticsearch 8 的向量存储实现
+type Store struct {
+	client *elasticsearch.Client
+}
+
+var _ vectorstore.VectorStore = (*Store)(nil)
+
+// New 创建 Elasticsearch 向量存储
+func New(client *elasticsearch.Client) *Store {
+	return &Store{client: client}
+}
+
+// Client 返回底层的 ES 客户端
+func (s *Store) Client() *elasticsearch.Client {
+	return s.client
+}
+
+// CreateIndex 索引不存在时按 spec 创建；已存在时为缺失的非向量字段补充映射（如历史索引缺少 chunk 状态字段）。
+// 已有向量字段的维度无法修改，需要通过重建索引处理。
+func (s *Store) CreateIndex(ctx context.Context, index string, spec *vectorstore.IndexSpec) error {
+	indexExists, err := exists.NewExistsFunc(s.client)(index).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !indexExists {
+		_, err = create.NewCreateFunc(s.client)(index).Request(&create.Request{
+			Mappings: &types.TypeMapping{Properties: properties(spec, true)},
+		}).Do(ctx)
+		return err
+	}
+	if err = s.checkDims(ctx, index, spec); err != nil {
+		return err
+	}
+	props := properties(spec, false)
+	if len(props) == 0 {
+		return nil
+	}
+	_, err = putmapping.NewPutMappingFunc(s.client)(index).Request(&putmapping.Request{
+		Properties: props,
+	}).Do(ctx)
+	return err
+}
+
+// checkDims 校验已有索引中向量字段的维度，避免写入或检索维度不一致的向量
+func (s *Store) checkDims(ctx context.Context, index string, spec *vectorstore.IndexSpec) error {
+	if spec.Dims <= 0 {
+		return nil
+	}
+	res, err := getmapping.NewGetMappingFunc(s.client)().Index(index).Do(ctx)
+	if err != nil {
+		return err
+	}
+	for name, record := range res {
+		for field, typ := range spec.Fields {
+			if typ != vectorstore.FieldTypeVector {
+				continue
+			}
+			prop, ok := record.Mappings.Properties[field].(*types.DenseVectorProperty)
+			if !ok || prop.Dims == nil {
+				continue
+			}
+			if *prop.Dims != spec.Dims {
+				return fmt.Errorf("%w: index=%s, field=%s, existing=%d, expect=%d",
+					vectorstore.ErrDimsMismatch, name, field, *prop.Dims, spec.Dims)
+			}
+		}
+	}
+	return nil
+}
+
+func properties(spec *vectorstore.IndexSpec, withVector bool) map[string]types.Property {
+	props := make(map[string]types.Property, len(spec.Fields))
+	for field, typ := range spec.Fields {
+		switch typ {
+		case vectorstore.FieldTypeText:
+			props[field] = types.NewTextProperty()
+		case vectorstore.FieldTypeKeyword:
+			props[field] = types.NewKeywordProperty()
+		case vectorstore.FieldTypeVector:
+			if !withVector {
+				continue
+			}
+			similarity := spec.Similarity
+			if similarity == "" {
+				similarity = vectorstore.SimilarityCosine
+			}
+			props[field] = &types.DenseVectorProperty{
+				Dims:       &spec.Dims,
+				Index:      of(true),
+				Similarity: &similarity,
+			}
+		}
+	}
+	return props
+}
+
+// Upsert 通过 bulk 接口批量写入，任一条失败都会返回错误
+func (s *Store) Upsert(ctx context.Context, index string, records []*vectorstore.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Index:  index,
+		Client: s.client,
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		body, err := sonic.Marshal(r.Fields)
+		if err != nil {
+			return fmt.Errorf("marshal record %s failed: %w", r.ID, err)
+		}
+		err = bi.Add(ctx, esutil.BulkIndexerItem{
+			Action:     "index",
+			DocumentID: r.ID,
+			Body:       bytes.NewReader(body),
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr != nil {
+					return
+				}
+				if err == nil {
+					err = fmt.Errorf("%s: %s", res.Error.Type, res.Error.Reason)
+				}
+				firstErr = fmt.Errorf("index record %s failed: %w", item.DocumentID, err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err = bi.Close(ctx); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// Delete 逐条删除，内置重试以应对网络或服务的临时异常
+func (s *Store) Delete(ctx context.Context, index string, ids ...string) error {
+	for _, id := range ids {
+		err := withRetry(func() error {
+			res, err := s.client.Delete(index, id, s.client.Delete.WithContext(ctx))
+			if err != nil {
+				return fmt.Errorf("delete document failed: %w", err)
+			}
+			defer res.Body.Close()
+			if res.IsError() && res.StatusCode != http.StatusNotFound {
+				return fmt.Errorf("delete document failed: %s", res.String())
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteByFilter(ctx context.Context, index string, filter *vectorstore.Filter) (deleted int64, err error) {
+	err = withRetry(func() error {
+		res, e := deletebyquery.NewDeleteByQueryFunc(s.client)(index).
+			Request(&deletebyquery.Request{Query: toQuery(filter)}).
+			Conflicts(conflicts.Proceed).
+			Refresh(true).
+			Do(ctx)
+		if e != nil {
+			return fmt.Errorf("delete by query failed: %w", e)
+		}
+		if res.Deleted != nil {
+			deleted = *res.Deleted
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Store) UpdateByFilter(ctx context.Context, index string, filter *vectorstore.Filter, fields map[string]any) (updated int64, err error) {
+	params, err := sonic.Marshal(fields)
+	if err != nil {
+		return
+	}
+	err = withRetry(func() error {
+		res, e := updatebyquery.NewUpdateByQueryFunc(s.client)(index).
+			Request(&updatebyquery.Request{
+				Query: toQuery(filter),
+				Script: &types.Script{
+					Source: of(updateScript),
+					Params: map[string]json.RawMessage{"fields": params},
+				},
+			}).
+			Conflicts(conflicts.Proceed).
+			Refresh(true).
+			Do(ctx)
+		if e != nil {
+			return fmt.Errorf("update by query failed: %w", e)
+		}
+		if res.Updated != nil {
+			updated = *res.Updated
+		}
+		return nil
+	})
+	return
+}
+
+// Query 数量在一页以内时直接查询，不限数量或超过一页时通过 point in time + search_after 分页读取
+func (s *Store) Query(ctx context.Context, index string, req *vectorstore.QueryRequest) ([]*vectorstore.Record, error) {
+	if req.Size > 0 && req.Size <= queryPageSize {
+		return s.search(ctx, index, queryRequest(req, req.Size))
+	}
+	pit, err := openpointintime.NewOpenPointInTimeFunc(s.client)(index).KeepAlive(pitKeepAlive).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pitId := pit.Id
+	defer func() {
+		_, _ = closepointintime.NewClosePointInTimeFunc(s.client)().
+			Request(&closepointintime.Request{Id: pitId}).
+			Do(context.WithoutCancel(ctx))
+	}()
+	var (
+		records []*vectorstore.Record
+		after   []types.FieldValue
+	)
+	for {
+		size := queryPageSize
+		if req.Size > 0 {
+			size = min(size, req.Size-len(records))
+		}
+		sreq := queryRequest(req, size)
+		sreq.Pit = &types.PointInTimeReference{Id: pitId, KeepAlive: pitKeepAlive}
+		sreq.Sort = []types.SortCombinations{"_shard_doc"}
+		sreq.SearchAfter = after
+		// 带 point in time 的请求不能再指定索引
+		resp, err := search.NewSearchFunc(s.client)().Request(sreq).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		page, err := toRecords(resp.Hits.Hits)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if len(resp.Hits.Hits) < size || (req.Size > 0 && len(records) >= req.Size) {
+			return records, nil
+		}
+		after = resp.Hits.Hits[len(resp.Hits.Hits)-1].Sort
+		if resp.PitId != nil {
+			pitId = *resp.PitId
+		}
+	}
+}
+
+func queryRequest(req *vectorstore.QueryRequest, size int) *search.Request {
+	sreq := search.NewRequest()
+	sreq.Query = toQuery(req.Filter)
+	sreq.Size = of(size)
+	if len(req.Excludes) > 0 {
+		sreq.Source_ = &types.SourceFilter{Excludes: req.Excludes}
+	}
+	return sreq
+}
+
+func (s *Store) KNN(ctx context.Context, index string, req *vectorstore.KNNRequest) ([]*vectorstore.Record, error) {
+	vector, err := sonic.Marshal(req.Vector)
+	if err != nil {
+		return nil, err
+	}
+	script, ok := similarityScripts[req.Similarity]
+	if !ok {
+		script = similarityScripts[vectorstore.SimilarityCosine]
+	}
+	sreq := search.NewRequest()
+	sreq.Query = &types.Query{
+		ScriptScore: &types.ScriptScoreQuery{
+			Query: toQuery(req.Filter),
+			Script: types.Script{
+				Source: of(fmt.Sprintf(script, req.Field)),
+				Params: map[string]json.RawMessage{"embedding": vector},
+			},
+		},
+	}
+	sreq.Size = of(req.TopK)
+	return s.search(ctx, index, sreq)
+}
+
+func (s *Store) KeywordSearch(ctx context.Context, index string, req *vectorstore.KeywordRequest) ([]*vectorstore.Record, error) {
+	should := make([]types.Query, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		if q == "" {
+			continue
+		}
+		should = append(should, types.Query{Match: map[string]types.MatchQuery{req.Field: {Query: q}}})
+	}
+	if len(should) == 0 {
+		return nil, nil
+	}
+	sreq := search.NewRequest()
+	sreq.Query = &types.Query{
+		Bool: &types.BoolQuery{
+			Should:             should,
+			MinimumShouldMatch: 1,
+			Filter:             []types.Query{*toQuery(req.Filter)},
+		},
+	}
+	sreq.Size = of(req.TopK)
+	if len(req.Excludes) > 0 {
+		sreq.Source_ = &types.SourceFilter{Excludes: req.Excludes}
+	}
+	return s.search(ctx, index, sreq)
+}
+
+func (s *Store) Aggregate(ctx context.Context, index string, req *vectorstore.AggregateRequest) (buckets []*vectorstore.Bucket, err error) {
+	const name = "terms"
+	sreq := search.NewRequest()
+	sreq.Query = toQuery(req.Filter)
+	sreq.Size = of(0) // 不返回实际文档，只做统计
+	sreq.Aggregations = map[string]types.Aggregations{
+		name: {
+			Terms: &types.TermsAggregation{
+				Field: of(req.Field),
+				Size:  of(req.Size),
+			},
+		},
+	}
+	res, err := search.NewSearchFunc(s.client)().Index(index).Request(sreq).Do(ctx)
+	if err != nil {
+		return
+	}
+	if res.Aggregations == nil {
+		return
+	}
+	termsAgg, ok := res.Aggregations[name].(*types.StringTermsAggregate)
+	if !ok || termsAgg == nil {
+		return nil, errors.New("failed to parse terms aggregation")
+	}
+	list, _ := termsAgg.Buckets.([]types.StringTermsBucket)
+	for _, bucket := range list {
+		buckets = append(buckets, &vectorstore.Bucket{Key: fmt.Sprint(bucket.Key), Count: bucket.DocCount})
+	}
+	return
+}
+
+func (s *Store) Refresh(ctx context.Context, index string) error {
+	_, err := refresh.NewRefreshFunc(s.client)().Index(index).Do(ctx)
+	return err
+}
+
+// SwitchAlias 在一次 update aliases 请求中移除别名原有的指向并指向 index，切换过程对读写方无感知
+func (s *Store) SwitchAlias(ctx context.Context, alias, index string) (previous []string, err error) {
+	aliasExists, err := existsalias.NewExistsAliasFunc(s.client)(alias).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var actions []types.IndicesAction
+	if aliasExists {
+		res, err := getalias.NewGetAliasFunc(s.client)().Name(alias).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for name := range res {
+			if name == index {
+				continue
+			}
+			previous = append(previous, name)
+			actions = append(actions, types.IndicesAction{
+				Remove: &types.RemoveAction{Index: of(name), Alias: of(alias)},
+			})
+		}
+	}
+	actions = append(actions, types.IndicesAction{
+		Add: &types.AddAction{Index: of(index), Alias: of(alias)},
+	})
+	_, err = updatealiases.NewUpdateAliasesFunc(s.client)().Request(&updatealiases.Request{
+		Actions: actions,
+	}).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (s *Store) search(ctx context.Context, index string, sreq *search.Request) (records []*vectorstore.Record, err error) {
+	resp, err := search.NewSearchFunc(s.client)().
+		Index(index).
+		Request(sreq).
+		Do(ctx)
+	if err != nil {
+		return
+	}
+	return toRecords(resp.Hits.Hits)
+}
+
+func toRecords(hits []types.Hit) ([]*vectorstore.Record, error) {
+	records := make([]*vectorstore.Record, 0, len(hits))
+	for _, hit := range hits {
+		r := &vectorstore.Record{Fields: map[string]any{}}
+		if hit.Id_ != nil {
+			r.ID = *hit.Id_
+		}
+		if err := sonic.Unmarshal(hit.Source_, &r.Fields); err != nil {
+			return nil, err
+		}
+		if hit.Score_ != nil {
+			r.Score = float64(*hit.Score_)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// toQuery 将通用过滤条件转换为 ES bool 查询
+func toQuery(filter *vectorstore.Filter) *types.Query {
+	if filter.Empty() {
+		return &types.Query{MatchAll: &types.MatchAllQuery{}}
+	}
+	q := &types.BoolQuery{}
+	for _, c := range filter.Must {
+		q.Filter = append(q.Filter, toCondition(c))
+	}
+	for _, c := range filter.MustNot {
+		q.MustNot = append(q.MustNot, toCondition(c))
+	}
+	for _, sub := range filter.Nested {
+		q.Filter = append(q.Filter, *toQuery(sub))
+	}
+	if len(filter.Should) > 0 {
+		for _, sub := range filter.Should {
+			q.Should = append(q.Should, *toQuery(sub))
+		}
+		q.MinimumShouldMatch = 1
+	}
+	return &types.Query{Bool: q}
+}
+
+func toCondition(c vectorstore.Condition) types.Query {
+	switch c.Op {
+	case vectorstore.OpIn:
+		values := make([]types.FieldValue, 0, len(c.Values))
+		for _, v := range c.Values {
+			values = append(values, v)
+		}
+		return types.Query{Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{c.Field: values}}}
+	case vectorstore.OpRange:
+		r := types.UntypedRangeQuery{}
+		if c.Gte != nil {
+			r.Gte, _ = sonic.Marshal(c.Gte)
+		}
+		if c.Lte != nil {
+			r.Lte, _ = sonic.Marshal(c.Lte)
+		}
+		if c.Gt != nil {
+			r.Gt, _ = sonic.Marshal(c.Gt)
+		}
+		if c.Lt != nil {
+			r.Lt, _ = sonic.Marshal(c.Lt)
+		}
+		return types.Query{Range: map[string]types.RangeQuery{c.Field: r}}
+	case vectorstore.OpPrefix:
+		return types.Query{Prefix: map[string]types.PrefixQuery{c.Field: {Value: fmt.Sprint(first(c.Values))}}}
+	case vectorstore.OpSuffix:
+		return types.Query{Wildcard: map[string]types.WildcardQuery{c.Field: {Value: of("*" + escapeWildcard(fmt.Sprint(first(c.Values))))}}}
+	case vectorstore.OpContains:
+		return types.Query{Wildcard: map[string]types.WildcardQuery{c.Field: {Value: of("*" + escapeWildcard(fmt.Sprint(first(c.Values))) + "*")}}}
+	case vectorstore.OpExists:
+		return types.Query{Exists: &types.ExistsQuery{Field: c.Field}}
+	default:
+		return types.Query{Term: map[string]types.TermQuery{c.Field: {Value: first(c.Values)}}}
+	}
+}
+
+// escapeWildcard 转义 wildcard 查询中的通配符，使其按字面匹配
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+func first(values []any) any {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func of[T any](v T) *T {
+	return &v
+}
+
+// withRetry 使用指数退避重试操作，适用于网络波动等暂时性错误，最长重试 30 秒
+func withRetry(operation func() error) error {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 30 * time.Second
+
+	return backoff.Retry(operation, b)
+}
@@ -0,0 +1,37 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore/storetest"
+)
+
+func newTestStore(t *testing.T) *Store {
+	address := os.Getenv("ES_ADDRESS")
+	if address == "" {
+		t.Skip("ES_ADDRESS not set")
+	}
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{address},
+		Username:  os.Getenv("ES_USERNAME"),
+		Password:  os.Getenv("ES_PASSWORD"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(client)
+}
+
+func TestQuerySize(t *testing.T) {
+	s := newTestStore(t)
+	index := fmt.Sprintf("storetest_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, _ = s.Client().Indices.Delete([]string{index}, s.Client().Indices.Delete.WithContext(context.Background()))
+	})
+	storetest.Query(t, s, index)
+}
@@ -0,0 +1,120 @@
+package vectorstore
+
+// IDField 过滤条件中表示记录 ID 的字段名
+const IDField = "_id"
+
+// Op 过滤条件的操作符
+type Op string
+
+const (
+	OpEq       Op = "eq"       // 等于，多值字段任一元素相等即可
+	OpIn       Op = "in"       // 属于给定集合
+	OpRange    Op = "range"    // 区间，边界为 nil 表示不限
+	OpPrefix   Op = "prefix"   // 字符串前缀
+	OpSuffix   Op = "suffix"   // 字符串后缀
+	OpContains Op = "contains" // 包含子串
+	OpExists   Op = "exists"   // 字段存在
+)
+
+// Condition 单个过滤条件
+type Condition struct {
+	Field  string
+	Op     Op
+	Values []any // eq、prefix、suffix、contains 取第一个值，in 取全部
+	Gte    any   // range 下界（含）
+	Lte    any   // range 上界（含）
+	Gt     any   // range 下界（不含）
+	Lt     any   // range 上界（不含）
+}
+
+// Filter 过滤条件，Must 中的条件与 Nested 中的子条件全部满足、MustNot 中的条件都不满足，
+// 且 Should 不为空时至少满足其中一个子条件
+type Filter struct {
+	Must    []Condition
+	MustNot []Condition
+	Nested  []*Filter
+	Should  []*Filter
+}
+
+// NewFilter 创建一个要求满足全部 conds 的过滤条件
+func NewFilter(conds ...Condition) *Filter {
+	return &Filter{Must: conds}
+}
+
+// And 追加必须满足的条件
+func (f *Filter) And(conds ...Condition) *Filter {
+	f.Must = append(f.Must, conds...)
+	return f
+}
+
+// Not 追加必须不满足的条件
+func (f *Filter) Not(conds ...Condition) *Filter {
+	f.MustNot = append(f.MustNot, conds...)
+	return f
+}
+
+// Nest 追加必须满足的子条件，用于组合包含 Should 的条件
+func (f *Filter) Nest(filters ...*Filter) *Filter {
+	f.Nested = append(f.Nested, filters...)
+	return f
+}
+
+// Or 追加子条件，满足其中任一即可
+func (f *Filter) Or(filters ...*Filter) *Filter {
+	f.Should = append(f.Should, filters...)
+	return f
+}
+
+// Empty 是否没有任何条件
+func (f *Filter) Empty() bool {
+	return f == nil || (len(f.Must) == 0 && len(f.MustNot) == 0 && len(f.Nested) == 0 && len(f.Should) == 0)
+}
+
+// Eq 字段等于 value
+func Eq(field string, value any) Condition {
+	return Condition{Field: field, Op: OpEq, Values: []any{value}}
+}
+
+// In 字段属于 values 中的任一值
+func In[T any](field string, values []T) Condition {
+	vs := make([]any, 0, len(values))
+	for _, v := range values {
+		vs = append(vs, v)
+	}
+	return Condition{Field: field, Op: OpIn, Values: vs}
+}
+
+// Range 字段位于 [gte, lte] 区间，边界为 nil 表示不限
+func Range(field string, gte, lte any) Condition {
+	return Condition{Field: field, Op: OpRange, Gte: gte, Lte: lte}
+}
+
+// Gt 字段大于 value
+func Gt(field string, value any) Condition {
+	return Condition{Field: field, Op: OpRange, Gt: value}
+}
+
+// Lt 字段小于 value
+func Lt(field string, value any) Condition {
+	return Condition{Field: field, Op: OpRange, Lt: value}
+}
+
+// Prefix 字段以 prefix 开头
+func Prefix(field, prefix string) Condition {
+	return Condition{Field: field, Op: OpPrefix, Values: []any{prefix}}
+}
+
+// Suffix 字段以 suffix 结尾
+func Suffix(field, suffix string) Condition {
+	return Condition{Field: field, Op: OpSuffix, Values: []any{suffix}}
+}
+
+// Contains 字段包含子串 sub
+func Contains(field, sub string) Condition {
+	return Condition{Field: field, Op: OpContains, Values: []any{sub}}
+}
+
+// Exists 字段存在
+func Exists(field string) Condition {
+	return Condition{Field: field, Op: OpExists}
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// BM25 参数，与 ES 默认值一致
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25 基于索引内全部记录统计词频的打分器
+type bm25 struct {
+	tf     map[string]map[string]int // 记录 ID -> 词 -> 词频
+	length map[string]int            // 记录 ID -> 词数
+	df     map[string]int            // 词 -> 包含该词的记录数
+	avgLen float64
+	total  int
+}
+
+func newBM25(records map[string]*vectorstore.Record, field string) *bm25 {
+	b := &bm25{
+		tf:     map[string]map[string]int{},
+		length: map[string]int{},
+		df:     map[string]int{},
+	}
+	var sum int
+	for id, r := range records {
+		v, ok := r.Fields[field]
+		if !ok || v == nil {
+			continue
+		}
+		tokens := tokenize(fmt.Sprint(v))
+		freq := map[string]int{}
+		for _, t := range tokens {
+			freq[t]++
+		}
+		for t := range freq {
+			b.df[t]++
+		}
+		b.tf[id] = freq
+		b.length[id] = len(tokens)
+		sum += len(tokens)
+		b.total++
+	}
+	if b.total > 0 {
+		b.avgLen = float64(sum) / float64(b.total)
+	}
+	return b
+}
+
+func (b *bm25) score(id, query string) float64 {
+	freq, ok := b.tf[id]
+	if !ok || b.avgLen == 0 {
+		return 0
+	}
+	var score float64
+	for _, t := range tokenize(query) {
+		f := float64(freq[t])
+		if f == 0 {
+			continue
+		}
+		df := float64(b.df[t])
+		idf := math.Log(1 + (float64(b.total)-df+0.5)/(df+0.5))
+		norm := f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(b.length[id])/b.avgLen))
+		score += idf * norm
+	}
+	return score
+}
+
+// tokenize 与 ES standard 分词器的行为近似：字母数字按词切分并转小写，中日韩文字按单字切分
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
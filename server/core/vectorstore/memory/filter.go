@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// match 判断记录是否满足过滤条件，语义与 ES 的 bool filter / must_not 保持一致
+func match(r *vectorstore.Record, filter *vectorstore.Filter) bool {
+	if filter.Empty() {
+		return true
+	}
+	for _, c := range filter.Must {
+		if !matchCondition(r, c) {
+			return false
+		}
+	}
+	for _, c := range filter.MustNot {
+		if matchCondition(r, c) {
+			return false
+		}
+	}
+	for _, sub := range filter.Nested {
+		if !match(r, sub) {
+			return false
+		}
+	}
+	if len(filter.Should) == 0 {
+		return true
+	}
+	for _, sub := range filter.Should {
+		if match(r, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCondition(r *vectorstore.Record, c vectorstore.Condition) bool {
+	var v any
+	if c.Field == vectorstore.IDField {
+		v = r.ID
+	} else {
+		var ok bool
+		if v, ok = r.Fields[c.Field]; !ok || v == nil {
+			return false
+		}
+	}
+	// 多值字段（如标题路径）任一元素满足即可
+	for _, item := range values(v) {
+		if matchValue(item, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchValue(v any, c vectorstore.Condition) bool {
+	switch c.Op {
+	case vectorstore.OpExists:
+		return true
+	case vectorstore.OpIn:
+		for _, want := range c.Values {
+			if equal(v, want) {
+				return true
+			}
+		}
+		return false
+	case vectorstore.OpRange:
+		if c.Gte != nil && compare(v, c.Gte) < 0 {
+			return false
+		}
+		if c.Lte != nil && compare(v, c.Lte) > 0 {
+			return false
+		}
+		if c.Gt != nil && compare(v, c.Gt) <= 0 {
+			return false
+		}
+		if c.Lt != nil && compare(v, c.Lt) >= 0 {
+			return false
+		}
+		return true
+	case vectorstore.OpPrefix:
+		return len(c.Values) > 0 && strings.HasPrefix(fmt.Sprint(v), fmt.Sprint(c.Values[0]))
+	case vectorstore.OpSuffix:
+		return len(c.Values) > 0 && strings.HasSuffix(fmt.Sprint(v), fmt.Sprint(c.Values[0]))
+	case vectorstore.OpContains:
+		return len(c.Values) > 0 && strings.Contains(fmt.Sprint(v), fmt.Sprint(c.Values[0]))
+	default:
+		return len(c.Values) > 0 && equal(v, c.Values[0])
+	}
+}
+
+// equal 数值类型按数值比较，其余按字符串精确比较（与 keyword 字段的 term 查询一致）
+func equal(a, b any) bool {
+	_, strA := a.(string)
+	_, strB := b.(string)
+	if !strA && !strB {
+		fa, okA := toFloat(a)
+		fb, okB := toFloat(b)
+		if okA && okB {
+			return fa == fb
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// compare 两边都能解析为数字时按数值比较，否则按字符串比较（RFC3339 时间按字符串比较即可保证顺序）
+func compare(a, b any) int {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func values(v any) []any {
+	switch t := v.(type) {
+	case []any:
+		return t
+	case []string:
+		res := make([]any, 0, len(t))
+		for _, s := range t {
+			res = append(res, s)
+		}
+		return res
+	}
+	return []any{v}
+}
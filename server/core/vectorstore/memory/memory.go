@@ -0,0 +1,469 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// Store 纯 Go 实现的内置向量存储，数据保存在内存中，检索为暴力计算。
+// 指定 dir 时每个索引以追加写日志的方式持久化到 <dir>/<index>.jsonl，启动时回放并压缩，
+// 适合小规模部署与单元测试，不需要 Elasticsearch。别名保存在 <dir>/aliases.json 中。
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	indexes map[string]*index
+	aliases map[string]string // 别名 -> 索引名
+}
+
+var _ vectorstore.VectorStore = (*Store)(nil)
+
+type index struct {
+	spec    *vectorstore.IndexSpec
+	records map[string]*vectorstore.Record
+	log     *os.File
+}
+
+// logEntry 持久化日志中的一条操作，Fields 为空表示删除
+type logEntry struct {
+	ID     string         `json:"id"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// aliasFile 别名持久化文件，不使用 .jsonl 后缀以免被当作索引加载
+const aliasFile = "aliases.json"
+
+// New 创建内置向量存储，dir 为空时只保存在内存中
+func New(dir string) (*Store, error) {
+	s := &Store{dir: dir, indexes: map[string]*index{}, aliases: map[string]string{}}
+	if dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, aliasFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &s.aliases); err != nil {
+			return nil, fmt.Errorf("load aliases failed: %w", err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		if _, err = s.open(name); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Close 关闭所有持久化文件
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, idx := range s.indexes {
+		if idx.log != nil {
+			_ = idx.log.Close()
+			idx.log = nil
+		}
+	}
+	return nil
+}
+
+// open 获取索引（name 可以是别名），不存在时从磁盘加载或新建，调用方需持有写锁
+func (s *Store) open(name string) (*index, error) {
+	name = s.resolve(name)
+	if idx, ok := s.indexes[name]; ok {
+		return idx, nil
+	}
+	idx := &index{records: map[string]*vectorstore.Record{}}
+	if s.dir != "" {
+		path := filepath.Join(s.dir, name+".jsonl")
+		if err := idx.load(path); err != nil {
+			return nil, fmt.Errorf("load index %s failed: %w", name, err)
+		}
+		if err := idx.compact(path); err != nil {
+			return nil, fmt.Errorf("compact index %s failed: %w", name, err)
+		}
+	}
+	s.indexes[name] = idx
+	return idx, nil
+}
+
+func (s *Store) get(name string) *index {
+	return s.indexes[s.resolve(name)]
+}
+
+// resolve 将别名解析为索引名，不是别名时原样返回
+func (s *Store) resolve(name string) string {
+	if target, ok := s.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+func (s *Store) SwitchAlias(ctx context.Context, alias, name string) (previous []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indexes[alias]; ok {
+		return nil, fmt.Errorf("alias %s conflicts with an existing index", alias)
+	}
+	if _, err = s.open(name); err != nil {
+		return nil, err
+	}
+	if old, ok := s.aliases[alias]; ok && old != name {
+		previous = append(previous, old)
+	}
+	s.aliases[alias] = name
+	if s.dir == "" {
+		return previous, nil
+	}
+	data, err := json.Marshal(s.aliases)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.dir, aliasFile)
+	if err = os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return nil, err
+	}
+	return previous, os.Rename(path+".tmp", path)
+}
+
+func (s *Store) CreateIndex(ctx context.Context, name string, spec *vectorstore.IndexSpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, err := s.open(name)
+	if err != nil {
+		return err
+	}
+	// 已有数据的向量维度必须与配置一致
+	if spec.Dims > 0 {
+		for _, r := range idx.records {
+			for field, typ := range spec.Fields {
+				if typ != vectorstore.FieldTypeVector {
+					continue
+				}
+				if vec, ok := vectorstore.Float64s(r.Fields[field]); ok && len(vec) > 0 && len(vec) != spec.Dims {
+					return fmt.Errorf("%w: index=%s, field=%s, existing=%d, expect=%d",
+						vectorstore.ErrDimsMismatch, name, field, len(vec), spec.Dims)
+				}
+			}
+			break
+		}
+	}
+	idx.spec = spec
+	return nil
+}
+
+func (s *Store) Upsert(ctx context.Context, name string, records []*vectorstore.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, err := s.open(name)
+	if err != nil {
+		return err
+	}
+	entries := make([]logEntry, 0, len(records))
+	for _, r := range records {
+		fields := make(map[string]any, len(r.Fields))
+		for k, v := range r.Fields {
+			if vec, ok := vectorstore.Float64s(v); ok && idx.isVector(k) {
+				v = vec
+			}
+			fields[k] = v
+		}
+		idx.records[r.ID] = &vectorstore.Record{ID: r.ID, Fields: fields}
+		entries = append(entries, logEntry{ID: r.ID, Fields: fields})
+	}
+	return idx.append(entries...)
+}
+
+func (s *Store) Delete(ctx context.Context, name string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := s.get(name)
+	if idx == nil {
+		return nil
+	}
+	entries := make([]logEntry, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := idx.records[id]; !ok {
+			continue
+		}
+		delete(idx.records, id)
+		entries = append(entries, logEntry{ID: id})
+	}
+	return idx.append(entries...)
+}
+
+func (s *Store) DeleteByFilter(ctx context.Context, name string, filter *vectorstore.Filter) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := s.get(name)
+	if idx == nil {
+		return 0, nil
+	}
+	var entries []logEntry
+	for id, r := range idx.records {
+		if match(r, filter) {
+			delete(idx.records, id)
+			entries = append(entries, logEntry{ID: id})
+		}
+	}
+	return int64(len(entries)), idx.append(entries...)
+}
+
+func (s *Store) UpdateByFilter(ctx context.Context, name string, filter *vectorstore.Filter, fields map[string]any) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := s.get(name)
+	if idx == nil {
+		return 0, nil
+	}
+	var entries []logEntry
+	for _, r := range idx.records {
+		if !match(r, filter) {
+			continue
+		}
+		for k, v := range fields {
+			r.Fields[k] = v
+		}
+		entries = append(entries, logEntry{ID: r.ID, Fields: r.Fields})
+	}
+	return int64(len(entries)), idx.append(entries...)
+}
+
+func (s *Store) Query(ctx context.Context, name string, req *vectorstore.QueryRequest) ([]*vectorstore.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	idx := s.get(name)
+	if idx == nil {
+		return nil, nil
+	}
+	var res []*vectorstore.Record
+	for _, id := range idx.sortedIDs() {
+		r := idx.records[id]
+		if match(r, req.Filter) {
+			res = append(res, clone(r, 0, req.Excludes))
+		}
+		if req.Size > 0 && len(res) >= req.Size {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) KNN(ctx context.Context, name string, req *vectorstore.KNNRequest) ([]*vectorstore.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	idx := s.get(name)
+	if idx == nil {
+		return nil, nil
+	}
+	var res []*vectorstore.Record
+	for _, r := range idx.records {
+		vec, ok := vectorstore.Float64s(r.Fields[req.Field])
+		if !ok || len(vec) == 0 || !match(r, req.Filter) {
+			continue
+		}
+		if len(vec) != len(req.Vector) {
+			return nil, fmt.Errorf("vector dims mismatch, field=%s, expect=%d, got=%d", req.Field, len(vec), len(req.Vector))
+		}
+		res = append(res, clone(r, vectorstore.Score(req.Similarity, req.Vector, vec), nil))
+	}
+	return topK(res, req.TopK), nil
+}
+
+func (s *Store) KeywordSearch(ctx context.Context, name string, req *vectorstore.KeywordRequest) ([]*vectorstore.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	idx := s.get(name)
+	if idx == nil {
+		return nil, nil
+	}
+	corpus := newBM25(idx.records, req.Field)
+	var res []*vectorstore.Record
+	for id, r := range idx.records {
+		if !match(r, req.Filter) {
+			continue
+		}
+		var score float64
+		for _, q := range req.Queries {
+			score += corpus.score(id, q)
+		}
+		if score > 0 {
+			res = append(res, clone(r, score, req.Excludes))
+		}
+	}
+	return topK(res, req.TopK), nil
+}
+
+func (s *Store) Aggregate(ctx context.Context, name string, req *vectorstore.AggregateRequest) ([]*vectorstore.Bucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	idx := s.get(name)
+	if idx == nil {
+		return nil, nil
+	}
+	counts := map[string]int64{}
+	for _, r := range idx.records {
+		v, ok := r.Fields[req.Field]
+		if !ok || v == nil || !match(r, req.Filter) {
+			continue
+		}
+		for _, item := range values(v) {
+			counts[fmt.Sprint(item)]++
+		}
+	}
+	buckets := make([]*vectorstore.Bucket, 0, len(counts))
+	for k, c := range counts {
+		buckets = append(buckets, &vectorstore.Bucket{Key: k, Count: c})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Key < buckets[j].Key
+	})
+	if req.Size > 0 && len(buckets) > req.Size {
+		buckets = buckets[:req.Size]
+	}
+	return buckets, nil
+}
+
+// Refresh 内置存储写入后立即可见
+func (s *Store) Refresh(ctx context.Context, name string) error {
+	return nil
+}
+
+func (idx *index) isVector(field string) bool {
+	if idx.spec == nil {
+		return true
+	}
+	typ, ok := idx.spec.Fields[field]
+	return !ok || typ == vectorstore.FieldTypeVector
+}
+
+func (idx *index) sortedIDs() []string {
+	ids := make([]string, 0, len(idx.records))
+	for id := range idx.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// load 回放持久化日志
+func (idx *index) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var e logEntry
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// 进程中途退出可能留下写了一半的最后一行，忽略即可
+			continue
+		}
+		if e.Fields == nil {
+			delete(idx.records, e.ID)
+			continue
+		}
+		for k, v := range e.Fields {
+			if vec, ok := vectorstore.Float64s(v); ok && len(vec) > 0 {
+				e.Fields[k] = vec
+			}
+		}
+		idx.records[e.ID] = &vectorstore.Record{ID: e.ID, Fields: e.Fields}
+	}
+	return scanner.Err()
+}
+
+// compact 将当前数据重写为只包含最新记录的日志，并打开用于后续追加写入
+func (idx *index) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, id := range idx.sortedIDs() {
+		if err = enc.Encode(logEntry{ID: id, Fields: idx.records[id].Fields}); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	idx.log, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	return err
+}
+
+func (idx *index) append(entries ...logEntry) error {
+	if idx.log == nil || len(entries) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	_, err := idx.log.WriteString(buf.String())
+	return err
+}
+
+// clone 复制记录，避免调用方修改到存储中的数据
+func clone(r *vectorstore.Record, score float64, excludes []string) *vectorstore.Record {
+	fields := make(map[string]any, len(r.Fields))
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	for _, k := range excludes {
+		delete(fields, k)
+	}
+	return &vectorstore.Record{ID: r.ID, Fields: fields, Score: score}
+}
+
+func topK(records []*vectorstore.Record, k int) []*vectorstore.Record {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Score != records[j].Score {
+			return records[i].Score > records[j].Score
+		}
+		return records[i].ID < records[j].ID
+	})
+	if k > 0 && len(records) > k {
+		records = records[:k]
+	}
+	return records
+}
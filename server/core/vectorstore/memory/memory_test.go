@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore/storetest"
+)
+
+const testIndex = "test"
+
+func newTestStore(t *testing.T, dir string) *Store {
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	err = s.CreateIndex(ctx, testIndex, &vectorstore.IndexSpec{
+		Fields: map[string]vectorstore.FieldType{
+			"content": vectorstore.FieldTypeText,
+			"kb":      vectorstore.FieldTypeKeyword,
+			"vector":  vectorstore.FieldTypeVector,
+		},
+		Dims: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func seed(t *testing.T, s *Store) {
+	err := s.Upsert(context.Background(), testIndex, []*vectorstore.Record{
+		{ID: "a", Fields: map[string]any{"content": "错误码 E1024 表示磁盘已满", "kb": "ops", "vector": []float64{1, 0}}},
+		{ID: "b", Fields: map[string]any{"content": "重启服务即可恢复", "kb": "ops", "vector": []float64{0.8, 0.6}}},
+		{ID: "c", Fields: map[string]any{"content": "产品介绍", "kb": "sales", "vector": []float64{0, 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKNN(t *testing.T) {
+	s := newTestStore(t, "")
+	seed(t, s)
+	res, err := s.KNN(context.Background(), testIndex, &vectorstore.KNNRequest{
+		Field:  "vector",
+		Vector: []float64{1, 0},
+		TopK:   2,
+		Filter: vectorstore.NewFilter(vectorstore.Eq("kb", "ops")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != "a" || res[1].ID != "b" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// 分数为 cosine + 1
+	if res[0].Score != 2 {
+		t.Fatalf("expect score 2, got %v", res[0].Score)
+	}
+}
+
+func TestKeywordSearch(t *testing.T) {
+	s := newTestStore(t, "")
+	seed(t, s)
+	res, err := s.KeywordSearch(context.Background(), testIndex, &vectorstore.KeywordRequest{
+		Field:    "content",
+		Queries:  []string{"e1024"},
+		TopK:     10,
+		Excludes: []string{"vector"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].ID != "a" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if _, ok := res[0].Fields["vector"]; ok {
+		t.Fatal("vector should be excluded")
+	}
+}
+
+func TestUpdateAndDeleteByFilter(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, "")
+	seed(t, s)
+	updated, err := s.UpdateByFilter(ctx, testIndex,
+		(&vectorstore.Filter{}).Not(vectorstore.Exists("status")),
+		map[string]any{"status": "enabled"})
+	if err != nil || updated != 3 {
+		t.Fatalf("updated=%d, err=%v", updated, err)
+	}
+	deleted, err := s.DeleteByFilter(ctx, testIndex, vectorstore.NewFilter(vectorstore.In(vectorstore.IDField, []string{"a", "c"})))
+	if err != nil || deleted != 2 {
+		t.Fatalf("deleted=%d, err=%v", deleted, err)
+	}
+	buckets, err := s.Aggregate(ctx, testIndex, &vectorstore.AggregateRequest{Field: "kb", Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || buckets[0].Key != "ops" || buckets[0].Count != 1 {
+		t.Fatalf("unexpected buckets: %+v", buckets)
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, "")
+	seed(t, s)
+	cases := []struct {
+		filter *vectorstore.Filter
+		expect int
+	}{
+		{vectorstore.NewFilter(vectorstore.Contains("content", "E1024")), 1},
+		{vectorstore.NewFilter(vectorstore.Suffix("kb", "les")), 1},
+		{vectorstore.NewFilter(vectorstore.Gt(vectorstore.IDField, "a"), vectorstore.Lt(vectorstore.IDField, "c")), 1},
+		// kb = sales 或 id = a
+		{(&vectorstore.Filter{}).Or(
+			vectorstore.NewFilter(vectorstore.Eq("kb", "sales")),
+			vectorstore.NewFilter(vectorstore.Eq(vectorstore.IDField, "a")),
+		), 2},
+		// kb = ops 且 content 不包含 "重启"
+		{vectorstore.NewFilter(vectorstore.Eq("kb", "ops")).Or(
+			(&vectorstore.Filter{}).Not(vectorstore.Contains("content", "重启")),
+		), 1},
+		// kb = ops 且 (id = b 或 id = c)
+		{vectorstore.NewFilter(vectorstore.Eq("kb", "ops")).Nest((&vectorstore.Filter{}).Or(
+			vectorstore.NewFilter(vectorstore.Eq(vectorstore.IDField, "b")),
+			vectorstore.NewFilter(vectorstore.Eq(vectorstore.IDField, "c")),
+		)), 1},
+	}
+	for i, c := range cases {
+		res, err := s.Query(ctx, testIndex, &vectorstore.QueryRequest{Filter: c.filter, Size: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != c.expect {
+			t.Fatalf("case %d: expect %d records, got %d", i, c.expect, len(res))
+		}
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := newTestStore(t, dir)
+	seed(t, s)
+	if err := s.Delete(ctx, testIndex, "b"); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	s = newTestStore(t, dir)
+	defer s.Close()
+	res, err := s.Query(ctx, testIndex, &vectorstore.QueryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != "a" || res[1].ID != "c" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// 重新加载后向量仍可用于检索
+	knn, err := s.KNN(ctx, testIndex, &vectorstore.KNNRequest{Field: "vector", Vector: []float64{0, 1}, TopK: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(knn) != 1 || knn[0].ID != "c" {
+		t.Fatalf("unexpected result: %+v", knn)
+	}
+}
+
+func TestCreateIndexDimsMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, "")
+	seed(t, s)
+	err := s.CreateIndex(ctx, testIndex, &vectorstore.IndexSpec{
+		Fields: map[string]vectorstore.FieldType{"vector": vectorstore.FieldTypeVector},
+		Dims:   3,
+	})
+	if !errors.Is(err, vectorstore.ErrDimsMismatch) {
+		t.Fatalf("expect ErrDimsMismatch, got %v", err)
+	}
+}
+
+func TestSwitchAlias(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := newTestStore(t, dir)
+	seed(t, s)
+	if _, err := s.SwitchAlias(ctx, "current", testIndex); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Upsert(ctx, "v2", []*vectorstore.Record{{ID: "d", Fields: map[string]any{"kb": "ops"}}}); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := s.SwitchAlias(ctx, "current", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous) != 1 || previous[0] != testIndex {
+		t.Fatalf("unexpected previous: %v", previous)
+	}
+	_ = s.Close()
+
+	// 别名持久化后重新加载仍指向新索引，旧索引保留
+	s = newTestStore(t, dir)
+	defer s.Close()
+	res, err := s.Query(ctx, "current", &vectorstore.QueryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].ID != "d" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res, _ = s.Query(ctx, testIndex, &vectorstore.QueryRequest{}); len(res) != 3 {
+		t.Fatalf("old index should be kept, got %+v", res)
+	}
+	if _, err = s.SwitchAlias(ctx, testIndex, "v2"); err == nil {
+		t.Fatal("expect error when alias conflicts with an index")
+	}
+}
+
+func TestQuerySize(t *testing.T) {
+	storetest.Query(t, newTestStore(t, ""), "size")
+}
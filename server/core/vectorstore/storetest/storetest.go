@@ -0,0 +1,65 @@
+// Package storetest 提供各 VectorStore 实现共用的测试用例，保证不同后端的行为一致
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+)
+
+// Query 校验 Query 的数量语义：Size 为返回数量上限，<= 0 时返回全部匹配的记录。
+// 记录数超过 ES 单页读取的数量，覆盖分页读取的路径
+func Query(t *testing.T, s vectorstore.VectorStore, index string) {
+	ctx := context.Background()
+	err := s.CreateIndex(ctx, index, &vectorstore.IndexSpec{
+		Fields: map[string]vectorstore.FieldType{"kb": vectorstore.FieldTypeKeyword},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const total = 2500
+	records := make([]*vectorstore.Record, 0, total)
+	for i := 0; i < total; i++ {
+		kb := "ops"
+		if i%5 == 0 {
+			kb = "sales"
+		}
+		records = append(records, &vectorstore.Record{ID: fmt.Sprintf("r%04d", i), Fields: map[string]any{"kb": kb}})
+	}
+	if err = s.Upsert(ctx, index, records); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Refresh(ctx, index); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		size   int
+		filter *vectorstore.Filter
+		expect int
+	}{
+		{0, nil, total},
+		{-1, vectorstore.NewFilter(vectorstore.Eq("kb", "ops")), 2000},
+		{10, vectorstore.NewFilter(vectorstore.Eq("kb", "ops")), 10},
+		{1500, nil, 1500},
+		{total + 10, vectorstore.NewFilter(vectorstore.Eq("kb", "sales")), 500},
+	}
+	for i, c := range cases {
+		res, err := s.Query(ctx, index, &vectorstore.QueryRequest{Filter: c.filter, Size: c.size})
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if len(res) != c.expect {
+			t.Fatalf("case %d: expect %d records, got %d", i, c.expect, len(res))
+		}
+		seen := make(map[string]bool, len(res))
+		for _, r := range res {
+			if seen[r.ID] {
+				t.Fatalf("case %d: duplicate record %s", i, r.ID)
+			}
+			seen[r.ID] = true
+		}
+	}
+}
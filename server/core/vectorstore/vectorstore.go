@@ -0,0 +1,161 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// VectorStore 向量存储的抽象，屏蔽 Elasticsearch 与内置存储之间的差异。
+// 索引、检索、删除等操作都通过该接口完成，调用方不再直接依赖具体的客户端。
+type VectorStore interface {
+	// CreateIndex 创建索引；索引已存在时只补齐缺失的非向量字段，
+	// 已有向量字段的维度与 spec 不一致时返回 ErrDimsMismatch
+	CreateIndex(ctx context.Context, index string, spec *IndexSpec) error
+	// Upsert 按 ID 写入记录，ID 已存在时整条覆盖
+	Upsert(ctx context.Context, index string, records []*Record) error
+	// Delete 按 ID 删除记录，记录不存在时忽略
+	Delete(ctx context.Context, index string, ids ...string) error
+	// DeleteByFilter 删除满足条件的记录，返回删除的数量
+	DeleteByFilter(ctx context.Context, index string, filter *Filter) (int64, error)
+	// UpdateByFilter 将满足条件的记录中的字段覆盖为 fields 中的值，返回更新的数量
+	UpdateByFilter(ctx context.Context, index string, filter *Filter, fields map[string]any) (int64, error)
+	// Query 按条件读取记录，不计算相关性。req.Size 为返回数量上限，<= 0 表示返回全部匹配的记录
+	Query(ctx context.Context, index string, req *QueryRequest) ([]*Record, error)
+	// KNN 向量相似度检索，各种相似度的分数都映射到 0-2（cosine 与 ES 的 cosineSimilarity + 1 一致）
+	KNN(ctx context.Context, index string, req *KNNRequest) ([]*Record, error)
+	// KeywordSearch 关键词（BM25）检索
+	KeywordSearch(ctx context.Context, index string, req *KeywordRequest) ([]*Record, error)
+	// Aggregate 对字段做 terms 分组聚合，按数量降序返回
+	Aggregate(ctx context.Context, index string, req *AggregateRequest) ([]*Bucket, error)
+	// Refresh 使刚写入的数据可以被检索到
+	Refresh(ctx context.Context, index string) error
+	// SwitchAlias 原子地把别名指向 index，返回切换前别名指向的索引（别名不存在时为空）。
+	// 其余方法的 index 参数都可以传别名，读写的是别名当前指向的索引
+	SwitchAlias(ctx context.Context, alias, index string) (previous []string, err error)
+}
+
+// FieldType 索引字段类型
+type FieldType string
+
+const (
+	FieldTypeText    FieldType = "text"    // 分词文本，可用于关键词检索
+	FieldTypeKeyword FieldType = "keyword" // 不分词，用于精确过滤与聚合
+	FieldTypeVector  FieldType = "vector"  // 稠密向量
+)
+
+// 向量相似度
+const (
+	SimilarityCosine     = "cosine"      // 余弦相似度，分数为 cos + 1
+	SimilarityDotProduct = "dot_product" // 点积，要求向量已归一化，分数为 dot + 1
+	SimilarityL2Norm     = "l2_norm"     // 欧氏距离，分数为 2 / (1 + l2)
+)
+
+// ErrDimsMismatch 已有索引的向量维度与配置不一致，需要重建索引
+var ErrDimsMismatch = errors.New("vector dims mismatch")
+
+// IndexSpec 索引的字段定义
+type IndexSpec struct {
+	Fields     map[string]FieldType
+	Dims       int    // 向量维度，需与 embedding 模型一致
+	Similarity string // 向量相似度，为空时使用 cosine
+}
+
+// Record 向量存储中的一条记录（即一个 chunk）
+type Record struct {
+	ID     string
+	Fields map[string]any // 字段值，向量字段为 []float64
+	Score  float64        // 检索得分，Query 返回的记录为 0
+}
+
+// QueryRequest 按条件读取记录
+type QueryRequest struct {
+	Filter   *Filter
+	Size     int      // 返回数量上限，<= 0 表示不限
+	Excludes []string // 不需要返回的字段，通常是向量字段
+}
+
+// KNNRequest 向量检索请求
+type KNNRequest struct {
+	Field      string // 向量字段
+	Vector     []float64
+	TopK       int
+	Filter     *Filter
+	Similarity string // 为空时使用 cosine
+}
+
+// KeywordRequest 关键词检索请求，Queries 中任一命中即可，得分累加
+type KeywordRequest struct {
+	Field    string // 文本字段
+	Queries  []string
+	TopK     int
+	Filter   *Filter
+	Excludes []string
+}
+
+// AggregateRequest terms 聚合请求
+type AggregateRequest struct {
+	Field  string
+	Filter *Filter
+	Size   int
+}
+
+// Bucket 聚合结果中的一个分组
+type Bucket struct {
+	Key   string
+	Count int64
+}
+
+// Float64s 将记录中的向量值转换为 []float64，兼容 JSON 反序列化得到的 []any
+func Float64s(v any) ([]float64, bool) {
+	switch t := v.(type) {
+	case []float64:
+		return t, true
+	case []float32:
+		res := make([]float64, len(t))
+		for i, f := range t {
+			res[i] = float64(f)
+		}
+		return res, true
+	case []any:
+		res := make([]float64, 0, len(t))
+		for _, item := range t {
+			f, ok := item.(float64)
+			if !ok {
+				return nil, false
+			}
+			res = append(res, f)
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// Score 按相似度计算两个向量的检索得分，范围 0-2，供不支持服务端计算的存储使用
+func Score(similarity string, a, b []float64) float64 {
+	switch similarity {
+	case SimilarityDotProduct:
+		return max(dot(a, b)+1, 0)
+	case SimilarityL2Norm:
+		var sum float64
+		for i := range a {
+			d := a[i] - b[i]
+			sum += d * d
+		}
+		return 2 / (1 + math.Sqrt(sum))
+	default:
+		na, nb := math.Sqrt(dot(a, a)), math.Sqrt(dot(b, b))
+		if na == 0 || nb == 0 {
+			return 1
+		}
+		return dot(a, b)/(na*nb) + 1
+	}
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,43 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+)
+
+// verifyDocMaxRunes 校验时每个参考资料保留的最大字符数
+const verifyDocMaxRunes = 1500
+
+var verifySystem = "You are a strict fact checker for answers generated from reference documents.\n" +
+	"1. Split the answer into atomic factual claims. Skip greetings, questions and statements that the references do not cover the topic.\n" +
+	"2. For each claim, decide whether it is supported by the numbered references. " +
+	"A claim is supported only if the references state it or it follows directly from them; general knowledge does not count.\n" +
+	"3. List the numbers of the references that support each claim.\n" +
+	"Return only a JSON object without any further explanation, for example:\n" +
+	"{\"claims\":[{\"claim\":\"Refunds must be requested within 7 days\",\"supported\":true,\"evidence\":[1]}," +
+	"{\"claim\":\"Refunds are processed within 24 hours\",\"supported\":false,\"reason\":\"no reference mentions the processing time\"}]}"
+
+// verifyMessages 校验的消息，参考资料与回答包含花括号，不使用 FString 模板
+func verifyMessages(question, answer string, docs []*schema.Document) []*schema.Message {
+	truncated := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		if content := []rune(doc.Content); len(content) > verifyDocMaxRunes {
+			doc = &schema.Document{ID: doc.ID, Content: string(content[:verifyDocMaxRunes]), MetaData: doc.MetaData}
+		}
+		truncated = append(truncated, doc)
+	}
+	var sb strings.Builder
+	sb.WriteString("References:\n")
+	if len(truncated) == 0 {
+		sb.WriteString("(none)")
+	}
+	sb.WriteString(citation.Format(truncated))
+	fmt.Fprintf(&sb, "\n\nQuestion: %s\n\nAnswer:\n%s", question, answer)
+	return []*schema.Message{
+		schema.SystemMessage(verifySystem),
+		schema.UserMessage(sb.String()),
+	}
+}
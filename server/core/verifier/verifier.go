@@ -0,0 +1,152 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 得分低于阈值时的处理方式
+const (
+	ActionOff        = "off"        // 不校验
+	ActionWarn       = "warn"       // 返回警告
+	ActionRegenerate = "regenerate" // 按不被支持的论断重新生成一次，仍低于阈值时返回警告
+)
+
+// DefaultThreshold 默认的得分阈值
+const DefaultThreshold = 0.7
+
+// Claim 回答中的一个论断
+type Claim struct {
+	Claim     string `json:"claim"`              // 论断内容
+	Supported bool   `json:"supported"`          // 参考资料是否支持该论断
+	Evidence  []int  `json:"evidence,omitempty"` // 支持该论断的参考资料编号，从 1 开始
+	Reason    string `json:"reason,omitempty"`   // 判断理由
+}
+
+// Result 一次校验的结果
+type Result struct {
+	Score       float64  `json:"score"`       // 被支持的论断占比，没有论断时为 1
+	Claims      []*Claim `json:"claims"`      // 拆分出的论断
+	Unsupported []string `json:"unsupported"` // 不被支持的论断
+}
+
+// Policy 校验策略
+type Policy struct {
+	Action    string  // off / warn / regenerate
+	Threshold float64 // 得分阈值，0-1
+}
+
+// Report 校验报告，随回答一起返回
+type Report struct {
+	*Result
+	Threshold   float64 `json:"threshold"`         // 使用的阈值
+	Action      string  `json:"action"`            // 使用的处理方式
+	Regenerated bool    `json:"regenerated"`       // 是否重新生成过回答，Result 为重新生成后的校验结果
+	Warning     string  `json:"warning,omitempty"` // 最终得分仍低于阈值时的警告
+}
+
+type Verifier struct {
+	cm model.BaseChatModel
+}
+
+func NewVerifier(cm model.BaseChatModel) *Verifier {
+	return &Verifier{
+		cm: cm,
+	}
+}
+
+// Verify 把回答拆分为论断，在一次调用中逐条判断参考资料是否支持
+func (x *Verifier) Verify(ctx context.Context, question, answer string, docs []*schema.Document) (*Result, error) {
+	if strings.TrimSpace(answer) == "" {
+		return &Result{Score: 1, Claims: []*Claim{}, Unsupported: []string{}}, nil
+	}
+	result, err := x.cm.Generate(ctx, verifyMessages(question, answer, docs))
+	if err != nil {
+		return nil, fmt.Errorf("verify the answer against the references (failed): %w", err)
+	}
+	return parseResult(result.Content, len(docs))
+}
+
+// Check 按策略校验回答：得分低于阈值且策略为 regenerate 时，用 regenerate 按反馈重新生成一次并再次校验；
+// 最终得分仍低于阈值时在报告中给出警告。返回最终的回答与校验报告
+func (x *Verifier) Check(ctx context.Context, policy *Policy, question, answer string, docs []*schema.Document,
+	regenerate func(ctx context.Context, feedback string) (string, error)) (string, *Report, error) {
+	result, err := x.Verify(ctx, question, answer, docs)
+	if err != nil {
+		return answer, nil, err
+	}
+	report := &Report{Result: result, Threshold: policy.Threshold, Action: policy.Action}
+	if result.Score >= policy.Threshold {
+		return answer, report, nil
+	}
+	if policy.Action == ActionRegenerate && regenerate != nil {
+		revised, err := regenerate(ctx, Feedback(result))
+		if err != nil {
+			g.Log().Warningf(ctx, "verifier: regenerate failed, keep the original answer: %v", err)
+		} else if revisedResult, err := x.Verify(ctx, question, revised, docs); err != nil {
+			g.Log().Warningf(ctx, "verifier: verify regenerated answer failed, keep the original answer: %v", err)
+		} else {
+			answer = revised
+			report.Result = revisedResult
+			report.Regenerated = true
+		}
+	}
+	if report.Score < policy.Threshold {
+		report.Warning = fmt.Sprintf("Only %.0f%% of the claims in this answer are supported by the references (threshold %.0f%%), "+
+			"please check the unsupported claims before relying on it.", report.Score*100, policy.Threshold*100)
+	}
+	return answer, report, nil
+}
+
+// Feedback 重新生成回答时给模型的反馈，列出不被支持的论断
+func Feedback(result *Result) string {
+	var sb strings.Builder
+	sb.WriteString("The following claims in your answer are not supported by the references:\n")
+	for _, claim := range result.Unsupported {
+		fmt.Fprintf(&sb, "- %s\n", claim)
+	}
+	sb.WriteString("Rewrite the answer using only information from the references. " +
+		"Remove these claims or state that the references do not cover them.")
+	return sb.String()
+}
+
+func parseResult(content string, docCount int) (*Result, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("no json object in verify result: %s", content)
+	}
+	raw := &Result{}
+	if err := sonic.UnmarshalString(content[start:end+1], raw); err != nil {
+		return nil, fmt.Errorf("unmarshal verify result failed: %w", err)
+	}
+	res := &Result{Claims: []*Claim{}, Unsupported: []string{}}
+	for _, c := range raw.Claims {
+		if c == nil || strings.TrimSpace(c.Claim) == "" {
+			continue
+		}
+		c.Claim = strings.TrimSpace(c.Claim)
+		// 只保留存在的参考资料编号
+		evidence := c.Evidence[:0]
+		for _, n := range c.Evidence {
+			if n >= 1 && n <= docCount {
+				evidence = append(evidence, n)
+			}
+		}
+		c.Evidence = evidence
+		res.Claims = append(res.Claims, c)
+		if !c.Supported {
+			res.Unsupported = append(res.Unsupported, c.Claim)
+		}
+	}
+	res.Score = 1
+	if len(res.Claims) > 0 {
+		res.Score = float64(len(res.Claims)-len(res.Unsupported)) / float64(len(res.Claims))
+	}
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeChatModel 依次返回固定的内容
+type fakeChatModel struct {
+	replies []string
+	calls   int
+}
+
+func (f *fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if f.calls >= len(f.replies) {
+		return nil, errors.New("no more replies")
+	}
+	f.calls++
+	return schema.AssistantMessage(f.replies[f.calls-1], nil), nil
+}
+
+func (f *fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, nil
+}
+
+var docs = []*schema.Document{
+	{ID: "a", Content: "退款需在 7 天内申请"},
+	{ID: "b", Content: "数字商品不支持退款 {见条款}"},
+}
+
+const (
+	partial = "```json\n" + `{"claims":[{"claim":"退款需在 7 天内申请","supported":true,"evidence":[1,5]},` +
+		`{"claim":" 退款 24 小时到账 ","supported":false,"reason":"未提及"},{"claim":""}]}` + "\n```"
+	grounded = `{"claims":[{"claim":"退款需在 7 天内申请","supported":true,"evidence":[1]}]}`
+)
+
+func TestVerify(t *testing.T) {
+	res, err := NewVerifier(&fakeChatModel{replies: []string{partial}}).Verify(context.Background(), "怎么退款", "退款需在 7 天内申请[1]，24 小时到账。", docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Claims) != 2 || res.Score != 0.5 || len(res.Unsupported) != 1 || res.Unsupported[0] != "退款 24 小时到账" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	// 不存在的参考资料编号被忽略
+	if len(res.Claims[0].Evidence) != 1 || res.Claims[0].Evidence[0] != 1 {
+		t.Fatalf("unexpected evidence: %v", res.Claims[0].Evidence)
+	}
+	if _, err = NewVerifier(&fakeChatModel{replies: []string{"looks fine"}}).Verify(context.Background(), "q", "a", docs); err == nil {
+		t.Fatal("expect error without json object")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	regenerate := func(ctx context.Context, feedback string) (string, error) {
+		return "退款需在 7 天内申请[1]。", nil
+	}
+
+	// 重新生成后通过校验
+	answer, report, err := NewVerifier(&fakeChatModel{replies: []string{partial, grounded}}).
+		Check(ctx, &Policy{Action: ActionRegenerate, Threshold: 0.8}, "怎么退款", "draft", docs, regenerate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "退款需在 7 天内申请[1]。" || !report.Regenerated || report.Score != 1 || report.Warning != "" {
+		t.Fatalf("unexpected report: %q %+v", answer, report)
+	}
+
+	// 只警告时保留原回答
+	answer, report, err = NewVerifier(&fakeChatModel{replies: []string{partial}}).
+		Check(ctx, &Policy{Action: ActionWarn, Threshold: 0.8}, "怎么退款", "draft", docs, regenerate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "draft" || report.Regenerated || report.Warning == "" {
+		t.Fatalf("unexpected report: %q %+v", answer, report)
+	}
+
+	// 重新生成的回答校验失败时保留原回答与原结果
+	answer, report, err = NewVerifier(&fakeChatModel{replies: []string{partial}}).
+		Check(ctx, &Policy{Action: ActionRegenerate, Threshold: 0.8}, "怎么退款", "draft", docs, regenerate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "draft" || report.Regenerated || report.Score != 0.5 || report.Warning == "" {
+		t.Fatalf("unexpected report: %q %+v", answer, report)
+	}
+}
@@ -15,10 +15,8 @@ require (
 	github.com/cloudwego/eino-ext/components/document/transformer/splitter/markdown v0.0.0-20251009103408-8fdc37455fa1
 	github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive v0.0.0-20251009103408-8fdc37455fa1
 	github.com/cloudwego/eino-ext/components/embedding/openai v0.0.0-20251009103408-8fdc37455fa1
-	github.com/cloudwego/eino-ext/components/indexer/es8 v0.0.0-20251009103408-8fdc37455fa1
 	github.com/cloudwego/eino-ext/components/model/openai v0.1.1
 	github.com/cloudwego/eino-ext/components/model/qwen v0.1.1
-	github.com/cloudwego/eino-ext/components/retriever/es8 v0.0.0-20251009103408-8fdc37455fa1
 	github.com/elastic/go-elasticsearch/v8 v8.16.0
 	github.com/gogf/gf/contrib/drivers/mysql/v2 v2.9.5
 	github.com/gogf/gf/v2 v2.9.5
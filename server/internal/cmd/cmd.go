@@ -5,6 +5,8 @@ import (
 	"github.com/ThinkInAIXYZ/go-mcp/server"
 	"github.com/ThinkInAIXYZ/go-mcp/transport"
 	"github.com/everfid-ever/ThinkForge/internal/controller/rag"
+	"github.com/everfid-ever/ThinkForge/internal/logic/indexjob"
+	ragsvr "github.com/everfid-ever/ThinkForge/internal/logic/rag"
 	"github.com/everfid-ever/ThinkForge/internal/mcp"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
@@ -46,6 +48,21 @@ var (
 				)
 			})
 
+			// 校验各知识库独立索引的向量维度，不一致的知识库在重建索引前拒绝写入与检索
+			if err := ragsvr.GetRagSvr().PrepareKnowledgeBases(ctx); err != nil {
+				g.Log().Errorf(ctx, "PrepareKnowledgeBases failed, err=%v", err)
+			}
+
+			// 启动索引任务 worker，继续处理上次退出前未完成的任务；需在校验索引之后，避免写入未经校验的索引
+			indexjob.Start(ctx)
+
+			// 回填 ES 中 chunk 的启用状态，使历史数据也能在检索时过滤已禁用的 chunk
+			go func() {
+				if err := ragsvr.GetRagSvr().BackfillChunkStatus(ctx); err != nil {
+					g.Log().Errorf(ctx, "BackfillChunkStatus failed, err=%v", err)
+				}
+			}()
+
 			// 启动 HTTP 服务（默认监听 127.0.0.1:8199，或在 config.yaml 中配置）
 			s.Run()
 			return nil
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/everfid-ever/ThinkForge/core"
+	ragsvr "github.com/everfid-ever/ThinkForge/internal/logic/rag"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcmd"
+)
+
+// Reindex 命令把知识库的所有 chunk 重新向量化写入新的版本化索引并切换别名，旧索引保留用于回滚。
+// 更换 embedding 模型后可通过 `go run main.go reindex -kb=<name> -model=<model> -dims=<dims>` 执行，
+// 重建期间正在运行的服务仍可正常检索该知识库。命令同样登记为重建任务，知识库已有进行中的重建任务时拒绝执行。
+var (
+	Reindex = gcmd.Command{
+		Name:  "reindex",
+		Usage: "reindex -kb=<name> [-model=<model>] [-dims=<dims>] [-similarity=<similarity>] [-qa]",
+		Brief: "re-embed all chunks of a knowledge base into a new index",
+		Arguments: []gcmd.Argument{
+			{Name: "kb", Brief: "knowledge base name"},
+			{Name: "model", Brief: "embedding model, defaults to the current one of the knowledge base"},
+			{Name: "dims", Brief: "embedding dimensions, must match the model output"},
+			{Name: "similarity", Brief: "vector similarity: cosine / dot_product / l2_norm"},
+			{Name: "qa", Brief: "regenerate qa content instead of reusing the existing one", Orphan: true},
+		},
+		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
+			name := parser.GetOpt("kb").String()
+			if name == "" {
+				return fmt.Errorf("knowledge base name is required, use -kb=<name>")
+			}
+			res, err := ragsvr.GetRagSvr().ReindexNow(ctx, &core.ReindexReq{
+				KnowledgeName:  name,
+				EmbeddingModel: parser.GetOpt("model").String(),
+				EmbeddingDims:  parser.GetOpt("dims").Int(),
+				Similarity:     parser.GetOpt("similarity").String(),
+				RegenerateQA:   parser.GetOpt("qa") != nil,
+			})
+			if err != nil {
+				return err
+			}
+			g.Log().Infof(ctx, "reindex done, alias=%s, index=%s, previous=%v, chunks=%d, deleted=%d",
+				res.Alias, res.Index, res.Previous, res.Chunks, res.Deleted)
+			return nil
+		},
+	}
+)
+
+func init() {
+	if err := Main.AddCommand(&Reindex); err != nil {
+		panic(err)
+	}
+}
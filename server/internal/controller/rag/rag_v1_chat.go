@@ -3,24 +3,84 @@ package rag
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
 	"github.com/everfid-ever/ThinkForge/core/agent"
 	"github.com/everfid-ever/ThinkForge/core/agent/tools"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
+	"github.com/everfid-ever/ThinkForge/core/verifier"
 	"github.com/everfid-ever/ThinkForge/internal/logic/chat"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
 	ragLogic "github.com/everfid-ever/ThinkForge/internal/logic/rag"
 	"github.com/gogf/gf/v2/frame/g"
 )
 
-// Chat 智能 RAG 统一入口（支持传统模式和 Agentic 模式）
+// Chat 智能 RAG 统一入口（支持传统模式和 Agentic 模式）。
+// 相似的问题直接返回语义缓存中的答案，多轮对话中的后续问题依赖上下文，不使用缓存
 func (c *ControllerV1) Chat(ctx context.Context, req *v1.ChatReq) (res *v1.ChatRes, err error) {
 	startTime := time.Now()
+	chatI := chat.GetChat()
+	hasHistory, err := chatI.HasHistory(req.ConvID)
+	if err != nil {
+		return nil, err
+	}
+	if hasHistory {
+		return c.chat(ctx, req, startTime)
+	}
+
+	cacheKey := ragLogic.ChatCacheKey(req)
+	cached := &v1.ChatRes{}
+	if ragLogic.GetRagSvr().Cache().Get(ctx, cacheKey, cached) {
+		cached.CacheHit = true
+		cached.ExecutionTime = time.Since(startTime).Milliseconds()
+		saveCtx, saved := chat.WithMessageRecorder(ctx)
+		if err = chatI.SaveExchange(saveCtx, req.ConvID, req.Question, cached.Answer); err != nil {
+			g.Log().Errorf(ctx, "save cached exchange failed, err=%v", err)
+		}
+		saveMessageMeta(ctx, saved, cached, nil)
+		return cached, nil
+	}
+	if res, err = c.chat(ctx, req, startTime); err != nil {
+		return
+	}
+	// 按意图扩大的知识库也要参与缓存失效
+	cacheKey.AddKnowledgeBases(ragLogic.KnowledgeBaseNames(req.KnowledgeName, req.KnowledgeBases)...)
+	ragLogic.GetRagSvr().Cache().Set(ctx, cacheKey, res)
+	return
+}
+
+func (c *ControllerV1) chat(ctx context.Context, req *v1.ChatReq, startTime time.Time) (res *v1.ChatRes, err error) {
 	g.Log().Infof(ctx, "🚀 Smart RAG: %s", req.Question)
 
-	useAgentic := req.EnableAgentic || req.KnowledgeName != ""
+	// 记录各策略检索时实际使用的改写问题；按策略校验回答（可能重新生成），
+	// 再解析答案中的引用标记，编号对应 References 的顺序，最后把审计信息记录到写入会话历史的回答上
+	// 对话结果已有自己的缓存，内部的检索不再读写检索接口的缓存
+	ctx, rec := rewrite.WithRecorder(ctx)
+	ctx, saved := chat.WithMessageRecorder(ctx)
+	ctx = ragLogic.WithoutRetrieverCache(ctx)
+	ctx = agent.WithOutputTracking(ctx)
+	var intent *agent.RAGIntent
+
+	// 多轮对话中的追问结合历史改写为独立的问题，意图识别与检索使用它；回答仍针对原问题，由对话历史补充上下文
+	query, history := c.standaloneQuestion(ctx, req)
+	defer func() {
+		if res != nil {
+			res.Rewrites = rec.Queries()
+			if query != req.Question {
+				res.StandaloneQuestion = query
+			}
+			c.verifyAnswer(ctx, req, res)
+			res.Citations = parseCitations(ctx, res.Answer, res.References)
+			saveMessageMeta(ctx, saved, res, intent)
+		}
+	}()
+
+	useAgentic := req.EnableAgentic || req.KnowledgeName != "" || len(req.KnowledgeBases) > 0
 
 	// 🔍 重要：调试日志，便于排查
 	g.Log().Infof(ctx, "📊 Agentic mode: %v (EnableAgentic=%v, KnowledgeName=%q)",
@@ -28,322 +88,198 @@ func (c *ControllerV1) Chat(ctx context.Context, req *v1.ChatReq) (res *v1.ChatR
 
 	if !useAgentic {
 		g.Log().Info(ctx, "Using legacy RAG mode (no KnowledgeName)")
-		return c.legacyRAG(ctx, req)
+		return c.legacyRAG(ctx, req, query)
 	}
 
 	// ===== Agentic RAG 模式 =====
 
 	// Step 1: 意图识别
+	if req.AutoScope {
+		ctx = ragLogic.WithKnowledgeScope(ctx)
+	}
 	classifier := c.getClassifier(req)
-	intent, err := classifier.Classify(ctx, req.Question)
+	if len(history) > 0 {
+		intent, err = classifier.ClassifyWithContext(ctx, query, historyLines(history))
+	} else {
+		intent, err = classifier.Classify(ctx, query)
+	}
 	if err != nil {
 		g.Log().Warningf(ctx, "Intent classification failed: %v, fallback to legacy", err)
-		return c.legacyRAG(ctx, req)
+		return c.legacyRAG(ctx, req, query)
+	}
+	// 按问题中提到的知识库缩小或扩大检索范围
+	if req.AutoScope {
+		req.KnowledgeName, req.KnowledgeBases = ragLogic.ScopeKnowledgeBases(req.KnowledgeName, req.KnowledgeBases, intent.ScopeConstraint)
 	}
 
 	g.Log().Infof(ctx, "🎯 Intent: type=%s, confidence=%.2f, strategy=%s",
 		intent.Type, intent.Confidence, intent.Strategy)
 
-	// Step 2: 置信度极低 → 无法判断意图，走快速通道兜底
-	// 注意：不应将 ComplexitySimple 作为 fast-path 的条件，
-	// 简单问题会通过 intent.Strategy == "simple_rag" 在 Step 3 中正确路由。
-	if intent.Confidence < 0.3 {
-		g.Log().Infof(ctx, "Very low confidence (%.2f), using fast-path (simple RAG)", intent.Confidence)
-		answer, references, err := c.executeSimpleRAG(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-		return c.buildChatResponse(answer, references, intent, time.Since(startTime), req), nil
+	// Step 2: 按意图路由到已注册的策略（置信度过低时为默认策略），失败时按回退链继续尝试
+	env, err := c.strategyEnv(ctx, req)
+	if err != nil {
+		g.Log().Errorf(ctx, "Build strategy env failed: %v, fallback to legacy", err)
+		return c.legacyRAG(ctx, req, query)
 	}
-
-	// Step 3: 根据策略执行
-	var answer string
-	var references []*schema.Document
-	var reasoningSteps []agent.ReasoningStep
-
-	switch intent.Strategy {
-	case "simple_rag":
-		answer, references, err = c.executeSimpleRAG(ctx, req)
-
-	case "react_agent":
-		answer, references, reasoningSteps, err = c.executeReActAgent(ctx, req, intent)
-
-	case "hybrid":
-		answer, references, err = c.executeHybridSearch(ctx, req, intent)
-
-	default:
-		answer, references, err = c.executeSimpleRAG(ctx, req)
+	router := agent.NewRouter(agent.DefaultRouterConfig(ctx), env)
+	name := router.Resolve(intent)
+	// 纠错检索替换 ReAct 之外策略的检索过程，ReAct 由模型自行判断是否需要再次检索
+	if req.Corrective && name != agent.StrategyReAct {
+		name = agent.StrategyCorrective
+	}
+	strategy, err := router.Strategy(name)
+	if err != nil {
+		g.Log().Errorf(ctx, "Route strategy failed: %v, fallback to legacy", err)
+		return c.legacyRAG(ctx, req, query)
 	}
 
+	// Step 3: 执行策略
+	result, err := strategy.Execute(ctx, intent, query)
 	if err != nil {
+		// 已推送部分答案或写入会话历史时不能再用传统模式重新回答，直接返回错误（流式对话推送 error 后结束）
+		if agent.OutputStarted(ctx) {
+			g.Log().Errorf(ctx, "Strategy execution failed after output started: %v", err)
+			return nil, err
+		}
 		g.Log().Errorf(ctx, "Strategy execution failed: %v, fallback to legacy", err)
-		return c.legacyRAG(ctx, req)
+		return c.legacyRAG(ctx, req, query)
 	}
 
 	// Step 4: 构造响应
 	executionTime := time.Since(startTime)
-	res = c.buildChatResponse(answer, references, intent, executionTime, req)
+	res = c.buildChatResponse(result.Answer, result.References, intent, executionTime, req)
+	res.Strategy = result.Strategy
 
-	// 可选：返回推理步骤
-	if req.ReturnSteps && len(reasoningSteps) > 0 {
-		res.ReasoningSteps = reasoningSteps
+	// 可选：返回推理步骤，纠错检索的打分结果总是返回
+	if (req.ReturnSteps || result.Strategy == agent.StrategyCorrective) && len(result.ReasoningSteps) > 0 {
+		res.ReasoningSteps = result.ReasoningSteps
 	}
 
-	g.Log().Infof(ctx, "✅ Completed in %dms using %s", executionTime.Milliseconds(), intent.Strategy)
+	g.Log().Infof(ctx, "✅ Completed in %dms using %s", executionTime.Milliseconds(), result.Strategy)
 
 	return res, nil
 }
 
-// ===== 策略执行方法 =====
-
-// executeSimpleRAG 执行简单 RAG 策略
-func (c *ControllerV1) executeSimpleRAG(ctx context.Context, req *v1.ChatReq) (string, []*schema.Document, error) {
-	// Step 1: 检索
-	retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-		Question:      req.Question,
-		TopK:          req.TopK,
-		Score:         req.Score,
-		KnowledgeName: req.KnowledgeName,
-	})
-	if err != nil {
-		return "", nil, err
-	}
+// ===== 策略依赖 =====
 
-	// Step 2: 生成
-	chatI := chat.GetChat()
-	answer, err := chatI.GetAnswer(ctx, req.ConvID, retriever.Document, req.Question)
+// strategyEnv 按请求构造策略依赖：检索使用请求的知识库与检索参数，回答针对原问题生成并写入会话历史
+func (c *ControllerV1) strategyEnv(ctx context.Context, req *v1.ChatReq) (*agent.StrategyEnv, error) {
+	ragSvr := ragLogic.GetRagSvr()
+	filter, err := ragLogic.BuildFilter(req.Filters, nil)
 	if err != nil {
-		return "", nil, err
-	}
-
-	return answer, retriever.Document, nil
-}
-
-// executeReActAgent 执行 ReAct Agent 策略
-func (c *ControllerV1) executeReActAgent(ctx context.Context, req *v1.ChatReq, intent *agent.RAGIntent) (string, []*schema.Document, []agent.ReasoningStep, error) {
-	g.Log().Infof(ctx, "🤖 Executing ReAct agent (intent=%s, estimated_steps=%d)", intent.Type, intent.EstimatedSteps)
-
-	// 获取 LLM 实例
-	chatModel := agent.GetChatModel()
-	if chatModel == nil {
-		g.Log().Warning(ctx, "ChatModel not available for ReAct, fallback to simple RAG")
-		answer, references, err := c.executeSimpleRAG(ctx, req)
-		if err != nil {
-			return "", nil, nil, err
-		}
-		return answer, references, nil, nil
+		return nil, err
 	}
 
-	// 获取 RAG 服务
-	ragSvr := ragLogic.GetRagSvr()
-
-	// 构建工具注册表
+	// ReAct 与多跳推理使用的工具
 	registry := agent.NewToolRegistry()
-	ragTool := tools.NewRagTool(ragSvr, req.KnowledgeName, req.TopK, req.Score)
-	registry.Register(ragTool)
+	registry.Register(tools.NewRagTool(ragSvr, req.KnowledgeName, req.TopK, req.Score).
+		WithRetrievalMode(req.RetrievalMode, req.Fusion).
+		WithFilter(filter).
+		WithKnowledgeBases(ragLogic.KnowledgeBases(req.KnowledgeName, req.KnowledgeBases)).
+		WithRewrite(req.RewriteStrategy))
 
-	// 构建 ReAct 执行器
 	maxIter := req.MaxIterations
 	if maxIter <= 0 {
 		maxIter = 5
 	}
-	executor := agent.NewReactExecutor(&agent.ReactConfig{
-		MaxIterations:  maxIter,
-		Model:          chatModel,
-		Registry:       registry,
-		EnableMultiHop: agent.IsMultiHopIntent(intent),
-	})
-
-	// 执行 ReAct 循环
-	result, err := executor.Run(ctx, intent, req.Question, req.KnowledgeName, req.TopK, req.Score)
-	if err != nil {
-		g.Log().Errorf(ctx, "ReAct execution failed: %v, fallback to simple RAG", err)
-		answer, references, err2 := c.executeSimpleRAG(ctx, req)
-		if err2 != nil {
-			return "", nil, nil, err2
+	retrieve := func(ctx context.Context, query string, topK int) ([]*schema.Document, error) {
+		if topK <= 0 {
+			topK = req.TopK
 		}
-		return answer, references, nil, nil
-	}
-
-	g.Log().Infof(ctx, "✅ ReAct completed: %d steps, %d references", len(result.ReasoningSteps), len(result.References))
-	return result.Answer, result.References, result.ReasoningSteps, nil
-}
-
-// executeHybridSearch 执行混合检索策略（RAG + Web Search 并行）
-func (c *ControllerV1) executeHybridSearch(ctx context.Context, req *v1.ChatReq, intent *agent.RAGIntent) (string, []*schema.Document, error) {
-	g.Log().Infof(ctx, "🔍 Executing hybrid search (intent=%s)", intent.Type)
-
-	// 从配置读取 Web Search 参数
-	webEnabledVar, _ := g.Cfg().Get(ctx, "agent.web_search.enabled", false)
-	apiKeyVar, _ := g.Cfg().Get(ctx, "agent.web_search.api_key", "")
-	endpointVar, _ := g.Cfg().Get(ctx, "agent.web_search.endpoint", "")
-	webConfigEnabled := webEnabledVar.Bool()
-	apiKey := apiKeyVar.String()
-	endpoint := endpointVar.String()
-
-	doWebSearch := c.isWebSearchEnabled(ctx, req, intent) && webConfigEnabled
-
-	// 1. 并行执行 RAG 检索 和 Web Search
-	var (
-		ragDocs []*schema.Document
-		webDocs []*schema.Document
-		ragErr  error
-		webErr  error
-		wg      sync.WaitGroup
-	)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
 		retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-			Question:      req.Question,
-			TopK:          req.TopK,
-			Score:         req.Score,
-			KnowledgeName: req.KnowledgeName,
+			Question:        query,
+			TopK:            topK,
+			Score:           req.Score,
+			KnowledgeName:   req.KnowledgeName,
+			KnowledgeBases:  req.KnowledgeBases,
+			RetrievalMode:   req.RetrievalMode,
+			Fusion:          req.Fusion,
+			Filters:         req.Filters,
+			RewriteStrategy: req.RewriteStrategy,
 		})
 		if err != nil {
-			ragErr = err
-			return
+			return nil, err
 		}
-		ragDocs = retriever.Document
-	}()
-
-	if doWebSearch {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			topK := req.TopK
-			if topK <= 0 {
-				topK = 5
-			}
-			webTool := tools.NewWebSearchTool(true, apiKey, endpoint, topK)
-			input := map[string]interface{}{
-				"query":       req.Question,
-				"max_results": topK,
-			}
-			result, err := webTool.Execute(ctx, input)
-			if err != nil {
-				webErr = err
-				return
-			}
-			if searchResult, ok := result.(*tools.WebSearchResult); ok {
-				webDocs = searchResult.ToDocuments()
-			}
-		}()
-	}
-
-	wg.Wait()
-
-	// 2. 错误处理
-	if ragErr != nil && webErr != nil {
-		return "", nil, fmt.Errorf("hybrid search: both RAG and web search failed: rag=%w, web=%v", ragErr, webErr)
-	}
-	if ragErr != nil {
-		g.Log().Warningf(ctx, "⚠️ RAG retrieval failed, using only web results: %v", ragErr)
-	}
-	if webErr != nil {
-		g.Log().Warningf(ctx, "⚠️ Web search failed, using only RAG results: %v", webErr)
-	}
-
-	g.Log().Infof(ctx, "📚 Hybrid results: RAG=%d, Web=%d", len(ragDocs), len(webDocs))
-
-	// 3. 合并去重排序截断
-	mergedDocs := c.deduplicateAndMergeDocs(ragDocs, webDocs, intent, req.TopK)
-
-	// 4. 空结果降级到 simple RAG
-	if len(mergedDocs) == 0 {
-		g.Log().Info(ctx, "No hybrid results, fallback to simple RAG")
-		return c.executeSimpleRAG(ctx, req)
+		return retriever.Document, nil
 	}
-
-	g.Log().Infof(ctx, "🔀 Merged docs: %d (intent=%s)", len(mergedDocs), intent.Type)
-
-	// 5. 调用 LLM 生成答案
 	chatI := chat.GetChat()
-	answer, err := chatI.GetAnswer(ctx, req.ConvID, mergedDocs, req.Question)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return answer, mergedDocs, nil
-}
-
-// deduplicateAndMergeDocs 合并去重并排序文档列表
-// 对于 RAGIntentRealtimeQuery，web 结果优先；其他情况 RAG 结果优先
-func (c *ControllerV1) deduplicateAndMergeDocs(ragDocs, webDocs []*schema.Document, intent *agent.RAGIntent, topK int) []*schema.Document {
-	var primary, secondary []*schema.Document
-	if intent.Type == agent.RAGIntentRealtimeQuery {
-		// 实时查询：web 结果排在前面
-		primary = webDocs
-		secondary = ragDocs
-	} else {
-		// 其他情况：RAG 结果优先
-		primary = ragDocs
-		secondary = webDocs
-	}
-
-	seen := make(map[string]bool)
-	result := make([]*schema.Document, 0, len(primary)+len(secondary))
-
-	for _, doc := range append(primary, secondary...) {
-		if doc == nil {
-			continue
-		}
-		// 以内容前 100 字符作为去重 key
-		key := doc.Content
-		if len(key) > 100 {
-			key = key[:100]
-		}
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, doc)
-		}
-	}
-
-	// 截断：合并后最多保留 topK*2 条（不超过 20 条）
-	maxDocs := topK * 2
-	if maxDocs > 20 {
-		maxDocs = 20
-	}
-	if maxDocs <= 0 {
-		maxDocs = 10
-	}
-	if len(result) > maxDocs {
-		result = result[:maxDocs]
-	}
-
-	return result
+	return &agent.StrategyEnv{
+		Model:    agent.GetChatModel(),
+		Retrieve: retrieve,
+		Answer: func(ctx context.Context, docs []*schema.Document) (string, error) {
+			return chatI.GetAnswer(ctx, req.ConvID, docs, req.Question)
+		},
+		// ReAct 等策略的回答不经过 GetAnswer，单独写入会话历史
+		SaveAnswer: func(ctx context.Context, answer string) error {
+			return chatI.SaveExchange(ctx, req.ConvID, req.Question, answer)
+		},
+		WebSearch: c.webSearch(ctx, req),
+		// 总结整篇文档，问题中没有提到文件名时按检索结果确定文档
+		Summarize: func(ctx context.Context, query string) ([]*schema.Document, error) {
+			return ragLogic.SummarizeDocuments(ctx, query, ragLogic.KnowledgeBaseNames(req.KnowledgeName, req.KnowledgeBases),
+				func(ctx context.Context, query string) ([]*schema.Document, error) {
+					return retrieve(ctx, query, 0)
+				})
+		},
+		Tools:         registry,
+		Grader:        ragSvr.Grader(),
+		KnowledgeName: req.KnowledgeName,
+		TopK:          req.TopK,
+		Score:         req.Score,
+		MaxIterations: maxIter,
+		MaxRounds:     g.Cfg().MustGet(ctx, "agent.corrective.max_rounds", 2).Int(),
+	}, nil
 }
 
-// isWebSearchEnabled 判断当前请求是否应启用 Web Search
-func (c *ControllerV1) isWebSearchEnabled(_ context.Context, req *v1.ChatReq, intent *agent.RAGIntent) bool {
-	// 检查意图是否需要外部数据
-	intentNeedsWeb := intent.RequiresExternal ||
-		intent.Type == agent.RAGIntentHybridSearch ||
-		intent.Type == agent.RAGIntentRealtimeQuery
-	if !intentNeedsWeb {
-		return false
-	}
-
-	// EnabledTools 为空表示允许所有工具；否则需明确包含 "web_search"
-	if len(req.EnabledTools) == 0 {
-		return true
+// webSearch 返回混合检索与纠错检索使用的网络搜索，配置未启用或请求未允许 web_search 工具时返回 nil
+func (c *ControllerV1) webSearch(ctx context.Context, req *v1.ChatReq) func(ctx context.Context, query string) ([]*schema.Document, error) {
+	if !g.Cfg().MustGet(ctx, "agent.web_search.enabled", false).Bool() {
+		return nil
 	}
+	allowed := len(req.EnabledTools) == 0
 	for _, tool := range req.EnabledTools {
 		if tool == "web_search" {
-			return true
+			allowed = true
 		}
 	}
-	return false
+	if !allowed {
+		return nil
+	}
+	topK := req.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	webTool := tools.NewWebSearchTool(true,
+		g.Cfg().MustGet(ctx, "agent.web_search.api_key", "").String(),
+		g.Cfg().MustGet(ctx, "agent.web_search.endpoint", "").String(),
+		topK)
+	return func(ctx context.Context, query string) ([]*schema.Document, error) {
+		result, err := webTool.Execute(ctx, map[string]interface{}{
+			"query":       query,
+			"max_results": topK,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if searchResult, ok := result.(*tools.WebSearchResult); ok {
+			return searchResult.ToDocuments(), nil
+		}
+		return nil, nil
+	}
 }
 
-// legacyRAG 传统 RAG 实现（完全保留原逻辑）
-func (c *ControllerV1) legacyRAG(ctx context.Context, req *v1.ChatReq) (res *v1.ChatRes, err error) {
+// legacyRAG 传统 RAG 实现，query 为检索使用的（独立）问题
+func (c *ControllerV1) legacyRAG(ctx context.Context, req *v1.ChatReq, query string) (res *v1.ChatRes, err error) {
 	retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-		Question:      req.Question,
-		TopK:          req.TopK,
-		Score:         req.Score,
-		KnowledgeName: req.KnowledgeName,
+		Question:        query,
+		TopK:            req.TopK,
+		Score:           req.Score,
+		KnowledgeName:   req.KnowledgeName,
+		KnowledgeBases:  req.KnowledgeBases,
+		RetrievalMode:   req.RetrievalMode,
+		Fusion:          req.Fusion,
+		Filters:         req.Filters,
+		RewriteStrategy: req.RewriteStrategy,
 	})
 	if err != nil {
 		return nil, err
@@ -375,6 +311,117 @@ func (c *ControllerV1) getClassifier(req *v1.ChatReq) agent.IntentClassifier {
 	return agent.GetClassifier()
 }
 
+// standaloneQuestion 读取会话最近几轮问答（rewrite.historyTurns），把追问改写为独立的问题。
+// 没有历史或改写失败时返回原问题，历史仍用于意图识别
+func (c *ControllerV1) standaloneQuestion(ctx context.Context, req *v1.ChatReq) (string, []*schema.Message) {
+	turns := g.Cfg().MustGet(ctx, "rewrite.historyTurns", 3).Int()
+	history, err := chat.GetChat().RecentHistory(ctx, req.ConvID, turns)
+	if err != nil {
+		g.Log().Warningf(ctx, "load history of conversation %s failed, err=%v", req.ConvID, err)
+		return req.Question, nil
+	}
+	if len(history) == 0 {
+		return req.Question, nil
+	}
+	cm, err := common.GetRewriteModel(ctx, nil)
+	if err != nil {
+		g.Log().Warningf(ctx, "get rewrite model failed, err=%v", err)
+		return req.Question, history
+	}
+	query, err := rewrite.New(cm).Standalone(ctx, req.Question, history)
+	if err != nil {
+		g.Log().Warningf(ctx, "rewrite standalone question failed, use the original question, err=%v", err)
+		return req.Question, history
+	}
+	g.Log().Infof(ctx, "🔁 Standalone question: %q -> %q", req.Question, query)
+	return query, history
+}
+
+// historyLines 把对话历史转换为意图识别使用的文本
+func historyLines(history []*schema.Message) []string {
+	lines := make([]string, 0, len(history))
+	for _, m := range history {
+		lines = append(lines, fmt.Sprintf("%s: %s", m.Role, m.Content))
+	}
+	return lines
+}
+
+// verifyAnswer 按知识库的校验策略检查回答中的论断是否被参考资料支持，得分低于阈值时按策略重新生成或给出警告。
+// 流式对话的答案已经发送给客户端，重新生成的答案会与之矛盾，只给出警告。校验失败时保留原回答
+func (c *ControllerV1) verifyAnswer(ctx context.Context, req *v1.ChatReq, res *v1.ChatRes) {
+	policy := ragLogic.VerifyPolicy(ctx, req.KnowledgeName, req.KnowledgeBases, req.Verify)
+	if policy == nil || res.Answer == "" {
+		return
+	}
+	if policy.Action == verifier.ActionRegenerate && agent.IsStreaming(ctx) {
+		policy = &verifier.Policy{Action: verifier.ActionWarn, Threshold: policy.Threshold}
+	}
+	// 校验与修改时没有对话历史，使用独立问题
+	question := req.Question
+	if res.StandaloneQuestion != "" {
+		question = res.StandaloneQuestion
+	}
+	answer, report, err := ragLogic.GetRagSvr().Verifier().Check(ctx, policy, question, res.Answer, res.References,
+		func(ctx context.Context, feedback string) (string, error) {
+			return chat.GetChat().Revise(ctx, req.ConvID, res.References, question, res.Answer, feedback)
+		})
+	if err != nil {
+		g.Log().Warningf(ctx, "verify answer failed, err=%v", err)
+		return
+	}
+	if report.Warning != "" {
+		g.Log().Warningf(ctx, "⚠️ Low groundedness %.2f (threshold %.2f): %v", report.Score, report.Threshold, report.Unsupported)
+	}
+	res.Answer = answer
+	res.Groundedness = report
+}
+
+// saveMessageMeta 把策略、意图、参考文档与校验结果记录到本次请求写入会话历史的回答上，供事后审计。
+// 回答经校验重新生成时，之前写入的回答标记为草稿
+func saveMessageMeta(ctx context.Context, saved *chat.MessageRecorder, res *v1.ChatRes, intent *agent.RAGIntent) {
+	msgIDs := saved.MessageIDs()
+	if len(msgIDs) == 0 {
+		return
+	}
+	meta := conversation.NewMessageMeta(res, intent)
+	for i, msgID := range msgIDs {
+		m := *meta
+		m.Draft = i < len(msgIDs)-1
+		if err := conversation.SetMessageMeta(ctx, msgID, &m); err != nil {
+			g.Log().Warningf(ctx, "save meta of message %s failed, err=%v", msgID, err)
+		}
+	}
+}
+
+// parseCitations 解析答案中的引用标记，元数据中没有文档 ID 的历史 chunk 通过 chunk_id 查询补全
+func parseCitations(ctx context.Context, answer string, references []*schema.Document) []*citation.Citation {
+	citations := citation.Parse(answer, references)
+	var chunkIds []string
+	for _, c := range citations {
+		if c.KnowledgeDocID == 0 && c.ChunkID != "" {
+			chunkIds = append(chunkIds, c.ChunkID)
+		}
+	}
+	if len(chunkIds) == 0 {
+		return citations
+	}
+	chunks, err := knowledge.GetChunksByChunkIds(ctx, chunkIds, "chunk_id", "knowledge_doc_id")
+	if err != nil {
+		g.Log().Warningf(ctx, "get chunks for citations failed, err=%v", err)
+		return citations
+	}
+	docIds := make(map[string]int64, len(chunks))
+	for _, chunk := range chunks {
+		docIds[chunk.ChunkId] = chunk.KnowledgeDocId
+	}
+	for _, c := range citations {
+		if c.KnowledgeDocID == 0 {
+			c.KnowledgeDocID = docIds[c.ChunkID]
+		}
+	}
+	return citations
+}
+
 // buildChatResponse 构造响应
 func (c *ControllerV1) buildChatResponse(
 	answer string,
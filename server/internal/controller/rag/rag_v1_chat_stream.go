@@ -2,115 +2,101 @@ package rag
 
 import (
 	"context"
-	"io"
+	"time"
 
+	"github.com/cloudwego/eino/schema"
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
 	"github.com/everfid-ever/ThinkForge/core/agent"
-	"github.com/everfid-ever/ThinkForge/internal/logic/chat"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/sse"
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gctx"
 )
 
-// ChatStream 流式对话接口（支持 Agentic 模式）
+// ChatStream 流式对话接口，与 Chat 使用同一套意图识别与策略路由（不使用语义缓存）。
+// 执行过程中实时推送推理步骤、参考文档与答案，结束后推送引用、用量与完整的响应，事件格式见 v1.ChatStreamRes。
+// 客户端断开后继续执行到结束，期间携带 Last-Event-ID 重连可从断点续传
 func (c *ControllerV1) ChatStream(ctx context.Context, req *v1.ChatStreamReq) (res *v1.ChatStreamRes, err error) {
+	if common.ResumeSSE(ctx) {
+		return nil, nil
+	}
 	g.Log().Infof(ctx, "🚀 Stream RAG: %s", req.Question)
 
-	useAgentic := req.EnableAgentic || req.KnowledgeName != ""
-
-	// 添加调试日志
-	g.Log().Infof(ctx, "📊 Agentic mode: %v (KnowledgeName: %s, EnableAgentic field: %v)",
-		useAgentic, req.KnowledgeName, req.EnableAgentic)
+	w := common.NewSSEWriter(ctx, true)
+	defer w.Close()
+	_ = w.Meta("chat", map[string]any{"conv_id": req.ConvID})
 
-	if !useAgentic {
-		return c.legacyStreamRAG(ctx, req)
+	var sentRefs []*schema.Document
+	sendReferences := func(docs []*schema.Document) {
+		if sameDocs(docs, sentRefs) {
+			return
+		}
+		sentRefs = docs
+		_ = w.Send(sse.EventReferences, &sse.References{Documents: docs})
 	}
+	// 客户端断开时不中断执行，以便重连后续传
+	streamCtx, usage := common.WithUsageCounter(context.WithoutCancel(ctx))
+	streamCtx = agent.WithStreamHandler(streamCtx, &agent.StreamHandler{
+		OnStep: func(step agent.ReasoningStep) {
+			_ = w.Send(sse.EventReasoning, step)
+		},
+		OnReferences: sendReferences,
+		OnToken: func(token string) {
+			_ = w.Send(sse.EventToken, &sse.Token{Content: token})
+		},
+	})
 
-	// ===== Agentic 流式 RAG =====
-
-	// Step 1: 意图识别（快速，不阻塞流式）
-	classifier := agent.NewHybridIntentClassifierRuleOnly() // 仅用规则，保证快速
-	intent, err := classifier.Classify(ctx, req.Question)
+	out, err := c.chat(streamCtx, chatReqFromStream(req), time.Now())
 	if err != nil {
-		g.Log().Warningf(ctx, "Intent classification failed: %v, fallback to legacy", err)
-		return c.legacyStreamRAG(ctx, req)
+		g.Log().Errorf(ctx, "stream chat failed, err=%v", err)
+		_ = w.Error(err)
+		_ = w.Send(sse.EventUsage, usage.Usage())
+		_ = w.Done(nil)
+		return nil, nil
 	}
-
-	g.Log().Debugf(ctx, "Intent: type=%s, strategy=%s", intent.Type, intent.Strategy)
-
-	// Step 2: 简单问题 → 直接流式返回
-	if intent.Complexity == agent.ComplexitySimple {
-		return c.legacyStreamRAG(ctx, req)
+	// 最终的参考文档与引用编号一致，与已推送的不同时（如答案来自改写后的检索）再推送一次
+	sendReferences(out.References)
+	for _, cite := range out.Citations {
+		_ = w.Send(sse.EventCitation, cite)
 	}
-
-	// Step 3: 复杂问题 → 流式返回推理步骤 + 答案
-	// TODO: 实现流式 ReAct Agent
-	// 当前降级到传统流式
-	return c.legacyStreamRAG(ctx, req)
+	_ = w.Send(sse.EventUsage, usage.Usage())
+	_ = w.Done(out)
+	return nil, nil
 }
 
-// legacyStreamRAG 传统流式 RAG（保留原逻辑）
-func (c *ControllerV1) legacyStreamRAG(ctx context.Context, req *v1.ChatStreamReq) (res *v1.ChatStreamRes, err error) {
-	// Step 1: 检索
-	retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-		Question:      req.Question,
-		TopK:          req.TopK,
-		Score:         req.Score,
-		KnowledgeName: req.KnowledgeName,
-	})
-	if err != nil {
-		g.Log().Error(ctx, "Retriever failed:", err)
-		return nil, err
+// sameDocs 判断两组文档是否相同（同一批对象且顺序一致）
+func sameDocs(a, b []*schema.Document) bool {
+	if len(a) != len(b) {
+		return false
 	}
-
-	// Step 2: 获取 Chat 实例
-	chatI := chat.GetChat()
-
-	// Step 3: 流式生成（✅ 使用正确的方法名 GetAnswerStream）
-	streamReader, err := chatI.GetAnswerStream(ctx, req.ConvID, retriever.Document, req.Question)
-	if err != nil {
-		g.Log().Error(ctx, "Stream generation failed:", err)
-		return nil, err
-	}
-
-	// Step 4: 转换为 HTTP SSE 流
-	ctx = gctx.New() // 重置上下文，避免请求取消导致流中断
-	stream := make(chan string, 10)
-
-	// 后台协程：从 streamReader 读取并转发到 HTTP 流
-	go func() {
-		defer close(stream)
-		defer streamReader.Close()
-
-		for {
-			select {
-			case <-ctx.Done():
-				g.Log().Warning(ctx, "Stream context done:", ctx.Err())
-				return
-			default:
-				// 接收流片段
-				msg, err := streamReader.Recv()
-				if err != nil {
-					if err == io.EOF {
-						// 流结束
-						g.Log().Debug(ctx, "Stream completed")
-						return
-					}
-					g.Log().Error(ctx, "Stream recv error:", err)
-					return
-				}
-
-				// 发送到 HTTP 流
-				if msg != nil && msg.Content != "" {
-					stream <- msg.Content
-				}
-			}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
-	}()
-
-	res = &v1.ChatStreamRes{
-		Stream:     stream,
-		References: retriever.Document,
 	}
+	return true
+}
 
-	return res, nil
+// chatReqFromStream 把流式请求转换为 ChatReq，以便复用 Chat 的策略路由
+func chatReqFromStream(req *v1.ChatStreamReq) *v1.ChatReq {
+	return &v1.ChatReq{
+		ConvID:          req.ConvID,
+		Question:        req.Question,
+		KnowledgeName:   req.KnowledgeName,
+		KnowledgeBases:  req.KnowledgeBases,
+		AutoScope:       req.AutoScope,
+		TopK:            req.TopK,
+		Score:           req.Score,
+		RetrievalMode:   req.RetrievalMode,
+		Fusion:          req.Fusion,
+		RewriteStrategy: req.RewriteStrategy,
+		Filters:         req.Filters,
+		EnableAgentic:   req.EnableAgentic,
+		UseRuleOnly:     req.UseRuleOnly,
+		MaxIterations:   req.MaxIterations,
+		Corrective:      req.Corrective,
+		EnabledTools:    req.EnabledTools,
+		Verify:          req.Verify,
+		ReturnIntent:    req.ReturnIntent,
+		ReturnSteps:     req.ReturnSteps,
+	}
 }
@@ -18,7 +18,12 @@ func (c *ControllerV1) ChunkDelete(ctx context.Context, req *v1.ChunkDeleteReq)
 		return
 	}
 
-	err = svr.DeleteDocument(ctx, chunk.ChunkId)
+	document, err := knowledge.GetDocumentById(ctx, chunk.KnowledgeDocId)
+	if err != nil {
+		return
+	}
+
+	err = svr.DeleteDocument(ctx, document.KnowledgeBaseName, chunk.ChunkId)
 	if err != nil {
 		g.Log().Errorf(ctx, "DeleteDocumentAndChunks: ES DeleteByQuery failed for docId %v, err: %v", chunk.ChunkId, err)
 		return
@@ -0,0 +1,13 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+)
+
+func (c *ControllerV1) ConversationDelete(ctx context.Context, req *v1.ConversationDeleteReq) (res *v1.ConversationDeleteRes, err error) {
+	err = conversation.Delete(ctx, req.ConvID)
+	return
+}
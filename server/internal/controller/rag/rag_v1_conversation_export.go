@@ -0,0 +1,29 @@
+package rag
+
+import (
+	"context"
+	"mime"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// ConversationExport 以附件形式下载会话，json 格式包含完整的审计信息，markdown 格式便于阅读
+func (c *ControllerV1) ConversationExport(ctx context.Context, req *v1.ConversationExportReq) (res *v1.ConversationExportRes, err error) {
+	content, contentType, err := conversation.Export(ctx, req.ConvID, req.Format)
+	if err != nil {
+		return
+	}
+	ext := "json"
+	if req.Format == v1.ExportFormatMarkdown {
+		ext = "md"
+	}
+	resp := g.RequestFromCtx(ctx).Response
+	resp.Header().Set("Content-Type", contentType)
+	resp.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "conversation-" + req.ConvID + "." + ext,
+	}))
+	resp.Write(content)
+	return
+}
@@ -0,0 +1,21 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+)
+
+func (c *ControllerV1) ConversationGet(ctx context.Context, req *v1.ConversationGetReq) (res *v1.ConversationGetRes, err error) {
+	conv, err := conversation.Get(ctx, req.ConvID)
+	if err != nil {
+		return
+	}
+	messages, err := conversation.Messages(ctx, req.ConvID, req.Page, req.Size)
+	if err != nil {
+		return
+	}
+	res = &v1.ConversationGetRes{Conversation: conv, Messages: messages}
+	return
+}
@@ -0,0 +1,22 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+)
+
+func (c *ControllerV1) ConversationList(ctx context.Context, req *v1.ConversationListReq) (res *v1.ConversationListRes, err error) {
+	list, total, err := conversation.List(ctx, req.Keyword, req.Page, req.Size)
+	if err != nil {
+		return
+	}
+	res = &v1.ConversationListRes{
+		Data:  list,
+		Total: total,
+		Page:  req.Page,
+		Size:  req.Size,
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+)
+
+func (c *ControllerV1) ConversationRename(ctx context.Context, req *v1.ConversationRenameReq) (res *v1.ConversationRenameRes, err error) {
+	err = conversation.Rename(ctx, req.ConvID, req.Title)
+	return
+}
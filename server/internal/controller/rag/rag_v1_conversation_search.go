@@ -0,0 +1,22 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+)
+
+func (c *ControllerV1) ConversationSearch(ctx context.Context, req *v1.ConversationSearchReq) (res *v1.ConversationSearchRes, err error) {
+	hits, total, err := conversation.Search(ctx, req.Keyword, req.Role, req.Page, req.Size)
+	if err != nil {
+		return
+	}
+	res = &v1.ConversationSearchRes{
+		Data:  hits,
+		Total: total,
+		Page:  req.Page,
+		Size:  req.Size,
+	}
+	return
+}
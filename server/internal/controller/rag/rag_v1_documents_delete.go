@@ -12,6 +12,11 @@ import (
 func (c *ControllerV1) DocumentsDelete(ctx context.Context, req *v1.DocumentsDeleteReq) (res *v1.DocumentsDeleteRes, err error) {
 	svr := rag.GetRagSvr()
 
+	document, err := knowledge.GetDocumentById(ctx, req.DocumentId)
+	if err != nil {
+		return
+	}
+
 	ChunksList, err := knowledge.GetAllChunksByDocId(ctx, req.DocumentId, "id", "chunk_id")
 	if err != nil {
 		g.Log().Errorf(ctx, "DeleteDocumentAndChunks: GetAllChunksByDocId failed for id %d, err: %v", req.DocumentId, err)
@@ -21,7 +26,7 @@ func (c *ControllerV1) DocumentsDelete(ctx context.Context, req *v1.DocumentsDel
 	if len(ChunksList) > 0 {
 		for _, chunk := range ChunksList {
 			if chunk.ChunkId != "" {
-				err = svr.DeleteDocument(ctx, chunk.ChunkId)
+				err = svr.DeleteDocument(ctx, document.KnowledgeBaseName, chunk.ChunkId)
 				if err != nil {
 					g.Log().Errorf(ctx, "DeleteDocumentAndChunks: ES DeleteByQuery failed for docId %v, err: %v", chunk.ChunkId, err)
 					return
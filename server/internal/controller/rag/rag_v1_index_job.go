@@ -0,0 +1,75 @@
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bytedance/sonic"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// jobStreamInterval SSE 推送任务进度的轮询间隔
+const jobStreamInterval = time.Second
+
+// IndexJob 查询索引任务的状态与各阶段进度
+func (c *ControllerV1) IndexJob(ctx context.Context, req *v1.IndexJobReq) (res *v1.IndexJobRes, err error) {
+	job, err := getIndexJob(ctx, req.Id)
+	if err != nil {
+		return
+	}
+	res = &v1.IndexJobRes{IndexJob: job}
+	return
+}
+
+// IndexJobStream 以 SSE 的形式推送索引任务进度，任务结束（成功或最终失败）后在 done 事件中返回最终状态
+func (c *ControllerV1) IndexJobStream(ctx context.Context, req *v1.IndexJobStreamReq) (res *v1.IndexJobStreamRes, err error) {
+	err = common.PollResponse(ctx, "index_job", jobStreamInterval, func(ctx context.Context) (any, bool, error) {
+		job, e := getIndexJob(ctx, req.Id)
+		if e != nil {
+			return nil, true, e
+		}
+		return job, job.Finished(), nil
+	})
+	return
+}
+
+func getIndexJob(ctx context.Context, id int64) (*v1.IndexJob, error) {
+	job, err := knowledge.GetIndexJobById(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if job.Id == 0 {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "index job %d not found", id)
+	}
+	res := &v1.IndexJob{
+		Id:            job.Id,
+		DocumentId:    job.KnowledgeDocId,
+		KnowledgeName: job.KnowledgeBaseName,
+		Stage:         job.Stage,
+		Status:        v1.JobStatus(job.Status),
+		Attempts:      job.Attempts,
+		MaxAttempts:   job.MaxAttempts,
+		LastError:     job.LastError,
+		NextRunAt:     job.NextRunAt,
+		CreatedAt:     job.CreatedAt,
+		UpdatedAt:     job.UpdatedAt,
+	}
+	if len(job.Progress) > 0 {
+		if e := sonic.UnmarshalString(job.Progress, &res.Progress); e != nil {
+			g.Log().Warningf(ctx, "unmarshal progress of job %d failed, err=%v", job.Id, e)
+		}
+	}
+	if len(job.Result) > 0 && job.Stage == v1.JobStageReindex {
+		if e := sonic.UnmarshalString(job.Result, &res.Reindex); e != nil {
+			g.Log().Warningf(ctx, "unmarshal result of job %d failed, err=%v", job.Id, e)
+		}
+	}
+	return res, nil
+}
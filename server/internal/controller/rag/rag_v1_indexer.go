@@ -21,7 +21,8 @@ import (
 // @Param file formData file false "本地上传文件（可选）"
 // @Param url formData string false "网络文件地址（可选）"
 // @Param knowledge_name formData string true "知识库名称"
-// @Success 200 {object} v1.IndexerRes "索引成功后返回文档ID列表"
+// @Param async formData bool false "是否只登记任务后立即返回"
+// @Success 200 {object} v1.IndexerRes "返回 chunk ID 列表、文档ID与索引任务ID"
 // @Failure 400 {object} ghttp.DefaultHandlerResponse "参数错误或上传失败"
 // @Router /v1/indexer [post]
 func (c *ControllerV1) Indexer(ctx context.Context, req *v1.IndexerReq) (res *v1.IndexerRes, err error) {
@@ -36,9 +37,13 @@ func (c *ControllerV1) Indexer(ctx context.Context, req *v1.IndexerReq) (res *v1
 		uri = "./uploads/" + filename
 	}
 
+	fileName := req.URL
+	if req.File != nil {
+		fileName = req.File.Filename
+	}
 	documents := entity.KnowledgeDocuments{
 		KnowledgeBaseName: req.KnowledgeName,
-		FileName:          req.File.Filename,
+		FileName:          fileName,
 		Status:            int(v1.StatusPending),
 	}
 	documentsId, err := knowledge.SaveDocumentsInfo(ctx, documents)
@@ -52,12 +57,23 @@ func (c *ControllerV1) Indexer(ctx context.Context, req *v1.IndexerReq) (res *v1
 		KnowledgeName: req.KnowledgeName,
 		DocumentsId:   documentsId,
 	}
-	ids, err := svr.Index(ctx, indexReq)
+	res = &v1.IndexerRes{
+		DocumentId: documentsId,
+	}
+	// 异步模式只登记任务，进度通过 /v1/indexer/jobs/{id} 查询
+	if req.Async {
+		res.JobId, err = svr.EnqueueIndex(ctx, indexReq)
+		return
+	}
+	res.DocIDs, err = svr.Index(ctx, indexReq)
 	if err != nil {
 		return
 	}
-	res = &v1.IndexerRes{
-		DocIDs: ids,
+	job, e := knowledge.GetIndexJobByDocId(ctx, documentsId)
+	if e != nil {
+		g.Log().Errorf(ctx, "GetIndexJobByDocId failed, err=%v", e)
+		return
 	}
+	res.JobId = job.Id
 	return
 }
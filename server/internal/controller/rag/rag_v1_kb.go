@@ -2,27 +2,56 @@ package rag
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/bytedance/sonic"
 
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core"
 	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/logic/rag"
 	"github.com/everfid-ever/ThinkForge/internal/model/do"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 // KBCreate 处理“创建知识库”的接口请求。
 // 功能：接收客户端传来的知识库信息（名称、描述、分类等），插入数据库并返回新ID。
+// 新知识库使用独立的索引，按请求中的向量配置（未指定时使用全局配置）创建。
 func (c *ControllerV1) KBCreate(ctx context.Context, req *v1.KBCreateReq) (res *v1.KBCreateRes, err error) {
 	// 向 knowledge_base 表中插入一条记录
-	insertId, err := dao.KnowledgeBase.Ctx(ctx).Data(do.KnowledgeBase{
+	data := do.KnowledgeBase{
 		Name:        req.Name,
 		Status:      v1.StatusOK, // 默认状态为 OK
 		Description: req.Description,
 		Category:    req.Category,
-	}).InsertAndGetId() // 插入并返回自增主键ID
+	}
+	if err = setChunkProfile(&data, req.ChunkProfile); err != nil {
+		return nil, err
+	}
+	setVerifyPolicy(&data, req.VerifyPolicy)
+	insertId, err := dao.KnowledgeBase.Ctx(ctx).Data(data).InsertAndGetId() // 插入并返回自增主键ID
 
 	if err != nil {
 		return nil, err // 插入失败，返回错误
 	}
 
+	// 创建知识库的独立索引并记录向量配置
+	err = rag.GetRagSvr().PrepareKnowledgeBase(ctx, entity.KnowledgeBase{
+		Id:             insertId,
+		Name:           req.Name,
+		EmbeddingModel: req.EmbeddingModel,
+		EmbeddingDims:  req.EmbeddingDims,
+		Similarity:     req.Similarity,
+	})
+	if err != nil {
+		// 索引创建失败时删除刚插入的记录，避免留下没有索引的知识库，也便于用户修正参数后重试
+		if _, e := dao.KnowledgeBase.Ctx(ctx).WherePri(insertId).Delete(); e != nil {
+			g.Log().Errorf(ctx, "delete knowledge base %d after prepare failed, err=%v", insertId, e)
+		}
+		return nil, err
+	}
+
 	// 返回响应结果（包含新创建的 ID）
 	res = &v1.KBCreateRes{
 		Id: insertId,
@@ -61,14 +90,83 @@ func (c *ControllerV1) KBGetOne(ctx context.Context, req *v1.KBGetOneReq) (res *
 }
 
 // KBUpdate 更新知识库信息。
-// 功能：根据 ID 修改知识库的名称、状态、描述、分类等字段。
+// 功能：根据 ID 修改知识库的名称、状态、描述、分类、切分配置、回答校验配置等字段。
+// 切分配置只对之后导入的文档生效。
 func (c *ControllerV1) KBUpdate(ctx context.Context, req *v1.KBUpdateReq) (res *v1.KBUpdateRes, err error) {
-	// 按主键更新记录
-	_, err = dao.KnowledgeBase.Ctx(ctx).Data(do.KnowledgeBase{
+	data := do.KnowledgeBase{
 		Name:        req.Name,
 		Status:      req.Status,
 		Description: req.Description,
 		Category:    req.Category,
-	}).WherePri(req.Id).Update()
+	}
+	if err = setChunkProfile(&data, req.ChunkProfile); err != nil {
+		return nil, err
+	}
+	setVerifyPolicy(&data, req.VerifyPolicy)
+	// 按主键更新记录
+	_, err = dao.KnowledgeBase.Ctx(ctx).Data(data).WherePri(req.Id).Update()
+	return
+}
+
+// setVerifyPolicy 把请求中的回答校验配置写入待保存的数据，未指定的项保持不变
+func setVerifyPolicy(data *do.KnowledgeBase, p v1.VerifyPolicy) {
+	if p.VerifyAction != nil {
+		data.VerifyAction = *p.VerifyAction
+	}
+	if p.VerifyThreshold != nil {
+		data.VerifyThreshold = *p.VerifyThreshold
+	}
+}
+
+// setChunkProfile 把请求中的切分配置写入待保存的数据，未指定的项保持不变
+func setChunkProfile(data *do.KnowledgeBase, p v1.ChunkProfile) error {
+	if p.ChunkSize != nil && p.ChunkOverlap != nil && *p.ChunkOverlap >= *p.ChunkSize {
+		return fmt.Errorf("chunk overlap must be less than chunk size")
+	}
+	if p.Splitter != nil {
+		data.Splitter = *p.Splitter
+	}
+	if p.ChunkSize != nil {
+		data.ChunkSize = *p.ChunkSize
+	}
+	if p.ChunkOverlap != nil {
+		data.ChunkOverlap = *p.ChunkOverlap
+	}
+	if p.Separators != nil {
+		separators, err := sonic.MarshalString(p.Separators)
+		if err != nil {
+			return err
+		}
+		data.Separators = separators
+	}
+	if p.HeaderLevels != nil {
+		data.HeaderLevels = *p.HeaderLevels
+	}
+	if p.MergeLength != nil {
+		data.MergeLength = *p.MergeLength
+	}
+	return nil
+}
+
+// KBReindex 重建知识库索引。
+// 功能：登记重建任务，由后台 worker 按 knowledge_chunks 重新向量化知识库的所有 chunk 写入新的版本化索引，
+// 完成后切换别名，旧索引保留用于回滚。重建期间知识库仍可正常检索，进度与结果通过 /v1/indexer/jobs/{id} 查询。
+func (c *ControllerV1) KBReindex(ctx context.Context, req *v1.KBReindexReq) (res *v1.KBReindexRes, err error) {
+	var kb entity.KnowledgeBase
+	err = dao.KnowledgeBase.Ctx(ctx).WherePri(req.Id).Scan(&kb)
+	if err != nil {
+		return nil, err
+	}
+	jobId, err := rag.GetRagSvr().EnqueueReindex(ctx, &core.ReindexReq{
+		KnowledgeName:  kb.Name,
+		EmbeddingModel: req.EmbeddingModel,
+		EmbeddingDims:  req.EmbeddingDims,
+		Similarity:     req.Similarity,
+		RegenerateQA:   req.RegenerateQA,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res = &v1.KBReindexRes{JobId: jobId}
 	return
 }
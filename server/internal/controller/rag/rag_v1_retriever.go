@@ -6,6 +6,7 @@ import (
 
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
 	"github.com/everfid-ever/ThinkForge/core"
+	"github.com/everfid-ever/ThinkForge/core/rewrite"
 	"github.com/everfid-ever/ThinkForge/internal/logic/rag"
 	"github.com/gogf/gf/v2/frame/g"
 )
@@ -14,7 +15,7 @@ import (
 // 主要职责：
 //  1. 调用底层 rag 逻辑模块（ragSvr）执行向量检索；
 //  2. 过滤或清理无用的元数据字段；
-//  3. 将检索结果封装成统一响应结构返回。
+//  3. 将检索结果封装成统一响应结构返回，相似问题的结果会写入语义缓存。
 //
 // 整体流程：
 //
@@ -36,12 +37,33 @@ func (c *ControllerV1) Retriever(ctx context.Context, req *v1.RetrieverReq) (res
 		req.Score += 1
 	}
 
-	// Step 3: 调用 RAG 服务执行检索。
+	// Step 3: 查找语义缓存，相似的问题直接返回之前的检索结果（对话内部的检索不使用）。
+	// 改写问题同时记录到调用方（如 /v1/chat）的记录器中
+	ctx, rec := rewrite.WithRecorder(ctx)
+	useCache := rag.RetrieverCacheEnabled(ctx)
+	cacheKey := rag.RetrieverCacheKey(req)
+	cached := &v1.RetrieverRes{}
+	if useCache && ragSvr.Cache().Get(ctx, cacheKey, cached) {
+		rec.Add(cached.Rewrites...)
+		cached.CacheHit = true
+		return cached, nil
+	}
+
+	// Step 4: 调用 RAG 服务执行检索。
+	filter, err := rag.BuildFilter(req.Filters, req.MetadataCondition)
+	if err != nil {
+		return
+	}
 	ragReq := &core.RetrieveReq{
-		Query:         req.Question,
-		TopK:          req.TopK,
-		Score:         req.Score,
-		KnowledgeName: req.KnowledgeName,
+		Query:          req.Question,
+		TopK:           req.TopK,
+		Score:          req.Score,
+		KnowledgeName:  req.KnowledgeName,
+		KnowledgeBases: rag.KnowledgeBases(req.KnowledgeName, req.KnowledgeBases),
+		Mode:           req.RetrievalMode,
+		Fusion:         req.Fusion,
+		Filter:         filter,
+		Rewrite:        req.RewriteStrategy,
 	}
 	g.Log().Infof(ctx, "ragReq: %v", ragReq)
 	msg, err := ragSvr.Retrieve(ctx, ragReq)
@@ -50,7 +72,7 @@ func (c *ControllerV1) Retriever(ctx context.Context, req *v1.RetrieverReq) (res
 		return
 	}
 
-	// Step 4: 清理每个文档的 MetaData 字段中不必要的内容。
+	// Step 5: 清理每个文档的 MetaData 字段中不必要的内容。
 	// 比如 "_dense_vector" 是内部使用的向量字段，在返回给前端时应删除。
 	for _, document := range msg {
 		if document.MetaData != nil {
@@ -64,9 +86,13 @@ func (c *ControllerV1) Retriever(ctx context.Context, req *v1.RetrieverReq) (res
 		}
 	}
 
-	// Step 5: 构造响应对象。
+	// Step 6: 构造响应对象并写入缓存。
 	res = &v1.RetrieverRes{
-		Document: msg, // 返回经过处理的文档列表
+		Document: msg,           // 返回经过处理的文档列表
+		Rewrites: rec.Queries(), // 实际用于检索的改写问题
+	}
+	if useCache {
+		ragSvr.Cache().Set(ctx, cacheKey, res)
 	}
 	return
 }
@@ -9,10 +9,11 @@ import (
 
 func (c *ControllerV1) RetrieverDify(ctx context.Context, req *v1.RetrieverDifyReq) (res *v1.RetrieverDifyRes, err error) {
 	retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-		Question:      req.Query,
-		TopK:          req.RetrievalSetting.TopK,
-		Score:         req.RetrievalSetting.ScoreThreshold,
-		KnowledgeName: req.KnowledgeID,
+		Question:          req.Query,
+		TopK:              req.RetrievalSetting.TopK,
+		Score:             req.RetrievalSetting.ScoreThreshold,
+		KnowledgeName:     req.KnowledgeID,
+		MetadataCondition: req.MetadataCondition,
 	})
 	if err != nil {
 		return
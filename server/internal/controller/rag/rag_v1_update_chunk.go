@@ -5,16 +5,43 @@ import (
 
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
 	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
-	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/everfid-ever/ThinkForge/internal/logic/rag"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 func (c *ControllerV1) UpdateChunk(ctx context.Context, req *v1.UpdateChunkReq) (res *v1.UpdateChunkRes, err error) {
-	err = knowledge.UpdateChunkByIds(ctx, req.Ids, entity.KnowledgeChunks{
-		Status: req.Status,
-	})
+	chunks, err := knowledge.GetChunksByIds(ctx, req.Ids, "knowledge_doc_id", "chunk_id")
 	if err != nil {
 		return
 	}
 
+	err = knowledge.UpdateChunkStatusByIds(ctx, req.Ids, req.Status)
+	if err != nil {
+		return
+	}
+
+	// 同步到向量存储，检索时据此过滤已禁用的 chunk；不同知识库可能位于不同索引，按知识库分组
+	kbNames := map[int64]string{}
+	chunkIds := map[string][]string{}
+	for _, chunk := range chunks {
+		kbName, ok := kbNames[chunk.KnowledgeDocId]
+		if !ok {
+			document, err := knowledge.GetDocumentById(ctx, chunk.KnowledgeDocId)
+			if err != nil {
+				return nil, err
+			}
+			kbName = document.KnowledgeBaseName
+			kbNames[chunk.KnowledgeDocId] = kbName
+		}
+		chunkIds[kbName] = append(chunkIds[kbName], chunk.ChunkId)
+	}
+	for kbName, ids := range chunkIds {
+		err = rag.GetRagSvr().UpdateChunkStatus(ctx, kbName, ids, req.Status == v1.ChunkStatusEnabled)
+		if err != nil {
+			g.Log().Errorf(ctx, "UpdateChunkStatus failed, ids=%v, err=%v", req.Ids, err)
+			return
+		}
+	}
+
 	return
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
 	"github.com/everfid-ever/ThinkForge/core"
+	"github.com/everfid-ever/ThinkForge/core/common"
 	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
 	"github.com/everfid-ever/ThinkForge/internal/logic/rag"
 	"github.com/everfid-ever/ThinkForge/internal/model/entity"
@@ -60,6 +61,14 @@ func (c *ControllerV1) UpdateChunkContent(ctx context.Context, req *v1.UpdateChu
 				doc.MetaData = extData
 			}
 		}
+		if doc.MetaData == nil {
+			doc.MetaData = map[string]any{}
+		}
+		// 重新索引时保留 chunk 的启用状态
+		doc.MetaData[common.FieldStatus] = common.ChunkStatusEnabled
+		if chunk.Status == v1.ChunkStatusDisabled {
+			doc.MetaData[common.FieldStatus] = common.ChunkStatusDisabled
+		}
 
 		// 调用异步索引更新
 		ragSvr := rag.GetRagSvr()
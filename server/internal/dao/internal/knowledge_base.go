@@ -20,24 +20,48 @@ type KnowledgeBaseDao struct {
 
 // KnowledgeBaseColumns defines and stores column names for the table knowledge_base.
 type KnowledgeBaseColumns struct {
-	Id          string // 主键ID
-	Name        string // 知识库名称
-	Description string // 知识库描述
-	Category    string // 知识库分类
-	Status      string // 状态：1-启用,2-禁用
-	CreateTime  string // 创建时间
-	UpdateTime  string // 更新时间
+	Id              string // 主键ID
+	Name            string // 知识库名称
+	Description     string // 知识库描述
+	Category        string // 知识库分类
+	Status          string // 状态：1-启用,2-禁用
+	EmbeddingModel  string // 向量模型
+	EmbeddingDims   string // 向量维度
+	Similarity      string // 向量相似度
+	IndexName       string // 索引名称
+	Splitter        string // 切分方式
+	ChunkSize       string // 切分长度
+	ChunkOverlap    string // 切分重叠长度
+	Separators      string // 切分分隔符
+	HeaderLevels    string // Markdown 标题切分层级
+	MergeLength     string // Markdown 合并长度
+	VerifyAction    string // 回答校验的处理方式
+	VerifyThreshold string // 回答校验的得分阈值
+	CreateTime      string // 创建时间
+	UpdateTime      string // 更新时间
 }
 
 // knowledgeBaseColumns holds the columns for the table knowledge_base.
 var knowledgeBaseColumns = KnowledgeBaseColumns{
-	Id:          "id",
-	Name:        "name",
-	Description: "description",
-	Category:    "category",
-	Status:      "status",
-	CreateTime:  "create_time",
-	UpdateTime:  "update_time",
+	Id:              "id",
+	Name:            "name",
+	Description:     "description",
+	Category:        "category",
+	Status:          "status",
+	EmbeddingModel:  "embedding_model",
+	EmbeddingDims:   "embedding_dims",
+	Similarity:      "similarity",
+	IndexName:       "index_name",
+	Splitter:        "splitter",
+	ChunkSize:       "chunk_size",
+	ChunkOverlap:    "chunk_overlap",
+	Separators:      "separators",
+	HeaderLevels:    "header_levels",
+	MergeLength:     "merge_length",
+	VerifyAction:    "verify_action",
+	VerifyThreshold: "verify_threshold",
+	CreateTime:      "create_time",
+	UpdateTime:      "update_time",
 }
 
 // NewKnowledgeBaseDao creates and returns a new DAO object for table data access.
@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"github.com/everfid-ever/ThinkForge/internal/dao/internal"
+)
+
+// knowledgeDocumentSummariesDao is the data access object for the table knowledge_document_summaries.
+// You can define custom methods on it to extend its functionality as needed.
+type knowledgeDocumentSummariesDao struct {
+	*internal.KnowledgeDocumentSummariesDao
+}
+
+var (
+	// KnowledgeDocumentSummaries is a globally accessible object for table knowledge_document_summaries operations.
+	KnowledgeDocumentSummaries = knowledgeDocumentSummariesDao{internal.NewKnowledgeDocumentSummariesDao()}
+)
+
+// Add your custom methods and functionality below.
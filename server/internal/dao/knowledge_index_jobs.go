@@ -0,0 +1,22 @@
+// =================================================================================
+// This file is auto-generated by the GoFrame CLI tool. You may modify it as needed.
+// =================================================================================
+
+package dao
+
+import (
+	"github.com/everfid-ever/ThinkForge/internal/dao/internal"
+)
+
+// knowledgeIndexJobsDao is the data access object for the table knowledge_index_jobs.
+// You can define custom methods on it to extend its functionality as needed.
+type knowledgeIndexJobsDao struct {
+	*internal.KnowledgeIndexJobsDao
+}
+
+var (
+	// KnowledgeIndexJobs is a globally accessible object for table knowledge_index_jobs operations.
+	KnowledgeIndexJobs = knowledgeIndexJobsDao{internal.NewKnowledgeIndexJobsDao()}
+)
+
+// Add your custom methods and functionality below.
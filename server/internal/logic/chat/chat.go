@@ -7,6 +7,9 @@ import (
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/memory"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
 	"github.com/gogf/gf/v2/frame/g"
 	"io"
 )
@@ -36,8 +39,9 @@ func createTemplate() prompt.ChatTemplate {
 			"3. If the reference is incomplete or vague, reasonable inferences can be made but the information must be explained\n"+
 			"4. If the reference content is completely irrelevant or does not exist, inform the user that the question cannot be answered based on the available information\n"+
 			"5. Keep your answers professional, concise, and accurate\n"+
-			"6. When necessary, you can quote specific data or original text from the reference content\n\n"+
-			"Currently available reference content:\n"+
+			"6. When necessary, you can quote specific data or original text from the reference content\n"+
+			"7. "+citation.Instruction+"\n\n"+
+			"Currently available reference content (numbered):\n"+
 			"{docs}\n\n"+
 			""),
 
@@ -65,21 +69,39 @@ func formatMessages(template prompt.ChatTemplate, data map[string]any) ([]*schem
 // ===================== 文档检索与消息封装 =====================
 //
 
+// recentMessages 每次最多读取的未并入摘要的历史消息数
+const recentMessages = 200
+
 // docsMessages 将检索到的文档内容（docs）与用户问题（question）封装成可供 LLM 输入的消息列表。
 // 步骤包括：
-// 1. 从会话历史中获取上下文（用于多轮问答）
+// 1. 从会话中获取对话记忆与未并入摘要的历史消息（用于多轮问答）
 // 2. 记录当前用户问题
-// 3. 构建 Prompt 模板（含 role、docs、question、chat_history）
-// 4. 格式化为 LLM 可理解的消息结构
+// 3. 在上下文预算内装入参考文档与历史，必要时把较早的消息折叠进摘要（见 memory.Manager）
+// 4. 构建 Prompt 模板（含 role、docs、question、chat_history）并格式化为 LLM 可理解的消息结构
 func (x *Chat) docsMessages(ctx context.Context, convID string, docs []*schema.Document, question string) (messages []*schema.Message, err error) {
-	// Step 1: 获取指定会话 ID 的历史消息
-	chatHistory, err := x.eh.GetHistory(convID, 100)
-	if err != nil {
-		return
+	// Step 1: 获取对话记忆与近期消息，convID 为空时没有历史
+	var (
+		state   *memory.State
+		history []*memory.Message
+	)
+	if convID != "" {
+		if _, err = x.cr.FirstOrCreat(convID); err != nil {
+			return
+		}
+		if state, err = conversation.Memory(ctx, convID); err != nil {
+			return
+		}
+		var after uint64
+		if state != nil {
+			after = state.SummarizedUntil
+		}
+		if history, err = conversation.RecentMessages(ctx, convID, after, recentMessages); err != nil {
+			return
+		}
 	}
 
 	// Step 2: 将当前用户问题写入历史记录中
-	err = x.eh.SaveMessage(&schema.Message{
+	err = x.saveMessage(ctx, &schema.Message{
 		Role:    schema.User,
 		Content: question,
 	}, convID)
@@ -87,30 +109,36 @@ func (x *Chat) docsMessages(ctx context.Context, convID string, docs []*schema.D
 		return
 	}
 
-	// Step 3: 创建聊天模板
+	// Step 3: 创建聊天模板，不含参考文档与历史时即为必须完整保留的部分
 	template := createTemplate()
-
-	// Step 4: 打印每个检索到的文档内容，用于调试
-	for i, doc := range docs {
-		g.Log().Debugf(context.Background(), "docs[%d]: %s", i, doc.Content)
-	}
-
-	// Step 5: 组装模板变量
 	data := map[string]any{
-		"role":         role,        // AI 助手角色设定
-		"question":     question,    // 当前用户问题
-		"docs":         docs,        // 检索到的知识文档
-		"chat_history": chatHistory, // 上下文历史
+		"role":         role,                // AI 助手角色设定
+		"question":     question,            // 当前用户问题
+		"docs":         "",                  // 检索到的知识文档
+		"chat_history": []*schema.Message{}, // 上下文历史
 	}
-
-	// Step 6: 执行模板格式化，将数据填充到模板中
-	messages, err = formatMessages(template, data)
+	fixed, err := formatMessages(template, data)
 	if err != nil {
 		return
 	}
 
-	// Step 7: 返回构造好的消息序列
-	return
+	// Step 4: 在预算内装入参考文档与历史，摘要有更新时随会话保存
+	mc := x.mm.Prepare(ctx, &memory.Input{State: state, History: history, Docs: docs, Fixed: fixed})
+	if mc.Changed {
+		if err = conversation.SetMemory(ctx, convID, mc.State); err != nil {
+			g.Log().Warningf(ctx, "save memory of conversation %s failed, err=%v", convID, err)
+			err = nil
+		}
+	}
+	g.Log().Debugf(ctx, "context budget of conversation %s: %+v", convID, mc.Usage)
+	for i, doc := range mc.Docs {
+		g.Log().Debugf(ctx, "docs[%d]: %s", i, doc.Content)
+	}
+
+	// Step 5: 填充参考文档（带编号与来源，供回答中引用）与历史，执行模板格式化
+	data["docs"] = citation.Format(mc.Docs)
+	data["chat_history"] = mc.History
+	return formatMessages(template, data)
 }
 
 // GetAnswerStream 获取答案流式输出
@@ -145,7 +173,7 @@ func (x *Chat) GetAnswerStream(ctx context.Context, convID string, docs []*schem
 				return
 			}
 			// 保存完整消息到对话历史
-			err = x.eh.SaveMessage(fullMsg, convID)
+			err = x.saveMessage(ctx, fullMsg, convID)
 			if err != nil {
 				g.Log().Errorf(ctx, "error saving message: %v", err)
 				return
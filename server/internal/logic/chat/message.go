@@ -3,7 +3,17 @@ package chat
 import (
 	"context"
 	"fmt"
+	"sync"
+
+	"github.com/everfid-ever/ThinkForge/core/agent"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/memory"
+	"github.com/everfid-ever/ThinkForge/core/tokenizer"
 	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/logic/conversation"
+	"github.com/google/uuid"
+	"github.com/wangle201210/chat-history/models"
+	"github.com/wangle201210/chat-history/repositories"
 
 	"github.com/cloudwego/eino-ext/components/model/openai" // Eino 扩展：OpenAI 模型封装
 	"github.com/cloudwego/eino/components/model"            // Eino 通用模型接口定义
@@ -23,9 +33,15 @@ var chat *Chat
 // Chat 结构体封装了一个完整的对话生成引擎，包含：
 // - cm：底层 Chat 模型实例（例如 OpenAI GPT）
 // - eh：聊天历史记录管理器（支持多轮上下文）
+// - mr：消息仓库，写入消息时生成 msg_id，便于之后补充审计信息
+// - cr：会话仓库，第一条消息时创建会话
+// - mm：对话记忆，在上下文预算内装入参考文档、摘要与近期消息
 type Chat struct {
-	cm model.BaseChatModel // 底层大语言模型（LLM）
-	eh *eino.History       // 聊天历史管理器
+	cm model.BaseChatModel                  // 底层大语言模型（LLM）
+	eh *eino.History                        // 聊天历史管理器
+	mr *repositories.MessageRepository      // 消息仓库
+	cr *repositories.ConversationRepository // 会话仓库
+	mm *memory.Manager                      // 对话记忆
 }
 
 // GetChat 返回全局 Chat 实例，供外部模块调用（例如 ControllerV1）
@@ -41,15 +57,17 @@ func GetChat() *Chat {
 // 1. 从配置文件读取 OpenAI API 参数
 // 2. 创建 ChatModel 实例
 // 3. 初始化聊天历史管理器
-// 4. 注入全局 chat 单例
+// 4. 按对话模型创建对话记忆
+// 5. 注入全局 chat 单例
 func init() {
 	ctx := gctx.New()
 
 	// 从配置文件中读取 LLM 参数
+	modelName := g.Cfg().MustGet(ctx, "chat.model").String()
 	c, err := newChat(&openai.ChatModelConfig{
 		APIKey:  g.Cfg().MustGet(ctx, "chat.apiKey").String(),  // API 密钥
 		BaseURL: g.Cfg().MustGet(ctx, "chat.baseURL").String(), // API 地址
-		Model:   g.Cfg().MustGet(ctx, "chat.model").String(),   // 模型名称（例如 gpt-4）
+		Model:   modelName,                                     // 模型名称（例如 gpt-4）
 	})
 	if err != nil {
 		g.Log().Fatalf(ctx, "newChat failed, err=%v", err)
@@ -58,6 +76,19 @@ func init() {
 
 	// 初始化历史记录管理器（存储路径来自配置文件）
 	c.eh = eino.NewEinoHistory(dao.GetDsn())
+	c.mr = repositories.NewMessageRepository(repositories.GetDB())
+	c.cr = repositories.NewConversationRepository(repositories.GetDB())
+
+	// 对话记忆：token 按对话模型估算，较早的对话由对话模型生成摘要
+	c.mm = memory.NewManager(&memory.Config{
+		Model:            c.cm,
+		Tokenizer:        tokenizer.For(modelName),
+		ContextWindow:    g.Cfg().MustGet(ctx, "memory.contextWindow", memory.DefaultContextWindow).Int(),
+		ReservedOutput:   g.Cfg().MustGet(ctx, "memory.reservedOutput", memory.DefaultReservedOutput).Int(),
+		HistoryRatio:     g.Cfg().MustGet(ctx, "memory.historyRatio", memory.DefaultHistoryRatio).Float64(),
+		KeepRatio:        g.Cfg().MustGet(ctx, "memory.keepRatio", memory.DefaultKeepRatio).Float64(),
+		SummaryMaxTokens: g.Cfg().MustGet(ctx, "memory.summaryMaxTokens", memory.DefaultSummaryMaxTokens).Int(),
+	})
 
 	// 注册为全局单例
 	chat = c
@@ -94,14 +125,15 @@ func (x *Chat) GetAnswer(ctx context.Context, convID string, docs []*schema.Docu
 		return "", err
 	}
 
-	// Step 2: 调用底层 LLM 生成答案
-	result, err := generate(ctx, x.cm, messages)
+	// Step 2: 调用底层 LLM 生成答案，流式对话时（见 agent.WithStreamHandler）先回调参考文档，再边生成边回调答案
+	agent.EmitReferences(ctx, docs)
+	result, err := agent.GenerateAnswer(ctx, x.cm, messages)
 	if err != nil {
 		return "", fmt.Errorf("生成答案失败: %w", err)
 	}
 
 	// Step 3: 将 LLM 输出保存到对话历史中
-	err = x.eh.SaveMessage(result, convID)
+	err = x.saveMessage(ctx, result, convID)
 	if err != nil {
 		g.Log().Error(ctx, "save assistant message err: %v", err)
 		return
@@ -111,6 +143,159 @@ func (x *Chat) GetAnswer(ctx context.Context, convID string, docs []*schema.Docu
 	return result.Content, nil
 }
 
+// Revise 按校验反馈修改回答：在参考资料、问题与原回答之后追加反馈，由 LLM 重新生成。
+// convID 不为空时用修改后的回答替换会话历史中本次请求写入的原回答（见 MessageRecorder），没有记录时才新写入一条
+func (x *Chat) Revise(ctx context.Context, convID string, docs []*schema.Document, question, answer, feedback string) (string, error) {
+	messages, err := formatMessages(createTemplate(), map[string]any{
+		"role":         role,
+		"question":     question,
+		"docs":         citation.Format(docs),
+		"chat_history": []*schema.Message{},
+	})
+	if err != nil {
+		return "", err
+	}
+	messages = append(messages, schema.AssistantMessage(answer, nil), schema.UserMessage(feedback))
+	result, err := generate(ctx, x.cm, messages)
+	if err != nil {
+		return "", fmt.Errorf("修改答案失败: %w", err)
+	}
+	if convID != "" {
+		if err = x.replaceAnswer(ctx, result, convID); err != nil {
+			g.Log().Errorf(ctx, "save revised message err: %v", err)
+		}
+	}
+	return result.Content, nil
+}
+
+// HasHistory 判断会话中是否已有消息，convID 为空时视为没有
+func (x *Chat) HasHistory(convID string) (bool, error) {
+	if convID == "" {
+		return false, nil
+	}
+	history, err := x.eh.GetHistory(convID, 1)
+	if err != nil {
+		return false, err
+	}
+	return len(history) > 0, nil
+}
+
+// RecentHistory 返回会话最近 turns 轮问答，有对话摘要时作为一条系统消息放在最前面，供改写追问与意图识别使用。
+// convID 为空或 turns <= 0 时返回空
+func (x *Chat) RecentHistory(ctx context.Context, convID string, turns int) ([]*schema.Message, error) {
+	if convID == "" || turns <= 0 {
+		return nil, nil
+	}
+	state, err := conversation.Memory(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := conversation.RecentMessages(ctx, convID, 0, 2*turns)
+	if err != nil {
+		return nil, err
+	}
+	var history []*schema.Message
+	if state != nil && state.Summary != "" {
+		history = append(history, schema.SystemMessage("Summary of the earlier conversation: "+state.Summary))
+	}
+	for _, m := range msgs {
+		history = append(history, m.Message)
+	}
+	return history, nil
+}
+
+// SaveExchange 把一问一答写入会话历史，答案来自缓存或 ReAct 等未经过 GetAnswer 生成时使用，保证后续的多轮对话有上下文
+func (x *Chat) SaveExchange(ctx context.Context, convID, question, answer string) error {
+	if convID == "" {
+		return nil
+	}
+	if err := x.saveMessage(ctx, schema.UserMessage(question), convID); err != nil {
+		return err
+	}
+	return x.saveMessage(ctx, schema.AssistantMessage(answer, nil), convID)
+}
+
+// saveMessage 写入会话历史（附带按对话模型估算的 token 数）并更新会话的最后活跃时间，第一个问题作为会话标题。
+// 助手消息的 msg_id 记录到 ctx 中的 MessageRecorder
+func (x *Chat) saveMessage(ctx context.Context, msg *schema.Message, convID string) error {
+	m := &models.Message{
+		MsgID:          uuid.NewString(),
+		Role:           string(msg.Role),
+		Content:        msg.Content,
+		ConversationID: convID,
+		TokenCount:     x.mm.Tokenizer().Count(msg.Content),
+	}
+	if err := x.mr.Create(m); err != nil {
+		return err
+	}
+	var title string
+	if msg.Role == schema.User {
+		title = msg.Content
+	} else {
+		recorderFromContext(ctx).add(m.MsgID)
+	}
+	if err := conversation.Touch(ctx, convID, title); err != nil {
+		g.Log().Warningf(ctx, "touch conversation %s failed, err=%v", convID, err)
+	}
+	return nil
+}
+
+// replaceAnswer 用 msg 替换本次请求最后写入的助手消息
+func (x *Chat) replaceAnswer(ctx context.Context, msg *schema.Message, convID string) error {
+	msgID := recorderFromContext(ctx).last()
+	if msgID == "" {
+		return x.saveMessage(ctx, msg, convID)
+	}
+	return conversation.UpdateMessageContent(ctx, msgID, msg.Content, x.mm.Tokenizer().Count(msg.Content))
+}
+
+type messageRecorderCtxKey struct{}
+
+// MessageRecorder 记录一次请求中写入会话历史的助手消息，请求结束后据此补充审计信息（见 conversation.SetMessageMeta）
+type MessageRecorder struct {
+	mu     sync.Mutex
+	msgIDs []string
+}
+
+// WithMessageRecorder 在 ctx 中放入新的记录器
+func WithMessageRecorder(ctx context.Context) (context.Context, *MessageRecorder) {
+	r := &MessageRecorder{}
+	return context.WithValue(ctx, messageRecorderCtxKey{}, r), r
+}
+
+func recorderFromContext(ctx context.Context) *MessageRecorder {
+	r, _ := ctx.Value(messageRecorderCtxKey{}).(*MessageRecorder)
+	return r
+}
+
+func (r *MessageRecorder) add(msgID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.msgIDs = append(r.msgIDs, msgID)
+	r.mu.Unlock()
+}
+
+func (r *MessageRecorder) last() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.msgIDs) == 0 {
+		return ""
+	}
+	return r.msgIDs[len(r.msgIDs)-1]
+}
+
+// MessageIDs 按写入顺序返回助手消息的 msg_id
+func (r *MessageRecorder) MessageIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.msgIDs...)
+}
+
 //
 // ===================== LLM 调用封装 =====================
 //
@@ -0,0 +1,242 @@
+// Package conversation 会话与消息的管理。conversations / messages 表由 chat-history 创建并写入，
+// 这里负责查询、重命名、搜索、导出、删除，记录助手消息的审计信息，以及保存对话记忆（见 core/memory）
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// titleMaxRunes 使用第一个问题作为标题时保留的最大字符数
+const titleMaxRunes = 50
+
+// List 分页获取会话，置顶的在前，其余按最后一条消息的时间倒序。keyword 不为空时按标题过滤
+func List(ctx context.Context, keyword string, page, size int) (list []*v1.Conversation, total int, err error) {
+	model := dao.Conversations.Ctx(ctx)
+	if keyword != "" {
+		model = model.WhereLike("title", "%"+keyword+"%")
+	}
+	total, err = model.Count()
+	if err != nil || total == 0 {
+		return nil, total, err
+	}
+	var convs []*entity.Conversations
+	err = model.Page(page, size).OrderDesc("is_pinned").OrderDesc("updated_at").OrderDesc("id").Scan(&convs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	convIDs := make([]string, 0, len(convs))
+	for _, c := range convs {
+		convIDs = append(convIDs, c.ConvId)
+	}
+	counts, err := messageCounts(ctx, convIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, c := range convs {
+		list = append(list, toConversation(c, counts[c.ConvId]))
+	}
+	return list, total, nil
+}
+
+// Get 获取会话，不存在时返回 CodeNotFound
+func Get(ctx context.Context, convID string) (*v1.Conversation, error) {
+	var conv *entity.Conversations
+	if err := dao.Conversations.Ctx(ctx).Where("conv_id", convID).Scan(&conv); err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		return nil, gerror.NewCodef(gcode.CodeNotFound, "conversation %q not found", convID)
+	}
+	counts, err := messageCounts(ctx, []string{convID})
+	if err != nil {
+		return nil, err
+	}
+	return toConversation(conv, counts[convID]), nil
+}
+
+// Messages 按时间顺序分页获取会话中的消息，size <= 0 时返回全部
+func Messages(ctx context.Context, convID string, page, size int) ([]*v1.Message, error) {
+	model := dao.Messages.Ctx(ctx).Where("conversation_id", convID).OrderAsc("id")
+	if size > 0 {
+		model = model.Page(page, size)
+	}
+	var msgs []*entity.Messages
+	if err := model.Scan(&msgs); err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	res := make([]*v1.Message, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, toMessage(ctx, m))
+	}
+	return res, nil
+}
+
+// Rename 修改会话标题
+func Rename(ctx context.Context, convID, title string) error {
+	result, err := dao.Conversations.Ctx(ctx).Where("conv_id", convID).Data(g.Map{"title": title}).Update()
+	if err != nil {
+		return fmt.Errorf("failed to rename conversation: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// 标题未变化时影响行数也为 0，确认会话是否存在
+		if _, err = Get(ctx, convID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search 在所有会话的消息内容中搜索关键词，最新的消息在前。role 不为空时只搜索该角色的消息
+func Search(ctx context.Context, keyword, role string, page, size int) (hits []*v1.MessageHit, total int, err error) {
+	model := dao.Messages.Ctx(ctx).WhereLike("content", "%"+keyword+"%")
+	if role != "" {
+		model = model.Where("role", role)
+	}
+	total, err = model.Count()
+	if err != nil || total == 0 {
+		return nil, total, err
+	}
+	var msgs []*entity.Messages
+	if err = model.Page(page, size).OrderDesc("id").Scan(&msgs); err != nil {
+		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
+	}
+	convIDs := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		convIDs = append(convIDs, m.ConversationId)
+	}
+	titles, err := dao.Conversations.Ctx(ctx).Fields("conv_id", "title").WhereIn("conv_id", convIDs).All()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get conversation titles: %w", err)
+	}
+	titleOf := make(map[string]string, len(titles))
+	for _, r := range titles {
+		titleOf[r["conv_id"].String()] = r["title"].String()
+	}
+	for _, m := range msgs {
+		hits = append(hits, &v1.MessageHit{Message: toMessage(ctx, m), Title: titleOf[m.ConversationId]})
+	}
+	return hits, total, nil
+}
+
+// Delete 删除会话及其消息（含消息的附件）
+func Delete(ctx context.Context, convID string) error {
+	if _, err := Get(ctx, convID); err != nil {
+		return err
+	}
+	return dao.Conversations.Ctx(ctx).Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		values, err := dao.Messages.Ctx(ctx).TX(tx).Where("conversation_id", convID).Fields("msg_id").Array()
+		if err != nil {
+			return fmt.Errorf("failed to get messages: %w", err)
+		}
+		msgIDs := make([]string, 0, len(values))
+		for _, v := range values {
+			msgIDs = append(msgIDs, v.String())
+		}
+		if len(msgIDs) > 0 {
+			for _, table := range []string{"message_attachments", "attachments"} {
+				if _, err = tx.Model(table).Ctx(ctx).WhereIn("message_id", msgIDs).Delete(); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", table, err)
+				}
+			}
+		}
+		if _, err = dao.Messages.Ctx(ctx).TX(tx).Where("conversation_id", convID).Delete(); err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		if _, err = dao.Conversations.Ctx(ctx).TX(tx).Where("conv_id", convID).Delete(); err != nil {
+			return fmt.Errorf("failed to delete conversation: %w", err)
+		}
+		g.Log().Infof(ctx, "conversation deleted: conv_id=%s, messages=%d", convID, len(msgIDs))
+		return nil
+	})
+}
+
+// Touch 会话有新消息时更新最后活跃时间；title 不为空且会话还没有标题时，以其（截断后）作为标题
+func Touch(ctx context.Context, convID, title string) error {
+	_, err := dao.Conversations.Ctx(ctx).Where("conv_id", convID).Data(g.Map{"updated_at": time.Now().Unix()}).Update()
+	if err != nil || title == "" {
+		return err
+	}
+	if runes := []rune(title); len(runes) > titleMaxRunes {
+		title = string(runes[:titleMaxRunes]) + "…"
+	}
+	_, err = dao.Conversations.Ctx(ctx).Where("conv_id", convID).Where("title", "").Data(g.Map{"title": title}).Update()
+	return err
+}
+
+// UpdateMessageContent 替换消息的内容与 token 数，用于校验后修改的回答覆盖原回答
+func UpdateMessageContent(ctx context.Context, msgID, content string, tokenCount int) error {
+	_, err := dao.Messages.Ctx(ctx).Where("msg_id", msgID).Data(g.Map{
+		"content":     content,
+		"token_count": tokenCount,
+	}).Update()
+	return err
+}
+
+// SetMessageMeta 记录助手消息的审计信息
+func SetMessageMeta(ctx context.Context, msgID string, meta *v1.MessageMeta) error {
+	data, err := sonic.MarshalString(meta)
+	if err != nil {
+		return err
+	}
+	_, err = dao.Messages.Ctx(ctx).Where("msg_id", msgID).Data(g.Map{"metadata": data}).Update()
+	return err
+}
+
+// messageCounts 统计各会话的消息数
+func messageCounts(ctx context.Context, convIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(convIDs))
+	if len(convIDs) == 0 {
+		return counts, nil
+	}
+	rows, err := dao.Messages.Ctx(ctx).
+		Fields("conversation_id", "COUNT(1) AS n").
+		WhereIn("conversation_id", convIDs).
+		Group("conversation_id").
+		All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+	for _, r := range rows {
+		counts[r["conversation_id"].String()] = r["n"].Int()
+	}
+	return counts, nil
+}
+
+func toConversation(c *entity.Conversations, messageCount int) *v1.Conversation {
+	return &v1.Conversation{
+		ConvID:       c.ConvId,
+		Title:        c.Title,
+		MessageCount: messageCount,
+		IsPinned:     c.IsPinned,
+		IsArchived:   c.IsArchived,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
+func toMessage(ctx context.Context, m *entity.Messages) *v1.Message {
+	msg := &v1.Message{
+		MsgID:     m.MsgId,
+		ConvID:    m.ConversationId,
+		Role:      m.Role,
+		Content:   m.Content,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.Metadata != "" && m.Metadata != "null" {
+		if err := sonic.UnmarshalString(m.Metadata, &msg.Meta); err != nil {
+			g.Log().Warningf(ctx, "unmarshal metadata of message %s failed, err=%v", m.MsgId, err)
+		}
+	}
+	return msg
+}
@@ -0,0 +1,105 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+)
+
+// Export 导出会话的全部消息及助手消息的审计信息，返回文件内容与对应的 Content-Type
+func Export(ctx context.Context, convID, format string) (content []byte, contentType string, err error) {
+	conv, err := Get(ctx, convID)
+	if err != nil {
+		return nil, "", err
+	}
+	msgs, err := Messages(ctx, convID, 0, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == v1.ExportFormatMarkdown {
+		return []byte(renderMarkdown(conv, msgs)), "text/markdown; charset=utf-8", nil
+	}
+	content, err = sonic.ConfigStd.MarshalIndent(&v1.ConversationGetRes{Conversation: conv, Messages: msgs}, "", "  ")
+	return content, "application/json; charset=utf-8", err
+}
+
+// renderMarkdown 按时间顺序渲染消息，助手消息后附上策略、意图、校验结果与参考文档
+func renderMarkdown(conv *v1.Conversation, msgs []*v1.Message) string {
+	var sb strings.Builder
+	title := conv.Title
+	if title == "" {
+		title = conv.ConvID
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	fmt.Fprintf(&sb, "- Conversation: `%s`\n", conv.ConvID)
+	fmt.Fprintf(&sb, "- Created: %s\n", formatUnix(conv.CreatedAt))
+	fmt.Fprintf(&sb, "- Messages: %d\n", len(msgs))
+	for _, m := range msgs {
+		fmt.Fprintf(&sb, "\n## %s · %s\n\n%s\n", roleName(m.Role), formatUnix(m.CreatedAt), m.Content)
+		if m.Meta != nil {
+			renderMeta(&sb, m.Meta)
+		}
+	}
+	return sb.String()
+}
+
+func renderMeta(sb *strings.Builder, meta *v1.MessageMeta) {
+	sb.WriteString("\n")
+	var notes []string
+	if meta.Strategy != "" {
+		notes = append(notes, "strategy `"+meta.Strategy+"`")
+	}
+	if meta.Intent != nil {
+		notes = append(notes, fmt.Sprintf("intent `%s` (%.2f)", meta.Intent.Type, meta.Intent.Confidence))
+	}
+	if meta.Standalone != "" {
+		notes = append(notes, "searched as `"+meta.Standalone+"`")
+	}
+	if meta.CacheHit {
+		notes = append(notes, "from cache")
+	}
+	if meta.Draft {
+		notes = append(notes, "draft, replaced by a regenerated answer")
+	}
+	if g := meta.Groundedness; g != nil && g.Result != nil {
+		notes = append(notes, fmt.Sprintf("groundedness %.2f", g.Score))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(sb, "> %s\n", strings.Join(notes, " · "))
+	}
+	if g := meta.Groundedness; g != nil && g.Warning != "" {
+		fmt.Fprintf(sb, ">\n> ⚠️ %s\n", g.Warning)
+	}
+	if len(meta.References) > 0 {
+		sb.WriteString(">\n> References:\n")
+		for _, ref := range meta.References {
+			label := ref.Label()
+			if label == "" {
+				label = ref.ChunkID
+			}
+			fmt.Fprintf(sb, "> - [%d] %s (score %.2f)\n", ref.Index, label, ref.Score)
+		}
+	}
+}
+
+func roleName(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	default:
+		return role
+	}
+}
+
+func formatUnix(sec int64) string {
+	if sec == 0 {
+		return "-"
+	}
+	return time.Unix(sec, 0).Format(time.DateTime)
+}
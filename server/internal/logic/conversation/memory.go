@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/eino/schema"
+	"github.com/everfid-ever/ThinkForge/core/memory"
+	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// settingsMemoryKey 对话记忆在 conversations.settings 中的键
+const settingsMemoryKey = "memory"
+
+// Memory 获取会话的对话记忆，会话不存在或还没有摘要时返回 nil
+func Memory(ctx context.Context, convID string) (*memory.State, error) {
+	settings, err := dao.Conversations.Ctx(ctx).Where("conv_id", convID).Value("settings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation settings: %w", err)
+	}
+	var s struct {
+		Memory *memory.State `json:"memory"`
+	}
+	if v := settings.String(); v != "" && v != "null" {
+		if err = sonic.UnmarshalString(v, &s); err != nil {
+			g.Log().Warningf(ctx, "unmarshal settings of conversation %s failed, err=%v", convID, err)
+		}
+	}
+	return s.Memory, nil
+}
+
+// SetMemory 保存会话的对话记忆，settings 中的其他配置保持不变
+func SetMemory(ctx context.Context, convID string, state *memory.State) error {
+	settings, err := dao.Conversations.Ctx(ctx).Where("conv_id", convID).Value("settings")
+	if err != nil {
+		return fmt.Errorf("failed to get conversation settings: %w", err)
+	}
+	s := map[string]any{}
+	if v := settings.String(); v != "" && v != "null" {
+		if err = sonic.UnmarshalString(v, &s); err != nil {
+			g.Log().Warningf(ctx, "unmarshal settings of conversation %s failed, overwrite it, err=%v", convID, err)
+			s = map[string]any{}
+		}
+	}
+	s[settingsMemoryKey] = state
+	data, err := sonic.MarshalString(s)
+	if err != nil {
+		return err
+	}
+	_, err = dao.Conversations.Ctx(ctx).Where("conv_id", convID).Data(g.Map{"settings": data}).Update()
+	return err
+}
+
+// RecentMessages 按时间顺序返回 ID 大于 afterID 的最近 limit 条问答消息
+func RecentMessages(ctx context.Context, convID string, afterID uint64, limit int) ([]*memory.Message, error) {
+	var msgs []*entity.Messages
+	err := dao.Messages.Ctx(ctx).
+		Fields("id", "role", "content").
+		Where("conversation_id", convID).
+		WhereGT("id", afterID).
+		WhereIn("role", []string{string(schema.User), string(schema.Assistant)}).
+		OrderDesc("id").
+		Limit(limit).
+		Scan(&msgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent messages: %w", err)
+	}
+	slices.Reverse(msgs)
+	res := make([]*memory.Message, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, &memory.Message{
+			ID:      m.Id,
+			Message: &schema.Message{Role: schema.RoleType(m.Role), Content: m.Content},
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,42 @@
+package conversation
+
+import (
+	"github.com/cloudwego/eino/schema"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/agent"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+)
+
+// NewMessageMeta 根据对话响应生成助手消息的审计信息。参考文档保存生成时的内容，并附上回答中引用它的句子
+func NewMessageMeta(res *v1.ChatRes, intent *agent.RAGIntent) *v1.MessageMeta {
+	if intent == nil {
+		intent = res.Intent
+	}
+	sentences := make(map[int][]string, len(res.Citations))
+	for _, c := range res.Citations {
+		sentences[c.Index] = c.Sentences
+	}
+	return &v1.MessageMeta{
+		Strategy:     res.Strategy,
+		Intent:       intent,
+		References:   messageReferences(res.References, sentences),
+		Rewrites:     res.Rewrites,
+		Standalone:   res.StandaloneQuestion,
+		Groundedness: res.Groundedness,
+		CacheHit:     res.CacheHit,
+	}
+}
+
+func messageReferences(docs []*schema.Document, sentences map[int][]string) []*v1.MessageReference {
+	refs := make([]*v1.MessageReference, 0, len(docs))
+	for i, doc := range docs {
+		c := citation.Source(doc)
+		c.Index = i + 1
+		c.Sentences = sentences[c.Index]
+		if c.Sentences == nil {
+			c.Sentences = []string{}
+		}
+		refs = append(refs, &v1.MessageReference{Citation: c, Score: doc.Score(), Content: doc.Content})
+	}
+	return refs
+}
@@ -0,0 +1,144 @@
+package indexjob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/everfid-ever/ThinkForge/internal/logic/rag"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// Handler 执行单个索引任务，返回错误时任务会按退避策略重试
+type Handler func(ctx context.Context, job entity.KnowledgeIndexJobs) error
+
+// Worker 是基于 knowledge_index_jobs 表的索引任务执行池
+type Worker struct {
+	handler      Handler
+	sem          chan struct{} // 控制并发数
+	pollInterval time.Duration
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	lease        time.Duration // 执行中任务的租约，心跳间隔为其三分之一
+}
+
+// Start 根据配置创建 worker 池并在后台运行，租约过期的执行中任务（如进程退出时中断的任务）会被重新执行
+func Start(ctx context.Context) *Worker {
+	w := &Worker{
+		handler:      rag.GetRagSvr().RunIndexJob,
+		sem:          make(chan struct{}, max(g.Cfg().MustGet(ctx, "indexJob.concurrency", 2).Int(), 1)),
+		pollInterval: g.Cfg().MustGet(ctx, "indexJob.pollInterval", "2s").Duration(),
+		backoffBase:  g.Cfg().MustGet(ctx, "indexJob.backoffBase", "5s").Duration(),
+		backoffMax:   g.Cfg().MustGet(ctx, "indexJob.backoffMax", "10m").Duration(),
+		lease:        knowledge.IndexJobLease(ctx),
+	}
+	if w.pollInterval <= 0 {
+		w.pollInterval = 2 * time.Second
+	}
+
+	go w.run(ctx)
+	return w
+}
+
+func (w *Worker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		w.reclaim(ctx)
+		w.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reclaim 把租约过期的执行中任务放回队列。多个实例共用任务表时，只有心跳停止的任务会被接管
+func (w *Worker) reclaim(ctx context.Context) {
+	n, err := knowledge.ReclaimExpiredIndexJobs(ctx, w.lease)
+	if err != nil {
+		g.Log().Errorf(ctx, "reclaim expired index jobs failed, err=%v", err)
+	} else if n > 0 {
+		g.Log().Infof(ctx, "resumed %d interrupted index jobs", n)
+	}
+}
+
+// dispatch 按空闲槽位数量拉取到期任务，抢占成功后交给 goroutine 执行
+func (w *Worker) dispatch(ctx context.Context) {
+	free := cap(w.sem) - len(w.sem)
+	if free <= 0 {
+		return
+	}
+	jobs, err := knowledge.GetDueIndexJobs(ctx, free)
+	if err != nil {
+		g.Log().Errorf(ctx, "GetDueIndexJobs failed, err=%v", err)
+		return
+	}
+	for _, job := range jobs {
+		ok, err := knowledge.ClaimIndexJob(ctx, job.Id)
+		if err != nil {
+			g.Log().Errorf(ctx, "ClaimIndexJob failed, id=%d, err=%v", job.Id, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		job.Attempts++
+		w.sem <- struct{}{}
+		go w.execute(job)
+	}
+}
+
+func (w *Worker) execute(job entity.KnowledgeIndexJobs) {
+	ctx := gctx.New()
+	defer func() { <-w.sem }()
+	stop := knowledge.KeepIndexJobAlive(ctx, job.Id, w.lease/3)
+	defer stop()
+
+	err := w.handle(ctx, job)
+	if err == nil {
+		_ = knowledge.UpdateIndexJob(ctx, job.Id, g.Map{
+			"status":     int(v1.JobSucceeded),
+			"last_error": "",
+		})
+		return
+	}
+
+	g.Log().Errorf(ctx, "index job failed, id=%d, attempt=%d/%d, err=%v", job.Id, job.Attempts, job.MaxAttempts, err)
+	if job.Attempts >= job.MaxAttempts {
+		_ = knowledge.FailIndexJob(ctx, job, err)
+		return
+	}
+	_ = knowledge.UpdateIndexJob(ctx, job.Id, g.Map{
+		"status":      int(v1.JobPending),
+		"last_error":  err.Error(),
+		"next_run_at": gtime.Now().Add(w.backoff(job.Attempts)),
+	})
+}
+
+func (w *Worker) handle(ctx context.Context, job entity.KnowledgeIndexJobs) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("index job panic: %v", e)
+		}
+	}()
+	return w.handler(ctx, job)
+}
+
+// backoff 计算第 attempts 次失败后的等待时间：base * 2^(attempts-1)，不超过 backoffMax
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.backoffBase
+	for i := 1; i < attempts && d < w.backoffMax; i++ {
+		d *= 2
+	}
+	if d > w.backoffMax {
+		d = w.backoffMax
+	}
+	return d
+}
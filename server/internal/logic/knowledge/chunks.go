@@ -7,6 +7,7 @@ import (
 	"github.com/everfid-ever/ThinkForge/internal/dao"
 	"github.com/everfid-ever/ThinkForge/internal/model/entity"
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
 // SaveChunksData 批量保存知识块数据
@@ -66,6 +67,12 @@ func DeleteChunkById(ctx context.Context, id int64) error {
 	return err
 }
 
+// DeleteChunksByDocId 删除文档的全部知识块
+func DeleteChunksByDocId(ctx context.Context, docId int64) error {
+	_, err := dao.KnowledgeChunks.Ctx(ctx).Where("knowledge_doc_id", docId).Delete()
+	return err
+}
+
 // UpdateChunkByIds 根据ID更新知识块
 func UpdateChunkByIds(ctx context.Context, ids []int64, data entity.KnowledgeChunks) error {
 	model := dao.KnowledgeChunks.Ctx(ctx).WhereIn("id", ids)
@@ -79,9 +86,62 @@ func UpdateChunkByIds(ctx context.Context, ids []int64, data entity.KnowledgeChu
 	return err
 }
 
-// GetAllChunksByDocId gets all chunks by document id
+// UpdateChunkStatusByIds 根据ID更新知识块启用状态（status 为 0 时 UpdateChunkByIds 无法更新，需要单独处理）
+func UpdateChunkStatusByIds(ctx context.Context, ids []int64, status int) error {
+	_, err := dao.KnowledgeChunks.Ctx(ctx).WhereIn("id", ids).Data("status", status).Update()
+	return err
+}
+
+// GetChunksByIds 根据ID批量查询知识块
+func GetChunksByIds(ctx context.Context, ids []int64, fields ...string) (list []entity.KnowledgeChunks, err error) {
+	model := dao.KnowledgeChunks.Ctx(ctx).WhereIn("id", ids)
+	if len(fields) > 0 {
+		model = model.Fields(fields)
+	}
+	err = model.Scan(&list)
+	return
+}
+
+// GetChunksByChunkIds 根据 chunk_id（向量存储中的文档 ID）批量查询知识块
+func GetChunksByChunkIds(ctx context.Context, chunkIds []string, fields ...string) (list []entity.KnowledgeChunks, err error) {
+	model := dao.KnowledgeChunks.Ctx(ctx).WhereIn("chunk_id", chunkIds)
+	if len(fields) > 0 {
+		model = model.Fields(fields)
+	}
+	err = model.Scan(&list)
+	return
+}
+
+// GetChunkIdsByStatus 查询指定状态下所有知识块的 chunk_id
+func GetChunkIdsByStatus(ctx context.Context, status int) (chunkIds []string, err error) {
+	values, err := dao.KnowledgeChunks.Ctx(ctx).Where("status", status).Fields("chunk_id").Array()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		chunkIds = append(chunkIds, v.String())
+	}
+	return chunkIds, nil
+}
+
+// GetChunkIdsByDocIds 查询多个文档全部知识块的 chunk_id
+func GetChunkIdsByDocIds(ctx context.Context, docIds []int64) (chunkIds []string, err error) {
+	if len(docIds) == 0 {
+		return nil, nil
+	}
+	values, err := dao.KnowledgeChunks.Ctx(ctx).WhereIn("knowledge_doc_id", docIds).Fields("chunk_id").Array()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		chunkIds = append(chunkIds, v.String())
+	}
+	return chunkIds, nil
+}
+
+// GetAllChunksByDocId gets all chunks by document id, ordered as they were split
 func GetAllChunksByDocId(ctx context.Context, docId int64, fields ...string) (list []entity.KnowledgeChunks, err error) {
-	model := dao.KnowledgeChunks.Ctx(ctx).Where("knowledge_doc_id", docId)
+	model := dao.KnowledgeChunks.Ctx(ctx).Where("knowledge_doc_id", docId).OrderAsc("id")
 	if len(fields) > 0 {
 		for _, field := range fields {
 			model = model.Fields(field)
@@ -90,3 +150,9 @@ func GetAllChunksByDocId(ctx context.Context, docId int64, fields ...string) (li
 	err = model.Scan(&list)
 	return
 }
+
+// GetChunksByDocIdUpdatedSince 获取文档在 since 之后新增或修改过的知识块
+func GetChunksByDocIdUpdatedSince(ctx context.Context, docId int64, since *gtime.Time) (list []entity.KnowledgeChunks, err error) {
+	err = dao.KnowledgeChunks.Ctx(ctx).Where("knowledge_doc_id", docId).WhereGTE("updated_at", since).Scan(&list)
+	return
+}
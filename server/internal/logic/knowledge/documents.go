@@ -59,6 +59,27 @@ func GetDocumentById(ctx context.Context, id int64) (document entity.KnowledgeDo
 	return document, nil
 }
 
+// GetDocumentIdsByKnowledgeName 获取知识库下所有文档的ID
+func GetDocumentIdsByKnowledgeName(ctx context.Context, knowledgeName string) (ids []int64, err error) {
+	values, err := dao.KnowledgeDocuments.Ctx(ctx).Where("knowledge_base_name", knowledgeName).Fields("id").Array()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		ids = append(ids, v.Int64())
+	}
+	return ids, nil
+}
+
+// GetDocumentsByKnowledgeNames 获取知识库下指定状态的全部文档
+func GetDocumentsByKnowledgeNames(ctx context.Context, knowledgeNames []string, status int) (documents []entity.KnowledgeDocuments, err error) {
+	err = dao.KnowledgeDocuments.Ctx(ctx).
+		WhereIn("knowledge_base_name", knowledgeNames).
+		Where("status", status).
+		Scan(&documents)
+	return
+}
+
 // GetDocumentsList 获取文档列表
 func GetDocumentsList(ctx context.Context, where entity.KnowledgeDocuments, page int, pageSize int) (documents []entity.KnowledgeDocuments, total int, err error) {
 	// 参数验证和默认值设置
@@ -110,6 +131,20 @@ func DeleteDocument(ctx context.Context, id int64) error {
 			return fmt.Errorf("failed to delete document block: %w", err)
 		}
 
+		// 删除文档关联的索引任务
+		_, err = dao.KnowledgeIndexJobs.Ctx(ctx).TX(tx).Where("knowledge_doc_id", id).Delete()
+		if err != nil {
+			g.Log().Errorf(ctx, "failed to delete index jobs: ID=%d, Error: %v", id, err)
+			return fmt.Errorf("failed to delete index jobs: %w", err)
+		}
+
+		// 删除文档的摘要
+		_, err = dao.KnowledgeDocumentSummaries.Ctx(ctx).TX(tx).Where("knowledge_doc_id", id).Delete()
+		if err != nil {
+			g.Log().Errorf(ctx, "failed to delete document summaries: ID=%d, Error: %v", id, err)
+			return fmt.Errorf("failed to delete document summaries: %w", err)
+		}
+
 		// 再删除文档
 		result, err := dao.KnowledgeDocuments.Ctx(ctx).TX(tx).Where("id", id).Delete()
 		if err != nil {
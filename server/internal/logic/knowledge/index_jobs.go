@@ -0,0 +1,157 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+const (
+	defaultJobMaxAttempts = 5
+	defaultJobLease       = "5m"
+)
+
+// CreateIndexJob 创建索引任务
+func CreateIndexJob(ctx context.Context, job entity.KnowledgeIndexJobs) (id int64, err error) {
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = g.Cfg().MustGet(ctx, "indexJob.maxAttempts", defaultJobMaxAttempts).Int()
+	}
+	if job.NextRunAt == nil {
+		job.NextRunAt = gtime.Now()
+	}
+	result, err := dao.KnowledgeIndexJobs.Ctx(ctx).Data(job).Insert()
+	if err != nil {
+		g.Log().Errorf(ctx, "failed to create index job: %+v, Error: %v", job, err)
+		return 0, fmt.Errorf("failed to create index job: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetIndexJobById 根据ID获取索引任务
+func GetIndexJobById(ctx context.Context, id int64) (job entity.KnowledgeIndexJobs, err error) {
+	err = dao.KnowledgeIndexJobs.Ctx(ctx).Where("id", id).Scan(&job)
+	return
+}
+
+// GetIndexJobByDocId 获取文档最近一次的索引任务
+func GetIndexJobByDocId(ctx context.Context, docId int64) (job entity.KnowledgeIndexJobs, err error) {
+	err = dao.KnowledgeIndexJobs.Ctx(ctx).Where("knowledge_doc_id", docId).OrderDesc("id").Limit(1).Scan(&job)
+	return
+}
+
+// CountActiveIndexJobs 统计知识库中处于指定阶段、尚未结束（待处理或执行中）的任务数
+func CountActiveIndexJobs(ctx context.Context, knowledgeName string, stages ...string) (int, error) {
+	return dao.KnowledgeIndexJobs.Ctx(ctx).
+		Where("knowledge_base_name", knowledgeName).
+		WhereIn("stage", stages).
+		WhereIn("status", []int{int(v1.JobPending), int(v1.JobRunning)}).
+		Count()
+}
+
+// UpdateIndexJob 更新索引任务
+func UpdateIndexJob(ctx context.Context, id int64, data g.Map) error {
+	_, err := dao.KnowledgeIndexJobs.Ctx(ctx).Where("id", id).Data(data).Update()
+	if err != nil {
+		g.Log().Errorf(ctx, "index job update failed: ID=%d, Error: %v", id, err)
+	}
+	return err
+}
+
+// GetDueIndexJobs 获取已到执行时间的待处理任务
+func GetDueIndexJobs(ctx context.Context, limit int) (jobs []entity.KnowledgeIndexJobs, err error) {
+	err = dao.KnowledgeIndexJobs.Ctx(ctx).
+		Where("status", int(v1.JobPending)).
+		WhereLTE("next_run_at", gtime.Now()).
+		OrderAsc("next_run_at").
+		Limit(limit).
+		Scan(&jobs)
+	return
+}
+
+// ClaimIndexJob 抢占待处理任务，只有状态仍为 pending 时才会成功，避免同一任务被重复执行
+func ClaimIndexJob(ctx context.Context, id int64) (bool, error) {
+	result, err := dao.KnowledgeIndexJobs.Ctx(ctx).
+		Where("id", id).
+		Where("status", int(v1.JobPending)).
+		Data(g.Map{
+			"status":   int(v1.JobRunning),
+			"attempts": &gdb.Counter{Field: "attempts", Value: 1},
+		}).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ReclaimExpiredIndexJobs 将租约已过期的执行中任务重新放回队列。执行中的任务会定期刷新 updated_at 作为心跳，
+// 超过 lease 没有心跳说明执行它的进程已经退出
+func ReclaimExpiredIndexJobs(ctx context.Context, lease time.Duration) (int64, error) {
+	result, err := dao.KnowledgeIndexJobs.Ctx(ctx).
+		Where("status", int(v1.JobRunning)).
+		WhereLT("updated_at", gtime.Now().Add(-lease)).
+		Data(g.Map{
+			"status":      int(v1.JobPending),
+			"next_run_at": gtime.Now(),
+		}).
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// KeepIndexJobAlive 在后台按 interval 刷新执行中任务的心跳，直到返回的 stop 被调用
+func KeepIndexJobAlive(ctx context.Context, id int64, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = UpdateIndexJob(ctx, id, g.Map{"updated_at": gtime.Now()})
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// IndexJobLease 执行中任务的租约时长，超过该时长没有心跳的任务会被重新执行
+func IndexJobLease(ctx context.Context) time.Duration {
+	lease := g.Cfg().MustGet(ctx, "indexJob.leaseTimeout", defaultJobLease).Duration()
+	if lease <= 0 {
+		lease = 5 * time.Minute
+	}
+	return lease
+}
+
+// FailIndexJob 将任务标记为失败并记录错误，同时把对应文档（如有）置为失败状态
+func FailIndexJob(ctx context.Context, job entity.KnowledgeIndexJobs, cause error) error {
+	err := UpdateIndexJob(ctx, job.Id, g.Map{
+		"status":     int(v1.JobFailed),
+		"last_error": cause.Error(),
+	})
+	if job.KnowledgeDocId == 0 {
+		return err
+	}
+	if e := UpdateDocumentsStatus(ctx, job.KnowledgeDocId, int(v1.StatusFailed)); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetKnowledgeBaseByName 根据名称获取知识库，不存在时返回 found=false
+func GetKnowledgeBaseByName(ctx context.Context, name string) (kb entity.KnowledgeBase, found bool, err error) {
+	record, err := dao.KnowledgeBase.Ctx(ctx).Where("name", name).One()
+	if err != nil {
+		g.Log().Errorf(ctx, "failed to retrieve knowledge base: name=%s, Error: %v", name, err)
+		return kb, false, fmt.Errorf("failed to retrieve knowledge base: %w", err)
+	}
+	if record.IsEmpty() {
+		return kb, false, nil
+	}
+	err = record.Struct(&kb)
+	return kb, err == nil, err
+}
+
+// GetKnowledgeBasesWithIndex 获取使用独立索引的知识库
+func GetKnowledgeBasesWithIndex(ctx context.Context) (list []entity.KnowledgeBase, err error) {
+	err = dao.KnowledgeBase.Ctx(ctx).WhereNot("index_name", "").Scan(&list)
+	return
+}
+
+// SaveKnowledgeBaseIndex 记录知识库使用的索引及 embedding 配置
+func SaveKnowledgeBaseIndex(ctx context.Context, kb entity.KnowledgeBase) error {
+	_, err := dao.KnowledgeBase.Ctx(ctx).WherePri(kb.Id).Data(g.Map{
+		"embedding_model": kb.EmbeddingModel,
+		"embedding_dims":  kb.EmbeddingDims,
+		"similarity":      kb.Similarity,
+		"index_name":      kb.IndexName,
+	}).Update()
+	return err
+}
+
+// GetEnabledKnowledgeBaseNames 获取所有启用的知识库名称
+func GetEnabledKnowledgeBaseNames(ctx context.Context) (names []string, err error) {
+	values, err := dao.KnowledgeBase.Ctx(ctx).Where("status", v1.StatusOK).Fields("name").Array()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		names = append(names, v.String())
+	}
+	return
+}
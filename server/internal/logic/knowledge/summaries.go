@@ -0,0 +1,37 @@
+package knowledge
+
+import (
+	"context"
+
+	"github.com/everfid-ever/ThinkForge/internal/dao"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// GetDocumentSummary 获取文档指定版本的摘要，没有时返回 nil
+func GetDocumentSummary(ctx context.Context, docId int64, version string) (summary *entity.KnowledgeDocumentSummaries, err error) {
+	err = dao.KnowledgeDocumentSummaries.Ctx(ctx).
+		Where("knowledge_doc_id", docId).
+		Where("version", version).
+		Scan(&summary)
+	return
+}
+
+// SaveDocumentSummary 保存文档的摘要，同时删除该文档其他版本的摘要
+func SaveDocumentSummary(ctx context.Context, summary *entity.KnowledgeDocumentSummaries) error {
+	_, err := dao.KnowledgeDocumentSummaries.Ctx(ctx).Data(g.Map{
+		"knowledge_doc_id": summary.KnowledgeDocId,
+		"version":          summary.Version,
+		"summary":          summary.Summary,
+		"chunk_count":      summary.ChunkCount,
+		"token_count":      summary.TokenCount,
+	}).Save()
+	if err != nil {
+		return err
+	}
+	_, err = dao.KnowledgeDocumentSummaries.Ctx(ctx).
+		Where("knowledge_doc_id", summary.KnowledgeDocId).
+		WhereNot("version", summary.Version).
+		Delete()
+	return err
+}
@@ -0,0 +1,75 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/cache"
+)
+
+// 缓存类型
+const (
+	cacheKindRetriever = "retriever"
+	cacheKindChat      = "chat"
+)
+
+// defaultKnowledgeName 请求未指定知识库时使用的知识库名称：检索默认索引中知识库名称为空的文档，
+// 这些文档变化时同样以空名称失效缓存
+const defaultKnowledgeName = ""
+
+type retrieverCacheCtxKey struct{}
+
+// WithoutRetrieverCache 返回不读写检索接口缓存的 ctx，用于对话等自身已有缓存的调用方，避免两层缓存叠加
+func WithoutRetrieverCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrieverCacheCtxKey{}, true)
+}
+
+// RetrieverCacheEnabled 当前调用是否使用检索接口的缓存
+func RetrieverCacheEnabled(ctx context.Context) bool {
+	skip, _ := ctx.Value(retrieverCacheCtxKey{}).(bool)
+	return !skip
+}
+
+// RetrieverCacheKey 检索接口的缓存范围，问题之外的请求参数都参与区分
+func RetrieverCacheKey(req *v1.RetrieverReq) *cache.Key {
+	params := *req
+	params.Question = ""
+	return &cache.Key{
+		Kind:           cacheKindRetriever,
+		KnowledgeBases: cacheKnowledgeBases(req.KnowledgeName, req.KnowledgeBases),
+		Params:         params,
+		Question:       req.Question,
+	}
+}
+
+// ChatCacheKey 对话接口的缓存范围，问题与会话 ID 之外的请求参数都参与区分
+func ChatCacheKey(req *v1.ChatReq) *cache.Key {
+	params := *req
+	params.Question = ""
+	params.ConvID = ""
+	return &cache.Key{
+		Kind:           cacheKindChat,
+		KnowledgeBases: cacheKnowledgeBases(req.KnowledgeName, req.KnowledgeBases),
+		Params:         params,
+		Question:       req.Question,
+	}
+}
+
+// KnowledgeBaseNames 返回请求涉及的全部知识库名称
+func KnowledgeBaseNames(knowledgeName string, kbs []*v1.KnowledgeWeight) []string {
+	merged := mergeKnowledgeBases(knowledgeName, kbs)
+	names := make([]string, 0, len(merged))
+	for _, kb := range merged {
+		names = append(names, kb.Name)
+	}
+	return names
+}
+
+// cacheKnowledgeBases 返回缓存条目涉及的知识库，未指定知识库时使用默认知识库，保证文档变化后能失效
+func cacheKnowledgeBases(knowledgeName string, kbs []*v1.KnowledgeWeight) []string {
+	names := KnowledgeBaseNames(knowledgeName, kbs)
+	if len(names) == 0 {
+		return []string{defaultKnowledgeName}
+	}
+	return names
+}
@@ -0,0 +1,227 @@
+package rag
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/core/indexer"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// filterFields 过滤条件中的字段名与索引字段的对应关系
+var filterFields = map[string]string{
+	v1.FilterFieldFileName:   common.FieldFileName,
+	v1.FilterFieldExtension:  common.FieldExtension,
+	v1.FilterFieldH1:         common.Title1,
+	v1.FilterFieldH2:         common.Title2,
+	v1.FilterFieldH3:         common.Title3,
+	v1.FilterFieldUploadedAt: common.FieldUploadedAt,
+	v1.FilterFieldDocumentID: common.FieldDocumentID,
+}
+
+// difyFields Dify 内置元数据与索引字段的对应关系
+var difyFields = map[string]string{
+	"document_name":    common.FieldFileName,
+	"upload_date":      common.FieldUploadedAt,
+	"last_update_date": common.FieldUploadedAt,
+}
+
+// BuildFilter 将接口中的元数据过滤条件转换为向量存储的过滤条件，filters 与 Dify 的 metadata_condition 同时满足。
+// 没有任何条件时返回 nil
+func BuildFilter(filters []*v1.MetadataFilter, cond *v1.MetadataCondition) (*vectorstore.Filter, error) {
+	filter := &vectorstore.Filter{}
+	for _, f := range filters {
+		c, err := toCondition(f)
+		if err != nil {
+			return nil, err
+		}
+		filter.And(c)
+	}
+	if cond != nil && len(cond.Conditions) > 0 {
+		subs := make([]*vectorstore.Filter, 0, len(cond.Conditions))
+		for _, dc := range cond.Conditions {
+			sub, err := difyFilter(dc)
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, sub)
+		}
+		if strings.EqualFold(cond.LogicalOperator, "or") {
+			filter.Nest((&vectorstore.Filter{}).Or(subs...))
+		} else {
+			filter.Nest(subs...)
+		}
+	}
+	if filter.Empty() {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+func toCondition(f *v1.MetadataFilter) (c vectorstore.Condition, err error) {
+	field, ok := filterFields[f.Field]
+	if !ok {
+		return c, invalidFilter("unknown filter field: %s", f.Field)
+	}
+	switch f.Op {
+	case string(vectorstore.OpEq):
+		if f.Value == nil {
+			return c, invalidFilter("value of %s is required", f.Field)
+		}
+		v, err := filterValue(field, f.Value, false)
+		return vectorstore.Eq(field, v), err
+	case string(vectorstore.OpIn):
+		if len(f.Values) == 0 {
+			return c, invalidFilter("values of %s is required", f.Field)
+		}
+		values := make([]string, 0, len(f.Values))
+		for _, value := range f.Values {
+			v, err := filterValue(field, value, false)
+			if err != nil {
+				return c, err
+			}
+			values = append(values, v)
+		}
+		return vectorstore.In(field, values), nil
+	case string(vectorstore.OpRange):
+		if !orderedField(field) {
+			return c, invalidFilter("range is not supported on %s", f.Field)
+		}
+		if f.Gte == nil && f.Lte == nil {
+			return c, invalidFilter("gte or lte of %s is required", f.Field)
+		}
+		var gte, lte any
+		if f.Gte != nil {
+			if gte, err = filterValue(field, f.Gte, false); err != nil {
+				return
+			}
+		}
+		if f.Lte != nil {
+			if lte, err = filterValue(field, f.Lte, true); err != nil {
+				return
+			}
+		}
+		return vectorstore.Range(field, gte, lte), nil
+	case string(vectorstore.OpPrefix):
+		return vectorstore.Prefix(field, gconv.String(f.Value)), nil
+	default:
+		return c, invalidFilter("unknown filter op: %s", f.Op)
+	}
+}
+
+// difyFilter 转换 Dify 的单个条件，多个 name 任一满足即可
+func difyFilter(dc *v1.DifyCondition) (*vectorstore.Filter, error) {
+	if len(dc.Name) == 0 {
+		return nil, invalidFilter("name of metadata condition is required")
+	}
+	subs := make([]*vectorstore.Filter, 0, len(dc.Name))
+	for _, name := range dc.Name {
+		field, ok := filterFields[name]
+		if !ok {
+			if field, ok = difyFields[name]; !ok {
+				return nil, invalidFilter("unknown metadata name: %s", name)
+			}
+		}
+		sub, err := difyFieldFilter(field, dc.ComparisonOperator, dc.Value)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return (&vectorstore.Filter{}).Or(subs...), nil
+}
+
+func difyFieldFilter(field, op string, value any) (*vectorstore.Filter, error) {
+	filter := &vectorstore.Filter{}
+	switch op {
+	case "empty":
+		return filter.Not(vectorstore.Exists(field)), nil
+	case "not empty":
+		return filter.And(vectorstore.Exists(field)), nil
+	}
+	if value == nil {
+		return nil, invalidFilter("value of %s %s is required", field, op)
+	}
+	// 字符串匹配按原值比较
+	raw := gconv.String(value)
+	switch op {
+	case "contains":
+		return filter.And(vectorstore.Contains(field, raw)), nil
+	case "not contains":
+		return filter.Not(vectorstore.Contains(field, raw)), nil
+	case "start with":
+		return filter.And(vectorstore.Prefix(field, raw)), nil
+	case "end with":
+		return filter.And(vectorstore.Suffix(field, raw)), nil
+	}
+	switch op {
+	case ">", "<", "≥", "≤", "after", "before":
+		// 其余元数据以字符串保存，按字典序比较没有意义（如 "10" < "9"）
+		if !orderedField(field) {
+			return nil, invalidFilter("comparison operator %s is not supported on %s", op, field)
+		}
+	}
+	// "≤" 与 "after" 按当天结束计算，使 "≤ 2025-12-31" 包含当天、"after 2025-12-31" 不包含当天
+	v, err := filterValue(field, value, op == "≤" || op == ">" || op == "after")
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "is", "=":
+		filter.And(vectorstore.Eq(field, v))
+	case "is not", "≠":
+		filter.Not(vectorstore.Eq(field, v))
+	case ">", "after":
+		filter.And(vectorstore.Gt(field, v))
+	case "<", "before":
+		filter.And(vectorstore.Lt(field, v))
+	case "≥":
+		filter.And(vectorstore.Range(field, v, nil))
+	case "≤":
+		filter.And(vectorstore.Range(field, nil, v))
+	default:
+		return nil, invalidFilter("unknown comparison operator: %s", op)
+	}
+	return filter, nil
+}
+
+// orderedField 字段值的字典序是否与实际大小一致，只有这类字段支持范围与大小比较。
+// 上传时间统一保存为 UTC RFC3339 格式，字典序即时间先后
+func orderedField(field string) bool {
+	return field == common.FieldUploadedAt
+}
+
+// filterValue 统一过滤值的格式：索引中的元数据都以字符串保存，上传时间转换为 UTC RFC3339 格式。
+// endOfDay 为 true 时，只有日期的上传时间取当天最后一秒，用作闭区间上界或开区间下界
+func filterValue(field string, value any, endOfDay bool) (string, error) {
+	if field != common.FieldUploadedAt {
+		return gconv.String(value), nil
+	}
+	s := strings.TrimSpace(gconv.String(value))
+	// Dify 的时间类型元数据为 Unix 秒级时间戳
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil && ts > 0 {
+		return indexer.FormatUploadedAt(time.Unix(ts, 0)), nil
+	}
+	t, err := gtime.StrToTime(s)
+	if err != nil {
+		return "", invalidFilter("invalid time of %s: %v", v1.FilterFieldUploadedAt, value)
+	}
+	if endOfDay && len(s) <= len(time.DateOnly) {
+		t = t.EndOfDay()
+	}
+	return indexer.FormatUploadedAt(t.Time), nil
+}
+
+func invalidFilter(format string, args ...any) error {
+	return gerror.NewCodef(gcode.CodeInvalidParameter, format, args...)
+}
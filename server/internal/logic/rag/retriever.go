@@ -1,13 +1,25 @@
 package rag
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/elastic/go-elasticsearch/v8"         // ElasticSearch v8 官方客户端
 	"github.com/everfid-ever/ThinkForge/core"        // RAG 核心逻辑封装
 	"github.com/everfid-ever/ThinkForge/core/config" // RAG 配置结构定义
-	"github.com/gogf/gf/v2/frame/g"                  // GoFrame 配置与日志模块
+	"github.com/everfid-ever/ThinkForge/core/vectorstore"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore/es"
+	"github.com/everfid-ever/ThinkForge/core/vectorstore/memory"
+	"github.com/gogf/gf/v2/frame/g" // GoFrame 配置与日志模块
 	"github.com/gogf/gf/v2/os/gctx"
 )
 
+// 向量存储类型，对应配置 vectorStore.type
+const (
+	storeTypeES     = "es"     // Elasticsearch（默认）
+	storeTypeMemory = "memory" // 内置存储，配置 vectorStore.path 后持久化到磁盘
+)
+
 //
 // ===================== 全局变量定义 =====================
 //
@@ -22,33 +34,32 @@ var ragSvr = &core.Rag{}
 
 // init() 在包导入时自动执行。
 // 功能：
-// 1. 读取配置文件中的 ElasticSearch 与 Embedding 模型配置。
-// 2. 创建 ES 客户端。
+// 1. 读取配置文件中的向量存储与 Embedding 模型配置。
+// 2. 创建向量存储（ElasticSearch 或内置存储）。
 // 3. 创建 core.Rag 实例（封装向量检索与语义嵌入功能）。
 // 4. 若任意步骤失败，则打印错误日志并停止后续初始化。
 func init() {
 	ctx := gctx.New()
 
-	// Step 1: 创建 ElasticSearch 客户端
-	client, err := elasticsearch.NewClient(elasticsearch.Config{
-		Username: g.Cfg().MustGet(ctx, "es.username").String(),
-		Password: g.Cfg().MustGet(ctx, "es.password").String(),
-		Addresses: []string{
-			g.Cfg().MustGet(ctx, "es.address").String(), // 从配置读取 ES 地址
-		},
-	})
+	// Step 1: 创建向量存储
+	store, err := newVectorStore(ctx)
 	if err != nil {
-		g.Log().Fatalf(ctx, "NewClient of es8 failed, err=%v", err)
+		g.Log().Fatalf(ctx, "create vector store failed, err=%v", err)
 		return
 	}
 
 	// Step 2: 初始化 core.Rag 服务
 	ragSvr, err = core.New(ctx, &config.Config{
-		Client:    client,                                             // ES 客户端实例
+		Store:     store,                                              // 向量存储实例
 		IndexName: g.Cfg().MustGet(ctx, "es.indexName").String(),      // 索引名（如 thinkforge_docs）
 		APIKey:    g.Cfg().MustGet(ctx, "embedding.apiKey").String(),  // 向量嵌入 API Key
 		BaseURL:   g.Cfg().MustGet(ctx, "embedding.baseURL").String(), // 向量 API Base URL
 		ChatModel: g.Cfg().MustGet(ctx, "embedding.model").String(),   // 嵌入模型名（如 text-embedding-3-small）
+
+		// 默认向量配置，知识库未单独指定时使用；维度未配置时为 1024，相似度默认 cosine
+		EmbeddingModel: g.Cfg().MustGet(ctx, "embedding.model").String(),
+		Dims:           g.Cfg().MustGet(ctx, "embedding.dims").Int(),
+		Similarity:     g.Cfg().MustGet(ctx, "embedding.similarity").String(),
 	})
 	if err != nil {
 		g.Log().Fatalf(ctx, "New of rag failed, err=%v", err)
@@ -56,6 +67,28 @@ func init() {
 	}
 }
 
+// newVectorStore 按配置 vectorStore.type 创建向量存储
+func newVectorStore(ctx context.Context) (vectorstore.VectorStore, error) {
+	switch typ := g.Cfg().MustGet(ctx, "vectorStore.type", storeTypeES).String(); typ {
+	case storeTypeMemory:
+		return memory.New(g.Cfg().MustGet(ctx, "vectorStore.path").String())
+	case storeTypeES:
+		client, err := elasticsearch.NewClient(elasticsearch.Config{
+			Username: g.Cfg().MustGet(ctx, "es.username").String(),
+			Password: g.Cfg().MustGet(ctx, "es.password").String(),
+			Addresses: []string{
+				g.Cfg().MustGet(ctx, "es.address").String(), // 从配置读取 ES 地址
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return es.New(client), nil
+	default:
+		return nil, fmt.Errorf("unknown vector store type: %s", typ)
+	}
+}
+
 //
 // ===================== 外部访问接口 =====================
 //
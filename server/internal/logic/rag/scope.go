@@ -0,0 +1,95 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core"
+	"github.com/everfid-ever/ThinkForge/core/agent"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// KnowledgeBases 把接口中的知识库列表转换为跨知识库检索的参数，knowledgeName 排在最前并与列表去重。
+// 列表为空时返回 nil，按 knowledgeName 单知识库检索
+func KnowledgeBases(knowledgeName string, kbs []*v1.KnowledgeWeight) []*core.KnowledgeWeight {
+	if len(kbs) == 0 {
+		return nil
+	}
+	merged := mergeKnowledgeBases(knowledgeName, kbs)
+	res := make([]*core.KnowledgeWeight, 0, len(merged))
+	for _, kb := range merged {
+		res = append(res, &core.KnowledgeWeight{Name: kb.Name, Weight: kb.Weight})
+	}
+	return res
+}
+
+// WithKnowledgeScope 把启用的知识库名称放入 ctx，供意图分类识别问题中提到的知识库
+func WithKnowledgeScope(ctx context.Context) context.Context {
+	names, err := knowledge.GetEnabledKnowledgeBaseNames(ctx)
+	if err != nil {
+		g.Log().Warningf(ctx, "GetEnabledKnowledgeBaseNames failed, err=%v", err)
+		return ctx
+	}
+	return agent.WithKnowledgeBases(ctx, names)
+}
+
+// ScopeKnowledgeBases 按意图中的范围约束调整检索的知识库：问题中提到的知识库都在请求范围内时缩小到这些知识库，
+// 否则把提到的知识库加入检索范围（权重为 1）。只剩一个知识库时返回其名称与空列表，按单知识库检索
+func ScopeKnowledgeBases(knowledgeName string, kbs []*v1.KnowledgeWeight, scope *agent.ScopeConstraint) (string, []*v1.KnowledgeWeight) {
+	if scope == nil || len(scope.KnowledgeBases) == 0 {
+		return knowledgeName, kbs
+	}
+	merged := mergeKnowledgeBases(knowledgeName, kbs)
+	requested := make(map[string]*v1.KnowledgeWeight, len(merged))
+	for _, kb := range merged {
+		requested[kb.Name] = kb
+	}
+	var narrowed []*v1.KnowledgeWeight
+	for _, name := range scope.KnowledgeBases {
+		kb, ok := requested[name]
+		if !ok {
+			narrowed = nil
+			break
+		}
+		narrowed = append(narrowed, kb)
+	}
+	scoped := narrowed
+	if scoped == nil {
+		scoped = merged
+		for _, name := range scope.KnowledgeBases {
+			if _, ok := requested[name]; !ok {
+				scoped = append(scoped, &v1.KnowledgeWeight{Name: name, Weight: 1})
+			}
+		}
+	}
+	if len(scoped) == 1 {
+		return scoped[0].Name, nil
+	}
+	return "", scoped
+}
+
+// mergeKnowledgeBases 合并 knowledgeName 与知识库列表，同名的知识库只保留第一个
+func mergeKnowledgeBases(knowledgeName string, kbs []*v1.KnowledgeWeight) []*v1.KnowledgeWeight {
+	res := make([]*v1.KnowledgeWeight, 0, len(kbs)+1)
+	seen := make(map[string]bool, len(kbs)+1)
+	if knowledgeName != "" {
+		weight := 1.0
+		for _, kb := range kbs {
+			if kb.Name == knowledgeName && kb.Weight > 0 {
+				weight = kb.Weight
+				break
+			}
+		}
+		res = append(res, &v1.KnowledgeWeight{Name: knowledgeName, Weight: weight})
+		seen[knowledgeName] = true
+	}
+	for _, kb := range kbs {
+		if kb == nil || kb.Name == "" || seen[kb.Name] {
+			continue
+		}
+		seen[kb.Name] = true
+		res = append(res, kb)
+	}
+	return res
+}
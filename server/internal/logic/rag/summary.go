@@ -0,0 +1,220 @@
+package rag
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/citation"
+	"github.com/everfid-ever/ThinkForge/core/common"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/everfid-ever/ThinkForge/internal/model/entity"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// defaultSummaryMaxDocuments 一个问题最多总结的文档数
+const defaultSummaryMaxDocuments = 3
+
+// SummarizeDocuments 解析问题要总结的文档，返回每篇文档的全文摘要，元数据与 chunk 相同以便引用。
+// 没有可总结的文档时返回空
+func SummarizeDocuments(ctx context.Context, question string, knowledgeNames []string,
+	retrieve func(ctx context.Context, query string) ([]*schema.Document, error)) ([]*schema.Document, error) {
+	documents, err := ResolveDocuments(ctx, question, knowledgeNames, retrieve)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*schema.Document, 0, len(documents))
+	for _, doc := range documents {
+		summary, err := DocumentSummary(ctx, doc)
+		if err != nil {
+			return nil, fmt.Errorf("summarize document %d failed: %w", doc.Id, err)
+		}
+		if summary == nil {
+			continue
+		}
+		res = append(res, &schema.Document{
+			Content: summary.Summary,
+			MetaData: map[string]any{
+				common.FieldDocumentID: doc.Id,
+				common.FieldFileName:   doc.FileName,
+				common.KnowledgeName:   doc.KnowledgeBaseName,
+			},
+		})
+	}
+	return res, nil
+}
+
+// ResolveDocuments 确定问题要总结的文档：优先使用问题中提到文件名的文档，
+// 没有提到时检索问题，取命中 chunk 最多的文档（命中数不少于最多者一半的文档一并总结）
+func ResolveDocuments(ctx context.Context, question string, knowledgeNames []string,
+	retrieve func(ctx context.Context, query string) ([]*schema.Document, error)) ([]entity.KnowledgeDocuments, error) {
+	maxDocs := g.Cfg().MustGet(ctx, "summarize.maxDocuments", defaultSummaryMaxDocuments).Int()
+	var candidates []entity.KnowledgeDocuments
+	if len(knowledgeNames) > 0 {
+		var err error
+		candidates, err = knowledge.GetDocumentsByKnowledgeNames(ctx, knowledgeNames, int(v1.StatusActive))
+		if err != nil {
+			return nil, err
+		}
+		if matched := matchDocumentNames(question, candidates, maxDocs); len(matched) > 0 {
+			return matched, nil
+		}
+	}
+
+	chunks, err := retrieve(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	ids := rankDocuments(ctx, chunks, maxDocs)
+	res := make([]entity.KnowledgeDocuments, 0, len(ids))
+	for _, id := range ids {
+		i := slices.IndexFunc(candidates, func(d entity.KnowledgeDocuments) bool { return d.Id == id })
+		if i >= 0 {
+			res = append(res, candidates[i])
+			continue
+		}
+		// 不在候选中的文档（如按 chunk 查到的其他知识库的文档）单独查询
+		doc, err := knowledge.GetDocumentById(ctx, id)
+		if err != nil || doc.Id == 0 {
+			continue
+		}
+		res = append(res, doc)
+	}
+	return res, nil
+}
+
+// matchDocumentNames 返回问题中提到文件名（不区分大小写，可省略扩展名）的文档，较长的文件名优先，
+// 被更长的已匹配文件名包含的不再计入
+func matchDocumentNames(question string, docs []entity.KnowledgeDocuments, limit int) []entity.KnowledgeDocuments {
+	type match struct {
+		doc  entity.KnowledgeDocuments
+		stem string
+	}
+	q := strings.ToLower(question)
+	var matches []match
+	for _, doc := range docs {
+		name := strings.ToLower(doc.FileName)
+		stem := strings.TrimSpace(strings.TrimSuffix(name, path.Ext(name)))
+		if utf8.RuneCountInString(stem) < 2 || !strings.Contains(q, stem) {
+			continue
+		}
+		matches = append(matches, match{doc: doc, stem: stem})
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return cmp.Compare(len(b.stem), len(a.stem)) })
+	var res []entity.KnowledgeDocuments
+	var kept []string
+	for _, m := range matches {
+		if len(res) >= limit {
+			break
+		}
+		if slices.ContainsFunc(kept, func(s string) bool { return s != m.stem && strings.Contains(s, m.stem) }) {
+			continue
+		}
+		kept = append(kept, m.stem)
+		res = append(res, m.doc)
+	}
+	return res
+}
+
+// rankDocuments 按命中 chunk 数排序检索结果所属的文档，返回命中数不少于最多者一半的前 limit 个。
+// 元数据中没有文档 ID 的历史 chunk 通过 chunk_id 查询
+func rankDocuments(ctx context.Context, chunks []*schema.Document, limit int) []int64 {
+	var (
+		order    []int64
+		hits     = map[int64]int{}
+		chunkIds []string
+	)
+	add := func(id int64) {
+		if _, ok := hits[id]; !ok {
+			order = append(order, id)
+		}
+		hits[id]++
+	}
+	for _, chunk := range chunks {
+		if id := citation.Source(chunk).KnowledgeDocID; id != 0 {
+			add(id)
+		} else if chunk.ID != "" {
+			chunkIds = append(chunkIds, chunk.ID)
+		}
+	}
+	if len(chunkIds) > 0 {
+		list, err := knowledge.GetChunksByChunkIds(ctx, chunkIds, "chunk_id", "knowledge_doc_id")
+		if err != nil {
+			g.Log().Warningf(ctx, "get chunks for summary failed, err=%v", err)
+		}
+		for _, c := range list {
+			add(c.KnowledgeDocId)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int64) int { return cmp.Compare(hits[b], hits[a]) })
+	var res []int64
+	for _, id := range order {
+		if len(res) >= limit || hits[id]*2 < hits[order[0]] {
+			break
+		}
+		res = append(res, id)
+	}
+	return res
+}
+
+// DocumentSummary 按顺序读取文档启用的全部 chunk 生成摘要。摘要按文档版本（chunk 内容的哈希）缓存，
+// 文档重新解析或 chunk 被编辑、启停后重新生成。文档没有内容时返回 nil
+func DocumentSummary(ctx context.Context, doc entity.KnowledgeDocuments) (*entity.KnowledgeDocumentSummaries, error) {
+	chunks, err := knowledge.GetAllChunksByDocId(ctx, doc.Id, "id", "chunk_id", "content", "status")
+	if err != nil {
+		return nil, err
+	}
+	chunks = slices.DeleteFunc(chunks, func(c entity.KnowledgeChunks) bool {
+		return c.Status == v1.ChunkStatusDisabled || strings.TrimSpace(c.Content) == ""
+	})
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	version := documentVersion(chunks)
+	cached, err := knowledge.GetDocumentSummary(ctx, doc.Id, version)
+	if err != nil {
+		g.Log().Warningf(ctx, "get summary of document %d failed, err=%v", doc.Id, err)
+	}
+	if cached != nil {
+		return cached, nil
+	}
+
+	contents := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		contents = append(contents, c.Content)
+	}
+	result, err := GetRagSvr().Summarizer().Summarize(ctx, doc.FileName, contents)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Infof(ctx, "📝 Summarized document %d (%s): %d chunks, %d tokens, %d calls in %d rounds",
+		doc.Id, doc.FileName, len(chunks), result.Tokens, result.Calls, result.Rounds)
+	summary := &entity.KnowledgeDocumentSummaries{
+		KnowledgeDocId: doc.Id,
+		Version:        version,
+		Summary:        result.Summary,
+		ChunkCount:     len(chunks),
+		TokenCount:     result.Tokens,
+	}
+	if err = knowledge.SaveDocumentSummary(ctx, summary); err != nil {
+		g.Log().Warningf(ctx, "save summary of document %d failed, err=%v", doc.Id, err)
+	}
+	return summary, nil
+}
+
+// documentVersion 文档版本：按顺序对 chunk 的 ID 与内容取哈希
+func documentVersion(chunks []entity.KnowledgeChunks) string {
+	h := sha256.New()
+	for _, c := range chunks {
+		fmt.Fprintf(h, "%s\x00%s\x00", c.ChunkId, c.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
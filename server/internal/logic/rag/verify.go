@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"context"
+
+	v1 "github.com/everfid-ever/ThinkForge/api/rag/v1"
+	"github.com/everfid-ever/ThinkForge/core/verifier"
+	"github.com/everfid-ever/ThinkForge/internal/logic/knowledge"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// actionRank 处理方式的严格程度，涉及多个知识库时取最严格的
+var actionRank = map[string]int{
+	verifier.ActionOff:        0,
+	verifier.ActionWarn:       1,
+	verifier.ActionRegenerate: 2,
+}
+
+// VerifyPolicy 返回回答校验策略：全局配置 verify.* 为默认值，知识库单独配置时覆盖；涉及多个知识库时
+// 取最严格的处理方式与最高的阈值。force 为 true 时即使配置为 off 也校验并返回警告。返回 nil 表示不校验
+func VerifyPolicy(ctx context.Context, knowledgeName string, kbs []*v1.KnowledgeWeight, force bool) *verifier.Policy {
+	def := verifier.Policy{
+		Action:    g.Cfg().MustGet(ctx, "verify.action", verifier.ActionOff).String(),
+		Threshold: g.Cfg().MustGet(ctx, "verify.threshold", verifier.DefaultThreshold).Float64(),
+	}
+	var policy *verifier.Policy
+	for _, name := range KnowledgeBaseNames(knowledgeName, kbs) {
+		p := def
+		kb, found, err := knowledge.GetKnowledgeBaseByName(ctx, name)
+		if err != nil {
+			g.Log().Warningf(ctx, "get verify policy of knowledge base %s failed, use the default, err=%v", name, err)
+		} else if found {
+			if kb.VerifyAction != "" {
+				p.Action = kb.VerifyAction
+			}
+			if kb.VerifyThreshold > 0 {
+				p.Threshold = kb.VerifyThreshold
+			}
+		}
+		if policy == nil {
+			policy = &p
+			continue
+		}
+		if actionRank[p.Action] > actionRank[policy.Action] {
+			policy.Action = p.Action
+		}
+		policy.Threshold = max(policy.Threshold, p.Threshold)
+	}
+	if policy == nil {
+		policy = &def
+	}
+	if actionRank[policy.Action] == 0 {
+		if !force {
+			return nil
+		}
+		policy.Action = verifier.ActionWarn
+	}
+	return policy
+}
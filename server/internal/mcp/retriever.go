@@ -12,10 +12,23 @@ import (
 // RetrieverParam 定义“检索知识库文档”的输入参数结构。
 // 该结构由 MCP 协议自动解析并传入 HandleRetriever。
 type RetrieverParam struct {
-	Question      string  `json:"question" description:"Questions asked by users" required:"true"`
-	KnowledgeName string  `json:"knowledge_name" description:"For the knowledge base name, please first retrieve the list using getKnowledgeBaseList and then check if there is a knowledge base that matches the user's suggested keywords." required:"true"`
-	TopK          int     `json:"top_k" description:"The default number of search results is 5." required:"false"`               // 默认为5
-	Score         float64 `json:"score"  description:"The score threshold for search results defaults to 0.2." required:"false"` // 默认为0.2
+	Question        string             `json:"question" description:"Questions asked by users" required:"true"`
+	KnowledgeName   string             `json:"knowledge_name" description:"For the knowledge base name, please first retrieve the list using getKnowledgeBaseList and then check if there is a knowledge base that matches the user's suggested keywords." required:"true"`
+	TopK            int                `json:"top_k" description:"The default number of search results is 5." required:"false"`               // 默认为5
+	Score           float64            `json:"score"  description:"The score threshold for search results defaults to 0.2." required:"false"` // 默认为0.2
+	Filters         []*RetrieverFilter `json:"filters" description:"Metadata filters, all of them must match." required:"false"`
+	KnowledgeBases  []string           `json:"knowledge_bases" description:"Other knowledge bases to search together with knowledge_name, results are merged by normalized score." required:"false"` // 跨知识库检索时的其他知识库，权重相同
+	RewriteStrategy string             `json:"rewrite_strategy" description:"Query rewrite strategy: none, keyword, hyde, multi_query or step_back. Uses the server config by default." required:"false"`
+}
+
+// RetrieverFilter 元数据过滤条件，对应 v1.MetadataFilter（MCP 的参数定义不支持 any 类型，取值统一用字符串）
+type RetrieverFilter struct {
+	Field  string   `json:"field" description:"Metadata field: file_name, extension, h1, h2, h3, uploaded_at or document_id." required:"true"`
+	Op     string   `json:"op" description:"Operator: eq, in, range or prefix." required:"true"`
+	Value  string   `json:"value" description:"Value of eq and prefix." required:"false"`
+	Values []string `json:"values" description:"Values of in." required:"false"`
+	Gte    string   `json:"gte" description:"Inclusive lower bound of range, e.g. 2025-01-01 for uploaded_at." required:"false"`
+	Lte    string   `json:"lte" description:"Inclusive upper bound of range." required:"false"`
 }
 
 // GetRetrieverTool 定义一个 MCP 工具 “retriever”
@@ -39,10 +52,13 @@ func HandleRetriever(ctx context.Context, toolReq *protocol.CallToolRequest) (re
 		return nil, err
 	}
 	retriever, err := c.Retriever(ctx, &v1.RetrieverReq{
-		Question:      req.Question,
-		TopK:          req.TopK,
-		Score:         req.Score,
-		KnowledgeName: req.KnowledgeName,
+		Question:        req.Question,
+		TopK:            req.TopK,
+		Score:           req.Score,
+		KnowledgeName:   req.KnowledgeName,
+		KnowledgeBases:  toKnowledgeWeights(req.KnowledgeBases),
+		Filters:         toMetadataFilters(req.Filters),
+		RewriteStrategy: req.RewriteStrategy,
 	})
 	if err != nil {
 		return nil, err
@@ -64,3 +80,32 @@ func HandleRetriever(ctx context.Context, toolReq *protocol.CallToolRequest) (re
 		},
 	}, nil
 }
+
+func toMetadataFilters(filters []*RetrieverFilter) []*v1.MetadataFilter {
+	res := make([]*v1.MetadataFilter, 0, len(filters))
+	for _, f := range filters {
+		mf := &v1.MetadataFilter{Field: f.Field, Op: f.Op}
+		if f.Value != "" {
+			mf.Value = f.Value
+		}
+		for _, v := range f.Values {
+			mf.Values = append(mf.Values, v)
+		}
+		if f.Gte != "" {
+			mf.Gte = f.Gte
+		}
+		if f.Lte != "" {
+			mf.Lte = f.Lte
+		}
+		res = append(res, mf)
+	}
+	return res
+}
+
+func toKnowledgeWeights(names []string) []*v1.KnowledgeWeight {
+	res := make([]*v1.KnowledgeWeight, 0, len(names))
+	for _, name := range names {
+		res = append(res, &v1.KnowledgeWeight{Name: name})
+	}
+	return res
+}
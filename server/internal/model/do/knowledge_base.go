@@ -11,12 +11,24 @@ import (
 
 // KnowledgeBase is the golang structure of table knowledge_base for DAO operations like Where/Data.
 type KnowledgeBase struct {
-	g.Meta      `orm:"table:knowledge_base, do:true"`
-	Id          interface{} // 主键ID
-	Name        interface{} // 知识库名称
-	Description interface{} // 知识库描述
-	Category    interface{} // 知识库分类
-	Status      interface{} // 状态：0-禁用，1-启用
-	CreateTime  *gtime.Time // 创建时间
-	UpdateTime  *gtime.Time // 更新时间
+	g.Meta          `orm:"table:knowledge_base, do:true"`
+	Id              interface{} // 主键ID
+	Name            interface{} // 知识库名称
+	Description     interface{} // 知识库描述
+	Category        interface{} // 知识库分类
+	Status          interface{} // 状态：0-禁用，1-启用
+	EmbeddingModel  interface{} // 向量模型
+	EmbeddingDims   interface{} // 向量维度
+	Similarity      interface{} // 向量相似度
+	IndexName       interface{} // 索引名称
+	Splitter        interface{} // 切分方式
+	ChunkSize       interface{} // 切分长度
+	ChunkOverlap    interface{} // 切分重叠长度
+	Separators      interface{} // 切分分隔符
+	HeaderLevels    interface{} // Markdown 标题切分层级
+	MergeLength     interface{} // Markdown 合并长度
+	VerifyAction    interface{} // 回答校验的处理方式
+	VerifyThreshold interface{} // 回答校验的得分阈值
+	CreateTime      *gtime.Time // 创建时间
+	UpdateTime      *gtime.Time // 更新时间
 }
@@ -10,11 +10,23 @@ import (
 
 // KnowledgeBase is the golang structure for table knowledge_base.
 type KnowledgeBase struct {
-	Id          int64       `json:"id"          orm:"id"          description:"Primary Key ID"`         	   // 主键ID
-	Name        string      `json:"name"        orm:"name"        description:"Knowledge base name"`           // 知识库名称
-	Description string      `json:"description" orm:"description" description:"Knowledge base description"`    // 知识库描述
-	Category    string      `json:"category"    orm:"category"    description:"Knowledge base category"`       // 知识库分类
-	Status      int         `json:"status"      orm:"status"      description:"Status: 0-disabled, 1-enabled"` // 状态：0-禁用，1-启用
-	CreateTime  *gtime.Time `json:"createTime"  orm:"create_time" description:"Creation time"`        		   // 创建时间
-	UpdateTime  *gtime.Time `json:"updateTime"  orm:"update_time" description:"Update time"`  	  		       // 更新时间
+	Id              int64       `json:"id"          orm:"id"          description:"Primary Key ID"`                   // 主键ID
+	Name            string      `json:"name"        orm:"name"        description:"Knowledge base name"`              // 知识库名称
+	Description     string      `json:"description" orm:"description" description:"Knowledge base description"`       // 知识库描述
+	Category        string      `json:"category"    orm:"category"    description:"Knowledge base category"`          // 知识库分类
+	Status          int         `json:"status"      orm:"status"      description:"Status: 0-disabled, 1-enabled"`    // 状态：0-禁用，1-启用
+	EmbeddingModel  string      `json:"embeddingModel" orm:"embedding_model" description:"Embedding model"`           // 向量模型
+	EmbeddingDims   int         `json:"embeddingDims"  orm:"embedding_dims"  description:"Embedding dimensions"`      // 向量维度
+	Similarity      string      `json:"similarity"     orm:"similarity"      description:"Similarity metric"`         // 向量相似度
+	IndexName       string      `json:"indexName"      orm:"index_name"      description:"Index name"`                // 索引名称
+	Splitter        string      `json:"splitter"       orm:"splitter"        description:"Splitter type"`             // 切分方式
+	ChunkSize       int         `json:"chunkSize"      orm:"chunk_size"      description:"Chunk size"`                // 切分长度
+	ChunkOverlap    int         `json:"chunkOverlap"   orm:"chunk_overlap"   description:"Chunk overlap"`             // 切分重叠长度
+	Separators      string      `json:"separators"     orm:"separators"      description:"Separators (JSON)"`         // 切分分隔符
+	HeaderLevels    int         `json:"headerLevels"   orm:"header_levels"   description:"Markdown header levels"`    // Markdown 标题切分层级
+	MergeLength     int         `json:"mergeLength"    orm:"merge_length"    description:"Markdown merge length"`     // Markdown 合并长度
+	VerifyAction    string      `json:"verifyAction"    orm:"verify_action"    description:"Answer verify action"`    // 回答校验的处理方式
+	VerifyThreshold float64     `json:"verifyThreshold" orm:"verify_threshold" description:"Answer verify threshold"` // 回答校验的得分阈值
+	CreateTime      *gtime.Time `json:"createTime"  orm:"create_time" description:"Creation time"`                    // 创建时间
+	UpdateTime      *gtime.Time `json:"updateTime"  orm:"update_time" description:"Update time"`                      // 更新时间
 }
@@ -8,13 +8,28 @@ import (
 
 // KnowledgeBase GORM模型定义
 type KnowledgeBase struct {
-	ID          int64     `gorm:"primaryKey;column:id"`
-	Name        string    `gorm:"column:name;type:varchar(255)"`
-	Description string    `gorm:"column:description;type:varchar(255)"`
-	Category    string    `gorm:"column:category;type:varchar(255)"`
-	Status      int       `gorm:"column:status;default:1"`
-	CreateTime  time.Time `gorm:"column:created_at"`
-	UpdateTime  time.Time `gorm:"column:updated_at"`
+	ID          int64  `gorm:"primaryKey;column:id"`
+	Name        string `gorm:"column:name;type:varchar(255)"`
+	Description string `gorm:"column:description;type:varchar(255)"`
+	Category    string `gorm:"column:category;type:varchar(255)"`
+	Status      int    `gorm:"column:status;default:1"`
+	// 向量配置：同一知识库的 chunk 必须使用同一 embedding 模型与维度，修改需要重建索引
+	EmbeddingModel string `gorm:"column:embedding_model;type:varchar(128)"`
+	EmbeddingDims  int    `gorm:"column:embedding_dims;default:0"`
+	Similarity     string `gorm:"column:similarity;type:varchar(16)"`
+	IndexName      string `gorm:"column:index_name;type:varchar(255)"` // 为空表示使用全局共享索引（历史数据）
+	// 切分配置：为空或 0 时使用默认值，修改后只对之后导入的文档生效
+	Splitter     string `gorm:"column:splitter;type:varchar(16)"`
+	ChunkSize    int    `gorm:"column:chunk_size;default:0"`
+	ChunkOverlap int    `gorm:"column:chunk_overlap;default:0"`
+	Separators   string `gorm:"column:separators;type:varchar(255)"` // JSON 数组
+	HeaderLevels int    `gorm:"column:header_levels;default:0"`
+	MergeLength  int    `gorm:"column:merge_length;default:0"`
+	// 回答校验配置：为空或 0 时使用全局配置 verify.*
+	VerifyAction    string    `gorm:"column:verify_action;type:varchar(16)"`
+	VerifyThreshold float64   `gorm:"column:verify_threshold;default:0"`
+	CreateTime      time.Time `gorm:"column:created_at"`
+	UpdateTime      time.Time `gorm:"column:updated_at"`
 }
 
 // TableName 设置表名
@@ -0,0 +1,22 @@
+package gorm
+
+import (
+	"time"
+)
+
+// KnowledgeDocumentSummaries GORM模型定义，每个文档只保留最新版本的摘要
+type KnowledgeDocumentSummaries struct {
+	ID             int64     `gorm:"primaryKey;column:id;autoIncrement"`
+	KnowledgeDocID int64     `gorm:"column:knowledge_doc_id;not null;uniqueIndex:idx_doc_version,priority:1"`
+	Version        string    `gorm:"column:version;type:varchar(64);not null;uniqueIndex:idx_doc_version,priority:2"`
+	Summary        string    `gorm:"column:summary;type:mediumtext"`
+	ChunkCount     int       `gorm:"column:chunk_count;not null;default:0"`
+	TokenCount     int       `gorm:"column:token_count;not null;default:0"`
+	CreateTime     time.Time `gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdateTime     time.Time `gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+// TableName 设置表名
+func (KnowledgeDocumentSummaries) TableName() string {
+	return "knowledge_document_summaries"
+}
@@ -0,0 +1,30 @@
+package gorm
+
+import (
+	"time"
+)
+
+// KnowledgeIndexJobs GORM模型定义
+type KnowledgeIndexJobs struct {
+	ID                int64     `gorm:"primaryKey;column:id;autoIncrement"`
+	KnowledgeDocID    int64     `gorm:"column:knowledge_doc_id;not null;index"`
+	KnowledgeBaseName string    `gorm:"column:knowledge_base_name;type:varchar(255);not null"`
+	URI               string    `gorm:"column:uri;type:varchar(1024)"`
+	Stage             string    `gorm:"column:stage;type:varchar(32);not null"`
+	Status            int8      `gorm:"column:status;type:tinyint;not null;default:0;index:idx_status_next_run,priority:1"`
+	ChunkIds          string    `gorm:"column:chunk_ids;type:mediumtext"`
+	Attempts          int       `gorm:"column:attempts;not null;default:0"`
+	MaxAttempts       int       `gorm:"column:max_attempts;not null;default:5"`
+	LastError         string    `gorm:"column:last_error;type:text"`
+	Progress          string    `gorm:"column:progress;type:text"`
+	Params            string    `gorm:"column:params;type:text"`
+	Result            string    `gorm:"column:result;type:text"`
+	NextRunAt         time.Time `gorm:"column:next_run_at;type:timestamp;index:idx_status_next_run,priority:2"`
+	CreateTime        time.Time `gorm:"column:created_at;type:timestamp;autoCreateTime"`
+	UpdateTime        time.Time `gorm:"column:updated_at;type:timestamp;autoUpdateTime"`
+}
+
+// TableName 设置表名
+func (KnowledgeIndexJobs) TableName() string {
+	return "knowledge_index_jobs"
+}
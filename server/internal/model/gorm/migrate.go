@@ -25,5 +25,17 @@ func AutoMigrate(db *gorm.DB) error {
 	}
 	fmt.Println("✓ KnowledgeChunks migration is successful ")
 
+	fmt.Println("Start to migrate KnowledgeIndexJobs...")
+	if err := db.AutoMigrate(&KnowledgeIndexJobs{}); err != nil {
+		return fmt.Errorf("KnowledgeIndexJobs migration is failed: %v", err)
+	}
+	fmt.Println("✓ KnowledgeIndexJobs migration is successful")
+
+	fmt.Println("Start to migrate KnowledgeDocumentSummaries...")
+	if err := db.AutoMigrate(&KnowledgeDocumentSummaries{}); err != nil {
+		return fmt.Errorf("KnowledgeDocumentSummaries migration is failed: %v", err)
+	}
+	fmt.Println("✓ KnowledgeDocumentSummaries migration is successful")
+
 	return nil
 }